@@ -7,6 +7,9 @@ set -e
 VERSION=${1:-$(cat ../public/cli/version.txt 2>/dev/null || echo "1.0.0")}
 OUTPUT_DIR="../public/cli"
 GO="/usr/local/go/bin/go"
+COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo "")
+DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+PKG="github.com/justtype/cli/internal/updater"
 
 echo "Building justtype CLI v$VERSION..."
 
@@ -25,10 +28,13 @@ for platform in "${platforms[@]}"; do
     echo "  Building $OS/$ARCH..."
 
     TMP=$(mktemp -d)
-    CGO_ENABLED=0 GOOS=$OS GOARCH=$ARCH $GO build -ldflags="-s -w" -o "$TMP/justtype" .
+    CGO_ENABLED=0 GOOS=$OS GOARCH=$ARCH $GO build -ldflags="-s -w -X $PKG.Commit=$COMMIT -X $PKG.BuildDate=$DATE" -o "$TMP/justtype" .
 
     tar -czf "$OUTPUT_DIR/justtype_${OS}_${ARCH}.tar.gz" -C "$TMP" justtype
     rm -rf "$TMP"
+
+    # The updater refuses archives without a matching checksum
+    (cd "$OUTPUT_DIR" && shasum -a 256 "justtype_${OS}_${ARCH}.tar.gz" > "justtype_${OS}_${ARCH}.tar.gz.sha256")
 done
 
 # Update version file
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/justtype/cli/internal/storage"
+)
+
+// runDedupe lists slates with identical content and, with --apply, trashes
+// all but one copy of each
+func runDedupe(args []string) error {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "trash the duplicates instead of only listing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := openStorage()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	groups, err := storage.FindDuplicates(s)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Println("no duplicates")
+		return nil
+	}
+
+	extra := 0
+	for _, g := range groups {
+		fmt.Printf("keep   %s  %q\n", g.Keep.ID, g.Keep.Title)
+		for _, dup := range g.Extra {
+			fmt.Printf("  trash %s  %q\n", dup.ID, dup.Title)
+		}
+		extra += len(g.Extra)
+	}
+
+	if !*apply {
+		fmt.Printf("%d duplicates in %d groups, run with --apply to trash them\n", extra, len(groups))
+		return nil
+	}
+
+	removed, err := storage.MergeDuplicates(s, groups)
+	fmt.Printf("trashed %d duplicates\n", removed)
+	return err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/storage"
+)
+
+func TestDedupeIsDryRunByDefault(t *testing.T) {
+	notes := importHome(t)
+	ls, err := storage.NewLocal(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"twice", "twice", "once"} {
+		if err := ls.Save(&storage.Slate{Slate: model.Slate{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := runDedupe(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := imported(t, notes); len(got) != 3 {
+		t.Fatalf("dry run left %q, want all three", got)
+	}
+
+	if err := runDedupe([]string{"--apply"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := imported(t, notes); len(got) != 2 || got[0] != "once" || got[1] != "twice" {
+		t.Fatalf("--apply left %q, want one of each", got)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/updater"
+)
+
+// Exit codes for subcommands, so scripts can tell failures apart. They're
+// listed in the README; don't renumber them.
+const (
+	exitOK       = 0
+	exitError    = 1 // anything not listed below
+	exitUsage    = 2 // bad flags or arguments
+	exitAuth     = 3 // session expired or encryption key missing
+	exitNetwork  = 4 // couldn't reach the server
+	exitNotFound = 5 // a slate or file that doesn't exist
+)
+
+// exitKinds name the exit codes in error output
+var exitKinds = map[int]string{
+	exitError:    "error",
+	exitUsage:    "usage",
+	exitAuth:     "auth",
+	exitNetwork:  "network",
+	exitNotFound: "not found",
+}
+
+// usageError marks a problem with how a command was called
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// parseFlags parses a subcommand's arguments, marking bad ones as usage
+// errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	return nil
+}
+
+// exitCode maps an error from a subcommand to the exit code for it
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, api.ErrSessionExpired):
+		return exitAuth
+	case errors.Is(err, api.ErrOffline), errors.Is(err, storage.ErrOffline):
+		return exitNetwork
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, fs.ErrNotExist), errors.Is(err, updater.ErrNoBackup):
+		return exitNotFound
+	}
+	return exitError
+}
+
+// fail reports err on stderr as "justtype <command>: <kind>: <message>" and
+// exits with its code
+func fail(command string, err error) {
+	code := exitCode(err)
+	if code != exitOK {
+		fmt.Fprintf(os.Stderr, "justtype %s: %s: %v\n", command, exitKinds[code], err)
+	}
+	os.Exit(code)
+}
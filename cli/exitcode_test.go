@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/updater"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{flag.ErrHelp, exitOK},
+		{errors.New("disk full"), exitError},
+		{usageErrorf("usage: justtype get <id>"), exitUsage},
+		{fmt.Errorf("list: %w", usageErrorf("bad flag")), exitUsage},
+		{fmt.Errorf("unauthorized: %w", api.ErrSessionExpired), exitAuth},
+		{fmt.Errorf("can't reach it: %w", api.ErrOffline), exitNetwork},
+		{fmt.Errorf("saved, but couldn't publish: %w", storage.ErrOffline), exitNetwork},
+		{fmt.Errorf("slate abc: %w", storage.ErrNotFound), exitNotFound},
+		{&fs.PathError{Op: "open", Path: "notes.txt", Err: fs.ErrNotExist}, exitNotFound},
+		{updater.ErrNoBackup, exitNotFound},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// cloudHome sets up a justtype home logged in to the server at url
+func cloudHome(t *testing.T, url string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	t.Setenv(config.APIURLEnv, "")
+	cfg := `{"token": "t0k", "username": "ada", "api_url": "` + url + `"}`
+	if err := os.WriteFile(filepath.Join(home, "config.json"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	expired := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer expired.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+		args  []string
+		want  int
+	}{
+		{"unknown flag", func(t *testing.T) { importHome(t) }, []string{"list", "--bogus"}, exitUsage},
+		{"missing argument", func(t *testing.T) { importHome(t) }, []string{"get"}, exitUsage},
+		{"extra argument", func(t *testing.T) { importHome(t) }, []string{"export", "a", "b", "c"}, exitUsage},
+		{"no such slate", func(t *testing.T) { importHome(t) }, []string{"get", "nope"}, exitNotFound},
+		{"no such file", func(t *testing.T) { importHome(t) }, []string{"import", "--file", filepath.Join(t.TempDir(), "missing.txt")}, exitNotFound},
+		{"session expired", func(t *testing.T) { cloudHome(t, expired.URL) }, []string{"get", "cloud-5"}, exitAuth},
+		{"server down", func(t *testing.T) { cloudHome(t, down.URL) }, []string{"get", "cloud-5"}, exitNetwork},
+		{"not set up", func(t *testing.T) {
+			t.Setenv(config.HomeEnv, t.TempDir())
+			t.Setenv(config.APIURLEnv, "")
+		}, []string{"list"}, exitError},
+		{"help", func(t *testing.T) { importHome(t) }, []string{"list", "-h"}, exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(t)
+			err := commands[tt.args[0]](tt.args[1:])
+			if got := exitCode(err); got != tt.want {
+				t.Fatalf("justtype %s: exit %d (%v), want %d", strings.Join(tt.args, " "), got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestFailOutput runs main in a child process to see what a script would:
+// the exit status and the error line on stderr
+func TestFailOutput(t *testing.T) {
+	if args := os.Getenv("JUSTTYPE_TEST_MAIN"); args != "" {
+		os.Args = append([]string{"justtype"}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+
+	importHome(t)
+	tests := []struct {
+		args   string
+		code   int
+		stderr string
+	}{
+		{"get nope", exitNotFound, "justtype get: not found: "},
+		{"list --bogus", exitUsage, "justtype list: usage: "},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFailOutput$")
+		cmd.Env = append(os.Environ(), "JUSTTYPE_TEST_MAIN="+tt.args)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) || exit.ExitCode() != tt.code {
+			t.Fatalf("justtype %s: %v, want exit status %d", tt.args, err, tt.code)
+		}
+		if !strings.Contains(stderr.String(), tt.stderr) {
+			t.Fatalf("justtype %s: stderr %q, want a %q line", tt.args, stderr.String(), tt.stderr)
+		}
+	}
+}
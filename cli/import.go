@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/justtype/cli/internal/storage"
+)
+
+// runImport brings text files or a slates bundle into the configured
+// storage, local or account, without the TUI
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dir := fs.String("dir", "", "import every .txt/.md file in a directory")
+	file := fs.String("file", "", "import a single text file")
+	bundle := fs.String("bundle", "", "import a JSON array of slates (e.g. slates.json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	set := 0
+	for _, v := range []string{*dir, *file, *bundle} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return usageErrorf("import needs exactly one of --dir, --file or --bundle")
+	}
+
+	s, err := openStorage()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var report *storage.ImportReport
+	switch {
+	case *file != "":
+		// A single file's error is the command's, so a missing one exits
+		// as not found
+		slate, err := storage.ImportFile(s, *file)
+		if err != nil && !errors.Is(err, storage.ErrNothingToImport) {
+			return err
+		}
+		report = &storage.ImportReport{}
+		if slate != nil {
+			report.Imported = append(report.Imported, slate)
+		} else {
+			report.Skipped = append(report.Skipped, *file)
+		}
+	case *dir != "":
+		report, err = storage.ImportDir(s, *dir)
+	default:
+		report, err = storage.ImportBundle(s, *bundle)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, slate := range report.Imported {
+		fmt.Printf("imported %q\n", slate.Title)
+	}
+	for _, name := range report.Skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s\n", name)
+	}
+	for _, err := range report.Failed {
+		fmt.Fprintf(os.Stderr, "failed %v\n", err)
+	}
+	fmt.Printf("%d imported, %d skipped, %d failed\n", len(report.Imported), len(report.Skipped), len(report.Failed))
+
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d failed to import", len(report.Failed))
+	}
+	return nil
+}
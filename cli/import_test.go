@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/storage"
+)
+
+// importHome sets up a justtype home with local storage and returns the
+// storage directory
+func importHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	t.Setenv(config.APIURLEnv, "")
+	notes := filepath.Join(home, "notes")
+	cfg := `{"storage_path": "` + filepath.ToSlash(notes) + `"}`
+	if err := os.WriteFile(filepath.Join(home, "config.json"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return notes
+}
+
+// imported is the content of every slate in the local storage at dir
+func imported(t *testing.T, dir string) []string {
+	t.Helper()
+	ls, err := storage.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slates, err := ls.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, slate := range slates {
+		contents = append(contents, slate.Content)
+	}
+	sort.Strings(contents)
+	return contents
+}
+
+func TestImportDir(t *testing.T) {
+	notes := importHome(t)
+	src := t.TempDir()
+	for name, content := range map[string]string{
+		"todo.md":         "Todo\n\nwater the plants",
+		"sub/journal.txt": "Journal\n\na quiet day",
+		"empty.txt":       "  \n",
+		"photo.png":       "\x89PNG",
+		".hidden/x.md":    "not this one",
+	} {
+		path := filepath.Join(src, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := runImport([]string{"--dir", src}); err != nil {
+		t.Fatal(err)
+	}
+	got := imported(t, notes)
+	want := []string{"Journal\n\na quiet day", "Todo\n\nwater the plants"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("imported %q, want %q", got, want)
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	notes := importHome(t)
+	path := filepath.Join(t.TempDir(), "idea.txt")
+	if err := os.WriteFile(path, []byte("Idea\n\na better mousetrap"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runImport([]string{"--file", path}); err != nil {
+		t.Fatal(err)
+	}
+	if got := imported(t, notes); len(got) != 1 || got[0] != "Idea\n\na better mousetrap" {
+		t.Fatalf("imported %q", got)
+	}
+}
+
+func TestImportMissingFileIsNotFound(t *testing.T) {
+	notes := importHome(t)
+	err := runImport([]string{"--file", filepath.Join(t.TempDir(), "missing.txt")})
+	if code := exitCode(err); code != exitNotFound {
+		t.Fatalf("exit code %d for %v, want %d (not found)", code, err, exitNotFound)
+	}
+	if got := imported(t, notes); len(got) != 0 {
+		t.Fatalf("imported %q from a missing file", got)
+	}
+}
+
+func TestImportNeedsOneSource(t *testing.T) {
+	importHome(t)
+	for _, args := range [][]string{nil, {"--file", "a.txt", "--dir", "b"}} {
+		if code := exitCode(runImport(args)); code != exitUsage {
+			t.Errorf("import %q: exit code %d, want %d (usage)", args, code, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ChangePassword sets a new password for the account. Accounts whose
+// encryption key is wrapped in the browser have to change it there; the
+// error from the server says so. If the server ends the session and sends
+// a new token, it's used from then on and passed to the OnTokenRefresh
+// callback.
+func (c *Client) ChangePassword(oldPassword, newPassword string) error {
+	return c.ChangePasswordCtx(context.Background(), oldPassword, newPassword)
+}
+
+// ChangePasswordCtx is ChangePassword, given up when ctx is done
+func (c *Client) ChangePasswordCtx(ctx context.Context, oldPassword, newPassword string) error {
+	resp, err := c.doRequest(ctx, "POST", "/api/account/change-password", map[string]string{
+		"currentPassword": oldPassword,
+		"newPassword":     newPassword,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "failed to change password")
+	}
+
+	var result struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result.Token != "" {
+		c.rotateTokens(result.Token, result.RefreshToken)
+	}
+	return nil
+}
+
+// UpdateEmail asks to move the account to a new email address. The server
+// mails a code there, which ConfirmEmail takes to make the change.
+func (c *Client) UpdateEmail(email string) error {
+	return c.UpdateEmailCtx(context.Background(), email)
+}
+
+// UpdateEmailCtx is UpdateEmail, given up when ctx is done
+func (c *Client) UpdateEmailCtx(ctx context.Context, email string) error {
+	resp, err := c.doRequest(ctx, "POST", "/api/account/change-email", map[string]string{
+		"newEmail": email,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "failed to change email")
+	}
+	return nil
+}
+
+// ConfirmEmail finishes an UpdateEmail with the code that was mailed
+func (c *Client) ConfirmEmail(code string) error {
+	return c.ConfirmEmailCtx(context.Background(), code)
+}
+
+// ConfirmEmailCtx is ConfirmEmail, given up when ctx is done
+func (c *Client) ConfirmEmailCtx(ctx context.Context, code string) error {
+	resp, err := c.doRequest(ctx, "POST", "/api/account/verify-email-change", map[string]string{
+		"code": code,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "failed to verify email")
+	}
+	return nil
+}
+
+// rotateTokens takes the new session the server sent with a response, as
+// refresh does
+func (c *Client) rotateTokens(token, refreshToken string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.token = token
+	if refreshToken != "" {
+		c.refreshToken = refreshToken
+	}
+	if c.onRefresh != nil {
+		c.onRefresh(c.token, c.refreshToken)
+	}
+}
+
+// responseError is the server's own message for a failed request, like
+// "Current password is incorrect", or fallback if it didn't send one
+func responseError(resp *http.Response, fallback string) error {
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Error != "" {
+		return errors.New(errResp.Error)
+	}
+	return errors.New(fallback)
+}
@@ -2,19 +2,77 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/textstats"
+	"github.com/justtype/cli/internal/updater"
 )
 
-const DefaultAPIURL = "https://justtype.io"
+const DefaultAPIURL = config.DefaultAPIURL
+
+// ErrSessionExpired means the session ended, or the server no longer has the
+// account's encryption key cached, and a silent refresh couldn't fix it: the
+// user needs to log in again
+var ErrSessionExpired = errors.New("SESSION_EXPIRED")
+
+// ErrOffline matches errors from requests that never reached the server.
+// They come from Offline, so their message names the server.
+var ErrOffline = errors.New("can't reach the server")
+
+// offlineError is a request that never reached host
+type offlineError struct {
+	host string
+	err  error
+}
+
+// Offline wraps err, from a request that never reached the server at
+// baseURL, as an error matching ErrOffline. err may be nil.
+func Offline(baseURL string, err error) error {
+	host := baseURL
+	if u, parseErr := url.Parse(baseURL); parseErr == nil && u.Host != "" {
+		host = u.Host
+	}
+	return &offlineError{host: host, err: err}
+}
+
+func (e *offlineError) Error() string {
+	if e.err == nil {
+		return "can't reach " + e.host
+	}
+	return fmt.Sprintf("can't reach %s: %v", e.host, e.err)
+}
+
+func (e *offlineError) Is(target error) bool {
+	return target == ErrOffline
+}
 
 type Client struct {
 	baseURL    string
-	token      string
 	httpClient *http.Client
+
+	// Session; see refresh.go. authMu also serializes refreshes.
+	authMu       sync.Mutex
+	token        string
+	refreshToken string
+	onRefresh    func(token, refreshToken string)
+
+	// Retry policy for flaky connections; see retry.go. Zero MaxRetries
+	// sends each request once.
+	MaxRetries int
+	RetryDelay time.Duration
+	key        *e2e.Key
 }
 
 type User struct {
@@ -24,69 +82,205 @@ type User struct {
 }
 
 type Slate struct {
-	ID          int     `json:"id"`
-	Title       string  `json:"title"`
-	Content     string  `json:"content,omitempty"`
-	WordCount   int     `json:"word_count"`
-	IsPublished int     `json:"is_published"`
-	ShareID     string  `json:"share_id,omitempty"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Content     string `json:"content,omitempty"`
+	WordCount   int    `json:"word_count"`
+	IsPublished int    `json:"is_published"`
+	ShareID     string `json:"share_id,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	// When the slate was pinned, in Unix milliseconds; nil if it isn't.
+	// Only read: pinning goes through SetPinned, so it's never sent.
+	PinnedAt *int64 `json:"pinned_at,omitempty"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	User         User   `json:"user"`
+}
+
+// PublishRequest is the body of PATCH /api/slates/{id}/publish
+type PublishRequest struct {
+	IsPublished bool `json:"isPublished"`
 }
 
 type PublishResponse struct {
-	ShareID  string `json:"shareId"`
-	ShareURL string `json:"shareUrl"`
+	ShareID  string `json:"share_id"`
+	ShareURL string `json:"share_url"`
+}
+
+// ShareURL builds the public link for a published slate
+func ShareURL(baseURL, shareID string) string {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/s/" + url.PathEscape(shareID)
 }
 
+// DefaultTimeout is how long a request may take, retries aside, unless
+// SetTimeout says otherwise
+const DefaultTimeout = 30 * time.Second
+
 func New(baseURL, token string) *Client {
 	if baseURL == "" {
 		baseURL = DefaultAPIURL
 	}
 	return &Client{
-		baseURL: baseURL,
-		token:   token,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		token:      token,
+		httpClient: updater.NewClient(DefaultTimeout),
+		MaxRetries: DefaultMaxRetries,
+		RetryDelay: DefaultRetryDelay,
 	}
 }
 
+// SetToken starts a new session, dropping the old refresh token; set the new
+// one after with SetRefreshToken
 func (c *Client) SetToken(token string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
 	c.token = token
+	c.refreshToken = ""
 }
 
-func (c *Client) doRequest(method, path string, body interface{}) (*http.Response, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonData, err := json.Marshal(body)
-		if err != nil {
-			return nil, err
-		}
-		bodyReader = bytes.NewReader(jsonData)
+// SetTimeout sets how long each request may take; 0 means DefaultTimeout.
+// A context passed to the Ctx methods can end a request sooner.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
 	}
+	c.httpClient.Timeout = timeout
+}
 
-	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+// SetEncryptionKey turns on end-to-end encryption; nil turns it off
+func (c *Client) SetEncryptionKey(key *e2e.Key) {
+	c.key = key
+}
+
+// sealSlate encrypts a slate body, counting words on the plaintext first,
+// the way the editor does
+func (c *Client) sealSlate(title, content string) (map[string]interface{}, error) {
+	wordCount := textstats.Count(content, true).Words
+
+	sealedTitle, err := e2e.Seal(c.key, title)
 	if err != nil {
 		return nil, err
 	}
+	sealedContent, err := e2e.Seal(c.key, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"title":      sealedTitle,
+		"content":    sealedContent,
+		"word_count": wordCount,
+	}, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "justtype-cli/2.0")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+// openSlate decrypts a slate in place
+func (c *Client) openSlate(slate *Slate) error {
+	title, err := e2e.Open(c.key, slate.Title)
+	if err != nil {
+		return err
 	}
+	content, err := e2e.Open(c.key, slate.Content)
+	if err != nil {
+		return err
+	}
+	slate.Title = title
+	slate.Content = content
+	return nil
+}
 
-	return c.httpClient.Do(req)
+// doRequest sends a request, retrying network errors and overloaded
+// servers as retryWait allows; a 429 still there after that comes back as
+// *ErrRateLimited. A 401 gets one silent token refresh and
+// replay before it's returned. Once ctx is done it stops, mid-request or
+// between retries, and returns ctx's error.
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var jsonData []byte
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		jsonData = data
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if jsonData != nil {
+			bodyReader = bytes.NewReader(jsonData)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		token := c.currentToken()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && token != "" && !refreshed && refreshable(path) {
+			refreshed = true
+			if !c.refresh(ctx, token) {
+				// The session really is over; the caller asks to log in
+				return resp, nil
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			attempt-- // the replay isn't a retry
+			continue
+		}
+
+		wait, retry := c.retryWait(method, resp, err, attempt)
+		if !retry || attempt >= c.MaxRetries {
+			if err != nil {
+				return nil, Offline(c.baseURL, err)
+			}
+			if err := CheckRateLimit(resp); err != nil {
+				// Every caller would only report it as a failure
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		if resp != nil {
+			// Read to the end so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 }
 
 func (c *Client) Login(username, password string) (*LoginResponse, error) {
-	resp, err := c.doRequest("POST", "/api/auth/login", map[string]string{
+	return c.LoginCtx(context.Background(), username, password)
+}
+
+// LoginCtx is Login, given up when ctx is done
+func (c *Client) LoginCtx(ctx context.Context, username, password string) (*LoginResponse, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/auth/login", map[string]string{
 		"username": username,
 		"password": password,
 	})
@@ -96,7 +290,9 @@ func (c *Client) Login(username, password string) (*LoginResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp struct{ Error string `json:"error"` }
+		var errResp struct {
+			Error string `json:"error"`
+		}
 		json.NewDecoder(resp.Body).Decode(&errResp)
 		if errResp.Error != "" {
 			return nil, fmt.Errorf("%s", errResp.Error)
@@ -110,7 +306,12 @@ func (c *Client) Login(username, password string) (*LoginResponse, error) {
 }
 
 func (c *Client) Register(username, email, password string) (*LoginResponse, error) {
-	resp, err := c.doRequest("POST", "/api/auth/register", map[string]string{
+	return c.RegisterCtx(context.Background(), username, email, password)
+}
+
+// RegisterCtx is Register, given up when ctx is done
+func (c *Client) RegisterCtx(ctx context.Context, username, email, password string) (*LoginResponse, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/auth/register", map[string]string{
 		"username": username,
 		"email":    email,
 		"password": password,
@@ -121,7 +322,9 @@ func (c *Client) Register(username, email, password string) (*LoginResponse, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var errResp struct{ Error string `json:"error"` }
+		var errResp struct {
+			Error string `json:"error"`
+		}
 		json.NewDecoder(resp.Body).Decode(&errResp)
 		if errResp.Error != "" {
 			return nil, fmt.Errorf("%s", errResp.Error)
@@ -135,7 +338,12 @@ func (c *Client) Register(username, email, password string) (*LoginResponse, err
 }
 
 func (c *Client) Verify() (*User, error) {
-	resp, err := c.doRequest("GET", "/api/auth/verify", nil)
+	return c.VerifyCtx(context.Background())
+}
+
+// VerifyCtx is Verify, given up when ctx is done
+func (c *Client) VerifyCtx(ctx context.Context) (*User, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/auth/verify", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -158,50 +366,164 @@ func (c *Client) Verify() (*User, error) {
 	return &result.User, nil
 }
 
+// Logout ends this session on the server, so its token stops working there
+// as well as here
+func (c *Client) Logout() error {
+	return c.LogoutCtx(context.Background())
+}
+
+// LogoutCtx is Logout, given up when ctx is done
+func (c *Client) LogoutCtx(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "POST", "/api/auth/logout", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusUnauthorized, http.StatusForbidden:
+		// Unauthorized means the session had already ended
+		return nil
+	}
+	return fmt.Errorf("logout failed: %d", resp.StatusCode)
+}
+
+// RevokeAllSessions ends every session on the account, on every device,
+// this one included
+func (c *Client) RevokeAllSessions() error {
+	return c.RevokeAllSessionsCtx(context.Background())
+}
+
+// RevokeAllSessionsCtx is RevokeAllSessions, given up when ctx is done
+func (c *Client) RevokeAllSessionsCtx(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "POST", "/api/account/logout-all", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// Without a session there's nothing to end the others with
+		return fmt.Errorf("session expired, log in again to sign out other devices")
+	case http.StatusNotFound:
+		return fmt.Errorf("the server doesn't support signing out of all devices")
+	}
+	return fmt.Errorf("failed to sign out of all devices: %d", resp.StatusCode)
+}
+
+// DefaultPageSize is how many slates to ask ListSlatesPage for at a time
+const DefaultPageSize = 50
+
+// ListSlates lists every slate, without content
 func (c *Client) ListSlates() ([]Slate, error) {
-	resp, err := c.doRequest("GET", "/api/slates", nil)
+	return c.ListSlatesCtx(context.Background())
+}
+
+// ListSlatesCtx is ListSlates, given up when ctx is done
+func (c *Client) ListSlatesCtx(ctx context.Context) ([]Slate, error) {
+	slates, _, err := c.listSlates(ctx, "/api/slates")
+	return slates, err
+}
+
+// ListSlatesPage lists one page of slates, most recently updated first,
+// counting pages from 1. more reports whether there are pages after it.
+// A server that doesn't paginate sends everything on the first page.
+func (c *Client) ListSlatesPage(page, limit int) (slates []Slate, more bool, err error) {
+	return c.ListSlatesPageCtx(context.Background(), page, limit)
+}
+
+// ListSlatesPageCtx is ListSlatesPage, given up when ctx is done
+func (c *Client) ListSlatesPageCtx(ctx context.Context, page, limit int) (slates []Slate, more bool, err error) {
+	slates, total, err := c.listSlates(ctx, fmt.Sprintf("/api/slates?page=%d&limit=%d", page, limit))
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	return slates, total >= 0 && page*limit < total, nil
+}
+
+// listSlates fetches a slate list, returning the total the server reports
+// in X-Total-Count, or -1 if it doesn't
+func (c *Client) listSlates(ctx context.Context, path string) ([]Slate, int, error) {
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, -1, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, -1, fmt.Errorf("unauthorized: %w", ErrSessionExpired)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to list slates")
+		return nil, -1, fmt.Errorf("failed to list slates")
+	}
+
+	total := -1
+	if n, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil {
+		total = n
 	}
 
 	var slates []Slate
 	json.NewDecoder(resp.Body).Decode(&slates)
-	return slates, nil
+
+	for i := range slates {
+		if title, err := e2e.Open(c.key, slates[i].Title); err == nil {
+			slates[i].Title = title
+		} else {
+			slates[i].Title = "encrypted slate"
+		}
+	}
+	return slates, total, nil
 }
 
 func (c *Client) GetSlate(id int) (*Slate, error) {
-	resp, err := c.doRequest("GET", fmt.Sprintf("/api/slates/%d", id), nil)
+	return c.GetSlateCtx(context.Background(), id)
+}
+
+// GetSlateCtx is GetSlate, given up when ctx is done
+func (c *Client) GetSlateCtx(ctx context.Context, id int) (*Slate, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/slates/%d", id), nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: %w", ErrSessionExpired)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("slate not found")
 	}
 
 	var slate Slate
 	json.NewDecoder(resp.Body).Decode(&slate)
+	if err := c.openSlate(&slate); err != nil {
+		return nil, err
+	}
 	return &slate, nil
 }
 
 func (c *Client) CreateSlate(title, content string) (*Slate, error) {
-	resp, err := c.doRequest("POST", "/api/slates", map[string]string{
-		"title":   title,
-		"content": content,
-	})
+	return c.CreateSlateCtx(context.Background(), title, content)
+}
+
+// CreateSlateCtx is CreateSlate, given up when ctx is done
+func (c *Client) CreateSlateCtx(ctx context.Context, title, content string) (*Slate, error) {
+	body, err := c.sealSlate(title, content)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/slates", body)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("unauthorized: session expired")
+		return nil, fmt.Errorf("unauthorized: %w", ErrSessionExpired)
 	}
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
@@ -214,17 +536,24 @@ func (c *Client) CreateSlate(title, content string) (*Slate, error) {
 }
 
 func (c *Client) UpdateSlate(id int, title, content string) error {
-	resp, err := c.doRequest("PUT", fmt.Sprintf("/api/slates/%d", id), map[string]string{
-		"title":   title,
-		"content": content,
-	})
+	return c.UpdateSlateCtx(context.Background(), id, title, content)
+}
+
+// UpdateSlateCtx is UpdateSlate, given up when ctx is done
+func (c *Client) UpdateSlateCtx(ctx context.Context, id int, title, content string) error {
+	body, err := c.sealSlate(title, content)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/slates/%d", id), body)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("unauthorized: session expired")
+		return fmt.Errorf("unauthorized: %w", ErrSessionExpired)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -235,49 +564,100 @@ func (c *Client) UpdateSlate(id int, title, content string) error {
 }
 
 func (c *Client) DeleteSlate(id int) error {
-	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/slates/%d", id), nil)
+	return c.DeleteSlateCtx(context.Background(), id)
+}
+
+// DeleteSlateCtx is DeleteSlate, given up when ctx is done
+func (c *Client) DeleteSlateCtx(ctx context.Context, id int) error {
+	resp, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/slates/%d", id), nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to delete slate")
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		// Not found means it's already gone, which is what we wanted
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("unauthorized: %w", ErrSessionExpired)
 	}
+	return fmt.Errorf("failed to delete slate: %d", resp.StatusCode)
+}
 
-	return nil
+func (c *Client) SetPinned(id int, pinned bool) error {
+	return c.SetPinnedCtx(context.Background(), id, pinned)
 }
 
-func (c *Client) PublishSlate(id int) (*PublishResponse, error) {
-	resp, err := c.doRequest("PATCH", fmt.Sprintf("/api/slates/%d/publish", id), map[string]bool{
-		"publish": true,
-	})
+// SetPinnedCtx is SetPinned, given up when ctx is done
+func (c *Client) SetPinnedCtx(ctx context.Context, id int, pinned bool) error {
+	body := map[string]interface{}{"pinned": pinned}
+	resp, err := c.doRequest(ctx, "PATCH", fmt.Sprintf("/api/slates/%d/metadata", id), body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to publish")
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("unauthorized: %w", ErrSessionExpired)
 	}
+	return responseError(resp, fmt.Sprintf("failed to pin slate: %d", resp.StatusCode))
+}
 
-	var result PublishResponse
-	json.NewDecoder(resp.Body).Decode(&result)
-	return &result, nil
+func (c *Client) PublishSlate(id int) (*PublishResponse, error) {
+	return c.PublishSlateCtx(context.Background(), id)
+}
+
+// PublishSlateCtx is PublishSlate, given up when ctx is done
+func (c *Client) PublishSlateCtx(ctx context.Context, id int) (*PublishResponse, error) {
+	if c.key != nil {
+		return nil, fmt.Errorf("end-to-end encrypted slates can't be published")
+	}
+	return c.setPublished(ctx, id, true)
 }
 
 func (c *Client) UnpublishSlate(id int) error {
-	resp, err := c.doRequest("PATCH", fmt.Sprintf("/api/slates/%d/publish", id), map[string]bool{
-		"publish": false,
-	})
+	return c.UnpublishSlateCtx(context.Background(), id)
+}
+
+// UnpublishSlateCtx is UnpublishSlate, given up when ctx is done
+func (c *Client) UnpublishSlateCtx(ctx context.Context, id int) error {
+	_, err := c.setPublished(ctx, id, false)
+	return err
+}
+
+// setPublished is the one place the publish endpoint is called, so the
+// request shape can't drift between callers
+func (c *Client) setPublished(ctx context.Context, id int, publish bool) (*PublishResponse, error) {
+	resp, err := c.doRequest(ctx, "PATCH", fmt.Sprintf("/api/slates/%d/publish", id), PublishRequest{IsPublished: publish})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Expired, or ENCRYPTION_KEY_MISSING, and the refresh didn't help
+		return nil, fmt.Errorf("unauthorized: %w", ErrSessionExpired)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to unpublish")
+		body, _ := io.ReadAll(resp.Body)
+
+		action := "publish"
+		if !publish {
+			action = "unpublish"
+		}
+		return nil, fmt.Errorf("%s failed: %s", action, strings.TrimSpace(string(body)))
 	}
 
-	return nil
+	var result PublishResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if publish && result.ShareURL == "" {
+		result.ShareURL = ShareURL(c.baseURL, result.ShareID)
+	}
+	return &result, nil
 }
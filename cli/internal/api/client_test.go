@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/updater"
+)
+
+// recorded is one request a test server saw
+type recorded struct {
+	method, path, auth, body string
+}
+
+// recordServer answers every request with status and response, keeping
+// what was asked
+func recordServer(t *testing.T, status int, response string) (*Client, *[]recorded) {
+	t.Helper()
+	var seen []recorded
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = append(seen, recorded{r.Method, r.URL.Path, r.Header.Get("Authorization"), string(body)})
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New(srv.URL, "secret")
+	c.MaxRetries = 0
+	return c, &seen
+}
+
+func TestPublishRequestBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    func(c *Client) error
+		wantRaw string
+	}{
+		{"publish", func(c *Client) error { _, err := c.PublishSlate(7); return err }, `{"isPublished":true}`},
+		{"unpublish", func(c *Client) error { return c.UnpublishSlate(7) }, `{"isPublished":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, seen := recordServer(t, http.StatusOK, `{"share_id":"abc","share_url":"https://justtype.io/s/abc"}`)
+			if err := tt.call(c); err != nil {
+				t.Fatal(err)
+			}
+			if len(*seen) != 1 {
+				t.Fatalf("made %d requests, want 1", len(*seen))
+			}
+			got := (*seen)[0]
+			if got.method != "PATCH" || got.path != "/api/slates/7/publish" {
+				t.Errorf("sent %s %s, want PATCH /api/slates/7/publish", got.method, got.path)
+			}
+			if got.body != tt.wantRaw {
+				t.Errorf("body = %s, want %s", got.body, tt.wantRaw)
+			}
+			if got.auth != "Bearer secret" {
+				t.Errorf("Authorization = %q", got.auth)
+			}
+		})
+	}
+}
+
+func TestPublishResponse(t *testing.T) {
+	c, _ := recordServer(t, http.StatusOK, `{"share_id":"abc","share_url":"https://justtype.io/s/abc"}`)
+	result, err := c.PublishSlate(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ShareID != "abc" || result.ShareURL != "https://justtype.io/s/abc" {
+		t.Fatalf("got %+v", result)
+	}
+}
+
+func TestPublishFailure(t *testing.T) {
+	c, _ := recordServer(t, http.StatusBadRequest, `{"error":"slate is empty"}`)
+	if _, err := c.PublishSlate(7); err == nil {
+		t.Fatal("want an error for a 400")
+	}
+}
+
+func TestSealSlateCountsLikeTheEditor(t *testing.T) {
+	c := New("http://localhost", "token")
+	tests := map[string]int{
+		"plain english words": 3,
+		"日本語のメモ and notes":    8, // each Japanese character is a word
+		"**bold** claim":      2,
+		"🎉 done":              2,
+	}
+	for content, want := range tests {
+		body, err := c.sealSlate("title", content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := body["word_count"]; got != want {
+			t.Errorf("word_count for %q = %v, want %d", content, got, want)
+		}
+	}
+}
+
+func TestRequestsCarryVersion(t *testing.T) {
+	var agents, versions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		versions = append(versions, r.Header.Get("X-CLI-Version"))
+		io.WriteString(w, `{"id":1}`)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "token")
+	c.GetSlate(1)
+	c.UpdateSlate(1, "title", "content")
+	c.ListSlates()
+	if len(agents) != 3 {
+		t.Fatalf("made %d requests, want 3", len(agents))
+	}
+	for i := range agents {
+		if agents[i] != updater.UserAgent() || versions[i] != updater.GetVersion() {
+			t.Errorf("request %d sent %q, %q; want %q, %q", i, agents[i], versions[i], updater.UserAgent(), updater.GetVersion())
+		}
+	}
+}
+
+func TestShareURL(t *testing.T) {
+	tests := []struct {
+		base, shareID, want string
+	}{
+		{"https://justtype.io", "abc", "https://justtype.io/s/abc"},
+		{"https://justtype.io/", "abc", "https://justtype.io/s/abc"},
+		{"http://localhost:3000//", "x1", "http://localhost:3000/s/x1"},
+		{"https://notes.example.com/jt", "abc", "https://notes.example.com/jt/s/abc"},
+		{"", "abc", DefaultAPIURL + "/s/abc"},
+		{"https://justtype.io", "a b/c", "https://justtype.io/s/a%20b%2Fc"},
+	}
+	for _, tt := range tests {
+		if got := ShareURL(tt.base, tt.shareID); got != tt.want {
+			t.Errorf("ShareURL(%q, %q) = %q, want %q", tt.base, tt.shareID, got, tt.want)
+		}
+	}
+}
+
+func TestListSlatesPage(t *testing.T) {
+	const total = 120
+	paged := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if !paged {
+			// An older server ignores the parameters and sends everything
+			page, limit = 1, total
+		} else {
+			w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		}
+		var slates []Slate
+		for id := (page-1)*limit + 1; id <= min(page*limit, total); id++ {
+			slates = append(slates, Slate{ID: id, Title: "slate " + strconv.Itoa(id)})
+		}
+		json.NewEncoder(w).Encode(slates)
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "secret")
+
+	for _, tt := range []struct {
+		page, first, n int
+		more           bool
+	}{
+		{1, 1, 50, true},
+		{2, 51, 50, true},
+		{3, 101, 20, false},
+		{4, 0, 0, false},
+	} {
+		slates, more, err := c.ListSlatesPage(tt.page, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(slates) != tt.n || more != tt.more {
+			t.Fatalf("page %d: %d slates, more %v; want %d, %v", tt.page, len(slates), more, tt.n, tt.more)
+		}
+		if tt.n > 0 && slates[0].ID != tt.first {
+			t.Fatalf("page %d starts at slate %d, want %d", tt.page, slates[0].ID, tt.first)
+		}
+	}
+
+	paged = false
+	slates, more, err := c.ListSlatesPage(1, 50)
+	if err != nil || len(slates) != total || more {
+		t.Fatalf("unpaginated server: %d slates, more %v, %v; want all %d on one page", len(slates), more, err, total)
+	}
+}
+
+// hangingServer never answers, until the test ends
+func hangingServer(t *testing.T) string {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) }) // runs first
+	return srv.URL
+}
+
+func TestCancelEndsRequestPromptly(t *testing.T) {
+	c := New(hangingServer(t), "secret")
+	calls := map[string]func(ctx context.Context) error{
+		"login": func(ctx context.Context) error {
+			_, err := c.LoginCtx(ctx, "ada", "hunter2")
+			return err
+		},
+		"list": func(ctx context.Context) error {
+			_, err := c.ListSlatesCtx(ctx)
+			return err
+		},
+		"get": func(ctx context.Context) error {
+			_, err := c.GetSlateCtx(ctx, 7)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			err := call(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("got %v, want context.Canceled", err)
+			}
+			if took := time.Since(start); took > time.Second {
+				t.Fatalf("returned %v after the cancel", took)
+			}
+		})
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	c := New(hangingServer(t), "secret")
+	c.MaxRetries = 0
+	c.SetTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := c.ListSlates(); !errors.Is(err, ErrOffline) {
+		t.Fatalf("got %v, want ErrOffline after the timeout", err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Fatalf("took %v with a 100ms timeout", took)
+	}
+
+	c.SetTimeout(0)
+	if c.httpClient.Timeout != DefaultTimeout {
+		t.Fatalf("SetTimeout(0) left %v, want DefaultTimeout", c.httpClient.Timeout)
+	}
+}
+
+func TestOfflineNamesServer(t *testing.T) {
+	c := New("http://127.0.0.1:1", "secret")
+	c.MaxRetries = 0
+	_, err := c.ListSlates()
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("got %v, want ErrOffline", err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "can't reach 127.0.0.1:1: ") || strings.Contains(msg, "justtype.io") {
+		t.Fatalf("message %q, want it to name the configured server", msg)
+	}
+
+	if got := Offline(DefaultAPIURL, nil).Error(); got != "can't reach justtype.io" {
+		t.Fatalf("Offline(default) = %q", got)
+	}
+}
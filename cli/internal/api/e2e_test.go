@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/justtype/cli/internal/e2e"
+)
+
+// slateServer keeps slates the way the server does, storing whatever it's
+// sent, so a test can look at what left the client
+type slateServer struct {
+	mu     sync.Mutex
+	slates map[int]*Slate
+}
+
+func (s *slateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/slates" {
+		switch r.Method {
+		case "GET":
+			var list []Slate
+			for id := 1; id <= len(s.slates); id++ {
+				listed := *s.slates[id]
+				listed.Content = ""
+				list = append(list, listed)
+			}
+			json.NewEncoder(w).Encode(list)
+		case "POST":
+			slate := &Slate{ID: len(s.slates) + 1}
+			json.NewDecoder(r.Body).Decode(slate)
+			s.slates[slate.ID] = slate
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(slate)
+		}
+		return
+	}
+
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/slates/"))
+	slate := s.slates[id]
+	if slate == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(slate)
+	case "PUT":
+		json.NewDecoder(r.Body).Decode(slate)
+		json.NewEncoder(w).Encode(slate)
+	}
+}
+
+func newSlateServer(t *testing.T) (*slateServer, string) {
+	t.Helper()
+	s := &slateServer{slates: map[int]*Slate{}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	server, url := newSlateServer(t)
+	key, err := e2e.DeriveKey("correct horse", "ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(url, "token")
+	c.MaxRetries = 0
+	c.SetEncryptionKey(key)
+
+	created, err := c.CreateSlate("Diary", "Diary\n\nthe plan for tuesday")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only ciphertext reaches the server, with the plaintext's word count
+	stored := server.slates[created.ID]
+	for _, field := range []string{stored.Title, stored.Content} {
+		if !e2e.IsEncrypted(field) || strings.Contains(field, "Diary") || strings.Contains(field, "tuesday") {
+			t.Fatalf("server holds %q, want only ciphertext", field)
+		}
+	}
+	if stored.WordCount != 5 {
+		t.Fatalf("word_count = %d, want 5 from the plaintext", stored.WordCount)
+	}
+
+	got, err := c.GetSlate(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Diary" || got.Content != "Diary\n\nthe plan for tuesday" {
+		t.Fatalf("downloaded %q / %q", got.Title, got.Content)
+	}
+
+	if err := c.UpdateSlate(created.ID, "Diary", "Diary\n\nthe plan changed"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetSlate(created.ID); got.Content != "Diary\n\nthe plan changed" {
+		t.Fatalf("after update downloaded %q", got.Content)
+	}
+	if list, err := c.ListSlates(); err != nil || len(list) != 1 || list[0].Title != "Diary" {
+		t.Fatalf("ListSlates = %+v, %v; want the title decrypted", list, err)
+	}
+
+	// The same passphrase on another device reads it; without it, it's refused
+	same, _ := e2e.DeriveKey("correct horse", "ada")
+	other := New(url, "token")
+	other.SetEncryptionKey(same)
+	if got, err := other.GetSlate(created.ID); err != nil || got.Content != "Diary\n\nthe plan changed" {
+		t.Fatalf("another device with the passphrase got %v", err)
+	}
+	wrong, _ := e2e.DeriveKey("wrong horse", "ada")
+	other.SetEncryptionKey(wrong)
+	if _, err := other.GetSlate(created.ID); !errors.Is(err, e2e.ErrWrongPassphrase) {
+		t.Fatalf("wrong passphrase: got %v, want ErrWrongPassphrase", err)
+	}
+	other.SetEncryptionKey(nil)
+	if _, err := other.GetSlate(created.ID); !errors.Is(err, e2e.ErrNoKey) {
+		t.Fatalf("no key: got %v, want ErrNoKey", err)
+	}
+	if list, _ := other.ListSlates(); list[0].Title != "encrypted slate" {
+		t.Fatalf("listed without a key as %q", list[0].Title)
+	}
+
+	if _, err := c.PublishSlate(created.ID); err == nil {
+		t.Fatal("published an end-to-end encrypted slate")
+	}
+}
+
+func TestPlaintextWithoutKey(t *testing.T) {
+	server, url := newSlateServer(t)
+	c := New(url, "token")
+	c.MaxRetries = 0
+
+	created, err := c.CreateSlate("Notes", "Notes\n\nnothing secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := server.slates[created.ID]; stored.Content != "Notes\n\nnothing secret" {
+		t.Fatalf("server holds %q, want the plaintext", stored.Content)
+	}
+
+	// Turning encryption on later still reads what was saved before
+	key, _ := e2e.DeriveKey("late starter", "ada")
+	c.SetEncryptionKey(key)
+	if got, err := c.GetSlate(created.ID); err != nil || got.Content != "Notes\n\nnothing secret" {
+		t.Fatalf("old plaintext slate: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// SetRefreshToken sets the token used to get a new session when the current
+// one is rejected. Without one a rejected session is final.
+func (c *Client) SetRefreshToken(refreshToken string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.refreshToken = refreshToken
+}
+
+// OnTokenRefresh registers fn to be called with the new tokens after a
+// silent refresh, so they can be saved. It runs on whichever goroutine made
+// the request.
+func (c *Client) OnTokenRefresh(fn func(token, refreshToken string)) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.onRefresh = fn
+}
+
+// currentToken is the session token to send
+func (c *Client) currentToken() string {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.token
+}
+
+// Token is the session token to send, which a refresh may have replaced
+// since the client was made
+func (c *Client) Token() string {
+	return c.currentToken()
+}
+
+// Refresh is the silent refresh requests made through the client get, for
+// callers sending their own: after stale was rejected with a 401 it gets a
+// new session token and reports whether to replay the request
+func (c *Client) Refresh(ctx context.Context, stale string) bool {
+	return c.refresh(ctx, stale)
+}
+
+// refresh swaps the session token rejected as stale for a new one from
+// /api/auth/refresh, and reports whether the request can be replayed. The
+// server also puts back the account's encryption key if it lost it, so
+// ENCRYPTION_KEY_MISSING is refreshed the same way. authMu is held
+// throughout, so requests failing together wait for a single refresh and
+// then find the token has already changed.
+func (c *Client) refresh(ctx context.Context, stale string) bool {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.token != stale {
+		// Another request refreshed it while this one waited
+		return c.token != ""
+	}
+	if c.refreshToken == "" {
+		return false
+	}
+
+	body, err := json.Marshal(map[string]string{"refresh_token": c.refreshToken})
+	if err != nil {
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/auth/refresh", bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+stale)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	var result struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Token == "" {
+		return false
+	}
+
+	c.token = result.Token
+	if result.RefreshToken != "" {
+		// Servers may rotate it on every use
+		c.refreshToken = result.RefreshToken
+	}
+	if c.onRefresh != nil {
+		c.onRefresh(c.token, c.refreshToken)
+	}
+	return true
+}
+
+// refreshable reports whether a 401 from path may just mean the session
+// token expired. Auth endpoints answer 401 for bad credentials, which a new
+// token won't fix.
+func refreshable(path string) bool {
+	return !strings.HasPrefix(path, "/api/auth/")
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// sessionServer accepts only the bearer token in *valid, answering others
+// the way the server does when a restart dropped the encryption key, and
+// swaps refresh "r1" for token "fresh" and refresh "r2"
+func sessionServer(t *testing.T, valid string) (*Client, *atomic.Int32) {
+	t.Helper()
+	var mu sync.Mutex
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/auth/refresh" {
+			refreshes.Add(1)
+			var body struct {
+				RefreshToken string `json:"refresh_token"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.RefreshToken != "r1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			valid = "fresh"
+			io.WriteString(w, `{"token":"fresh","refresh_token":"r2"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":"Session expired.","code":"ENCRYPTION_KEY_MISSING"}`)
+			return
+		}
+		io.WriteString(w, `[]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New(srv.URL, "stale")
+	c.MaxRetries = 0
+	return c, &refreshes
+}
+
+func TestRefreshReplaysRequest(t *testing.T) {
+	c, refreshes := sessionServer(t, "current")
+	c.SetRefreshToken("r1")
+	var saved []string
+	c.OnTokenRefresh(func(token, refreshToken string) {
+		saved = append(saved, token, refreshToken)
+	})
+
+	if _, err := c.ListSlates(); err != nil {
+		t.Fatalf("ListSlates after a refresh: %v", err)
+	}
+	if c.Token() != "fresh" {
+		t.Fatalf("token = %q, want the refreshed one", c.Token())
+	}
+	if len(saved) != 2 || saved[0] != "fresh" || saved[1] != "r2" {
+		t.Fatalf("OnTokenRefresh got %v, want the new tokens", saved)
+	}
+	if refreshes.Load() != 1 {
+		t.Fatalf("%d refreshes, want 1", refreshes.Load())
+	}
+}
+
+func TestRefreshFailsToSessionExpired(t *testing.T) {
+	tests := []struct {
+		name          string
+		refreshToken  string
+		wantRefreshes int32
+	}{
+		{"no refresh token", "", 0},
+		{"refresh token rejected", "revoked", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, refreshes := sessionServer(t, "current")
+			c.SetRefreshToken(tt.refreshToken)
+
+			if _, err := c.ListSlates(); !errors.Is(err, ErrSessionExpired) {
+				t.Fatalf("got %v, want ErrSessionExpired", err)
+			}
+			if _, err := c.GetSlate(1); !errors.Is(err, ErrSessionExpired) {
+				t.Fatalf("GetSlate: got %v, want ErrSessionExpired", err)
+			}
+			if got := refreshes.Load(); got != tt.wantRefreshes*2 {
+				t.Fatalf("%d refreshes, want %d", got, tt.wantRefreshes*2)
+			}
+		})
+	}
+}
+
+func TestRefreshOnceForConcurrentRequests(t *testing.T) {
+	c, refreshes := sessionServer(t, "current")
+	c.SetRefreshToken("r1")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = c.ListSlates()
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The rest find the token already changed; "r1" would be refused twice
+	if refreshes.Load() != 1 {
+		t.Fatalf("%d refreshes, want 1", refreshes.Load())
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how many times a failed request is retried
+	DefaultMaxRetries = 3
+
+	// DefaultRetryDelay is the wait before the first retry; it doubles
+	// with each one after
+	DefaultRetryDelay = 500 * time.Millisecond
+
+	// A Retry-After longer than this is cut short, so a 429 can't leave
+	// the UI hanging
+	maxRetryAfter = 30 * time.Second
+)
+
+// ErrRateLimited is returned for a 429 that retrying didn't get past.
+// RetryAfter is how long the server asked to wait, or 0 if it didn't say.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.RetryAfter <= 0 {
+		return "rate limited, try again shortly"
+	}
+	return fmt.Sprintf("rate limited, retry in %ds", int(e.RetryAfter.Round(time.Second)/time.Second))
+}
+
+// CheckRateLimit returns an *ErrRateLimited if resp is a 429, for callers
+// that make their own requests
+func CheckRateLimit(resp *http.Response) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	wait, _ := retryAfter(resp.Header.Get("Retry-After"))
+	return &ErrRateLimited{RetryAfter: wait}
+}
+
+// retryWait returns how long to wait before retrying a request that got
+// resp or err, or false if it shouldn't be retried. POST and PATCH aren't
+// safe to send twice, so they're only retried when the server never saw
+// them.
+func (c *Client) retryWait(method string, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		if idempotent(method) || notSent(err) {
+			return c.backoff(attempt), true
+		}
+		return 0, false
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// Turned away before being handled, so any method can go again
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, true
+		}
+		return c.backoff(attempt), true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if idempotent(method) {
+			return c.backoff(attempt), true
+		}
+	}
+	return 0, false
+}
+
+// backoff doubles the delay with each attempt, plus up to half again at
+// random so clients that failed together don't retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.RetryDelay << attempt
+	if d <= 0 {
+		return 0
+	}
+	return d + rand.N(d/2+1)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// notSent reports whether err happened before the request went out, while
+// connecting
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	var wait time.Duration
+	if secs, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(header); err == nil {
+		wait = time.Until(t)
+	} else {
+		return 0, false
+	}
+	return min(max(wait, 0), maxRetryAfter), true
+}
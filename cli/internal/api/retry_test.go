@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// reset stands for a connection dropped after the request was read
+const reset = 0
+
+// scriptedServer answers the nth request with replies[n], repeating the
+// last reply once they run out, and counts the requests
+func scriptedServer(t *testing.T, replies []int, header http.Header) (*Client, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(count.Add(1)) - 1
+		status := replies[min(n, len(replies)-1)]
+		if status == reset {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New(srv.URL, "")
+	c.RetryDelay = time.Millisecond
+	return c, &count
+}
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		replies  []int
+		header   http.Header
+		retries  int
+		requests int
+		status   int   // the final response, or 0 for an error
+		err      error // the error it wraps
+	}{
+		{"503 then ok", "GET", []int{503, 503, 200}, nil, 3, 3, 200, nil},
+		{"502 and 504 then ok", "PUT", []int{502, 504, 200}, nil, 3, 3, 200, nil},
+		{"gives up after MaxRetries", "GET", []int{503}, nil, 3, 4, 503, nil},
+		{"retries off", "GET", []int{503, 200}, nil, 0, 1, 503, nil},
+		{"500 isn't transient", "GET", []int{500, 200}, nil, 3, 1, 500, nil},
+		{"POST not resent after a 503", "POST", []int{503, 200}, nil, 3, 1, 503, nil},
+		{"PATCH not resent after a 502", "PATCH", []int{502, 200}, nil, 3, 1, 502, nil},
+		{"GET resent after a reset", "GET", []int{reset, reset, 200}, nil, 3, 3, 200, nil},
+		{"POST not resent after a reset", "POST", []int{reset, 200}, nil, 3, 1, 0, ErrOffline},
+		{"DELETE gives up on resets", "DELETE", []int{reset}, nil, 2, 3, 0, ErrOffline},
+		{"429 resent, even a POST", "POST", []int{429, 200}, http.Header{"Retry-After": {"0"}}, 3, 2, 200, nil},
+		{"429 that doesn't let up", "GET", []int{429}, http.Header{"Retry-After": {"0"}}, 2, 3, 0, &ErrRateLimited{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, count := scriptedServer(t, tt.replies, tt.header)
+			c.MaxRetries = tt.retries
+
+			resp, err := c.doRequest(context.Background(), tt.method, "/api/slates/1", map[string]string{"content": "x"})
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if got := int(count.Load()); got != tt.requests {
+				t.Fatalf("server saw %d requests, want %d", got, tt.requests)
+			}
+			switch want := tt.err.(type) {
+			case nil:
+				if err != nil || resp.StatusCode != tt.status {
+					t.Fatalf("got %v, %v; want status %d", resp, err, tt.status)
+				}
+			case *ErrRateLimited:
+				if !errors.As(err, &want) {
+					t.Fatalf("got %v, want ErrRateLimited", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Fatalf("got %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfterIsWaited(t *testing.T) {
+	c, count := scriptedServer(t, []int{429, 200}, http.Header{"Retry-After": {"1"}})
+	c.RetryDelay = 0
+
+	start := time.Now()
+	resp, err := c.doRequest(context.Background(), "GET", "/api/slates", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if waited := time.Since(start); waited < time.Second {
+		t.Fatalf("retried after %v, want the second Retry-After asked for", waited)
+	}
+	if count.Load() != 2 {
+		t.Fatalf("server saw %d requests, want 2", count.Load())
+	}
+}
+
+func TestRetryUnsentPOST(t *testing.T) {
+	// Nothing listens here, so the POST never reached a server
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	_, err := http.Post(srv.URL, "application/json", nil)
+	if err == nil {
+		t.Fatal("posted to a closed server")
+	}
+
+	c := New(srv.URL, "")
+	c.RetryDelay = time.Millisecond
+	if _, retry := c.retryWait("POST", nil, err, 0); !retry {
+		t.Fatalf("POST not retried after %v, which happened before it was sent", err)
+	}
+	c.MaxRetries = 1
+	if _, err := c.doRequest(context.Background(), "POST", "/api/slates", nil); !errors.Is(err, ErrOffline) {
+		t.Fatalf("got %v, want ErrOffline", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New("http://localhost", "")
+	c.RetryDelay = 100 * time.Millisecond
+	for attempt, base := range []time.Duration{100, 200, 400} {
+		base *= time.Millisecond
+		for range 50 {
+			if d := c.backoff(attempt); d < base || d > base+base/2 {
+				t.Fatalf("backoff(%d) = %v, want %v plus up to half again", attempt, d, base)
+			}
+		}
+	}
+	c.RetryDelay = 0
+	if d := c.backoff(2); d != 0 {
+		t.Fatalf("backoff with no delay = %v", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		header string
+		min    time.Duration
+		max    time.Duration
+		ok     bool
+	}{
+		{"5", 5 * time.Second, 5 * time.Second, true},
+		{"0", 0, 0, true},
+		{"-3", 0, 0, true},
+		{strconv.Itoa(3600), maxRetryAfter, maxRetryAfter, true},
+		{future, 8 * time.Second, 10 * time.Second, true},
+		{"", 0, 0, false},
+		{"soon", 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header)
+		if ok != tt.ok || got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v to %v, %v", tt.header, got, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestRateLimitedError(t *testing.T) {
+	tests := []struct {
+		header string
+		wait   time.Duration
+		msg    string
+	}{
+		{"7", 7 * time.Second, "rate limited, retry in 7s"},
+		{"", 0, "rate limited, try again shortly"},
+		{"whenever", 0, "rate limited, try again shortly"},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.header != "" {
+			header.Set("Retry-After", tt.header)
+		}
+		c, count := scriptedServer(t, []int{http.StatusTooManyRequests}, header)
+		c.MaxRetries = 0
+
+		_, err := c.ListSlates()
+		var limited *ErrRateLimited
+		if !errors.As(err, &limited) {
+			t.Fatalf("Retry-After %q: got %v, want ErrRateLimited", tt.header, err)
+		}
+		if limited.RetryAfter != tt.wait || err.Error() != tt.msg {
+			t.Fatalf("Retry-After %q: waits %v, says %q; want %v, %q", tt.header, limited.RetryAfter, err, tt.wait, tt.msg)
+		}
+		if count.Load() != 1 {
+			t.Fatalf("server saw %d requests with retries off", count.Load())
+		}
+	}
+}
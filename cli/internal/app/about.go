@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/clipboard"
+	"github.com/justtype/cli/internal/updater"
+	"github.com/rivo/tview"
+)
+
+// diagnostics is the plain-text summary asked for in bug reports. It leaves
+// out the account name and anything else personal.
+func (app *App) diagnostics() string {
+	storageLine := "not set up"
+	switch {
+	case app.isCloud:
+		storageLine = "cloud (cached in " + app.dataDir + ")"
+	case app.storagePath != "":
+		storageLine = "local (" + app.storagePath + ")"
+	}
+
+	update := "none found"
+	switch {
+	case app.updateAvailable != "":
+		update = "v" + app.updateAvailable + " available"
+	case updater.NormalizeMode(app.updateMode) == updater.ModeNever:
+		update = "checks off"
+	}
+
+	lines := []string{
+		"version:  " + updater.GetVersion(),
+		"build:    " + updater.BuildInfo(),
+		"os/arch:  " + runtime.GOOS + "/" + runtime.GOARCH,
+		"go:       " + runtime.Version(),
+		"config:   " + app.getConfigPath(),
+		"storage:  " + storageLine,
+		"api:      " + app.apiURL,
+		"update:   " + update,
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (app *App) showAbout() {
+	diag := app.diagnostics()
+
+	textView := tview.NewTextView().
+		SetText(tview.Escape(diag) + "\n\n" + tagDim + "c copy diagnostics · esc back[-]").
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+
+	textView.SetBorder(true).
+		SetTitle(" about justtype ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	// Handle keys
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyEnter {
+			app.pages.RemovePage("about")
+			app.resumeEditor()
+			return nil
+		}
+		if event.Rune() == 'c' {
+			if err := clipboard.Copy(diag); err != nil {
+				app.showError(fmt.Sprintf("Couldn't copy diagnostics: %v\n\nSelect the text on screen instead.", err))
+				return nil
+			}
+			app.notifications.Info("diagnostics copied")
+			app.pages.RemovePage("about")
+			app.resumeEditor()
+			return nil
+		}
+		return event
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(textView, 12, 0, true).
+			AddItem(nil, 0, 1, false), 80, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddAndSwitchToPage("about", centered, true)
+	app.tviewApp.SetFocus(textView)
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/justtype/cli/internal/updater"
+)
+
+func TestDiagnosticsFields(t *testing.T) {
+	home := t.TempDir()
+	app := hintsApp(t, home, `{"storage_path": "/notes", "username": "ada", "token": "secret-token"}`)
+	app.isCloud = false
+	app.updateAvailable = "9.9.9"
+
+	diag := app.diagnostics()
+	for _, want := range []string{
+		"version:  " + updater.GetVersion(),
+		"build:    " + updater.BuildInfo(),
+		"os/arch:  " + runtime.GOOS + "/" + runtime.GOARCH,
+		"go:       " + runtime.Version(),
+		"config:   " + app.getConfigPath(),
+		"storage:  local (/notes)",
+		"api:      " + app.apiURL,
+		"update:   v9.9.9 available",
+	} {
+		if !strings.Contains(diag, want+"\n") && !strings.HasSuffix(diag, want) {
+			t.Errorf("diagnostics has no line %q:\n%s", want, diag)
+		}
+	}
+	if !strings.HasPrefix(app.getConfigPath(), home) {
+		t.Errorf("config path %q isn't under the home", app.getConfigPath())
+	}
+	for _, private := range []string{"ada", "secret-token"} {
+		if strings.Contains(diag, private) {
+			t.Errorf("diagnostics include %q", private)
+		}
+	}
+
+	app.isCloud = true
+	app.updateAvailable = ""
+	app.updateMode = updater.ModeNever
+	diag = app.diagnostics()
+	for _, want := range []string{"storage:  cloud (cached in " + app.dataDir + ")", "update:   checks off"} {
+		if !strings.Contains(diag, want) {
+			t.Errorf("account diagnostics have no %q:\n%s", want, diag)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/api"
+	"github.com/rivo/tview"
+)
+
+// minPasswordLength is the shortest password registration accepts, and so
+// the shortest a new one can be
+const minPasswordLength = 8
+
+// showAccount lists what can be changed about the account from here
+func (app *App) showAccount() {
+	list := tview.NewList().
+		AddItem("change password", "", 'p', func() {
+			app.pages.RemovePage("account")
+			app.showChangePassword()
+		}).
+		AddItem("change email", "", 'm', func() {
+			app.pages.RemovePage("account")
+			app.showChangeEmail()
+		}).
+		AddItem("back", "", 'b', func() {
+			app.pages.RemovePage("account")
+			app.showSettings()
+		})
+
+	list.SetSelectedBackgroundColor(colorPurple)
+	list.SetSelectedTextColor(colorBackground)
+	list.SetMainTextColor(colorForeground)
+	list.SetShortcutColor(colorPurple)
+
+	list.SetBorder(true).
+		SetTitle(" account: " + app.username + " ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			app.pages.RemovePage("account")
+			app.showSettings()
+			return nil
+		}
+		return event
+	})
+
+	app.showAccountPage("account", list, 5, 40)
+}
+
+func (app *App) showChangePassword() {
+	currentField := tview.NewInputField().
+		SetLabel("Current password").
+		SetMaskCharacter('*').
+		SetFieldWidth(30)
+	newField := tview.NewInputField().
+		SetLabel("New password").
+		SetMaskCharacter('*').
+		SetFieldWidth(30)
+	confirmField := tview.NewInputField().
+		SetLabel("Confirm").
+		SetMaskCharacter('*').
+		SetFieldWidth(30)
+
+	status := accountStatus()
+	form := tview.NewForm().
+		AddFormItem(currentField).
+		AddFormItem(newField).
+		AddFormItem(confirmField)
+
+	busy := false
+	form.AddButton("Change", func() {
+		if busy {
+			return
+		}
+		current, password := currentField.GetText(), newField.GetText()
+		switch {
+		case current == "":
+			status.SetText(tagError + "enter your current password[-]")
+			return
+		case len(password) < minPasswordLength:
+			status.SetText(fmt.Sprintf(tagError+"new password must be at least %d characters[-]", minPasswordLength))
+			return
+		case password != confirmField.GetText():
+			status.SetText(tagError + "passwords don't match[-]")
+			return
+		case password == current:
+			status.SetText(tagError + "that's the password you have[-]")
+			return
+		}
+
+		busy = true
+		status.SetText(tagDim + "changing password...[-]")
+		client := app.accountClient()
+		var rotated, rotatedRefresh string
+		client.OnTokenRefresh(func(token, refreshToken string) {
+			rotated = token
+			rotatedRefresh = refreshToken
+		})
+
+		go func() {
+			err := client.ChangePassword(current, password)
+			app.tviewApp.QueueUpdateDraw(func() {
+				busy = false
+				if err != nil {
+					// The server's reason, like a wrong current password
+					status.SetText(tagError + tview.Escape(err.Error()) + "[-]")
+					return
+				}
+				app.pages.RemovePage("change-password")
+				app.notifications.Info("password changed")
+				if rotated != "" {
+					app.token = rotated
+					app.refreshToken = rotatedRefresh
+					app.saveConfig()
+					app.confirmRelogin()
+					return
+				}
+				app.showAccountDone("password changed")
+			})
+		}()
+	})
+
+	form.AddButton("Cancel", func() {
+		app.pages.RemovePage("change-password")
+		app.showAccount()
+	})
+
+	app.showAccountForm("change-password", " change password ", form, status, 14)
+}
+
+// confirmRelogin offers to log in again after a password change ended the
+// session this device had. The new token is saved already, but the storage
+// open now still holds the old one.
+func (app *App) confirmRelogin() {
+	modal := tview.NewModal().
+		SetText("password changed.\n\nthe server started a new session. log in again on this device now?").
+		AddButtons([]string{"Log In Again", "Later"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("relogin")
+			if buttonIndex == 0 {
+				app.forgetCredentials()
+				app.showAuth()
+				return
+			}
+			app.showSettings()
+		})
+
+	modal.SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("relogin", modal, true, true)
+}
+
+func (app *App) showChangeEmail() {
+	emailField := tview.NewInputField().
+		SetLabel("New email").
+		SetFieldWidth(34)
+
+	status := accountStatus()
+	form := tview.NewForm().AddFormItem(emailField)
+
+	busy := false
+	form.AddButton("Send code", func() {
+		if busy {
+			return
+		}
+		email := strings.TrimSpace(emailField.GetText())
+		if !looksLikeEmail(email) {
+			status.SetText(tagError + "enter an email address[-]")
+			return
+		}
+
+		busy = true
+		status.SetText(tagDim + "sending a code to " + tview.Escape(email) + "...[-]")
+		client := app.accountClient()
+		go func() {
+			err := client.UpdateEmail(email)
+			app.tviewApp.QueueUpdateDraw(func() {
+				busy = false
+				if err != nil {
+					status.SetText(tagError + tview.Escape(err.Error()) + "[-]")
+					return
+				}
+				app.pages.RemovePage("change-email")
+				app.showConfirmEmail(email)
+			})
+		}()
+	})
+
+	form.AddButton("Cancel", func() {
+		app.pages.RemovePage("change-email")
+		app.showAccount()
+	})
+
+	app.showAccountForm("change-email", " change email ", form, status, 9)
+}
+
+// showConfirmEmail takes the code mailed to the new address
+func (app *App) showConfirmEmail(email string) {
+	codeField := tview.NewInputField().
+		SetLabel("Code").
+		SetFieldWidth(10).
+		SetAcceptanceFunc(tview.InputFieldInteger)
+
+	status := accountStatus()
+	status.SetText(tagDim + "a code was sent to " + tview.Escape(email) + "[-]")
+	form := tview.NewForm().AddFormItem(codeField)
+
+	busy := false
+	form.AddButton("Verify", func() {
+		if busy {
+			return
+		}
+		code := strings.TrimSpace(codeField.GetText())
+		if code == "" {
+			status.SetText(tagError + "enter the code from the email[-]")
+			return
+		}
+
+		busy = true
+		status.SetText(tagDim + "verifying...[-]")
+		client := app.accountClient()
+		go func() {
+			err := client.ConfirmEmail(code)
+			app.tviewApp.QueueUpdateDraw(func() {
+				busy = false
+				if err != nil {
+					status.SetText(tagError + tview.Escape(err.Error()) + "[-]")
+					return
+				}
+				app.pages.RemovePage("confirm-email")
+				app.notifications.Info("email changed to " + email)
+				app.showAccountDone("email changed to " + email)
+			})
+		}()
+	})
+
+	form.AddButton("Cancel", func() {
+		app.pages.RemovePage("confirm-email")
+		app.showAccount()
+	})
+
+	app.showAccountForm("confirm-email", " confirm email ", form, status, 9)
+}
+
+// showAccountDone confirms a change and goes back to the account list
+func (app *App) showAccountDone(message string) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("account-done")
+			app.showAccount()
+		})
+
+	modal.SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("account-done", modal, true, true)
+}
+
+// accountClient is a client for the account endpoints, on the session
+// the storage uses
+func (app *App) accountClient() *api.Client {
+	client := api.New(app.apiURL, app.token)
+	client.SetTimeout(app.requestTimeout)
+	client.SetRefreshToken(app.refreshToken)
+	client.OnTokenRefresh(app.tokenRefreshed)
+	return client
+}
+
+// accountStatus is the line under an account form where validation and
+// the server's errors are shown, rather than in a modal over the form
+func accountStatus() *tview.TextView {
+	status := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true)
+	status.SetBackgroundColor(colorBackground)
+	return status
+}
+
+// showAccountForm shows form with status under it, esc going back to the
+// account list
+func (app *App) showAccountForm(page, title string, form *tview.Form, status *tview.TextView, height int) {
+	form.SetBackgroundColor(colorBackground)
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			app.pages.RemovePage(page)
+			app.showAccount()
+			return nil
+		}
+		return event
+	})
+
+	box := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(status, 2, 0, false)
+	box.SetBorder(true).
+		SetTitle(title).
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	app.showAccountPage(page, box, height, 60)
+}
+
+func (app *App) showAccountPage(page string, item tview.Primitive, height, width int) {
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(item, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage(page, centered, true, true)
+	app.tviewApp.SetFocus(item)
+}
+
+// looksLikeEmail is the check the form makes before the server's own: a
+// name, an @ and a domain with a dot in it
+func looksLikeEmail(s string) bool {
+	at := strings.LastIndex(s, "@")
+	return at > 0 && strings.Contains(s[at+1:], ".") && !strings.ContainsAny(s, " \t")
+}
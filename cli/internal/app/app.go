@@ -1,14 +1,23 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/idlelock"
+	"github.com/justtype/cli/internal/inbox"
+	"github.com/justtype/cli/internal/jsonfields"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/notify"
 	"github.com/justtype/cli/internal/storage"
 	"github.com/justtype/cli/internal/updater"
 	"github.com/rivo/tview"
@@ -25,38 +34,163 @@ const (
 type App struct {
 	tviewApp *tview.Application
 	pages    *tview.Pages
+	running  atomic.Bool // tviewApp's event loop is up, for updates from other goroutines
 
 	// Storage
+	dataDir     string           // ~/.justtype or $JUSTTYPE_HOME, or the profile's under it
+	configPath  string           // config.json, or the profile's
+	configExtra jsonfields.Extra // keys in config.json this UI has no setting for, kept on save
 	storage     storage.Storage
 	storagePath string
+	backend     string // storage.BackendJSON (default) or BackendSQLite, for local storage
 	isCloud     bool
+	file        *storage.FileStorage // set when editing a file given on the command line
 
 	// Auth
-	token    string
-	username string
-	apiURL   string
+	token        string
+	refreshToken string // renews token when the server rejects it; see tokenRefreshed
+	username     string
+	apiURL       string // resolved: $JUSTTYPE_API_URL, then api_url, then the default
+	savedAPIURL  string // api_url as written in config.json
+	caCertFile   string // extra root certificates, for a TLS-inspecting proxy
+	noProxy      string // hosts reached without the proxy
+	e2eKey       string // end-to-end encryption key, empty when off
 
 	// Current state
 	currentSlate *storage.Slate
 	slates       []*storage.Slate
 
+	// The slates list shows this many until "load all", 0 for all of them
+	recentLimit   int
+	showAllSlates bool
+	unloaded      int // slates past recentLimit not read from the storage yet
+
 	// Auto-save
-	saveTimer  *time.Timer
-	isDirty    bool
-	saveStatus string // "saved", "saving...", ""
+	saveTimer       *time.Timer
+	autosaveSeconds int // pause in typing before saving, 0 for ctrl+s only
+	isDirty         bool
+	saveStatus      string // "saved", "saving...", ""
 
 	// Update checking
 	lastUpdateCheck time.Time
 	updateAvailable string // version string if update available
+	updateMode      string // updater.ModeAuto, ModeNotify or ModeNever
+	updateSnoozed   time.Time
+	updateChannel   string // updater.ChannelStable or ChannelBeta
+	skippedVersion  string // never offered again
+	lastCheck       time.Time
+	latestVersion   string // what the check at lastCheck found
+
+	// Deletes
+	confirmDelete bool
+	undoSlate     *storage.Slate // last deleted slate, while undo is possible
+	undoTimer     *time.Timer
+	sweepMinutes  int // how often to sweep blank slates, 0 for only on quit
+
+	// New slates aren't saved until they have this many words
+	minWords int
+
+	// Words to aim for, shown as progress in the footer; 0 for none
+	wordGoal int
+
+	// How long a request to the server may take, 0 for api.DefaultTimeout
+	requestTimeout time.Duration
+
+	// Longest title taken from a slate's first line, 0 for the default
+	titleLength int
+
+	// Content cleanup on save
+	keepLineEnds bool
+	trimTrailing bool // off by default; trailing spaces can be deliberate
+	finalNewline bool
+
+	// Text files dropped here are imported as slates
+	inboxDir string
+	inbox    *inbox.Watcher
+
+	// Recent status and error messages, viewable with ctrl+l
+	notifications *notify.Log
+
+	// First-use tips; see hints.go
+	dismissedHints map[string]bool
+	currentHint    string
+	hintsOff       bool
+
+	// Idle lock; see lock.go
+	idle        *idlelock.Idle
+	locked      bool
+	lockMinutes int    // 0 for off
+	lockHash    string // hashed passphrase, empty unlocks with enter
+
+	// Failed delete/publish attempts by slate ID, shown in the slates list
+	slateErrors map[string]string
+
+	// Vim keys in the editor when editorMode is "vim"; see vim.go
+	editorMode string
+	vim        vimState
+
+	// Command for "edit in $EDITOR", empty for $EDITOR or $VISUAL
+	externalEditor string
+
+	// Colors from the theme setting; see applyTheme
+	themeName   string
+	themeColors config.Theme
+
+	// Loading animation from the spinner_style setting; see spinner.go
+	spinnerStyle  string
+	spinnerFrames []string
+
+	// Find and replace bar under the editor, nil when closed; see find.go
+	find *finder
+
+	// Keep the cursor mid-screen and dim other paragraphs; see typewriter.go
+	typewriter bool
+
+	// Markdown preview; see preview.go
+	preview        *tview.TextView
+	previewOn      bool
+	previewOverlay bool // covering the editor rather than beside it
+	previewTimer   *time.Timer
 
 	// UI components (created on demand)
 	editor       *tview.TextArea
+	editorBody   *tview.Flex // editor and, when split, the preview
+	editorColumn *tview.Flex // header, body and footer
+	editorLayout *tview.Flex // centers editorColumn
 	menuModal    *tview.Modal
 	slatesList   *tview.List
+	slatesHelp   *tview.TextView
 	settingsList *tview.List
 }
 
-func New() *App {
+func New() (*App, error) {
+	dataDir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := config.Path()
+	if err != nil {
+		return nil, err
+	}
+
+	app := &App{
+		tviewApp:        tview.NewApplication(),
+		pages:           tview.NewPages(),
+		dataDir:         dataDir,
+		configPath:      configPath,
+		confirmDelete:   true,
+		notifications:   notify.New(notify.DefaultSize),
+		autosaveSeconds: config.DefaultAutosaveSeconds,
+		slateErrors:     make(map[string]string),
+		idle:            idlelock.New(0),
+	}
+
+	// Load config
+	app.loadConfig()
+	if err := app.applyConfig(); err != nil {
+		return nil, err
+	}
+
 	// Set tview theme to match our color scheme
 	tview.Styles = tview.Theme{
 		PrimitiveBackgroundColor:    colorBackground,
@@ -72,137 +206,485 @@ func New() *App {
 		ContrastSecondaryTextColor:  colorDim,
 	}
 
-	app := &App{
-		tviewApp: tview.NewApplication(),
-		pages:    tview.NewPages(),
-		apiURL:   "https://justtype.io",
-	}
+	return app, nil
+}
 
-	// Load config
-	app.loadConfig()
+// applyConfig puts the settings loadConfig read into effect outside the
+// app: the server, the update channel, title length and the theme
+func (app *App) applyConfig() error {
+	var err error
+	app.apiURL, err = config.ResolveAPIURL(app.savedAPIURL)
+	if err != nil {
+		return err
+	}
+	updater.SetAPIURL(app.apiURL)
+	if err := updater.ConfigureTransport(app.caCertFile, app.noProxy); err != nil {
+		return err
+	}
+	updater.SetChannel(app.updateChannel)
+	updater.SetLastCheck(app.lastCheck, app.latestVersion)
+	app.autosaveSeconds = config.NormalizeAutosave(app.autosaveSeconds)
+	storage.SetTitleLength(app.titleLength)
 
-	return app
+	theme, err := config.ResolveTheme(app.themeName, app.themeColors)
+	if err != nil {
+		app.notifications.Error(err.Error())
+	}
+	applyTheme(theme)
+	return nil
 }
 
 func (app *App) Run() error {
 	// Check for updates in background (non-blocking)
 	go app.checkAndUpdate()
 
-	// Check if first run
+	if app.sweepMinutes > 0 {
+		go app.sweepBlankSlates(time.Duration(app.sweepMinutes) * time.Minute)
+	}
+
+	// Any key counts as activity for the idle lock
+	app.tviewApp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		app.idle.Touch()
+		return event
+	})
+	go app.watchIdle()
+
+	app.tviewApp.SetAfterDrawFunc(app.dimOutsideFocus)
+
+	if app.file != nil {
+		// Editing one file in place: no store, sync or inbox
+		app.showEditor(app.file.Slate())
+	} else if err := app.start(); err != nil {
+		return err
+	}
+
+	app.running.Store(true)
+	defer app.running.Store(false)
+	return app.tviewApp.SetRoot(app.pages, true).Run()
+}
+
+// start opens the slates the config points at and goes to the editor, or
+// shows the welcome screen on a first run
+func (app *App) start() error {
 	if app.token == "" && app.storagePath == "" {
-		// First run - show welcome
 		app.showWelcome()
-	} else {
-		// Initialize storage
-		if err := app.initStorage(); err != nil {
-			return fmt.Errorf("failed to initialize storage: %w", err)
-		}
+		return nil
+	}
 
-		// Go straight to editor
-		app.showEditor(nil)
+	if err := app.initStorage(); err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	return app.tviewApp.SetRoot(app.pages, true).Run()
+	// Go straight to editor, unless the slates need a passphrase first
+	if local, ok := app.localStorage(); ok && local.Locked() {
+		app.showUnlock(local)
+	} else {
+		app.showEditor(nil)
+	}
+	return nil
 }
 
 func (app *App) initStorage() error {
+	s, err := app.openStorage()
+	if err != nil {
+		return err
+	}
+
+	app.storage = s
+	app.file = nil
+	cloud, isCloud := s.(*storage.CloudStorage)
+	app.isCloud = isCloud
+	if app.isCloud {
+		app.storagePath = filepath.Join(app.dataDir, "temp")
+		if err := cloud.StartSession(); err != nil {
+			app.notifications.Error(err.Error())
+		} else {
+			go app.checkDraft(cloud)
+		}
+	}
+
+	app.startInbox()
+
+	// Don't load slates on init - fetch on demand
+	return nil
+}
+
+// OpenFile makes Run edit the file at path in place instead of opening the
+// slates store
+func (app *App) OpenFile(path string) error {
+	file, err := storage.NewFile(path)
+	if err != nil {
+		return err
+	}
+	app.file = file
+	app.storage = file
+	app.isCloud = false
+	return nil
+}
+
+// openStorage creates the backend for the configured mode
+func (app *App) openStorage() (storage.Storage, error) {
 	if app.token != "" {
 		// Cloud storage - use temp dir instead of persistent storage
-		homeDir, _ := os.UserHomeDir()
-		tempDir := filepath.Join(homeDir, ".justtype", "temp")
+		tempDir := filepath.Join(app.dataDir, "temp")
 		cloud, err := storage.NewCloud(tempDir, app.apiURL, app.token, app.username)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		app.storage = cloud
-		app.storagePath = tempDir
-		app.isCloud = true
-	} else if app.storagePath != "" {
+		if err := app.setUpCloud(cloud); err != nil {
+			return nil, err
+		}
+		return cloud, nil
+	}
+
+	if app.storagePath != "" && app.backend == storage.BackendSQLite {
+		db, err := storage.NewSQLite(app.storagePath)
+		if err != nil {
+			return nil, err
+		}
+		// The first time, bring over the slates from the JSON backend. The
+		// database works without them, so failing that doesn't stop it.
+		if _, err := storage.MigrateJSON(app.storagePath, db); err != nil {
+			app.notifications.Error(err.Error())
+		}
+		db.SetNormalize(app.normalizeOptions())
+		return db, nil
+	}
+
+	if app.storagePath != "" {
 		// Local storage
 		local, err := storage.NewLocal(app.storagePath)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		app.storage = local
-		app.isCloud = false
-	} else {
-		return fmt.Errorf("no storage configured")
+		local.SetNormalize(app.normalizeOptions())
+		if notice := local.Recovered(); notice != "" {
+			app.notifications.Error(notice)
+		}
+		return local, nil
 	}
 
-	// Don't load slates on init - fetch on demand
-	return nil
+	return nil, fmt.Errorf("no storage configured")
 }
 
-type Config struct {
-	Token       string `json:"token"`
-	Username    string `json:"username"`
-	StoragePath string `json:"storage_path"`
+// OpenStorage opens the slates the app would show, without starting the UI,
+// for headless subcommands that write; those that only read use
+// OpenReader. The caller closes it. Warnings are what the UI
+// would have shown about opening them, such as a damaged slates.json set
+// aside, for the caller to pass on.
+func OpenStorage() (s storage.Storage, warnings []string, err error) {
+	app, err := New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if app.token == "" && app.storagePath == "" {
+		return nil, nil, fmt.Errorf("no storage configured, run justtype once to set it up")
+	}
+	s, err = app.openStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range app.notifications.Entries() {
+		warnings = append(warnings, entry.Message)
+	}
+	return s, warnings, nil
 }
 
-func (app *App) getConfigPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".justtype", "config.json")
+// setUpCloud configures cloud storage for the account: its key, timeout,
+// session renewal and offline cache
+func (app *App) setUpCloud(cloud *storage.CloudStorage) error {
+	if app.e2eKey != "" {
+		key, err := e2e.ParseKey(app.e2eKey)
+		if err != nil {
+			return err
+		}
+		cloud.SetEncryptionKey(key)
+	}
+	cloud.SetNormalize(app.normalizeOptions())
+	cloud.SetTimeout(app.requestTimeout)
+	cloud.SetRefreshToken(app.refreshToken)
+	cloud.OnTokenRefresh(app.tokenRefreshed)
+	return cloud.EnableCache(app.cacheDir())
 }
 
-func (app *App) loadConfig() {
-	configPath := app.getConfigPath()
-	data, err := os.ReadFile(configPath)
+// OpenReader opens the slates for headless commands that only read them,
+// such as list and serve. Like ReadStatus it leaves everything on disk as
+// it is: nothing is migrated, set aside or re-encrypted, and recovering
+// damaged slates is left to the editor.
+func OpenReader() (storage.Reader, error) {
+	app, err := New()
 	if err != nil {
-		// Config doesn't exist yet, that's fine
-		return
+		return nil, err
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		// Invalid config, ignore
+	if app.token != "" {
+		tempDir := filepath.Join(app.dataDir, "temp")
+		cloud, err := storage.NewCloudReader(tempDir, app.apiURL, app.token, app.username)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.setUpCloud(cloud); err != nil {
+			return nil, err
+		}
+		return cloud, nil
+	}
+
+	if app.storagePath == "" {
+		return nil, fmt.Errorf("no storage configured, run justtype once to set it up")
+	}
+	return storage.ReadLocal(app.storagePath, app.backend)
+}
+
+// cacheDir is where the account's slates are kept for offline use, per
+// account so logging in as someone else never mixes slates. The username
+// comes from the server, so it's kept to a single name inside cache/.
+func (app *App) cacheDir() string {
+	name := filepath.Base(app.username)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		name = "_"
+	}
+	return filepath.Join(app.dataDir, "cache", name)
+}
+
+// tokenRefreshed saves the tokens from a silent refresh, and hands them to
+// the storage if another client made it. It's called on the request's
+// goroutine, so while the UI runs the change waits its turn there.
+func (app *App) tokenRefreshed(token, refreshToken string) {
+	if !app.running.Load() {
+		// A headless subcommand, with only the storage's client
+		app.token = token
+		app.refreshToken = refreshToken
+		app.saveConfig()
 		return
 	}
+	app.tviewApp.QueueUpdate(func() {
+		app.token = token
+		app.refreshToken = refreshToken
+		app.saveConfig()
+		if cloud, ok := app.storage.(*storage.CloudStorage); ok {
+			cloud.SetTokens(token, refreshToken)
+		}
+	})
+}
+
+// normalizeOptions is how content is cleaned up on save
+func (app *App) normalizeOptions() normalize.Options {
+	return normalize.Options{
+		KeepLineEndings: app.keepLineEnds,
+		TrimTrailing:    app.trimTrailing,
+		FinalNewline:    app.finalNewline,
+	}
+}
+
+type Config struct {
+	Token           string       `json:"token"`
+	RefreshToken    string       `json:"refresh_token,omitempty"`
+	Username        string       `json:"username"`
+	APIURL          string       `json:"api_url,omitempty"`
+	StoragePath     string       `json:"storage_path"`
+	Backend         string       `json:"storage_backend,omitempty"`
+	ConfirmDelete   bool         `json:"confirm_delete"`
+	AutosaveSeconds int          `json:"autosave_seconds"`
+	E2EKey          string       `json:"e2e_key,omitempty"`
+	UpdateMode      string       `json:"update_mode,omitempty"`
+	UpdateSnoozed   time.Time    `json:"update_snoozed_until,omitzero"`
+	UpdateChannel   string       `json:"update_channel,omitempty"`
+	SkippedVersion  string       `json:"skipped_version,omitempty"`
+	LastCheck       time.Time    `json:"last_update_check,omitzero"`
+	LatestVersion   string       `json:"latest_version,omitempty"`
+	SweepMinutes    int          `json:"empty_sweep_minutes,omitempty"`
+	MinWords        int          `json:"min_words,omitempty"`
+	InboxDir        string       `json:"inbox_dir,omitempty"`
+	KeepLineEnds    bool         `json:"keep_line_endings,omitempty"`
+	TrimTrailing    bool         `json:"trim_trailing_whitespace,omitempty"`
+	FinalNewline    bool         `json:"final_newline,omitempty"`
+	SeenHints       []string     `json:"seen_hints,omitempty"`
+	HideHints       bool         `json:"hide_hints,omitempty"`
+	LockMinutes     int          `json:"idle_lock_minutes,omitempty"`
+	LockHash        string       `json:"idle_lock_passphrase,omitempty"`
+	RecentLimit     int          `json:"startup_recent_limit,omitempty"`
+	WordGoal        int          `json:"word_goal,omitempty"`
+	EditorMode      string       `json:"editor_mode,omitempty"` // "vim" for vim keys, empty for standard
+	TypewriterMode  bool         `json:"typewriter_mode,omitempty"`
+	Theme           string       `json:"theme,omitempty"`
+	ThemeColors     config.Theme `json:"theme_colors,omitzero"`
+	SpinnerStyle    string       `json:"spinner_style,omitempty"`
+	SpinnerFrames   []string     `json:"spinner_frames,omitempty"`
+	Editor          string       `json:"editor,omitempty"`
+	RequestTimeout  int          `json:"request_timeout_seconds,omitempty"`
+	TitleLength     int          `json:"title_length,omitempty"`
+	CACertFile      string       `json:"ca_cert_file,omitempty"`
+	NoProxy         string       `json:"no_proxy,omitempty"`
+}
+
+func (app *App) getConfigPath() string {
+	return app.configPath
+}
+
+// loadConfig reads the config, setting every field it keeps so switching
+// profiles leaves nothing of the last one. A missing or invalid config
+// gives the defaults. Settings only the subcommands use, like
+// search_scope or sync_workers, are kept for saveConfig to write back.
+func (app *App) loadConfig() {
+	defaults := Config{ConfirmDelete: true, AutosaveSeconds: config.DefaultAutosaveSeconds}
+	config := defaults
+	app.configExtra = nil
+	if data, err := os.ReadFile(app.getConfigPath()); err == nil {
+		extra, err := jsonfields.Split(data, &config)
+		if err != nil {
+			config = defaults
+		}
+		app.configExtra = extra
+	}
 
 	app.token = config.Token
+	app.refreshToken = config.RefreshToken
 	app.username = config.Username
+	app.savedAPIURL = config.APIURL
 	app.storagePath = config.StoragePath
+	app.backend = config.Backend
+	app.confirmDelete = config.ConfirmDelete
+	app.autosaveSeconds = config.AutosaveSeconds
+	app.e2eKey = config.E2EKey
+	app.updateMode = updater.NormalizeMode(config.UpdateMode)
+	app.updateSnoozed = config.UpdateSnoozed
+	app.updateChannel = updater.NormalizeChannel(config.UpdateChannel)
+	app.skippedVersion = config.SkippedVersion
+	app.lastCheck = config.LastCheck
+	app.latestVersion = config.LatestVersion
+	app.sweepMinutes = config.SweepMinutes
+	app.minWords = config.MinWords
+	app.inboxDir = config.InboxDir
+	app.keepLineEnds = config.KeepLineEnds
+	app.trimTrailing = config.TrimTrailing
+	app.finalNewline = config.FinalNewline
+	app.hintsOff = config.HideHints
+	app.lockMinutes = config.LockMinutes
+	app.lockHash = config.LockHash
+	app.recentLimit = config.RecentLimit
+	app.wordGoal = config.WordGoal
+	app.editorMode = config.EditorMode
+	app.typewriter = config.TypewriterMode
+	app.themeName = config.Theme
+	app.themeColors = config.ThemeColors
+	app.spinnerStyle = config.SpinnerStyle
+	app.spinnerFrames = config.SpinnerFrames
+	app.externalEditor = config.Editor
+	app.requestTimeout = time.Duration(config.RequestTimeout) * time.Second
+	app.titleLength = config.TitleLength
+	app.caCertFile = config.CACertFile
+	app.noProxy = config.NoProxy
+	app.dismissedHints = make(map[string]bool)
+	for _, id := range config.SeenHints {
+		app.dismissedHints[id] = true
+	}
 }
 
 func (app *App) saveConfig() {
-	homeDir, _ := os.UserHomeDir()
-	configDir := filepath.Join(homeDir, ".justtype")
-	os.MkdirAll(configDir, 0755)
+	os.MkdirAll(app.dataDir, 0755)
+
+	var seenHints []string
+	for _, id := range hintOrder {
+		if app.dismissedHints[id] {
+			seenHints = append(seenHints, id)
+		}
+	}
 
 	config := Config{
-		Token:       app.token,
-		Username:    app.username,
-		StoragePath: app.storagePath,
+		Token:           app.token,
+		RefreshToken:    app.refreshToken,
+		Username:        app.username,
+		APIURL:          app.savedAPIURL,
+		StoragePath:     app.storagePath,
+		Backend:         app.backend,
+		ConfirmDelete:   app.confirmDelete,
+		AutosaveSeconds: app.autosaveSeconds,
+		E2EKey:          app.e2eKey,
+		UpdateMode:      app.updateMode,
+		UpdateSnoozed:   app.updateSnoozed,
+		UpdateChannel:   app.updateChannel,
+		SkippedVersion:  app.skippedVersion,
+		LastCheck:       app.lastCheck,
+		LatestVersion:   app.latestVersion,
+		SweepMinutes:    app.sweepMinutes,
+		MinWords:        app.minWords,
+		InboxDir:        app.inboxDir,
+		KeepLineEnds:    app.keepLineEnds,
+		TrimTrailing:    app.trimTrailing,
+		FinalNewline:    app.finalNewline,
+		SeenHints:       seenHints,
+		HideHints:       app.hintsOff,
+		LockMinutes:     app.lockMinutes,
+		LockHash:        app.lockHash,
+		RecentLimit:     app.recentLimit,
+		WordGoal:        app.wordGoal,
+		EditorMode:      app.editorMode,
+		TypewriterMode:  app.typewriter,
+		Theme:           app.themeName,
+		ThemeColors:     app.themeColors,
+		SpinnerStyle:    app.spinnerStyle,
+		SpinnerFrames:   app.spinnerFrames,
+		Editor:          app.externalEditor,
+		RequestTimeout:  int(app.requestTimeout / time.Second),
+		TitleLength:     app.titleLength,
+		CACertFile:      app.caCertFile,
+		NoProxy:         app.noProxy,
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := jsonfields.Join(config, app.configExtra)
 	if err != nil {
 		return
 	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return
+	}
 
-	os.WriteFile(app.getConfigPath(), data, 0600)
+	os.WriteFile(app.getConfigPath(), indented.Bytes(), 0600)
 }
 
 func (app *App) getDefaultStoragePath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".justtype")
+	return app.dataDir
 }
 
 func (app *App) checkAndUpdate() {
+	if !updater.ShouldCheck(app.updateMode) {
+		return
+	}
+
 	// Wait for UI to be ready
 	time.Sleep(500 * time.Millisecond)
 
-	// Check for updates
+	// Check for updates, or take the last check's answer if it's recent
 	info, err := updater.CheckForUpdate()
+	if at, version := updater.LastUpdateCheck(); !at.Equal(app.lastCheck) {
+		app.tviewApp.QueueUpdateDraw(func() {
+			app.lastCheck, app.latestVersion = at, version
+			app.saveConfig()
+		})
+	}
 	if err != nil {
 		// Fail silently - don't interrupt user experience
 		return
 	}
 
-	if !info.Available {
+	if !info.Available || info.LatestVersion == app.skippedVersion {
 		// Already up to date
 		return
 	}
 
+	if !updater.ShouldPrompt(app.updateMode, app.updateSnoozed, time.Now()) {
+		return
+	}
+
+	if app.updateMode == updater.ModeNotify {
+		app.tviewApp.QueueUpdateDraw(func() {
+			app.promptUpdate(info.CurrentVersion, info.LatestVersion)
+		})
+		return
+	}
+
 	// Show update notification
 	app.tviewApp.QueueUpdateDraw(func() {
 		modal := tview.NewModal().
@@ -238,7 +720,7 @@ func (app *App) checkAndUpdate() {
 		}
 
 		// Real error - show error message
-		message := fmt.Sprintf("Update failed: %v\n\nRun this command to update:\ncurl -fsSL https://justtype.io/cli/install.sh | bash", err)
+		message := fmt.Sprintf("Update failed: %v\n\nRun this command to update:\n%s", err, updater.InstallCommand())
 
 		app.tviewApp.QueueUpdateDraw(func() {
 			app.pages.RemovePage("update")
@@ -277,6 +759,10 @@ func (app *App) checkAndUpdate() {
 }
 
 func (app *App) checkForUpdates() {
+	if !updater.ShouldPrompt(app.updateMode, app.updateSnoozed, time.Now()) {
+		return
+	}
+
 	// Throttle: only check once per 24 hours
 	if time.Since(app.lastUpdateCheck) < 24*time.Hour {
 		return
@@ -285,61 +771,92 @@ func (app *App) checkForUpdates() {
 	// Get latest version from cloud storage (if available)
 	if cs, ok := app.storage.(*storage.CloudStorage); ok {
 		latestVersion := cs.GetLatestVersion()
-		if latestVersion != "" && latestVersion != updater.GetVersion() {
+		if updater.Newer(latestVersion, updater.GetVersion()) && latestVersion != app.skippedVersion {
 			app.lastUpdateCheck = time.Now()
 			app.updateAvailable = latestVersion
 
 			// Show update notification
 			app.tviewApp.QueueUpdateDraw(func() {
-				modal := tview.NewModal().
-					SetText(fmt.Sprintf("Update available: %s → %s\n\nUpdate now?", updater.GetVersion(), latestVersion)).
-					AddButtons([]string{"Update", "Later"}).
-					SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-						app.pages.RemovePage("update-available")
-						if buttonIndex == 0 {
-							// Trigger update
-							go func() {
-								if err := updater.Update(); err != nil {
-									app.tviewApp.QueueUpdateDraw(func() {
-										app.showError(fmt.Sprintf("Update failed: %v", err))
-									})
-								} else {
-									app.tviewApp.QueueUpdateDraw(func() {
-										successModal := tview.NewModal().
-											SetText("Updated! Please restart justtype.").
-											AddButtons([]string{"Quit"}).
-											SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-												app.tviewApp.Stop()
-											}).
-											SetBackgroundColor(colorBackground).
-											SetTextColor(colorGreen).
-											SetButtonBackgroundColor(colorPurple).
-											SetButtonTextColor(colorForeground)
-
-										app.pages.AddPage("update-success", successModal, true, true)
-									})
-								}
-							}()
-						}
-					}).
-					SetBackgroundColor(colorBackground).
-					SetTextColor(colorForeground).
-					SetButtonBackgroundColor(colorPurple).
-					SetButtonTextColor(colorForeground)
-
-				app.pages.AddPage("update-available", modal, true, true)
+				app.promptUpdate(updater.GetVersion(), latestVersion)
 			})
 		}
 	}
 }
 
+// promptUpdate asks before updating. "Later" snoozes the prompt; "Skip this
+// version" stops offering latest.
+func (app *App) promptUpdate(current, latest string) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Update available: %s → %s\n\nUpdate now?", current, latest)).
+		AddButtons([]string{"Update", "Later", "Skip this version"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("update-available")
+			if buttonIndex == 2 {
+				app.skipVersion(latest)
+				return
+			}
+			if buttonIndex != 0 {
+				app.snoozeUpdates()
+				return
+			}
+
+			// Trigger update
+			go func() {
+				if err := updater.Update(); err != nil {
+					app.tviewApp.QueueUpdateDraw(func() {
+						app.showError(fmt.Sprintf("Update failed: %v", err))
+					})
+				} else {
+					app.tviewApp.QueueUpdateDraw(func() {
+						successModal := tview.NewModal().
+							SetText("Updated! Please restart justtype.").
+							AddButtons([]string{"Quit"}).
+							SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+								app.tviewApp.Stop()
+							}).
+							SetBackgroundColor(colorBackground).
+							SetTextColor(colorGreen).
+							SetButtonBackgroundColor(colorPurple).
+							SetButtonTextColor(colorForeground)
+
+						app.pages.AddPage("update-success", successModal, true, true)
+					})
+				}
+			}()
+		}).
+		SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("update-available", modal, true, true)
+}
+
+// snoozeUpdates hides update prompts for updater.SnoozeDuration
+func (app *App) snoozeUpdates() {
+	app.updateSnoozed = time.Now().Add(updater.SnoozeDuration)
+	app.saveConfig()
+}
+
+// skipVersion stops offering version; a later one is still offered
+func (app *App) skipVersion(version string) {
+	app.skippedVersion = version
+	if app.updateAvailable == version {
+		app.updateAvailable = ""
+	}
+	app.saveConfig()
+}
+
 func (app *App) Close() {
+	app.stopInbox()
+	app.removeBlankSlates(true)
+
 	if app.storage != nil {
 		app.storage.Close()
 	}
 }
 
-// Theme colors
+// Theme colors, set by applyTheme
 var (
 	colorBackground = tcell.NewRGBColor(17, 17, 17)    // #111111
 	colorForeground = tcell.NewRGBColor(212, 212, 212) // #d4d4d4
@@ -347,3 +864,30 @@ var (
 	colorPurple     = tcell.NewRGBColor(139, 92, 246)  // #8B5CF6
 	colorGreen      = tcell.NewRGBColor(16, 185, 129)  // #10B981
 )
+
+// The theme colors as tview color tags, for text with dynamic colors
+var (
+	tagForeground = "[#d4d4d4]"
+	tagDim        = "[#666666]"
+	tagAccent     = "[#8B5CF6]"
+	tagSuccess    = "[#10B981]"
+	tagWarning    = "[#f59e0b]"
+	tagError      = "[#ef4444]"
+)
+
+// applyTheme sets the theme colors; the accent takes purple's place and
+// success green's
+func applyTheme(theme config.Theme) {
+	colorBackground = tcell.GetColor(theme.Background)
+	colorForeground = tcell.GetColor(theme.Foreground)
+	colorDim = tcell.GetColor(theme.Dim)
+	colorPurple = tcell.GetColor(theme.Accent)
+	colorGreen = tcell.GetColor(theme.Success)
+
+	tagForeground = "[" + theme.Foreground + "]"
+	tagDim = "[" + theme.Dim + "]"
+	tagAccent = "[" + theme.Accent + "]"
+	tagSuccess = "[" + theme.Success + "]"
+	tagWarning = "[" + theme.Warning + "]"
+	tagError = "[" + theme.Error + "]"
+}
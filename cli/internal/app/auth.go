@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"runtime"
@@ -41,12 +43,16 @@ func (app *App) showAuth() {
 	code.SetBorder(false).SetBackgroundColor(colorBackground)
 
 	status := tview.NewTextView().
-		SetText("● waiting for authorization...").
 		SetTextAlign(tview.AlignCenter).
 		SetTextColor(colorDim).
 		SetDynamicColors(true)
 	status.SetBorder(false).SetBackgroundColor(colorBackground)
 
+	// Offered in place of a dead screen once the code runs out
+	newCode := tview.NewButton("request new code")
+	newCode.SetStyle(tcell.StyleDefault.Background(colorBackground).Foreground(colorPurple))
+	newCode.SetActivatedStyle(tcell.StyleDefault.Background(colorPurple).Foreground(colorBackground))
+
 	help := tview.NewTextView().
 		SetText("browser will open automatically · o reopen · esc cancel").
 		SetTextAlign(tview.AlignCenter).
@@ -63,7 +69,9 @@ func (app *App) showAuth() {
 		AddItem(code, 3, 0, false).
 		AddItem(nil, 2, 0, false).
 		AddItem(status, 1, 0, false).
-		AddItem(nil, 2, 0, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(newCode, 0, 0, false).
+		AddItem(nil, 1, 0, false).
 		AddItem(help, 1, 0, false).
 		AddItem(nil, 0, 1, false)
 
@@ -74,13 +82,29 @@ func (app *App) showAuth() {
 
 	centered.SetBackgroundColor(colorBackground)
 
+	// Polling and the countdown stop when the screen is left
+	ctx, cancel := context.WithCancel(context.Background())
+	expired := false
+
+	requestNew := func() {
+		cancel()
+		app.pages.RemovePage(PageAuth)
+		app.showAuth()
+	}
+	newCode.SetSelectedFunc(requestNew)
+
 	// Handle keys
 	centered.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Rune() == 'o' {
+		if event.Rune() == 'o' && !expired {
 			openBrowser(dcr.VerificationURI)
 			return nil
 		}
+		if event.Rune() == 'r' && expired {
+			requestNew()
+			return nil
+		}
 		if event.Key() == tcell.KeyEsc {
+			cancel()
 			app.pages.SwitchToPage(PageWelcome)
 			return nil
 		}
@@ -89,21 +113,51 @@ func (app *App) showAuth() {
 
 	app.pages.AddPage(PageAuth, centered, true, true)
 
+	// showExpired swaps the waiting screen for the way to a new code
+	showExpired := func(message string) {
+		expired = true
+		status.SetText(tagError + "✗ " + message + "[-]")
+		content.ResizeItem(newCode, 1, 0)
+		help.SetText("enter or r request new code · esc cancel")
+		app.tviewApp.SetFocus(newCode)
+	}
+
+	// Count down to the code's expiry
+	deadline := time.Now().Add(time.Duration(dcr.ExpiresIn) * time.Second)
+	app.spin(ctx, func(frame string) {
+		if !expired {
+			status.SetText(waitingStatus(frame, time.Until(deadline)))
+		}
+	})
+
 	// Auto-open browser with code pre-filled
 	go openBrowser(dcr.VerificationURI + "?code=" + dcr.UserCode)
 
 	// Start polling for token in background
 	go func() {
-		tokenResp, err := deviceAuth.PollForToken(dcr.DeviceCode, dcr.Interval, dcr.ExpiresIn)
+		tokenResp, err := deviceAuth.PollForTokenCtx(ctx, dcr.DeviceCode, dcr.Interval, dcr.ExpiresIn)
+		if errors.Is(err, context.Canceled) {
+			// Left the screen
+			return
+		}
+		cancel()
 		if err != nil {
 			app.tviewApp.QueueUpdateDraw(func() {
-				status.SetText("[red]✗ " + err.Error())
+				switch {
+				case errors.Is(err, auth.ErrExpired):
+					showExpired("the code expired before it was approved")
+				case errors.Is(err, auth.ErrDenied):
+					showExpired("login was denied in the browser")
+				default:
+					showExpired(err.Error())
+				}
 			})
 			return
 		}
 
 		// Success!
 		app.token = tokenResp.Token
+		app.refreshToken = tokenResp.RefreshToken
 		app.username = tokenResp.Username
 		app.saveConfig()
 
@@ -141,3 +195,13 @@ func openBrowser(url string) {
 
 	cmd.Start()
 }
+
+// waitingStatus is the status line while the code waits for approval, after
+// a spinner frame, with the time it has left
+func waitingStatus(frame string, left time.Duration) string {
+	if left < 0 {
+		left = 0
+	}
+	seconds := int(left.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%s waiting for authorization... "+tagDim+"code expires in %d:%02d[-]", frame, seconds/60, seconds%60)
+}
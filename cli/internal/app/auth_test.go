@@ -0,0 +1,28 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitingStatusCountdown(t *testing.T) {
+	tests := []struct {
+		left time.Duration
+		want string
+	}{
+		{15 * time.Minute, "15:00"},
+		{10*time.Minute + 5*time.Second, "10:05"},
+		{61 * time.Second, "1:01"},
+		{59*time.Second + 600*time.Millisecond, "1:00"},
+		{9 * time.Second, "0:09"},
+		{0, "0:00"},
+		{-3 * time.Second, "0:00"},
+	}
+	for _, tt := range tests {
+		got := waitingStatus("*", tt.left)
+		if !strings.HasPrefix(got, "* waiting for authorization") || !strings.Contains(got, "code expires in "+tt.want+"[-]") {
+			t.Errorf("waitingStatus(%v) = %q, want it to count down %s", tt.left, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/justtype/cli/internal/storage"
+)
+
+// removeBlankSlates moves slates that were created empty and never written
+// in to the trash. The open slate is left alone unless closing is set, so a
+// sweep never pulls a fresh slate out from under the cursor.
+func (app *App) removeBlankSlates(closing bool) {
+	if app.storage == nil {
+		return
+	}
+
+	candidates := append([]*storage.Slate{}, app.slates...)
+	if app.currentSlate != nil {
+		candidates = append(candidates, app.currentSlate)
+	}
+
+	removed := false
+	seen := make(map[string]bool)
+	for _, slate := range candidates {
+		if slate.ID == "" || seen[slate.ID] || !storage.IsBlank(slate) {
+			continue
+		}
+		seen[slate.ID] = true
+
+		if app.currentSlate != nil && slate.ID == app.currentSlate.ID {
+			// Unsaved words in the editor mean it's not blank after all
+			if !closing || (app.editor != nil && strings.TrimSpace(app.editor.GetText()) != "") {
+				continue
+			}
+		}
+
+		if err := app.storage.Delete(slate.ID); err == nil {
+			removed = true
+		}
+	}
+
+	if removed {
+		if slates, err := app.storage.List(); err == nil {
+			app.setSlates(slates)
+		}
+	}
+}
+
+// sweepBlankSlates periodically removes blank slates while the app runs
+func (app *App) sweepBlankSlates(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		app.tviewApp.QueueUpdate(func() {
+			app.removeBlankSlates(false)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+func TestRemoveBlankSlates(t *testing.T) {
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(content string) *storage.Slate {
+		t.Helper()
+		slate := &storage.Slate{Slate: model.Slate{Content: content}}
+		if err := local.Save(slate); err != nil {
+			t.Fatal(err)
+		}
+		return slate
+	}
+	blank := save("")
+	open := save("")
+	kept := save("draft")
+	kept.Content = ""
+	local.Save(kept)
+
+	slates, _ := local.List()
+	app := &App{storage: local, editor: tview.NewTextArea(), currentSlate: open}
+	app.setSlates(slates)
+
+	listed := func() map[string]bool {
+		ids := map[string]bool{}
+		all, _ := local.List()
+		for _, s := range all {
+			ids[s.ID] = true
+		}
+		return ids
+	}
+
+	// A sweep takes the blank slate but not the open one or the cleared one
+	app.removeBlankSlates(false)
+	ids := listed()
+	if ids[blank.ID] || !ids[open.ID] || !ids[kept.ID] {
+		t.Fatalf("after a sweep: blank %v, open %v, cleared %v; want only the blank one gone", ids[blank.ID], ids[open.ID], ids[kept.ID])
+	}
+	if _, err := local.Restore(blank.ID); err != nil {
+		t.Fatalf("swept slate isn't in the trash: %v", err)
+	}
+
+	// Words typed but not saved yet keep the open slate on quit
+	app.editor.SetText("unsaved words", false)
+	app.removeBlankSlates(true)
+	if !listed()[open.ID] {
+		t.Fatal("quitting removed a slate with unsaved words")
+	}
+
+	app.editor.SetText("", false)
+	app.removeBlankSlates(true)
+	if listed()[open.ID] {
+		t.Fatal("quitting kept the open slate that was never written in")
+	}
+	if !listed()[kept.ID] {
+		t.Fatal("quitting removed a slate that was cleared on purpose")
+	}
+}
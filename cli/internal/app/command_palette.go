@@ -8,6 +8,7 @@ import (
 type Command struct {
 	Label       string
 	Description string
+	Shortcut    rune
 	Action      func()
 }
 
@@ -15,6 +16,7 @@ func (app *App) showCommandPalette() {
 	commands := []Command{
 		{
 			Label:       "new slate",
+			Shortcut:    'n',
 			Description: "create a new slate",
 			Action: func() {
 				app.pages.RemovePage("command_palette")
@@ -24,6 +26,7 @@ func (app *App) showCommandPalette() {
 		},
 		{
 			Label:       "all slates",
+			Shortcut:    'a',
 			Description: "view and manage all slates",
 			Action: func() {
 				app.pages.RemovePage("command_palette")
@@ -32,6 +35,7 @@ func (app *App) showCommandPalette() {
 		},
 		{
 			Label:       "help",
+			Shortcut:    'h',
 			Description: "show keyboard shortcuts",
 			Action: func() {
 				app.pages.RemovePage("command_palette")
@@ -40,21 +44,118 @@ func (app *App) showCommandPalette() {
 		},
 		{
 			Label:       "save",
+			Shortcut:    's',
 			Description: "save current slate",
 			Action: func() {
 				app.pages.RemovePage("command_palette")
-				app.tviewApp.SetFocus(app.editor)
+				app.resumeEditor()
 				app.saveNow()
 			},
 		},
 		{
 			Label:       "settings",
 			Description: "account settings",
+			Shortcut:    'e', // 'e' for "edit settings"
 			Action: func() {
 				app.pages.RemovePage("command_palette")
 				app.showSettings()
 			},
 		},
+		{
+			Label:       "notifications",
+			Description: "recent messages and errors",
+			Shortcut:    'l',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.showNotifications()
+			},
+		},
+		{
+			Label:       "stats",
+			Description: "words, sentences and reading ease",
+			Shortcut:    'w',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.showStats()
+			},
+		},
+		{
+			Label:       "table of contents",
+			Description: "insert or refresh a toc from headings",
+			Shortcut:    't',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.resumeEditor()
+				app.insertTOC()
+			},
+		},
+		{
+			Label:       "toggle preview",
+			Description: "rendered markdown beside the editor",
+			Shortcut:    'p',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.resumeEditor()
+				app.togglePreview()
+			},
+		},
+		{
+			Label:       "find and replace",
+			Description: "search the slate, replace one or all matches",
+			Shortcut:    'r',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.openFind(true)
+			},
+		},
+		{
+			Label:       "edit in $EDITOR",
+			Description: "open the slate in your own editor",
+			Shortcut:    'o',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.resumeEditor()
+				app.editExternally()
+			},
+		},
+		{
+			Label:       "typewriter mode",
+			Description: "keep the cursor centered, dim other paragraphs",
+			Shortcut:    'f',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.resumeEditor()
+				app.toggleTypewriter()
+			},
+		},
+		{
+			Label:       "version history",
+			Description: "compare with and restore earlier drafts",
+			Shortcut:    'v',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.saveNow()
+				app.showHistory()
+			},
+		},
+		{
+			Label:       "set word goal",
+			Description: "show progress toward a word count",
+			Shortcut:    'g',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.showWordGoalInput()
+			},
+		},
+		{
+			Label:       "about",
+			Description: "version and diagnostics for bug reports",
+			Shortcut:    'i',
+			Action: func() {
+				app.pages.RemovePage("command_palette")
+				app.showAbout()
+			},
+		},
 	}
 
 	list := tview.NewList()
@@ -63,23 +164,8 @@ func (app *App) showCommandPalette() {
 		SetTitleAlign(tview.AlignLeft).
 		SetBackgroundColor(colorBackground)
 
-	for i, cmd := range commands {
-		cmd := cmd // capture loop variable
-		// Add keyboard shortcuts
-		var shortcut rune
-		switch i {
-		case 0:
-			shortcut = 'n'
-		case 1:
-			shortcut = 'a'
-		case 2:
-			shortcut = 'h'
-		case 3:
-			shortcut = 's'
-		case 4:
-			shortcut = 'e' // settings = 'e' for "edit settings"
-		}
-		list.AddItem(cmd.Label, cmd.Description, shortcut, cmd.Action)
+	for _, cmd := range commands {
+		list.AddItem(cmd.Label, cmd.Description, cmd.Shortcut, cmd.Action)
 	}
 
 	list.SetSelectedBackgroundColor(colorPurple)
@@ -92,7 +178,7 @@ func (app *App) showCommandPalette() {
 	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
 			app.pages.RemovePage("command_palette")
-			app.tviewApp.SetFocus(app.editor)
+			app.resumeEditor()
 			return nil
 		}
 		return event
@@ -103,7 +189,7 @@ func (app *App) showCommandPalette() {
 		AddItem(nil, 0, 1, false).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 			AddItem(nil, 0, 1, false).
-			AddItem(list, 15, 0, true).
+			AddItem(list, 27, 0, true).
 			AddItem(nil, 0, 1, false), 60, 0, true).
 		AddItem(nil, 0, 1, false)
 
@@ -137,7 +223,7 @@ func (app *App) showQuitMenu() {
 	// Add cancel last
 	list.AddItem("cancel", "back to editor", 'c', func() {
 		app.pages.RemovePage("quit_menu")
-		app.tviewApp.SetFocus(app.editor)
+		app.resumeEditor()
 	})
 
 	list.SetSelectedBackgroundColor(colorPurple)
@@ -150,7 +236,7 @@ func (app *App) showQuitMenu() {
 	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
 			app.pages.RemovePage("quit_menu")
-			app.tviewApp.SetFocus(app.editor)
+			app.resumeEditor()
 			return nil
 		}
 		return event
@@ -183,7 +269,7 @@ func (app *App) confirmQuit() {
 					app.Close()
 					app.tviewApp.Stop()
 				} else {
-					app.tviewApp.SetFocus(app.editor)
+					app.resumeEditor()
 				}
 			})
 
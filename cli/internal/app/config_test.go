@@ -0,0 +1,102 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/storage"
+)
+
+func TestSaveConfigKeepsOtherSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	path := filepath.Join(home, "config.json")
+
+	// Written by the subcommands' config.Config, or a newer version
+	original := `{
+		"token": "tok",
+		"refresh_token": "ref",
+		"username": "writer",
+		"word_goal": 500,
+		"search_scope": "title",
+		"cloud_autosave": false,
+		"export_wrap": 72,
+		"manual_order": true,
+		"status_seconds": -1,
+		"spinner_style": "custom",
+		"spinner_frames": ["-", "+"],
+		"sync_workers": 3,
+		"export_metadata": true,
+		"from_the_future": {"nested": [1, 2]}
+	}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.wordGoal = 750
+	app.saveConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved config isn't JSON: %v", err)
+	}
+	var want map[string]any
+	json.Unmarshal([]byte(original), &want)
+	want["word_goal"] = 750.0
+
+	for key, value := range want {
+		got, _ := json.Marshal(saved[key])
+		expected, _ := json.Marshal(value)
+		if string(got) != string(expected) {
+			t.Errorf("%s = %s, want %s", key, got, expected)
+		}
+	}
+
+	// And config.Config still reads what the UI wrote
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SearchScope != "title" || cfg.CloudAutosave || cfg.SyncWorkers != 3 || cfg.RefreshToken != "ref" {
+		t.Fatalf("config.Load after saveConfig = %+v", cfg)
+	}
+}
+
+func TestCacheDirStaysInCache(t *testing.T) {
+	dataDir := t.TempDir()
+	cache := filepath.Join(dataDir, "cache")
+	for _, username := range []string{"writer", "../../etc", "a/b", "..", "/", ""} {
+		app := &App{dataDir: dataDir, username: username}
+		dir := app.cacheDir()
+		if filepath.Dir(dir) != cache {
+			t.Errorf("username %q caches in %s, want a directory in %s", username, dir, cache)
+		}
+	}
+}
+
+func TestTitleLengthFromConfig(t *testing.T) {
+	t.Cleanup(func() { storage.SetTitleLength(0) })
+	line := strings.Repeat("word ", 30)
+
+	hintsApp(t, t.TempDir(), `{"title_length": 30}`)
+	if got := storage.ExtractTitle(line); got != "word word word word word word…" {
+		t.Fatalf("with title_length 30: %q", got)
+	}
+	hintsApp(t, t.TempDir(), "")
+	if got := storage.ExtractTitle(line); utf8.RuneCountInString(got) != storage.MaxTitleLength {
+		t.Fatalf("without the setting: %q, want the default %d characters", got, storage.MaxTitleLength)
+	}
+}
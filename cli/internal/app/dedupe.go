@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+// findDuplicates looks for slates with the same content and offers to merge
+// them. Nothing is trashed until the user confirms.
+func (app *App) findDuplicates() {
+	if app.storage == nil {
+		return
+	}
+
+	go func() {
+		groups, err := storage.FindDuplicates(app.storage)
+		app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				app.showError(fmt.Sprintf("Failed to check for duplicates: %v", err))
+				return
+			}
+			app.confirmMergeDuplicates(groups)
+		})
+	}()
+}
+
+func (app *App) confirmMergeDuplicates(groups []storage.DuplicateGroup) {
+	modal := tview.NewModal()
+
+	if len(groups) == 0 {
+		modal.SetText("no duplicate slates").
+			AddButtons([]string{"OK"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				app.pages.RemovePage("duplicates")
+			})
+	} else {
+		var b strings.Builder
+		extra := 0
+		for i, g := range groups {
+			extra += len(g.Extra)
+			if i < 5 {
+				fmt.Fprintf(&b, "\"%s\" ×%d\n", g.Keep.Title, len(g.Extra)+1)
+			}
+		}
+		if len(groups) > 5 {
+			fmt.Fprintf(&b, "and %d more\n", len(groups)-5)
+		}
+		fmt.Fprintf(&b, "\nmerge keeps the synced or newest copy and moves %d to the trash", extra)
+
+		modal.SetText(b.String()).
+			AddButtons([]string{"Merge", "Cancel"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				app.pages.RemovePage("duplicates")
+				if buttonIndex == 0 {
+					app.mergeDuplicates(groups)
+				}
+			})
+	}
+
+	modal.SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("duplicates", modal, true, true)
+}
+
+func (app *App) mergeDuplicates(groups []storage.DuplicateGroup) {
+	go func() {
+		removed, err := storage.MergeDuplicates(app.storage, groups)
+		app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				app.showError(fmt.Sprintf("Merged %d, then failed: %v", removed, err))
+				return
+			}
+			// The editor may hold one of the copies that went to the trash
+			for _, g := range groups {
+				for _, extra := range g.Extra {
+					if app.currentSlate != nil && app.currentSlate.ID == extra.ID {
+						app.currentSlate = nil
+						app.pages.RemovePage(PageEditor)
+					}
+				}
+			}
+
+			message := fmt.Sprintf("trashed %d duplicate slates", removed)
+			app.notifications.Info(message)
+			app.confirmMergeDone(message)
+		})
+	}()
+}
+
+func (app *App) confirmMergeDone(message string) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("duplicates-merged")
+		}).
+		SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("duplicates-merged", modal, true, true)
+}
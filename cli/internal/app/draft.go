@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+// checkDraft offers back what a session that crashed was writing, if the
+// server never got it. It goes to the server, so it runs in the background.
+func (app *App) checkDraft(cloud *storage.CloudStorage) {
+	draft, err := cloud.Draft()
+	app.tviewApp.QueueUpdateDraw(func() {
+		if err != nil {
+			app.notifications.Error(err.Error())
+			return
+		}
+		if draft != nil {
+			app.confirmRestoreDraft(cloud, draft)
+		}
+	})
+}
+
+func (app *App) confirmRestoreDraft(cloud *storage.CloudStorage, draft *storage.Draft) {
+	title := draft.Slate.Title
+	if title == "" {
+		title = storage.ExtractTitle(draft.Slate.Content)
+	}
+
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("restore unsaved draft from %s?\n\n\"%s\", %d words, never reached the server.",
+			draft.SavedAt.Format("Jan 2 15:04"), title, storage.CountWords(draft.Slate.Content))).
+		AddButtons([]string{"Restore", "Set Aside"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("restore-draft")
+			if buttonIndex == 0 {
+				app.restoreDraft(cloud, draft)
+				return
+			}
+			path, err := cloud.SetAsideDraft()
+			if err != nil {
+				app.showError(fmt.Sprintf("Couldn't set the draft aside: %v", err))
+				return
+			}
+			app.notifications.Info("draft set aside in " + path)
+		})
+
+	modal.SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("restore-draft", modal, true, true)
+}
+
+// restoreDraft opens the draft in the editor as an unsaved edit, so the
+// next autosave pushes it
+func (app *App) restoreDraft(cloud *storage.CloudStorage, draft *storage.Draft) {
+	if err := cloud.RestoreDraft(draft); err != nil {
+		app.showError(fmt.Sprintf("Couldn't restore the draft: %v", err))
+		return
+	}
+	// Whatever was typed while the server was asked about it
+	app.saveNow()
+	app.showEditor(draft.Slate)
+	app.isDirty = true
+	app.scheduleAutoSave()
+	app.notifications.Info("restored the unsaved draft")
+}
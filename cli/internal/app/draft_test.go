@@ -0,0 +1,93 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/justtype/cli/internal/notify"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+// draftApp is a running App on cloud storage whose temp directory holds a
+// draft a crashed session left, and has been asked about it
+func draftApp(t *testing.T) (*App, string) {
+	t.Helper()
+	dir := t.TempDir()
+	draft := `{"id": "local-1", "content": "lost in the crash"}`
+	if err := os.WriteFile(filepath.Join(dir, "draft.json"), []byte(draft), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Never pushed, so the server isn't asked
+	cloud, err := storage.NewCloud(dir, "http://127.0.0.1:0", "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cloud.StartSession(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cloud.Close() })
+
+	app := runningApp(t)
+	app.pages = tview.NewPages()
+	app.storage = cloud
+	app.isCloud = true
+	app.notifications = notify.New(notify.DefaultSize)
+	app.slateErrors = map[string]string{}
+	onUI(app, func() { app.tviewApp.SetRoot(app.pages, true) })
+
+	app.checkDraft(cloud)
+	waitFor(t, app, "restore-draft")
+	return app, dir
+}
+
+// waitFor waits until page is in front
+func waitFor(t *testing.T, app *App, page string) {
+	t.Helper()
+	var front string
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		onUI(app, func() { front, _ = app.pages.GetFrontPage() })
+		if front == page {
+			return
+		}
+	}
+	t.Fatalf("%s in front, not %s", front, page)
+}
+
+func TestRestoreDraftOpensItUnsaved(t *testing.T) {
+	app, dir := draftApp(t)
+
+	press(t, app, tcell.KeyEnter, PageEditor)
+	var text string
+	var dirty bool
+	onUI(app, func() { text, dirty = app.editor.GetText(), app.isDirty })
+	if text != "lost in the crash" || !dirty {
+		t.Fatalf("editor has %q, dirty %v; want the draft, unsaved", text, dirty)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "draft.json")); !os.IsNotExist(err) {
+		t.Fatalf("draft still waiting after it was restored: %v", err)
+	}
+}
+
+func TestSetAsideDraftKeepsIt(t *testing.T) {
+	app, dir := draftApp(t)
+
+	app.tviewApp.QueueEvent(tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone))
+	app.tviewApp.QueueEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	var set []string
+	for deadline := time.Now().Add(2 * time.Second); len(set) == 0 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		set, _ = filepath.Glob(filepath.Join(dir, "recovered", "draft-*.json"))
+	}
+	if len(set) != 1 {
+		t.Fatalf("set aside %v, want the draft in the recovered folder", set)
+	}
+	var open bool
+	onUI(app, func() { open = app.pages.HasPage("restore-draft") })
+	if open {
+		t.Fatal("restore prompt still up after setting the draft aside")
+	}
+}
@@ -1,10 +1,16 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/markdown"
+	"github.com/justtype/cli/internal/model"
 	"github.com/justtype/cli/internal/storage"
 	"github.com/rivo/tview"
 )
@@ -15,6 +21,9 @@ func (app *App) showEditor(slate *storage.Slate) {
 	app.isDirty = false
 	app.saveStatus = ""
 
+	// A find bar belongs to the editor being replaced
+	app.closeFind()
+
 	// Create or reuse editor
 	if app.editor == nil {
 		app.editor = tview.NewTextArea()
@@ -36,21 +45,34 @@ func (app *App) showEditor(slate *storage.Slate) {
 			Foreground(colorDim)
 		app.editor.SetPlaceholderStyle(placeholderStyle)
 
-		// On text change, mark as dirty but don't auto-save
+		// On text change, mark as dirty and save once typing pauses
 		app.editor.SetChangedFunc(func() {
 			app.isDirty = true
 			app.saveStatus = ""
+			app.schedulePreview()
+			app.scheduleAutoSave()
+			app.centerCursor()
 		})
+		app.editor.SetMovedFunc(app.centerCursor)
 	}
 
 	// Load content
 	if app.currentSlate != nil {
-		app.editor.SetText(app.currentSlate.Content, true)
+		app.loadContent(app.currentSlate)
 		app.saveStatus = "saved"
 	} else {
 		app.editor.SetText("", true)
 		app.saveStatus = ""
 	}
+	// Loading the text counts as a change; it isn't one to save
+	app.isDirty = false
+	app.vim = vimState{}
+	if app.saveTimer != nil {
+		app.saveTimer.Stop()
+	}
+
+	// One first-use tip per session
+	app.currentHint = app.nextHint()
 
 	// Header showing account
 	header := tview.NewTextView().
@@ -79,11 +101,15 @@ func (app *App) showEditor(slate *storage.Slate) {
 		}
 	}()
 
+	// The preview, when it's on, goes beside the editor in here
+	body := tview.NewFlex().
+		AddItem(app.editor, 0, 1, true)
+
 	// Main flex layout
 	editorWrapper := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(header, 1, 0, false).
-		AddItem(app.editor, 0, 1, true).
+		AddItem(body, 0, 1, true).
 		AddItem(footer, 1, 0, false)
 
 	// Center horizontally
@@ -94,16 +120,40 @@ func (app *App) showEditor(slate *storage.Slate) {
 
 	centered.SetBackgroundColor(colorBackground)
 
+	app.editorBody = body
+	app.editorColumn = editorWrapper
+	app.editorLayout = centered
+
 	// Handle global keys
 	app.editor.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Vim keys come first, and hand on whatever they don't use
+		if app.editorMode == editorModeVim {
+			event = app.vimKey(event)
+			app.updateFooter(footer)
+			if event == nil {
+				return nil
+			}
+		}
+
 		// Esc opens quit menu
 		if event.Key() == tcell.KeyEsc {
 			app.showQuitMenu()
 			return nil
 		}
 
+		// Ctrl+F find, Ctrl+R find and replace
+		if event.Key() == tcell.KeyCtrlF {
+			app.openFind(false)
+			return nil
+		}
+		if isReplaceKey(event) {
+			app.openFind(true)
+			return nil
+		}
+
 		// Ctrl+K opens command palette
 		if event.Key() == tcell.KeyCtrlK {
+			app.dismissHint(hintPalette)
 			app.saveNow()
 			app.showCommandPalette()
 			return nil
@@ -111,13 +161,30 @@ func (app *App) showEditor(slate *storage.Slate) {
 
 		// Ctrl+S save
 		if event.Key() == tcell.KeyCtrlS {
+			app.dismissHint(hintSave)
 			app.saveNow()
+			if app.saveStatus == "saved" {
+				app.notifications.Info("saved")
+			}
+			return nil
+		}
+
+		// Ctrl+L notification log
+		if event.Key() == tcell.KeyCtrlL {
+			app.showNotifications()
+			return nil
+		}
+
+		// Ctrl+G writing stats
+		if event.Key() == tcell.KeyCtrlG {
+			app.showStats()
 			return nil
 		}
 
 		// Ctrl+P publish
 		if event.Key() == tcell.KeyCtrlP {
 			if app.currentSlate != nil {
+				app.dismissHint(hintPublish)
 				app.saveNow()
 				app.handlePublish(app.currentSlate)
 			}
@@ -129,11 +196,27 @@ func (app *App) showEditor(slate *storage.Slate) {
 
 	app.pages.AddAndSwitchToPage(PageEditor, centered, true)
 	app.tviewApp.SetFocus(app.editor)
+
+	if app.previewOn {
+		app.openPreview()
+	}
+}
+
+// resumeEditor returns to the open slate without reloading it, so the caret,
+// scroll position and unsaved edits survive a trip through other pages
+func (app *App) resumeEditor() {
+	if app.editor == nil || !app.pages.HasPage(PageEditor) {
+		app.showEditor(app.currentSlate)
+		return
+	}
+
+	app.pages.SwitchToPage(PageEditor)
+	app.tviewApp.SetFocus(app.editor)
 }
 
 func (app *App) updateHeader(header *tview.TextView) {
 	if app.isCloud && app.username != "" {
-		header.SetText(fmt.Sprintf("[#8B5CF6]hey, %s[-]", app.username))
+		header.SetText(fmt.Sprintf(tagAccent+"hey, %s[-]", app.username))
 	} else {
 		header.SetText("")
 	}
@@ -145,33 +228,94 @@ func (app *App) updateFooter(footer *tview.TextView) {
 
 	var parts []string
 
-	// Word count
-	parts = append(parts, fmt.Sprintf("[#666666]%d words[-]", words))
+	// Word count, with progress toward the goal if there is one
+	if app.wordGoal > 0 {
+		color := tagDim
+		if words >= app.wordGoal {
+			color = tagSuccess
+		}
+		parts = append(parts, fmt.Sprintf("%s%d / %d words[-]", color, words, app.wordGoal))
+	} else {
+		parts = append(parts, fmt.Sprintf(tagDim+"%d words[-]", words))
+	}
+
+	if app.editorMode == editorModeVim {
+		parts = append(parts, app.vimFooter())
+	}
 
 	// Save status
 	if app.saveStatus != "" {
-		color := "#666666"
+		color := tagDim
 		if app.saveStatus == "saving..." {
-			color = "#8B5CF6" // purple
+			color = tagAccent
 		} else if app.saveStatus == "saved" {
-			color = "#10B981" // green
+			color = tagSuccess
 		}
-		parts = append(parts, fmt.Sprintf("[%s]%s[-]", color, app.saveStatus))
+		parts = append(parts, color+app.saveStatus+"[-]")
 	}
 
 	// Mode indicator
-	if app.isCloud {
-		parts = append(parts, "[#666666]cloud[-]")
+	if app.file != nil {
+		parts = append(parts, tagAccent+"file: "+tview.Escape(filepath.Base(app.file.Path()))+"[-]")
+	} else if cloud, ok := app.storage.(*storage.CloudStorage); ok && cloud.Offline() {
+		parts = append(parts, tagWarning+"cloud (offline)[-]")
+	} else if app.isCloud {
+		parts = append(parts, tagDim+"cloud[-]")
 	} else {
-		parts = append(parts, "[#666666]local[-]")
+		parts = append(parts, tagDim+"local[-]")
+	}
+
+	// Writes made offline that haven't reached the server yet
+	if cloud, ok := app.storage.(*storage.CloudStorage); ok {
+		if n := cloud.Pending(); n > 0 {
+			parts = append(parts, fmt.Sprintf(tagWarning+"%d pending[-]", n))
+		}
+	}
+
+	// A first-use tip takes the place of the key help while it's showing
+	if app.currentHint != "" {
+		parts = append(parts, tagAccent+hintText[app.currentHint]+"[-]")
+		footer.SetText(joinParts(parts))
+		return
 	}
 
 	// Help
-	parts = append(parts, "[#666666]esc quit · ctrl+k commands · ctrl+s save · ctrl+p publish[-]")
+	if app.editorMode == editorModeVim {
+		parts = append(parts, tagDim+":q quit · ctrl+k commands · ctrl+s save · ctrl+p publish · ctrl+l log[-]")
+	} else {
+		parts = append(parts, tagDim+"esc quit · ctrl+k commands · ctrl+s save · ctrl+p publish · ctrl+l log[-]")
+	}
 
 	footer.SetText(joinParts(parts))
 }
 
+// loadContent puts a slate in the editor with the cursor where it was when
+// the slate was last saved, or at the end if that isn't known
+func (app *App) loadContent(slate *storage.Slate) {
+	if slate.CursorOffset <= 0 {
+		app.editor.SetText(slate.Content, true)
+		return
+	}
+	app.editor.SetText(slate.Content, false)
+	// The file may have shrunk since
+	offset := min(slate.CursorOffset, len(slate.Content))
+	app.editor.Select(offset, offset)
+}
+
+// scheduleAutoSave saves autosaveSeconds after the last edit, or never when
+// that's 0. It's read on every edit, so changing it applies straight away.
+func (app *App) scheduleAutoSave() {
+	if app.saveTimer != nil {
+		app.saveTimer.Stop()
+	}
+	if app.autosaveSeconds == 0 {
+		return
+	}
+	app.saveTimer = time.AfterFunc(time.Duration(app.autosaveSeconds)*time.Second, func() {
+		app.tviewApp.QueueUpdateDraw(app.saveNow)
+	})
+}
+
 func (app *App) saveNow() {
 	if !app.isDirty {
 		return
@@ -184,22 +328,27 @@ func (app *App) saveNow() {
 		return
 	}
 
+	if !storage.ShouldSave(content, app.currentSlate == nil, app.minWords) {
+		// Stay dirty so quitting still warns about the unsaved note
+		app.saveStatus = fmt.Sprintf("not saved until %d words", app.minWords)
+		return
+	}
+
 	// Show "saving..." status
 	app.saveStatus = "saving..."
 
 	if app.currentSlate == nil {
-		app.currentSlate = &storage.Slate{
-			Content: content,
-		}
+		app.currentSlate = &storage.Slate{Slate: model.Slate{Content: content}}
 	} else {
 		app.currentSlate.Content = content
 	}
+	_, app.currentSlate.CursorOffset, _ = app.editor.GetSelection()
 
 	if app.storage != nil {
 		err := app.storage.Save(app.currentSlate)
 		if err != nil {
 			// Check if session expired
-			if err.Error() == "SESSION_EXPIRED" {
+			if errors.Is(err, api.ErrSessionExpired) {
 				app.tviewApp.QueueUpdateDraw(func() {
 					modal := tview.NewModal().
 						SetText("session expired. re-login to continue?").
@@ -219,8 +368,10 @@ func (app *App) saveNow() {
 					app.pages.AddPage("session-expired", modal, true, true)
 				})
 				app.saveStatus = ""
+				app.notifications.Error("session expired")
 			} else {
 				app.saveStatus = fmt.Sprintf("error: %v", err)
+				app.notifications.Error(fmt.Sprintf("save failed: %v", err))
 			}
 			app.isDirty = true // Keep dirty flag since save failed
 			return
@@ -233,15 +384,70 @@ func (app *App) saveNow() {
 	// Refresh slates list
 	if app.storage != nil {
 		slates, _ := app.storage.List()
-		app.slates = slates
+		app.setSlates(slates)
+	}
+}
+
+// showWordGoalInput asks for the number of words to aim for
+func (app *App) showWordGoalInput() {
+	input := tview.NewInputField().
+		SetLabel("words (0 for none): ").
+		SetFieldWidth(8).
+		SetAcceptanceFunc(tview.InputFieldInteger)
+	if app.wordGoal > 0 {
+		input.SetText(strconv.Itoa(app.wordGoal))
 	}
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.pages.RemovePage("word-goal")
+		if key == tcell.KeyEnter {
+			goal, _ := strconv.Atoi(input.GetText())
+			app.wordGoal = max(goal, 0)
+			app.saveConfig()
+		}
+		app.resumeEditor()
+	})
+
+	input.SetBorder(true).
+		SetTitle(" word goal ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 40, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("word-goal", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
+
+// insertTOC inserts or refreshes the table of contents in the editor
+func (app *App) insertTOC() {
+	content := app.editor.GetText()
+	updated := markdown.InsertTOC(content)
+	if updated == content {
+		if len(markdown.Headings(content)) == 0 {
+			app.notifications.Info("no headings for a table of contents")
+		}
+		return
+	}
+
+	// Replace keeps the change on the undo stack
+	app.editor.Replace(0, app.editor.GetTextLength(), updated)
+	app.saveNow()
 }
 
 func joinParts(parts []string) string {
 	result := ""
 	for i, part := range parts {
 		if i > 0 {
-			result += "  [#666666]·[-]  "
+			result += "  " + tagDim + "·[-]  "
 		}
 		result += part
 	}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/notify"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+// editorApp is an App on fresh local storage with text typed into a new
+// slate that hasn't been saved yet
+func editorApp(t *testing.T, text string) (*App, *storage.LocalStorage) {
+	t.Helper()
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &App{storage: local, editor: tview.NewTextArea(), notifications: notify.New(notify.DefaultSize)}
+	app.editor.SetText(text, true)
+	app.isDirty = true
+	return app, local
+}
+
+func TestMinWordsDecidesSaving(t *testing.T) {
+	const note = "call the plumber"
+	tests := []struct {
+		name     string
+		minWords int
+		saved    bool
+	}{
+		{"save immediately", 0, true},
+		{"10+ words", storage.SuggestedMinWords, false},
+		{"custom 3", 3, true},
+		{"custom 4", 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, local := editorApp(t, note)
+			app.minWords = tt.minWords
+			app.saveNow()
+
+			slates, _ := local.List()
+			if saved := len(slates) == 1; saved != tt.saved {
+				t.Fatalf("saved = %v, want %v", saved, tt.saved)
+			}
+			if !tt.saved && (!app.isDirty || app.saveStatus == "") {
+				t.Fatalf("held back note not flagged: dirty %v, status %q", app.isDirty, app.saveStatus)
+			}
+		})
+	}
+}
+
+func TestMinWordsOnlyHoldsBackNewSlates(t *testing.T) {
+	app, local := editorApp(t, "now short")
+	existing := &storage.Slate{Slate: model.Slate{Content: "a slate that was long enough once"}}
+	if err := local.Save(existing); err != nil {
+		t.Fatal(err)
+	}
+	app.currentSlate = existing
+	app.minWords = storage.SuggestedMinWords
+
+	app.saveNow()
+	loaded, err := local.Load(existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Content != "now short" || app.isDirty {
+		t.Fatalf("edit to an existing slate held back: %q", loaded.Content)
+	}
+}
+
+func TestTrimOnSaveLeavesEditorAlone(t *testing.T) {
+	const typed = "a poem  \n   \nends mid-thought "
+	app, local := editorApp(t, typed)
+	local.SetNormalize(normalize.Options{TrimTrailing: true})
+	app.editor.Select(len(typed), len(typed))
+
+	app.saveNow()
+	loaded, err := local.Load(app.currentSlate.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a poem\n\nends mid-thought"; loaded.Content != want {
+		t.Fatalf("stored %q, want %q", loaded.Content, want)
+	}
+	if got := app.editor.GetText(); got != typed {
+		t.Fatalf("editor text changed to %q while typing", got)
+	}
+	if _, caret, _ := app.editor.GetSelection(); caret != len(typed) {
+		t.Fatalf("caret moved to %d, want %d", caret, len(typed))
+	}
+}
+
+// onUI runs f on the running app's UI goroutine, redraws, and waits for it
+func onUI(app *App, f func()) {
+	done := make(chan struct{})
+	app.tviewApp.QueueUpdateDraw(func() {
+		f()
+		close(done)
+	})
+	<-done
+}
+
+// press sends a key and waits until page is in front
+func press(t *testing.T, app *App, key tcell.Key, page string) {
+	t.Helper()
+	app.tviewApp.QueueEvent(tcell.NewEventKey(key, 0, tcell.ModNone))
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var front string
+		onUI(app, func() { front, _ = app.pages.GetFrontPage() })
+		if front == page {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	var front string
+	onUI(app, func() { front, _ = app.pages.GetFrontPage() })
+	t.Fatalf("%v brought up %s, not %s", key, front, page)
+}
+
+func TestCaretSurvivesMenus(t *testing.T) {
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for i := range 300 {
+		lines = append(lines, fmt.Sprintf("line %d of a long slate", i))
+	}
+	slate := &storage.Slate{Slate: model.Slate{Content: strings.Join(lines, "\n")}}
+	if err := local.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+
+	app := runningApp(t)
+	app.pages = tview.NewPages()
+	app.storage = local
+	app.notifications = notify.New(notify.DefaultSize)
+	app.slateErrors = map[string]string{}
+	onUI(app, func() {
+		app.tviewApp.SetRoot(app.pages, true)
+		app.showEditor(slate)
+		app.editor.Select(5000, 5000)
+		app.editor.SetOffset(180, 0)
+		// An edit that hasn't been saved yet
+		app.editor.Replace(5000, 5000, "unsaved ")
+	})
+
+	var text string
+	var caret, row int
+	state := func() (string, int, int) {
+		var s string
+		var c, r int
+		onUI(app, func() {
+			s = app.editor.GetText()
+			_, c, _ = app.editor.GetSelection()
+			r, _ = app.editor.GetOffset()
+		})
+		return s, c, r
+	}
+	text, caret, row = state()
+
+	trips := map[string]func(){
+		"quit menu": func() {
+			press(t, app, tcell.KeyEsc, "quit_menu")
+			press(t, app, tcell.KeyEsc, PageEditor)
+		},
+		"command palette": func() {
+			press(t, app, tcell.KeyCtrlK, "command_palette")
+			press(t, app, tcell.KeyEsc, PageEditor)
+		},
+		"settings": func() {
+			onUI(app, app.showSettings)
+			press(t, app, tcell.KeyEsc, PageEditor)
+		},
+		"help": func() {
+			onUI(app, app.showHelp)
+			press(t, app, tcell.KeyEsc, PageEditor)
+		},
+	}
+	for name, trip := range trips {
+		trip()
+		gotText, gotCaret, gotRow := state()
+		if gotText != text || gotCaret != caret || gotRow != row {
+			t.Fatalf("after the %s: caret %d at row %d, edit kept %v; want caret %d at row %d", name, gotCaret, gotRow, gotText == text, caret, row)
+		}
+		var focused bool
+		onUI(app, func() { focused = app.editor.HasFocus() })
+		if !focused {
+			t.Fatalf("editor not focused after the %s", name)
+		}
+	}
+}
+
+func TestEditFileInPlace(t *testing.T) {
+	// Signed in, but a file given on the command line stays off the cloud
+	app := hintsApp(t, t.TempDir(), `{"token": "tok", "username": "ada"}`)
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("first draft"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.OpenFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if app.isCloud {
+		t.Fatal("file mode still counts as cloud")
+	}
+
+	app.editor = tview.NewTextArea()
+	app.currentSlate = app.file.Slate()
+	app.editor.SetText("second draft, edited in place", true)
+	app.isDirty = true
+	app.saveNow()
+	if data, _ := os.ReadFile(path); string(data) != "second draft, edited in place" {
+		t.Fatalf("file holds %q after saving", data)
+	}
+
+	footer := tview.NewTextView()
+	app.updateFooter(footer)
+	if text := footer.GetText(true); !strings.Contains(text, "file: notes.md") || strings.Contains(text, "cloud") {
+		t.Fatalf("footer = %q, want it to name the file and not the cloud", text)
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+func (app *App) showEncryptionSetup() {
+	passField := tview.NewInputField().
+		SetLabel("Passphrase").
+		SetMaskCharacter('*').
+		SetFieldWidth(40)
+
+	confirmField := tview.NewInputField().
+		SetLabel("Confirm").
+		SetMaskCharacter('*').
+		SetFieldWidth(40)
+
+	form := tview.NewForm()
+	form.AddTextView("", "slates are encrypted before upload. use the same passphrase on every device - it can't be recovered.", 50, 3, false, false)
+	form.AddFormItem(passField)
+	form.AddFormItem(confirmField)
+
+	form.AddButton("Enable", func() {
+		passphrase := passField.GetText()
+		if len(passphrase) < 8 {
+			app.showError("passphrase must be at least 8 characters")
+			return
+		}
+		if passphrase != confirmField.GetText() {
+			app.showError("passphrases don't match")
+			return
+		}
+
+		key, err := e2e.DeriveKey(passphrase, app.username)
+		if err != nil {
+			app.showError(err.Error())
+			return
+		}
+
+		app.e2eKey = key.Encode()
+		app.saveConfig()
+		if cs, ok := app.storage.(*storage.CloudStorage); ok {
+			cs.SetEncryptionKey(key)
+		}
+
+		app.pages.RemovePage("e2e-setup")
+		app.showSettings()
+	})
+
+	form.AddButton("Cancel", func() {
+		app.pages.RemovePage("e2e-setup")
+		app.showSettings()
+	})
+
+	form.SetBorder(true).
+		SetTitle(" end-to-end encryption ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			app.pages.RemovePage("e2e-setup")
+			app.showSettings()
+			return nil
+		}
+		return event
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 13, 0, true).
+			AddItem(nil, 0, 1, false), 64, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("e2e-setup", centered, true, true)
+	app.tviewApp.SetFocus(form)
+}
+
+func (app *App) confirmDisableEncryption() {
+	modal := tview.NewModal().
+		SetText("turn off end-to-end encryption?\n\nnew saves will be uploaded as plain text. slates that are already encrypted need your passphrase to open.").
+		AddButtons([]string{"Turn off", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("confirm-e2e-off")
+			if buttonIndex == 0 {
+				app.e2eKey = ""
+				app.saveConfig()
+				if cs, ok := app.storage.(*storage.CloudStorage); ok {
+					cs.SetEncryptionKey(nil)
+				}
+				app.showSettings()
+			}
+		})
+
+	modal.SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("confirm-e2e-off", modal, true, true)
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/config"
+	"github.com/rivo/tview"
+)
+
+// editExternally hands the slate to the external editor in a temp file,
+// with justtype suspended until it exits, and takes back what was saved
+func (app *App) editExternally() {
+	command := strings.Fields(config.ResolveEditor(app.externalEditor))
+	if len(command) == 0 {
+		app.showError("No external editor set. Choose one in settings (external editor) or set $EDITOR.")
+		return
+	}
+
+	original := app.editor.GetText()
+	path, err := writeTemp(original)
+	if err != nil {
+		app.showError(fmt.Sprintf("Couldn't write the slate for %s: %v", command[0], err))
+		return
+	}
+	defer os.Remove(path)
+
+	// Don't let autosave run against the old text while the editor's open
+	if app.saveTimer != nil {
+		app.saveTimer.Stop()
+	}
+
+	// Suspend restores the terminal for the editor and takes it back after
+	var runErr error
+	app.tviewApp.Suspend(func() {
+		cmd := exec.Command(command[0], append(command[1:], path)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		runErr = cmd.Run()
+	})
+	if runErr != nil {
+		app.showError(fmt.Sprintf("%s exited with an error (%v), changes discarded", command[0], runErr))
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		app.showError(fmt.Sprintf("Couldn't read back the slate: %v", err))
+		return
+	}
+	edited := string(data)
+	if !strings.HasSuffix(original, "\n") {
+		// Most editors end the file with one
+		edited = strings.TrimSuffix(edited, "\n")
+	}
+	if edited == original {
+		return
+	}
+
+	// Replace rather than SetText, so ctrl+z can undo it
+	_, cursor, _ := app.editor.GetSelection()
+	app.editor.Replace(0, len(original), edited)
+	cursor = min(cursor, len(edited))
+	app.editor.Select(cursor, cursor)
+	app.saveNow()
+	if app.saveStatus == "saved" {
+		app.notifications.Info("saved changes from " + command[0])
+	}
+}
+
+func writeTemp(content string) (string, error) {
+	f, err := os.CreateTemp("", "justtype-*.md")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (app *App) showExternalEditorInput() {
+	input := tview.NewInputField().
+		SetLabel("editor command: ").
+		SetText(app.externalEditor).
+		SetPlaceholder("$EDITOR").
+		SetFieldWidth(30)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.pages.RemovePage("external-editor")
+		if key == tcell.KeyEnter {
+			app.externalEditor = strings.TrimSpace(input.GetText())
+			app.saveConfig()
+		}
+		app.showSettings()
+	})
+
+	input.SetBorder(true).
+		SetTitle(" external editor ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 50, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("external-editor", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
+
+// externalEditorLabel is the settings entry for the external editor
+func externalEditorLabel(configured string) string {
+	switch {
+	case configured != "":
+		return "external editor: " + configured
+	case config.ResolveEditor("") != "":
+		return "external editor: $EDITOR (" + config.ResolveEditor("") + ")"
+	default:
+		return "external editor: none"
+	}
+}
@@ -0,0 +1,347 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// finder is the find and replace bar under the editor. Matches are byte
+// ranges of the editor's text, found again whenever the query or the text
+// changes.
+type finder struct {
+	bar     *tview.Flex
+	query   *tview.InputField
+	replace *tview.InputField
+	status  *tview.TextView
+
+	regex   bool // the query is a regular expression, not literal text
+	pattern *regexp.Regexp
+	err     error // the query isn't a valid regular expression
+	matches [][]int
+	current int
+}
+
+// openFind shows the find bar, or focuses it if it's open already. With
+// replacing, the replace field gets the focus.
+func (app *App) openFind(replacing bool) {
+	if app.find == nil {
+		app.find = app.newFinder()
+		// Tuck the bar in above the footer
+		footer := app.editorColumn.GetItem(app.editorColumn.GetItemCount() - 1)
+		app.editorColumn.RemoveItem(footer)
+		app.editorColumn.AddItem(app.find.bar, 2, 0, false)
+		app.editorColumn.AddItem(footer, 1, 0, false)
+
+		// Matches show as a selection, which is otherwise invisible
+		app.editor.SetSelectedStyle(tcell.StyleDefault.Background(colorPurple).Foreground(colorBackground))
+
+		if selected, _, _ := app.editor.GetSelection(); selected != "" && !strings.Contains(selected, "\n") {
+			app.find.query.SetText(selected)
+		}
+	}
+
+	if replacing {
+		app.tviewApp.SetFocus(app.find.replace)
+	} else {
+		app.tviewApp.SetFocus(app.find.query)
+	}
+	app.refreshFind()
+}
+
+// isReplaceKey reports whether event opens find and replace: ctrl+r, or
+// ctrl+h where the terminal says ctrl was held. Most send ctrl+h as the
+// same byte as backspace, with nothing to tell them apart.
+func isReplaceKey(event *tcell.EventKey) bool {
+	if event.Key() == tcell.KeyCtrlR {
+		return true
+	}
+	return event.Key() == tcell.KeyBackspace && event.Modifiers()&tcell.ModCtrl != 0
+}
+
+// closeFind takes the bar away and gives the editor back the focus, leaving
+// the caret on the last match
+func (app *App) closeFind() {
+	if app.find == nil {
+		return
+	}
+	app.editorColumn.RemoveItem(app.find.bar)
+	app.find = nil
+
+	style := tcell.StyleDefault.Background(colorBackground).Foreground(colorForeground)
+	app.editor.SetSelectedStyle(style)
+	_, _, end := app.editor.GetSelection()
+	app.editor.Select(end, end)
+	app.tviewApp.SetFocus(app.editor)
+}
+
+func (app *App) newFinder() *finder {
+	f := &finder{
+		query: tview.NewInputField().
+			SetLabel("find: ").
+			SetFieldWidth(0),
+		replace: tview.NewInputField().
+			SetLabel("replace: ").
+			SetFieldWidth(0),
+		status: tview.NewTextView().
+			SetDynamicColors(true).
+			SetTextAlign(tview.AlignRight),
+	}
+	for _, field := range []*tview.InputField{f.query, f.replace} {
+		field.SetBackgroundColor(colorBackground)
+		field.SetLabelColor(colorPurple)
+		field.SetFieldBackgroundColor(colorBackground)
+		field.SetFieldTextColor(colorForeground)
+	}
+	f.status.SetBackgroundColor(colorBackground)
+
+	f.query.SetChangedFunc(func(string) {
+		app.refreshFind()
+	})
+	f.query.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		return app.findKey(event, false)
+	})
+	f.replace.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		return app.findKey(event, true)
+	})
+	f.status.SetInputCapture(app.findNavKey)
+
+	fields := tview.NewFlex().
+		AddItem(f.query, 0, 1, true).
+		AddItem(nil, 2, 0, false).
+		AddItem(f.replace, 0, 1, false)
+	f.bar = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(fields, 1, 0, true).
+		AddItem(f.status, 1, 0, false)
+	f.bar.SetBackgroundColor(colorBackground)
+	return f
+}
+
+// findKey handles the keys the bar's fields share. In the find field enter
+// leaves the typing for n and N (see findNavKey); in the replace field enter
+// replaces the current match and ctrl+r all of them. Ctrl+n and ctrl+p go to
+// the next and previous match from either, tab moves between them, ctrl+t
+// switches regular expressions on and off, and esc goes back to the editor.
+func (app *App) findKey(event *tcell.EventKey, replacing bool) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEsc:
+		app.closeFind()
+		return nil
+	case tcell.KeyTab, tcell.KeyBacktab:
+		if replacing {
+			app.tviewApp.SetFocus(app.find.query)
+		} else {
+			app.tviewApp.SetFocus(app.find.replace)
+		}
+		return nil
+	case tcell.KeyCtrlT:
+		app.find.regex = !app.find.regex
+		app.refreshFind()
+		return nil
+	case tcell.KeyCtrlN:
+		app.findStep(1)
+		return nil
+	case tcell.KeyCtrlP:
+		app.findStep(-1)
+		return nil
+	case tcell.KeyCtrlR:
+		if replacing {
+			app.replaceAll()
+		}
+		return nil
+	case tcell.KeyEnter:
+		if replacing {
+			app.replaceCurrent()
+		} else {
+			app.tviewApp.SetFocus(app.find.status)
+			app.updateFindStatus("")
+		}
+		return nil
+	}
+	return event
+}
+
+// findNavKey moves between matches once the query is in: n or enter for the
+// next, N for the previous, / to change the query and r or tab to replace
+func (app *App) findNavKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEsc:
+		app.closeFind()
+	case tcell.KeyEnter:
+		app.findStep(1)
+	case tcell.KeyTab:
+		app.tviewApp.SetFocus(app.find.replace)
+		app.updateFindStatus("")
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'n':
+			app.findStep(1)
+		case 'N':
+			app.findStep(-1)
+		case '/', 'f':
+			app.tviewApp.SetFocus(app.find.query)
+			app.updateFindStatus("")
+		case 'r':
+			app.tviewApp.SetFocus(app.find.replace)
+			app.updateFindStatus("")
+		}
+	}
+	return nil
+}
+
+// refreshFind finds the query in the editor's text again and selects the
+// first match at or after the caret
+func (app *App) refreshFind() {
+	f := app.find
+	if f == nil {
+		return
+	}
+
+	f.pattern, f.err, f.matches = nil, nil, nil
+	if query := f.query.GetText(); query != "" {
+		if !f.regex {
+			query = regexp.QuoteMeta(query)
+		}
+		f.pattern, f.err = regexp.Compile(query)
+	}
+	if f.pattern != nil {
+		for _, m := range f.pattern.FindAllStringSubmatchIndex(app.editor.GetText(), -1) {
+			// An empty match can't be selected or replaced sensibly
+			if m[1] > m[0] {
+				f.matches = append(f.matches, m)
+			}
+		}
+	}
+
+	_, start, _ := app.editor.GetSelection()
+	f.current = 0
+	for i, m := range f.matches {
+		if m[0] >= start {
+			f.current = i
+			break
+		}
+	}
+	app.selectMatch()
+}
+
+// findStep moves to the next match, or the previous with step -1, going
+// round at either end
+func (app *App) findStep(step int) {
+	f := app.find
+	if len(f.matches) == 0 {
+		app.updateFindStatus("")
+		return
+	}
+	f.current = (f.current + step + len(f.matches)) % len(f.matches)
+	app.selectMatch()
+}
+
+// selectMatch selects the current match in the editor, which scrolls it
+// into view
+func (app *App) selectMatch() {
+	f := app.find
+	if len(f.matches) > 0 {
+		m := f.matches[f.current]
+		app.editor.Select(m[0], m[1])
+	}
+	app.updateFindStatus("")
+}
+
+// replaceCurrent replaces the selected match and moves to the next
+func (app *App) replaceCurrent() {
+	f := app.find
+	if len(f.matches) == 0 {
+		app.updateFindStatus("no matches, nothing replaced")
+		return
+	}
+
+	text := app.editor.GetText()
+	m := f.matches[f.current]
+	replacement := app.replacement(text, m)
+	// Replace rather than SetText, so ctrl+z can undo it
+	app.editor.Replace(m[0], m[1], replacement)
+	app.editor.Select(m[0]+len(replacement), m[0]+len(replacement))
+	app.refreshFind()
+	app.updateFindStatus("replaced 1")
+}
+
+// replaceAll replaces every match in one edit, which one ctrl+z undoes,
+// keeping the caret where it was in the text around it
+func (app *App) replaceAll() {
+	f := app.find
+	if len(f.matches) == 0 {
+		app.updateFindStatus("no matches, nothing replaced")
+		return
+	}
+
+	text := app.editor.GetText()
+	_, _, cursor := app.editor.GetSelection()
+	var b strings.Builder
+	last, newCursor := 0, cursor
+	for _, m := range f.matches {
+		replacement := app.replacement(text, m)
+		b.WriteString(text[last:m[0]])
+		b.WriteString(replacement)
+		last = m[1]
+		if m[1] <= cursor {
+			newCursor += len(replacement) - (m[1] - m[0])
+		} else if m[0] < cursor {
+			newCursor = b.Len()
+		}
+	}
+	b.WriteString(text[last:])
+
+	count := len(f.matches)
+	app.editor.Replace(0, len(text), b.String())
+	app.editor.Select(newCursor, newCursor)
+	app.refreshFind()
+	app.updateFindStatus(fmt.Sprintf("replaced %d", count))
+	app.notifications.Info(fmt.Sprintf("replaced %d matches", count))
+}
+
+// replacement is what match m of text is replaced with: the replace field
+// as typed, or with $1 and the like expanded for a regular expression
+func (app *App) replacement(text string, m []int) string {
+	f := app.find
+	if !f.regex {
+		return f.replace.GetText()
+	}
+	return string(f.pattern.ExpandString(nil, f.replace.GetText(), text, m))
+}
+
+// updateFindStatus shows the match count, the mode and the keys, after
+// message if there is one
+func (app *App) updateFindStatus(message string) {
+	f := app.find
+	var parts []string
+	if message != "" {
+		parts = append(parts, tagAccent+message+"[-]")
+	}
+	switch {
+	case f.err != nil:
+		parts = append(parts, tagError+"invalid pattern[-]")
+	case f.query.GetText() == "":
+	case len(f.matches) == 0:
+		parts = append(parts, tagWarning+"no matches[-]")
+	default:
+		parts = append(parts, fmt.Sprintf("%d of %d", f.current+1, len(f.matches)))
+	}
+
+	mode := "literal"
+	if f.regex {
+		mode = "regex"
+	}
+	keys := "enter search · tab replace · ctrl+t " + mode + " · esc close"
+	switch {
+	case f.status.HasFocus():
+		keys = "n next · N previous · / find · r replace · esc close"
+	case f.replace.HasFocus():
+		keys = "enter replace · ctrl+r replace all · ctrl+n next · ctrl+t " + mode + " · esc close"
+	}
+	parts = append(parts, tagDim+keys+"[-]")
+	f.status.SetText(strings.Join(parts, "  "))
+}
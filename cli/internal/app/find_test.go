@@ -0,0 +1,29 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestIsReplaceKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *tcell.EventKey
+		want  bool
+	}{
+		{"ctrl+r", tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl), true},
+		{"ctrl+h reported as such", tcell.NewEventKey(tcell.KeyBackspace, 0, tcell.ModCtrl), true},
+		// What most terminals send for ctrl+h, and some for backspace
+		{"ctrl+h as backspace", tcell.NewEventKey(tcell.KeyBackspace, 0, tcell.ModNone), false},
+		{"backspace", tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), false},
+		{"r", tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isReplaceKey(tt.event); got != tt.want {
+				t.Fatalf("isReplaceKey = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
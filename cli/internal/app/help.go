@@ -14,6 +14,22 @@ func (app *App) showHelp() {
   ctrl+k        command palette
   ctrl+s        save
   ctrl+p        publish/unpublish
+  ctrl+l        notification log
+  ctrl+g        writing stats
+  ctrl+f        find (enter, then n / N for next / previous)
+  ctrl+r        find and replace (ctrl+r again replaces all)
+
+[white]vim keys[-] [dim](settings, editor keys)[-]
+  i / a         insert before / after the cursor
+  esc           back to normal mode
+  h j k l       move
+  0 / $         start / end of line
+  x             delete a character
+  dd / yy / p   delete / copy / paste a line
+  u / ctrl+r    undo / redo
+  / n           search, next match
+  :w :q :wq     save, quit menu, both
+  esc esc       quit menu
 
 [white]command palette[-]
   n             new slate
@@ -21,6 +37,16 @@ func (app *App) showHelp() {
   h             help
   s             save
   e             settings
+  t             table of contents
+  p             toggle markdown preview
+  f             typewriter mode
+  r             find and replace
+  o             edit in $EDITOR
+  g             set word goal
+  v             version history
+  l             notification log
+  w             writing stats
+  i             about and diagnostics
   esc           back to editor
 
 [white]quit menu[-]
@@ -32,8 +58,13 @@ func (app *App) showHelp() {
 [white]all slates[-]
   enter         open slate
   n             new slate
+  c             duplicate slate
+  f             pin/unpin to the top
   p             publish/unpublish
+  l             show share link
   d             delete slate
+  /             search (sqlite storage)
+  u             undo delete (when confirm is off)
   esc           back to editor
 
 [white]workflow[-]
@@ -57,7 +88,7 @@ func (app *App) showHelp() {
 	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
 			app.pages.RemovePage("help")
-			app.tviewApp.SetFocus(app.editor)
+			app.resumeEditor()
 			return nil
 		}
 		return event
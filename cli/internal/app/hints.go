@@ -0,0 +1,59 @@
+package app
+
+// First-use tips, shown one at a time in the editor footer. Each is shown
+// for one editor session and is then remembered in config, so it doesn't
+// come back. Using the feature it describes dismisses it straight away.
+const (
+	hintSave    = "save"
+	hintPalette = "palette"
+	hintPublish = "publish"
+	hintSync    = "sync"
+)
+
+// hintOrder is the order tips are shown in
+var hintOrder = []string{hintSave, hintPalette, hintPublish, hintSync}
+
+var hintText = map[string]string{
+	hintSave:    "tip: ctrl+s saves now; slates also save when you leave the editor",
+	hintPalette: "tip: ctrl+k opens commands for slates, settings and stats",
+	hintPublish: "tip: ctrl+p publishes this slate and gives you a link to share",
+	hintSync:    "tip: log in from settings to sync slates across devices",
+}
+
+// nextHint picks the tip for a new editor session and marks it as seen.
+// Publishing needs an account, so local users get the sync tip instead.
+func (app *App) nextHint() string {
+	if app.hintsOff {
+		return ""
+	}
+
+	for _, id := range hintOrder {
+		if app.dismissedHints[id] {
+			continue
+		}
+		if (id == hintPublish && !app.isCloud) || (id == hintSync && app.isCloud) {
+			continue
+		}
+
+		app.dismissHint(id)
+		return id
+	}
+	return ""
+}
+
+// dismissHint records that a tip shouldn't be shown again and clears it
+// from the footer if it's the current one
+func (app *App) dismissHint(id string) {
+	if app.currentHint == id {
+		app.currentHint = ""
+	}
+	if app.dismissedHints[id] {
+		return
+	}
+
+	if app.dismissedHints == nil {
+		app.dismissedHints = make(map[string]bool)
+	}
+	app.dismissedHints[id] = true
+	app.saveConfig()
+}
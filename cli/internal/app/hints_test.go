@@ -0,0 +1,89 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/justtype/cli/internal/config"
+)
+
+// hintsApp is a fresh App on a home with configJSON. Call it again with the
+// same home to see what the last one remembered.
+func hintsApp(t *testing.T, home, configJSON string) *App {
+	t.Helper()
+	t.Setenv(config.HomeEnv, home)
+	t.Setenv(config.APIURLEnv, "")
+	if configJSON != "" {
+		if err := os.WriteFile(filepath.Join(home, "config.json"), []byte(configJSON), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestHintsShowOnce(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		cloud bool
+		want  []string
+	}{
+		{"local", false, []string{hintSave, hintPalette, hintSync}},
+		{"account", true, []string{hintSave, hintPalette, hintPublish}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			app := hintsApp(t, home, `{}`)
+			app.isCloud = tt.cloud
+
+			// One tip per editor session, in order
+			for _, want := range tt.want {
+				if got := app.nextHint(); got != want {
+					t.Fatalf("nextHint = %q, want %q", got, want)
+				}
+			}
+			if got := app.nextHint(); got != "" {
+				t.Fatalf("tip %q shown after all of them were seen", got)
+			}
+
+			// Remembered across restarts
+			reopened := hintsApp(t, home, "")
+			reopened.isCloud = tt.cloud
+			if got := reopened.nextHint(); got != "" {
+				t.Fatalf("tip %q shown again after a restart", got)
+			}
+		})
+	}
+}
+
+func TestUsingFeatureDismissesHint(t *testing.T) {
+	home := t.TempDir()
+	app := hintsApp(t, home, `{}`)
+	app.currentHint = app.nextHint()
+	if app.currentHint != hintSave {
+		t.Fatalf("first tip = %q, want the save tip", app.currentHint)
+	}
+
+	// Opening the palette before its tip came up means it never does
+	app.dismissHint(hintPalette)
+	app.dismissHint(hintSave)
+	if app.currentHint != "" {
+		t.Fatal("the showing tip wasn't cleared once used")
+	}
+
+	reopened := hintsApp(t, home, "")
+	if got := reopened.nextHint(); got != hintSync {
+		t.Fatalf("next tip after a restart = %q, want the sync tip", got)
+	}
+}
+
+func TestHideHints(t *testing.T) {
+	app := hintsApp(t, t.TempDir(), `{"hide_hints": true}`)
+	if got := app.nextHint(); got != "" {
+		t.Fatalf("tip %q shown with hints turned off", got)
+	}
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/versions"
+	"github.com/rivo/tview"
+)
+
+// showHistory lists the saved versions of the open slate
+func (app *App) showHistory() {
+	history, ok := app.storage.(storage.Versioned)
+	if !ok || app.currentSlate == nil || app.currentSlate.ID == "" {
+		app.showError("No history for this slate yet.")
+		return
+	}
+
+	list, err := history.Versions(app.currentSlate.ID)
+	if err != nil {
+		app.showError(fmt.Sprintf("Couldn't read history: %v", err))
+		return
+	}
+	if len(list) == 0 {
+		app.showError("No history for this slate yet.")
+		return
+	}
+
+	versionList := tview.NewList()
+	versionList.SetBorder(true).
+		SetTitle(" version history ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	for _, v := range list {
+		v := v
+		label := v.Time.Local().Format("2006-01-02 15:04:05")
+		detail := fmt.Sprintf("%d words  %s", storage.CountWords(v.Content), formatTimeAgo(v.Time))
+		versionList.AddItem(label, detail, 0, func() {
+			app.showVersionDiff(v)
+		})
+	}
+
+	versionList.SetSelectedBackgroundColor(colorPurple)
+	versionList.SetSelectedTextColor(colorBackground)
+	versionList.SetMainTextColor(colorForeground)
+	versionList.SetSecondaryTextColor(colorDim)
+
+	versionList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			app.pages.RemovePage("history")
+			app.resumeEditor()
+			return nil
+		}
+		return event
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(versionList, 0, 3, true).
+			AddItem(nil, 0, 1, false), 60, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddAndSwitchToPage("history", centered, true)
+	app.tviewApp.SetFocus(versionList)
+}
+
+// showVersionDiff shows what restoring v would change in the editor, and
+// restores it on enter
+func (app *App) showVersionDiff(v versions.Version) {
+	previous := app.tviewApp.GetFocus()
+
+	var b strings.Builder
+	for _, line := range versions.Diff(app.editor.GetText(), v.Content) {
+		text := tview.Escape(line.Text)
+		switch line.Op {
+		case '-':
+			b.WriteString(tagError + "- " + text + "[-]\n")
+		case '+':
+			b.WriteString(tagSuccess + "+ " + text + "[-]\n")
+		default:
+			b.WriteString(tagDim + "  " + text + "[-]\n")
+		}
+	}
+
+	diff := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(b.String())
+	diff.SetBorder(true).
+		SetTitle(" " + v.Time.Local().Format("2006-01-02 15:04:05") + " vs now · enter restore · esc back ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	diff.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			app.pages.RemovePage("version-diff")
+			app.tviewApp.SetFocus(previous)
+			return nil
+		case tcell.KeyEnter:
+			app.pages.RemovePage("version-diff")
+			app.pages.RemovePage("history")
+			app.resumeEditor()
+			// Replace keeps the change on the undo stack
+			app.editor.Replace(0, app.editor.GetTextLength(), v.Content)
+			app.saveNow()
+			app.notifications.Info("restored version from " + v.Time.Local().Format("2006-01-02 15:04"))
+			return nil
+		}
+		return event
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(diff, 100, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("version-diff", centered, true, true)
+	app.tviewApp.SetFocus(diff)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/inbox"
+	"github.com/justtype/cli/internal/storage"
+)
+
+// startInbox watches the configured inbox directory, importing text files
+// dropped there as slates. It only runs while the app is open.
+func (app *App) startInbox() {
+	app.stopInbox()
+
+	if app.inboxDir == "" || app.storage == nil {
+		return
+	}
+
+	dir, err := config.ExpandHome(app.inboxDir)
+	if err != nil {
+		app.notifications.Error(fmt.Sprintf("inbox: %v", err))
+		return
+	}
+
+	st := app.storage
+	importFn := func(name, content string) error {
+		if _, err := storage.Import(st, content); err != nil {
+			return err
+		}
+
+		app.tviewApp.QueueUpdateDraw(func() {
+			app.notifications.Info("imported " + name)
+			if slates, err := st.List(); err == nil {
+				app.setSlates(slates)
+			}
+		})
+		return nil
+	}
+
+	onError := func(err error) {
+		app.tviewApp.QueueUpdateDraw(func() {
+			app.notifications.Error(fmt.Sprintf("inbox: %v", err))
+		})
+	}
+
+	w, err := inbox.Watch(dir, inbox.DefaultQuiet, importFn, onError)
+	if err != nil {
+		app.notifications.Error(fmt.Sprintf("inbox: %v", err))
+		return
+	}
+	app.inbox = w
+}
+
+func (app *App) stopInbox() {
+	if app.inbox != nil {
+		app.inbox.Close()
+		app.inbox = nil
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/idlelock"
+	"github.com/rivo/tview"
+)
+
+// watchIdle locks the screen after lockMinutes without input. The editor
+// and everything else stay as they were underneath.
+func (app *App) watchIdle() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		app.tviewApp.QueueUpdateDraw(app.checkIdle)
+	}
+}
+
+// checkIdle locks the screen once the idle time has passed. It runs on the
+// UI goroutine.
+func (app *App) checkIdle() {
+	app.idle.SetMinutes(app.lockMinutes)
+	if !app.locked && app.idle.Expired(time.Now()) {
+		app.showLock()
+	}
+}
+
+func (app *App) showLock() {
+	app.locked = true
+	previous := app.tviewApp.GetFocus()
+
+	message := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter)
+	message.SetBackgroundColor(colorBackground)
+
+	input := tview.NewInputField().
+		SetFieldBackgroundColor(colorBackground).
+		SetFieldTextColor(colorForeground).
+		SetFieldWidth(30)
+	input.SetBackgroundColor(colorBackground)
+
+	if app.lockHash == "" {
+		message.SetText(tagAccent + "locked[-]\n\n" + tagDim + "press enter to resume[-]")
+	} else {
+		message.SetText(tagAccent + "locked[-]\n\n" + tagDim + "enter your lock passphrase[-]")
+		input.SetMaskCharacter('*')
+	}
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter {
+			return
+		}
+		if !idlelock.Check(app.lockHash, input.GetText()) {
+			input.SetText("")
+			message.SetText(tagAccent + "locked[-]\n\n" + tagError + "wrong passphrase[-]")
+			return
+		}
+
+		app.locked = false
+		app.idle.Touch()
+		app.pages.RemovePage("lock")
+		if previous != nil {
+			app.tviewApp.SetFocus(previous)
+		}
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(message, 3, 0, false).
+			AddItem(nil, 1, 0, false).
+			AddItem(input, 1, 0, true).
+			AddItem(nil, 0, 1, false), 30, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	// Drawn over every page so nothing behind it shows
+	app.pages.AddPage("lock", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
+
+// lockSteps are the idle lock settings cycled through in settings
+var lockSteps = []int{0, 5, 15, 30}
+
+func nextLockMinutes(current int) int {
+	for i, m := range lockSteps {
+		if m == current && i+1 < len(lockSteps) {
+			return lockSteps[i+1]
+		}
+	}
+	return 0
+}
+
+func lockLabel(minutes int) string {
+	if minutes <= 0 {
+		return "idle lock: off"
+	}
+	return fmt.Sprintf("idle lock: after %d min", minutes)
+}
+
+func (app *App) showLockPassphraseInput() {
+	input := tview.NewInputField().
+		SetLabel("passphrase (empty for none): ").
+		SetFieldWidth(24).
+		SetMaskCharacter('*')
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.pages.RemovePage("lock-passphrase")
+		if key == tcell.KeyEnter {
+			hash, err := idlelock.Hash(input.GetText())
+			if err != nil {
+				app.showError(fmt.Sprintf("Couldn't set passphrase: %v", err))
+				return
+			}
+			app.lockHash = hash
+			app.saveConfig()
+		}
+		app.showSettings()
+	})
+
+	input.SetBorder(true).
+		SetTitle(" lock passphrase ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 60, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("lock-passphrase", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
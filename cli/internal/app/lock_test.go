@@ -0,0 +1,103 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/justtype/cli/internal/idlelock"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/notify"
+	"github.com/justtype/cli/internal/storage"
+)
+
+// typeText sends text and enter to whatever has focus
+func typeText(app *App, text string) {
+	for _, r := range text {
+		app.tviewApp.QueueEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	app.tviewApp.QueueEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+}
+
+// frontPage waits for page to come to the front, or for the wait to run out
+func frontPage(app *App, page string) string {
+	var front string
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		onUI(app, func() { front, _ = app.pages.GetFrontPage() })
+		if front == page {
+			break
+		}
+	}
+	return front
+}
+
+func TestIdleLockAndResume(t *testing.T) {
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate := &storage.Slate{Slate: model.Slate{Content: "private thoughts"}}
+	if err := local.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := idlelock.Hash("open sesame")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := runningApp(t)
+	app.pages = tview.NewPages()
+	app.storage = local
+	app.notifications = notify.New(notify.DefaultSize)
+	app.slateErrors = map[string]string{}
+	app.idle = idlelock.New(0)
+	app.lockMinutes = 5
+	app.lockHash = hash
+	const text = "private thoughts, not saved yet"
+	onUI(app, func() {
+		app.tviewApp.SetRoot(app.pages, true)
+		app.showEditor(slate)
+		app.editor.SetText(text, true)
+	})
+
+	// Input a moment ago: stays open
+	onUI(app, app.checkIdle)
+	if app.locked || frontPage(app, PageEditor) != PageEditor {
+		t.Fatal("locked before the idle time was up")
+	}
+
+	// No input since long ago: locks over the editor
+	app.idle = new(idlelock.Idle)
+	onUI(app, app.checkIdle)
+	if front := frontPage(app, "lock"); front != "lock" || !app.locked {
+		t.Fatalf("%s in front after the idle time, want the lock screen", front)
+	}
+	var editorFocused bool
+	onUI(app, func() { editorFocused = app.editor.HasFocus() })
+	if editorFocused {
+		t.Fatal("editor still takes typing while locked")
+	}
+
+	typeText(app, "wrong")
+	if front := frontPage(app, PageEditor); front != "lock" {
+		t.Fatalf("wrong passphrase brought up %s", front)
+	}
+
+	typeText(app, "open sesame")
+	if front := frontPage(app, PageEditor); front != PageEditor {
+		t.Fatalf("right passphrase left %s in front", front)
+	}
+	var got string
+	onUI(app, func() {
+		got = app.editor.GetText()
+		editorFocused = app.editor.HasFocus()
+	})
+	if got != text || !editorFocused || app.locked {
+		t.Fatalf("after resuming: text %q, focused %v, locked %v", got, editorFocused, app.locked)
+	}
+	if app.idle.Expired(time.Now()) {
+		t.Fatal("resuming didn't count as input")
+	}
+}
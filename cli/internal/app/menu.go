@@ -38,7 +38,7 @@ func (app *App) showMenu() {
 	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
 			app.pages.RemovePage("menu")
-			app.tviewApp.SetFocus(app.editor)
+			app.resumeEditor()
 			return nil
 		}
 		return event
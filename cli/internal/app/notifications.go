@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/notify"
+	"github.com/rivo/tview"
+)
+
+func (app *App) showNotifications() {
+	var b strings.Builder
+	entries := app.notifications.Entries()
+	if len(entries) == 0 {
+		b.WriteString(tagDim + "nothing yet[-]")
+	}
+
+	for _, e := range entries {
+		color := tagForeground
+		if e.Level == notify.Error {
+			color = tagError
+		}
+		fmt.Fprintf(&b, tagDim+"%s[-]  %s%s[-]\n", e.Time.Format("15:04:05"), color, tview.Escape(e.Message))
+	}
+
+	textView := tview.NewTextView().
+		SetText(b.String()).
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+
+	textView.SetBorder(true).
+		SetTitle(" notifications ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	// Handle keys
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyCtrlL {
+			app.pages.RemovePage("notifications")
+			app.resumeEditor()
+			return nil
+		}
+		return event
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(textView, notify.DefaultSize+2, 0, true).
+			AddItem(nil, 0, 1, false), 80, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddAndSwitchToPage("notifications", centered, true)
+	app.tviewApp.SetFocus(textView)
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/markdown"
+	"github.com/rivo/tview"
+)
+
+const (
+	// Below this many columns the preview covers the editor instead of
+	// sitting beside it
+	previewSplitWidth = 120
+
+	// Typing this long without a pause doesn't re-render the preview
+	previewDelay = 300 * time.Millisecond
+)
+
+// togglePreview shows or hides the rendered Markdown preview
+func (app *App) togglePreview() {
+	if app.previewOn {
+		app.closePreview()
+		return
+	}
+
+	app.previewOn = true
+	app.openPreview()
+}
+
+// openPreview puts the preview beside the editor, or over it on narrow
+// terminals
+func (app *App) openPreview() {
+	if app.preview == nil {
+		app.preview = tview.NewTextView().
+			SetDynamicColors(true).
+			SetWordWrap(true)
+		app.preview.SetBorder(true).
+			SetTitle(" preview ").
+			SetTitleAlign(tview.AlignLeft).
+			SetBackgroundColor(colorBackground)
+		app.preview.SetBorderColor(colorDim)
+	}
+	app.refreshPreview()
+
+	_, _, width, _ := app.pages.GetRect()
+	if width < previewSplitWidth {
+		app.previewOverlay = true
+		app.preview.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyEnter {
+				app.closePreview()
+				return nil
+			}
+			return event
+		})
+		app.pages.AddPage("preview", app.preview, true, true)
+		app.tviewApp.SetFocus(app.preview)
+		return
+	}
+
+	// Each pane gets up to the editor's usual 100 columns
+	app.previewOverlay = false
+	app.preview.SetInputCapture(nil)
+	app.editorBody.AddItem(app.preview, 0, 1, false)
+	app.editorLayout.ResizeItem(app.editorColumn, min(width, 200), 0)
+}
+
+func (app *App) closePreview() {
+	app.previewOn = false
+	if app.previewTimer != nil {
+		app.previewTimer.Stop()
+	}
+
+	if app.previewOverlay {
+		app.pages.RemovePage("preview")
+		app.resumeEditor()
+		return
+	}
+	app.editorBody.RemoveItem(app.preview)
+	app.editorLayout.ResizeItem(app.editorColumn, 100, 0)
+}
+
+// schedulePreview re-renders the preview once typing pauses
+func (app *App) schedulePreview() {
+	if !app.previewOn {
+		return
+	}
+	if app.previewTimer != nil {
+		app.previewTimer.Stop()
+	}
+	app.previewTimer = time.AfterFunc(previewDelay, func() {
+		app.tviewApp.QueueUpdateDraw(app.refreshPreview)
+	})
+}
+
+func (app *App) refreshPreview() {
+	if app.preview == nil || app.editor == nil {
+		return
+	}
+	app.preview.SetText(renderMarkdown(app.editor.GetText()))
+}
+
+var (
+	boldPattern   = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	italicPattern = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*|\b_(\S(?:[^_]*?\S)?)_\b`)
+	bulletPattern = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+)
+
+// renderMarkdown turns basic Markdown (headings, bold, italic, lists, quotes
+// and code) into tview color tags
+func renderMarkdown(content string) string {
+	var out []string
+	inFence := false
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, tagSuccess+"  "+tview.Escape(line)+"[-]")
+			continue
+		}
+
+		if level, text, ok := markdown.ParseHeading(line); ok {
+			style := "b"
+			if level == 1 {
+				style = "bu"
+			}
+			out = append(out, tagAccent+"[::"+style+"]"+renderInline(text)+"[-::-]")
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(trimmed, ">"); ok {
+			out = append(out, tagDim+"│ "+renderInline(strings.TrimSpace(rest))+"[-]")
+			continue
+		}
+
+		if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			out = append(out, m[1]+"  "+tagAccent+"•[-] "+renderInline(line[len(m[0]):]))
+			continue
+		}
+
+		out = append(out, renderInline(line))
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// renderInline styles bold, italic and `code` within one line. Code spans
+// are left as they are inside.
+func renderInline(line string) string {
+	parts := strings.Split(line, "`")
+	for i, part := range parts {
+		part = tview.Escape(part)
+		// Odd parts sit between backticks; an unclosed one is left alone
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = tagSuccess + part + "[-]"
+			continue
+		}
+		if i%2 == 1 {
+			part = "`" + part
+		}
+		part = boldPattern.ReplaceAllString(part, "[::b]$1$2[::B]")
+		part = italicPattern.ReplaceAllString(part, "[::i]$1$2[::I]")
+		parts[i] = part
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/config"
+	"github.com/rivo/tview"
+)
+
+// showProfiles lists the profiles to switch to, each with its own account,
+// settings and slates
+func (app *App) showProfiles() {
+	names, err := config.ListProfiles()
+	if err != nil {
+		app.showError(fmt.Sprintf("Couldn't list profiles: %v", err))
+		return
+	}
+
+	list := tview.NewList()
+	current := config.Profile()
+	for _, name := range names {
+		label := name
+		if name == current {
+			label += " (in use)"
+		}
+		list.AddItem(label, "", 0, func() {
+			app.pages.RemovePage("profiles")
+			app.switchProfile(name)
+		})
+	}
+	list.AddItem("new profile", "", 'n', func() {
+		app.pages.RemovePage("profiles")
+		app.showNewProfileInput()
+	}).
+		AddItem("back", "", 'b', func() {
+			app.pages.RemovePage("profiles")
+			app.showSettings()
+		})
+
+	list.SetSelectedBackgroundColor(colorPurple)
+	list.SetSelectedTextColor(colorBackground)
+	list.SetMainTextColor(colorForeground)
+	list.SetShortcutColor(colorPurple)
+
+	list.SetBorder(true).
+		SetTitle(" switch account ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			app.pages.RemovePage("profiles")
+			app.showSettings()
+			return nil
+		}
+		return event
+	})
+
+	app.showAccountPage("profiles", list, min(len(names)+4, 16), 40)
+}
+
+func (app *App) showNewProfileInput() {
+	input := tview.NewInputField().
+		SetLabel("profile name: ").
+		SetFieldWidth(24)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter {
+			app.pages.RemovePage("new-profile")
+			app.showProfiles()
+			return
+		}
+		name := strings.TrimSpace(input.GetText())
+		if err := config.CheckProfileName(name); err != nil || name == "" {
+			app.notifications.Error(config.ErrProfileName.Error())
+			return
+		}
+		app.pages.RemovePage("new-profile")
+		app.switchProfile(name)
+	})
+
+	input.SetBorder(true).
+		SetTitle(" new profile ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	app.showAccountPage("new-profile", input, 3, 50)
+}
+
+// switchProfile saves the open slate, closes this profile's storage and
+// starts over on the named one's config, as if justtype had been run with
+// --profile. The session here isn't logged out, so switching back needs no
+// login.
+func (app *App) switchProfile(name string) {
+	if name == config.Profile() {
+		app.resumeEditor()
+		return
+	}
+	app.saveNow()
+
+	previous := config.Profile()
+	if err := config.UseProfile(name); err != nil {
+		app.showError(err.Error())
+		return
+	}
+	dataDir, err := config.Dir()
+	if err == nil {
+		app.configPath, err = config.Path()
+	}
+	if err != nil {
+		config.UseProfile(previous)
+		app.showError(fmt.Sprintf("Couldn't switch to %s: %v", name, err))
+		return
+	}
+
+	app.Close()
+	app.storage = nil
+	app.isCloud = false
+	app.setSlates(nil)
+	app.currentSlate = nil
+	app.showAllSlates = false
+	app.updateAvailable = ""
+	app.slateErrors = make(map[string]string)
+	app.dataDir = dataDir
+
+	app.loadConfig()
+	if err := app.applyConfig(); err != nil {
+		app.showError(fmt.Sprintf("Profile %s: %v", name, err))
+	}
+	// Written now so a new profile is listed even if it's never set up
+	app.saveConfig()
+
+	for _, page := range []string{PageEditor, PageSlates, PageSettings} {
+		app.pages.RemovePage(page)
+	}
+	if err := app.start(); err != nil {
+		app.showError(err.Error())
+		return
+	}
+	app.notifications.Info("switched to " + name)
+}
@@ -2,8 +2,14 @@ package app
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/updater"
 	"github.com/rivo/tview"
 )
 
@@ -11,9 +17,9 @@ func (app *App) showSettings() {
 	// Build settings info
 	var info string
 	if app.isCloud {
-		info = fmt.Sprintf("signed in as: %s\nstorage: cloud (cached locally)\n\n[#666666]more settings available at justtype.io[-]", app.username)
+		info = fmt.Sprintf("signed in as: %s\nstorage: cloud (cached locally)\n%s\n\n"+tagDim+"more settings available at justtype.io[-]", app.username, app.syncInfo())
 	} else {
-		info = fmt.Sprintf("storage: %s\n\n[#666666]more settings available at justtype.io[-]", app.storagePath)
+		info = fmt.Sprintf("storage: %s\n\n"+tagDim+"more settings available at justtype.io[-]", app.storagePath)
 	}
 
 	infoView := tview.NewTextView().
@@ -26,9 +32,24 @@ func (app *App) showSettings() {
 
 	// Options depend on mode
 	if app.isCloud {
+		if app.e2eKey != "" {
+			list.AddItem("end-to-end encryption: on", "", 'e', func() {
+				app.confirmDisableEncryption()
+			})
+		} else {
+			list.AddItem("end-to-end encryption: off", "", 'e', func() {
+				app.showEncryptionSetup()
+			})
+		}
 		list.AddItem("logout", "", 'l', func() {
 			app.confirmLogout()
 		})
+		list.AddItem("manage account", "", 'm', func() {
+			app.showAccount()
+		})
+		list.AddItem("sign out of all devices", "", 'g', func() {
+			app.confirmLogoutEverywhere()
+		})
 	} else {
 		list.AddItem("change storage location", "", 'c', func() {
 			app.setupLocal()
@@ -36,10 +57,131 @@ func (app *App) showSettings() {
 			AddItem("login to sync", "", 'l', func() {
 				app.showAuth()
 			})
+		if local, ok := app.localStorage(); ok {
+			atRestLabel := "encrypt slates on disk: off"
+			if local.Encrypted() {
+				atRestLabel = "encrypt slates on disk: on"
+			}
+			list.AddItem(atRestLabel, "", 'x', func() {
+				app.showEncryptAtRestInput(local)
+			})
+		}
+	}
+
+	confirmLabel := "confirm deletes: on"
+	if !app.confirmDelete {
+		confirmLabel = "confirm deletes: off (undo instead)"
+	}
+	list.AddItem(confirmLabel, "", 'd', func() {
+		app.confirmDelete = !app.confirmDelete
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	list.AddItem(minWordsLabel(app.minWords), "", 'w', func() {
+		// Cycle: immediately -> 10+ words -> custom -> immediately
+		switch app.minWords {
+		case 0:
+			app.minWords = storage.SuggestedMinWords
+		case storage.SuggestedMinWords:
+			app.showMinWordsInput()
+			return
+		default:
+			app.minWords = 0
+		}
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	list.AddItem("autosave: "+config.AutosaveLabel(app.autosaveSeconds), "", 's', func() {
+		app.autosaveSeconds = config.NextAutosave(app.autosaveSeconds)
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	keysLabel := "editor keys: standard"
+	if app.editorMode == editorModeVim {
+		keysLabel = "editor keys: vim"
+	}
+	list.AddItem(keysLabel, "", 'v', func() {
+		if app.editorMode == editorModeVim {
+			app.editorMode = ""
+		} else {
+			app.editorMode = editorModeVim
+		}
+		app.vim = vimState{}
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	list.AddItem(externalEditorLabel(app.externalEditor), "", 'o', func() {
+		app.showExternalEditorInput()
+	})
+
+	updateLabels := map[string]string{
+		updater.ModeAuto:   "updates: install automatically",
+		updater.ModeNotify: "updates: ask first",
+		updater.ModeNever:  "updates: never check",
+	}
+	list.AddItem(updateLabels[updater.NormalizeMode(app.updateMode)], "", 'u', func() {
+		app.updateMode = updater.NextMode(app.updateMode)
+		app.updateSnoozed = time.Time{}
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	list.AddItem("update channel: "+app.updateChannel, "", 'r', func() {
+		app.updateChannel = updater.NextChannel(app.updateChannel)
+		updater.SetChannel(app.updateChannel)
+		app.skippedVersion = ""
+		app.lastCheck, app.latestVersion = time.Time{}, ""
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	tipsLabel := "first-use tips: on"
+	if app.hintsOff {
+		tipsLabel = "first-use tips: off"
+	}
+	list.AddItem(tipsLabel, "", 't', func() {
+		// Turning tips back on starts them over
+		app.hintsOff = !app.hintsOff
+		if !app.hintsOff {
+			app.dismissedHints = nil
+		}
+		app.saveConfig()
+		app.showSettings()
+	})
+
+	list.AddItem(lockLabel(app.lockMinutes), "", 'k', func() {
+		app.lockMinutes = nextLockMinutes(app.lockMinutes)
+		app.saveConfig()
+		app.showSettings()
+	})
+	if app.lockMinutes > 0 {
+		passLabel := "lock passphrase: none (enter unlocks)"
+		if app.lockHash != "" {
+			passLabel = "lock passphrase: set"
+		}
+		list.AddItem(passLabel, "", 'p', func() {
+			app.showLockPassphraseInput()
+		})
 	}
 
+	list.AddItem("switch account: "+config.Profile(), "", 'n', func() {
+		app.showProfiles()
+	})
+
+	list.AddItem("find duplicate slates", "", 'f', func() {
+		app.findDuplicates()
+	})
+
+	list.AddItem("about", "", 'a', func() {
+		app.showAbout()
+	})
+
 	list.AddItem("back", "", 'b', func() {
-		app.showEditor(app.currentSlate)
+		app.resumeEditor()
 	})
 
 	list.SetBorder(false).
@@ -48,7 +190,7 @@ func (app *App) showSettings() {
 	layout := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(nil, 1, 0, false).
-		AddItem(infoView, 3, 0, false).
+		AddItem(infoView, 5, 0, false).
 		AddItem(nil, 2, 0, false).
 		AddItem(list, 0, 1, true)
 
@@ -60,7 +202,7 @@ func (app *App) showSettings() {
 	// Handle keys
 	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
-			app.showEditor(app.currentSlate)
+			app.resumeEditor()
 			return nil
 		}
 		return event
@@ -70,34 +212,92 @@ func (app *App) showSettings() {
 	app.tviewApp.SetFocus(list)
 }
 
+func minWordsLabel(n int) string {
+	switch n {
+	case 0:
+		return "save new slates: immediately"
+	case storage.SuggestedMinWords:
+		return fmt.Sprintf("save new slates: at %d+ words", n)
+	}
+	return fmt.Sprintf("save new slates: at %d+ words (custom)", n)
+}
+
+func (app *App) showMinWordsInput() {
+	input := tview.NewInputField().
+		SetLabel("words before a new slate is saved: ").
+		SetFieldWidth(6).
+		SetAcceptanceFunc(tview.InputFieldInteger)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.pages.RemovePage("min-words")
+		if key == tcell.KeyEnter {
+			if n, err := strconv.Atoi(input.GetText()); err == nil && n >= 0 {
+				app.minWords = n
+				app.saveConfig()
+			}
+		}
+		app.showSettings()
+	})
+
+	input.SetBorder(true).
+		SetTitle(" save new slates ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 50, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("min-words", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
+
+// syncInfo summarizes the offline cache for the settings page
+func (app *App) syncInfo() string {
+	st, err := app.status()
+	if err != nil {
+		return ""
+	}
+
+	lastSync := "never synced"
+	if !st.LastSync.IsZero() {
+		lastSync = "last sync: " + formatTimeAgo(st.LastSync)
+	}
+	if st.Unsynced > 0 {
+		return fmt.Sprintf("%s · %d unsynced", lastSync, st.Unsynced)
+	}
+	return lastSync
+}
+
 func (app *App) confirmLogout() {
+	app.showLogoutModal("logout?", false)
+}
+
+// confirmLogoutEverywhere is logout for a lost or stolen device: every
+// session on the account ends, not just this one
+func (app *App) confirmLogoutEverywhere() {
+	app.showLogoutModal("sign out of all devices?\n\nevery device logged in to this account will have to log in again", true)
+}
+
+func (app *App) showLogoutModal(text string, everywhere bool) {
 	modal := tview.NewModal().
-		SetText("logout?").
+		SetText(text).
 		AddButtons([]string{"Logout & Exit", "Logout & Use Local", "Cancel"}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 			app.pages.RemovePage("confirm-logout")
-			if buttonIndex == 0 {
+			switch buttonIndex {
+			case 0:
 				// Logout and exit (like fresh install)
-				app.Close()
-				app.token = ""
-				app.username = ""
-				app.isCloud = false
-				app.storage = nil
-				app.slates = nil
-				app.currentSlate = nil
-				app.saveConfig()
-				app.tviewApp.Stop()
-			} else if buttonIndex == 1 {
+				app.logout(everywhere, app.tviewApp.Stop)
+			case 1:
 				// Logout and switch to local storage
-				app.Close()
-				app.token = ""
-				app.username = ""
-				app.isCloud = false
-				app.storage = nil
-				app.slates = nil
-				app.currentSlate = nil
-				app.saveConfig()
-				app.setupLocal()
+				app.logout(everywhere, app.setupLocal)
 			}
 		})
 
@@ -108,3 +308,61 @@ func (app *App) confirmLogout() {
 
 	app.pages.AddPage("confirm-logout", modal, true, true)
 }
+
+// forgetCredentials closes the cloud storage and drops the account from
+// the config, leaving nothing here that can reach it
+func (app *App) forgetCredentials() {
+	app.Close()
+	app.token = ""
+	app.refreshToken = ""
+	app.username = ""
+	app.e2eKey = ""
+	app.isCloud = false
+	app.storage = nil
+	app.setSlates(nil)
+	app.currentSlate = nil
+	app.saveConfig()
+}
+
+// logout ends the session on the server (every session, with everywhere
+// set), forgets the credentials here and then calls next. The credentials
+// go even if the server can't be reached, with a warning first that the
+// token wasn't revoked there.
+func (app *App) logout(everywhere bool, next func()) {
+	client := api.New(app.apiURL, app.token)
+	client.SetTimeout(app.requestTimeout)
+	app.notifications.Info("logging out...")
+
+	go func() {
+		var err error
+		if everywhere {
+			err = client.RevokeAllSessions()
+		} else {
+			err = client.Logout()
+		}
+
+		app.tviewApp.QueueUpdateDraw(func() {
+			app.forgetCredentials()
+			if err == nil {
+				if everywhere {
+					app.notifications.Info("signed out of all devices")
+				}
+				next()
+				return
+			}
+			app.notifications.Error("server-side logout failed: " + err.Error())
+			modal := tview.NewModal().
+				SetText(fmt.Sprintf("Logged out here, but the server couldn't be told (%v), so the session still works there until it expires. To end it, log in again and sign out of all devices.", err)).
+				AddButtons([]string{"OK"}).
+				SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+					app.pages.RemovePage("logout-warning")
+					next()
+				})
+			modal.SetBackgroundColor(colorBackground).
+				SetTextColor(colorForeground).
+				SetButtonBackgroundColor(colorPurple).
+				SetButtonTextColor(colorForeground)
+			app.pages.AddPage("logout-warning", modal, true, true)
+		})
+	}()
+}
@@ -1,10 +1,16 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/clipboard"
+	"github.com/justtype/cli/internal/model"
 	"github.com/justtype/cli/internal/storage"
 	"github.com/rivo/tview"
 )
@@ -14,14 +20,28 @@ func (app *App) showSlates() {
 	list.ShowSecondaryText(true)
 
 	// Clear cached slates to ensure fresh data
-	app.slates = nil
+	app.setSlates(nil)
 
 	// Fetch slates from API (always fresh)
 	if app.storage != nil {
 		list.AddItem("loading slates...", "", 0, nil)
+		loading, loaded := context.WithCancel(context.Background())
+		app.spin(loading, func(frame string) {
+			list.SetItemText(0, loadingText(frame, "loading slates..."), "")
+		})
 
 		go func() {
-			slates, err := app.storage.List()
+			slates, unloaded, err := app.loadSlates()
+			loaded()
+			var limited *api.ErrRateLimited
+			if errors.As(err, &limited) {
+				app.tviewApp.QueueUpdateDraw(func() {
+					list.Clear()
+					list.AddItem(limited.Error(), "the list loads by itself once it's over", 0, nil)
+					app.retrySlatesAfter(limited.RetryAfter)
+				})
+				return
+			}
 			if err != nil {
 				app.tviewApp.QueueUpdateDraw(func() {
 					app.showError(fmt.Sprintf("Failed to load slates: %v", err))
@@ -30,11 +50,17 @@ func (app *App) showSlates() {
 			}
 
 			app.slates = slates
+			app.unloaded = unloaded
 
 			// Check for updates (throttled)
 			app.checkForUpdates()
 
 			app.tviewApp.QueueUpdateDraw(func() {
+				if w, ok := app.storage.(storage.Warner); ok {
+					for _, warning := range w.Warnings() {
+						app.notifications.Info(warning)
+					}
+				}
 				list.Clear()
 				app.populateSlatesList(list)
 			})
@@ -50,10 +76,12 @@ func (app *App) showSlates() {
 		SetBackgroundColor(colorBackground)
 
 	help := tview.NewTextView().
-		SetText("enter open · n new · p publish · d delete · esc back").
+		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter).
 		SetTextColor(colorDim)
 	help.SetBorder(false).SetBackgroundColor(colorBackground)
+	app.slatesHelp = help
+	app.updateSlatesHelp()
 
 	layout := tview.NewFlex().
 		SetDirection(tview.FlexRow).
@@ -65,7 +93,7 @@ func (app *App) showSlates() {
 	// Handle keys
 	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
-			app.showEditor(app.currentSlate)
+			app.resumeEditor()
 			return nil
 		}
 
@@ -75,17 +103,52 @@ func (app *App) showSlates() {
 		}
 
 		if event.Rune() == 'd' {
-			idx := list.GetCurrentItem()
-			if idx >= 0 && idx < len(app.slates) {
-				app.confirmDelete(app.slates[idx])
+			if slate := app.selectedSlate(list); slate != nil {
+				if app.confirmDelete {
+					app.confirmDeleteSlate(slate)
+				} else {
+					app.deleteSlate(slate, true)
+				}
+			}
+			return nil
+		}
+
+		if event.Rune() == 'c' {
+			if slate := app.selectedSlate(list); slate != nil {
+				app.duplicateSlate(slate)
+			}
+			return nil
+		}
+
+		if event.Rune() == 'u' && app.undoSlate != nil {
+			app.undoDelete()
+			return nil
+		}
+
+		if event.Rune() == 'l' {
+			if slate := app.selectedSlate(list); slate != nil {
+				app.showShareLink(slate)
 			}
 			return nil
 		}
 
 		if event.Rune() == 'p' {
-			idx := list.GetCurrentItem()
-			if idx >= 0 && idx < len(app.slates) {
-				app.handlePublish(app.slates[idx])
+			if slate := app.selectedSlate(list); slate != nil {
+				app.handlePublish(slate)
+			}
+			return nil
+		}
+
+		if event.Rune() == 'f' {
+			if slate := app.selectedSlate(list); slate != nil {
+				app.togglePin(list, slate)
+			}
+			return nil
+		}
+
+		if event.Rune() == '/' {
+			if searcher, ok := app.storage.(storage.Searcher); ok {
+				app.showSlateSearch(list, searcher)
 			}
 			return nil
 		}
@@ -97,9 +160,67 @@ func (app *App) showSlates() {
 	app.tviewApp.SetFocus(list)
 }
 
+// rateLimitWait is how long to wait before listing slates again when the
+// server rate limits without saying for how long
+const rateLimitWait = 5 * time.Second
+
+// retrySlatesAfter lists the slates again after a rate limit, if the list is
+// still up by then
+func (app *App) retrySlatesAfter(wait time.Duration) {
+	if wait <= 0 {
+		wait = rateLimitWait
+	}
+	app.notifications.Info(fmt.Sprintf("rate limited, retrying in %ds", int(wait.Round(time.Second)/time.Second)))
+	time.AfterFunc(wait, func() {
+		app.tviewApp.QueueUpdateDraw(func() {
+			if front, _ := app.pages.GetFrontPage(); front == PageSlates {
+				app.showSlates()
+			}
+		})
+	})
+}
+
+// loadSlates reads the slates for the list. Until "load all" is picked, a
+// storage that can read only the most recent ones reads recentLimit of
+// them, and unloaded is how many it left.
+func (app *App) loadSlates() (slates []*storage.Slate, unloaded int, err error) {
+	if recent, ok := app.storage.(storage.RecentLister); ok && !app.showAllSlates && app.recentLimit > 0 {
+		slates, total, err := recent.Recent(app.recentLimit)
+		return slates, total - len(slates), err
+	}
+	slates, err = app.storage.List()
+	return slates, 0, err
+}
+
+// setSlates replaces app.slates with every slate, or a search's results
+func (app *App) setSlates(slates []*storage.Slate) {
+	app.slates = slates
+	app.unloaded = 0
+}
+
+// shownSlates is the part of app.slates in the list: only the most recent
+// ones until "load all" is picked
+func (app *App) shownSlates() []*storage.Slate {
+	if !app.showAllSlates && app.recentLimit > 0 && len(app.slates) > app.recentLimit {
+		return app.slates[:app.recentLimit]
+	}
+	return app.slates
+}
+
+// selectedSlate is the slate under the cursor, or nil on the "load all" row
+func (app *App) selectedSlate(list *tview.List) *storage.Slate {
+	idx := list.GetCurrentItem()
+	if shown := app.shownSlates(); idx >= 0 && idx < len(shown) {
+		return shown[idx]
+	}
+	return nil
+}
+
 func (app *App) populateSlatesList(list *tview.List) {
+	shown := app.shownSlates()
+
 	// Add slates to list
-	for _, slate := range app.slates {
+	for _, slate := range shown {
 		title := slate.Title
 		if title == "" {
 			title = "untitled"
@@ -107,21 +228,34 @@ func (app *App) populateSlatesList(list *tview.List) {
 
 		subtitle := fmt.Sprintf("%d words  %s", slate.WordCount, formatTimeAgo(slate.UpdatedAt))
 
+		if slate.Pinned {
+			subtitle += "  [pinned]"
+		}
+
 		// Add publish status
 		if slate.IsPublished {
 			subtitle += "  [published]"
 		}
 
+		if msg, ok := app.slateErrors[slate.ID]; ok {
+			subtitle += "  " + tagError + "● " + tview.Escape(msg) + "[-]"
+		}
+
 		// Capture slate in closure
 		s := slate
 		list.AddItem(title, subtitle, 0, func() {
+			if app.currentSlate != nil && app.currentSlate.ID == s.ID {
+				app.resumeEditor()
+				return
+			}
+
 			// Load slate content (may require re-login if key expired)
 			go func() {
 				loadedSlate, err := app.storage.Load(s.ID)
 				if err != nil {
 					app.tviewApp.QueueUpdateDraw(func() {
 						// Check if session expired
-						if err.Error() == "SESSION_EXPIRED" {
+						if errors.Is(err, api.ErrSessionExpired) {
 							modal := tview.NewModal().
 								SetText("Session expired. Re-login to continue?").
 								AddButtons([]string{"Re-login", "Cancel"}).
@@ -151,20 +285,97 @@ func (app *App) populateSlatesList(list *tview.List) {
 			}()
 		})
 	}
+
+	if hidden := len(app.slates) - len(shown) + app.unloaded; hidden > 0 {
+		list.AddItem(fmt.Sprintf("load all (%d more)", hidden), "", 0, func() {
+			app.showAllSlates = true
+			current := list.GetCurrentItem()
+			if app.unloaded == 0 {
+				list.Clear()
+				app.populateSlatesList(list)
+				list.SetCurrentItem(current)
+				return
+			}
+
+			// The rest were never read
+			go func() {
+				slates, err := app.storage.List()
+				app.tviewApp.QueueUpdateDraw(func() {
+					if err != nil {
+						app.showError(fmt.Sprintf("Failed to load slates: %v", err))
+						return
+					}
+					app.setSlates(slates)
+					list.Clear()
+					app.populateSlatesList(list)
+					list.SetCurrentItem(current)
+				})
+			}()
+		})
+	}
 }
 
-func (app *App) confirmDelete(slate *storage.Slate) {
+// showSlateSearch asks for a query and narrows the list to the slates the
+// storage's full-text search finds
+func (app *App) showSlateSearch(list *tview.List, searcher storage.Searcher) {
+	input := tview.NewInputField().
+		SetLabel("search: ").
+		SetFieldWidth(40)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.pages.RemovePage("slate-search")
+		app.tviewApp.SetFocus(list)
+		query := strings.TrimSpace(input.GetText())
+		if key != tcell.KeyEnter || query == "" {
+			return
+		}
+
+		go func() {
+			slates, err := searcher.Search(query)
+			app.tviewApp.QueueUpdateDraw(func() {
+				if err != nil {
+					app.showError(fmt.Sprintf("Search failed: %v", err))
+					return
+				}
+				app.setSlates(slates)
+				app.showAllSlates = true
+				list.Clear()
+				list.SetTitle(fmt.Sprintf(" search: %s ", tview.Escape(query)))
+				if len(slates) == 0 {
+					list.AddItem("no matches", "", 0, nil)
+				}
+				app.populateSlatesList(list)
+			})
+		}()
+	})
+
+	input.SetBorder(true).
+		SetTitle(" search slates ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 60, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("slate-search", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
+
+func (app *App) confirmDeleteSlate(slate *storage.Slate) {
 	modal := tview.NewModal().
 		SetText(fmt.Sprintf("delete \"%s\"?", slate.Title)).
 		AddButtons([]string{"Delete", "Cancel"}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 			app.pages.RemovePage("confirm-delete")
 			if buttonIndex == 0 {
-				// Delete
-				if app.storage != nil {
-					app.storage.Delete(slate.ID)
-				}
-				app.showSlates()
+				app.deleteSlate(slate, false)
 			}
 		})
 
@@ -176,6 +387,180 @@ func (app *App) confirmDelete(slate *storage.Slate) {
 	app.pages.AddPage("confirm-delete", modal, true, true)
 }
 
+// undoWindow is how long the undo toast stays up after a delete
+const undoWindow = 5 * time.Second
+
+func (app *App) deleteSlate(slate *storage.Slate, withUndo bool) {
+	if app.storage == nil {
+		return
+	}
+
+	go func() {
+		err := app.storage.Delete(slate.ID)
+		app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				// Delete leaves the slate alone when it fails, so it's still listed
+				app.setSlateError(slate, "delete failed", err)
+				return
+			}
+			delete(app.slateErrors, slate.ID)
+			// The editor still holds the deleted slate; start fresh next time
+			if app.currentSlate != nil && app.currentSlate.ID == slate.ID {
+				app.currentSlate = nil
+				app.pages.RemovePage(PageEditor)
+			}
+			app.notifications.Info(fmt.Sprintf("deleted \"%s\"", slate.Title))
+			if _, ok := app.storage.(storage.Restorer); ok && withUndo {
+				app.startUndo(slate)
+			}
+			app.showSlates()
+		})
+	}()
+}
+
+// duplicateSlate saves a copy of a slate as a new one. It has no ID yet, so
+// every storage creates it fresh, unpublished and, with cloud storage, as a
+// new cloud slate. Local storage titles it from its content, as always.
+func (app *App) duplicateSlate(slate *storage.Slate) {
+	if app.storage == nil {
+		return
+	}
+
+	go func() {
+		// The list may not carry the content, so load it
+		orig, err := app.storage.Load(slate.ID)
+		if err == nil {
+			err = app.storage.Save(&storage.Slate{
+				Slate: model.Slate{
+					Title:   orig.Title + " (copy)",
+					Content: orig.Content,
+				},
+			})
+		}
+		app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				app.setSlateError(slate, "duplicate failed", err)
+				return
+			}
+			delete(app.slateErrors, slate.ID)
+			app.notifications.Info(fmt.Sprintf("duplicated \"%s\"", slate.Title))
+			app.showSlates()
+		})
+	}()
+}
+
+// setSlateError marks a slate in the list with a failed operation
+func (app *App) setSlateError(slate *storage.Slate, what string, err error) {
+	app.slateErrors[slate.ID] = fmt.Sprintf("%s: %v", what, err)
+
+	// Outside the list (publishing from the editor) a modal is the only place
+	// it would be seen
+	if name, _ := app.pages.GetFrontPage(); name != PageSlates {
+		app.showError(fmt.Sprintf("%s: %v", what, err))
+		return
+	}
+	app.notifications.Error(fmt.Sprintf("%s \"%s\": %v", what, slate.Title, err))
+	app.showSlates()
+}
+
+// togglePin pins a slate above the rest of the list, or unpins it, keeping
+// it selected where it moves to
+func (app *App) togglePin(list *tview.List, slate *storage.Slate) {
+	pinner, ok := app.storage.(storage.Pinner)
+	if !ok {
+		return
+	}
+	pinned := !slate.Pinned
+
+	go func() {
+		err := pinner.SetPinned(slate.ID, pinned)
+		app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				what := "pin failed"
+				if !pinned {
+					what = "unpin failed"
+				}
+				app.setSlateError(slate, what, err)
+				return
+			}
+			delete(app.slateErrors, slate.ID)
+			slate.Pinned = pinned
+			storage.SortSlates(app.slates)
+
+			list.Clear()
+			app.populateSlatesList(list)
+			for i, s := range app.shownSlates() {
+				if s.ID == slate.ID {
+					list.SetCurrentItem(i)
+				}
+			}
+		})
+	}()
+}
+
+func (app *App) startUndo(slate *storage.Slate) {
+	if app.undoTimer != nil {
+		app.undoTimer.Stop()
+	}
+
+	app.undoSlate = slate
+	app.undoTimer = time.AfterFunc(undoWindow, func() {
+		app.tviewApp.QueueUpdateDraw(func() {
+			if app.undoSlate == slate {
+				app.undoSlate = nil
+				app.updateSlatesHelp()
+			}
+		})
+	})
+}
+
+func (app *App) undoDelete() {
+	slate := app.undoSlate
+	app.undoSlate = nil
+	if app.undoTimer != nil {
+		app.undoTimer.Stop()
+	}
+
+	r, ok := app.storage.(storage.Restorer)
+	if !ok {
+		return
+	}
+
+	go func() {
+		_, err := r.Restore(slate.ID)
+		app.tviewApp.QueueUpdateDraw(func() {
+			if err != nil {
+				app.showError(fmt.Sprintf("Failed to restore: %v", err))
+				return
+			}
+			app.notifications.Info(fmt.Sprintf("restored \"%s\"", slate.Title))
+			app.showSlates()
+		})
+	}()
+}
+
+func (app *App) updateSlatesHelp() {
+	if app.slatesHelp == nil {
+		return
+	}
+
+	if app.undoSlate != nil {
+		app.slatesHelp.SetText(fmt.Sprintf(tagSuccess+"deleted \"%s\"[-]  "+tagAccent+"u undo[-]", tview.Escape(app.undoSlate.Title)))
+		return
+	}
+
+	if len(app.slates) == 0 {
+		app.slatesHelp.SetText("no slates yet. press n to create one · esc back")
+		return
+	}
+
+	if _, ok := app.storage.(storage.Searcher); ok {
+		app.slatesHelp.SetText("enter open · n new · c duplicate · f pin · / search · p publish · l link · d delete · esc back")
+		return
+	}
+	app.slatesHelp.SetText("enter open · n new · c duplicate · f pin · p publish · l link · d delete · esc back")
+}
+
 func (app *App) handlePublish(slate *storage.Slate) {
 	// Only works with cloud storage
 	cs, ok := app.storage.(*storage.CloudStorage)
@@ -206,11 +591,13 @@ func (app *App) handlePublish(slate *storage.Slate) {
 					go func() {
 						if err := cs.Unpublish(slate); err != nil {
 							app.tviewApp.QueueUpdateDraw(func() {
-								app.showError(fmt.Sprintf("Failed to unpublish: %v", err))
+								app.setSlateError(slate, "unpublish failed", err)
 							})
 							return
 						}
 						app.tviewApp.QueueUpdateDraw(func() {
+							delete(app.slateErrors, slate.ID)
+							app.notifications.Info(fmt.Sprintf("unpublished \"%s\"", slate.Title))
 							app.showSlates()
 						})
 					}()
@@ -226,10 +613,14 @@ func (app *App) handlePublish(slate *storage.Slate) {
 		// Publish
 		go func() {
 			shareURL, err := cs.Publish(slate)
+			var copied string
+			if err == nil {
+				copied = copyShareURL(shareURL)
+			}
 			if err != nil {
 				app.tviewApp.QueueUpdateDraw(func() {
 					// Check if session expired
-					if err.Error() == "SESSION_EXPIRED" {
+					if errors.Is(err, api.ErrSessionExpired) {
 						modal := tview.NewModal().
 							SetText("Session expired. Re-login to continue?").
 							AddButtons([]string{"Re-login", "Cancel"}).
@@ -247,15 +638,17 @@ func (app *App) handlePublish(slate *storage.Slate) {
 
 						app.pages.AddPage("session-expired", modal, true, true)
 					} else {
-						app.showError(fmt.Sprintf("Failed to publish: %v", err))
+						app.setSlateError(slate, "publish failed", err)
 					}
 				})
 				return
 			}
 
 			app.tviewApp.QueueUpdateDraw(func() {
+				delete(app.slateErrors, slate.ID)
+				app.notifications.Info("published " + shareURL)
 				modal := tview.NewModal().
-					SetText(fmt.Sprintf("Published!\n\n%s", shareURL)).
+					SetText(fmt.Sprintf("Published!\n\n%s\n\n%s", shareURL, copied)).
 					AddButtons([]string{"OK"}).
 					SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 						app.pages.RemovePage("publish-success")
@@ -272,6 +665,49 @@ func (app *App) handlePublish(slate *storage.Slate) {
 	}
 }
 
+// copyShareURL puts a share link on the clipboard and returns a line saying
+// how that went, for under the link. A missing clipboard tool isn't an
+// error; the link is on screen to select.
+func copyShareURL(url string) string {
+	if err := clipboard.Copy(url); err != nil {
+		return "couldn't copy it: " + err.Error()
+	}
+	return "copied to clipboard"
+}
+
+// showShareLink shows the public link of an already published slate
+func (app *App) showShareLink(slate *storage.Slate) {
+	text := fmt.Sprintf("\"%s\" isn't published.\n\nPress p to publish it.", slate.Title)
+	buttons := []string{"OK"}
+	var url string
+	if slate.IsPublished && slate.ShareID != "" {
+		url = api.ShareURL(app.apiURL, slate.ShareID)
+		text = url
+		buttons = []string{"OK", "Copy"}
+	}
+
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons(buttons).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.pages.RemovePage("share-link")
+			if buttonLabel != "Copy" {
+				return
+			}
+			if err := clipboard.Copy(url); err != nil {
+				app.showError(fmt.Sprintf("Couldn't copy the link: %v\n\n%s", err, url))
+				return
+			}
+			app.notifications.Info("link copied to clipboard")
+		}).
+		SetBackgroundColor(colorBackground).
+		SetTextColor(colorForeground).
+		SetButtonBackgroundColor(colorPurple).
+		SetButtonTextColor(colorForeground)
+
+	app.pages.AddPage("share-link", modal, true, true)
+}
+
 func formatTimeAgo(t time.Time) string {
 	diff := time.Since(t)
 
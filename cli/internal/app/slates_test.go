@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/storage"
+)
+
+// listCounter counts the calls that read every slate
+type listCounter struct {
+	*storage.LocalStorage
+	lists int
+}
+
+func (l *listCounter) List() ([]*storage.Slate, error) {
+	l.lists++
+	return l.LocalStorage.List()
+}
+
+func TestLoadSlatesReadsOnlyRecent(t *testing.T) {
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 12 {
+		if err := local.Save(&storage.Slate{Slate: model.Slate{Content: fmt.Sprintf("slate %d", i)}}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	st := &listCounter{LocalStorage: local}
+	app := &App{storage: st, recentLimit: 5}
+
+	slates, unloaded, err := app.loadSlates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slates) != 5 || unloaded != 7 || st.lists != 0 {
+		t.Fatalf("got %d slates, %d unloaded, %d full lists; want 5, 7 and none", len(slates), unloaded, st.lists)
+	}
+	if slates[0].Content != "slate 11" {
+		t.Fatalf("first slate %q, want the newest", slates[0].Content)
+	}
+
+	// "load all" reads the rest
+	app.showAllSlates = true
+	slates, unloaded, _ = app.loadSlates()
+	if len(slates) != 12 || unloaded != 0 || st.lists != 1 {
+		t.Fatalf("after load all: %d slates, %d unloaded, %d full lists", len(slates), unloaded, st.lists)
+	}
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/justtype/cli/internal/spinners"
+	"github.com/rivo/tview"
+)
+
+// spin animates a loading indicator with the spinner_style spinner until
+// ctx is done. show gets each frame, already in the theme's accent, and is
+// called on the UI goroutine, the first time before spin returns.
+func (app *App) spin(ctx context.Context, show func(frame string)) {
+	s := spinners.For(app.spinnerStyle, app.spinnerFrames)
+	frame := func(i int) string {
+		return tagAccent + tview.Escape(s.Frames[i%len(s.Frames)]) + "[-]"
+	}
+	show(frame(0))
+
+	go func() {
+		ticker := time.NewTicker(s.FPS)
+		defer ticker.Stop()
+		for i := 1; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				app.tviewApp.QueueUpdateDraw(func() {
+					// Whoever stopped it may have replaced the indicator
+					if ctx.Err() == nil {
+						show(frame(i))
+					}
+				})
+			}
+		}
+	}()
+}
+
+// loadingText is a spinner frame and what it's waiting on
+func loadingText(frame, msg string) string {
+	return frame + " " + tagDim + msg + "[-]"
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/spinners"
+	"github.com/rivo/tview"
+)
+
+// runningApp is an App whose tview application runs on a simulated screen
+func runningApp(t *testing.T) *App {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	tv := tview.NewApplication().SetScreen(screen).SetRoot(tview.NewBox(), true)
+	done := make(chan struct{})
+	go func() {
+		tv.Run()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		tv.Stop()
+		<-done
+	})
+	return &App{tviewApp: tv}
+}
+
+func TestSpinUnknownStyleFallsBack(t *testing.T) {
+	app := runningApp(t)
+	app.spinnerStyle = "no such style"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := make(chan string, 100)
+	app.spin(ctx, func(frame string) { frames <- frame })
+
+	want := tagAccent + spinners.Default.Frames[0] + "[-]"
+	if got := <-frames; got != want {
+		t.Fatalf("first frame %q, want the default spinner's %q", got, want)
+	}
+	select {
+	case <-frames:
+	case <-time.After(time.Second):
+		t.Fatal("the spinner didn't move on")
+	}
+}
+
+func TestSpinCustomFramesStop(t *testing.T) {
+	app := runningApp(t)
+	app.spinnerStyle = "custom"
+	app.spinnerFrames = []string{"[x]", "%d"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(chan string, 100)
+	app.spin(ctx, func(frame string) { frames <- frame })
+
+	// Frames are taken literally, tags and verbs and all
+	if got := <-frames; !strings.Contains(got, tview.Escape("[x]")) {
+		t.Fatalf("first frame %q isn't escaped", got)
+	}
+	if got := <-frames; !strings.Contains(got, "%d") {
+		t.Fatalf("second frame %q, want the custom one", got)
+	}
+	if got := waitingStatus("%d", time.Minute); !strings.HasPrefix(got, "%d waiting") {
+		t.Fatalf("waitingStatus = %q", got)
+	}
+
+	// Stopped on the UI goroutine, as the screens do, nothing more is shown
+	stopped := make(chan struct{})
+	app.tviewApp.QueueUpdate(func() {
+		cancel()
+		close(stopped)
+	})
+	<-stopped
+	for len(frames) > 0 {
+		<-frames
+	}
+	time.Sleep(300 * time.Millisecond)
+	if n := len(frames); n != 0 {
+		t.Fatalf("%d frames shown after it stopped", n)
+	}
+}
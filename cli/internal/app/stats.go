@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/markdown"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/textstats"
+	"github.com/rivo/tview"
+)
+
+func (app *App) showStats() {
+	var content string
+	if app.editor != nil {
+		content = app.editor.GetText()
+	}
+	counts := storage.CountStats(content)
+	prose, _ := markdown.Strip(content)
+	stats := textstats.Compute(prose)
+
+	words := fmt.Sprintf("%d", counts.Words)
+	if counts.CodeWords > 0 {
+		words += fmt.Sprintf(" (%d in code)", counts.CodeWords)
+	}
+
+	text := fmt.Sprintf(tagDim+"words[-]           %s\n"+
+		tagDim+"characters[-]      %d\n"+
+		tagDim+"  no spaces[-]     %d\n"+
+		tagDim+"reading time[-]    %s\n\n"+
+		tagDim+"sentences[-]       %d\n"+
+		tagDim+"paragraphs[-]      %d\n"+
+		tagDim+"avg sentence[-]    %.1f words\n\n"+
+		tagDim+"reading ease[-]    %.0f "+tagAccent+"(%s)[-]",
+		words,
+		counts.Characters,
+		counts.CharactersNoSpaces,
+		readingTime(counts.ReadingTime()),
+		stats.Sentences,
+		stats.Paragraphs,
+		stats.AvgSentenceLength(),
+		stats.Flesch(),
+		stats.Level())
+
+	textView := tview.NewTextView().
+		SetText(text).
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+
+	textView.SetBorder(true).
+		SetTitle(" stats ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	// Handle keys
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyCtrlG || event.Key() == tcell.KeyEnter {
+			app.pages.RemovePage("stats")
+			app.resumeEditor()
+			return nil
+		}
+		return event
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(textView, 14, 0, true).
+			AddItem(nil, 0, 1, false), 44, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddAndSwitchToPage("stats", centered, true)
+	app.tviewApp.SetFocus(textView)
+}
+
+// readingTime describes a reading time estimate
+func readingTime(d time.Duration) string {
+	switch minutes := int(d.Minutes()); {
+	case minutes == 0:
+		return "-"
+	case minutes == 1:
+		return "about a minute"
+	default:
+		return fmt.Sprintf("about %d minutes", minutes)
+	}
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/updater"
+)
+
+// Status describes where slates live. It's read from config and local files
+// only, never the network, and reading it changes nothing on disk.
+type Status struct {
+	Mode     string // "local" or "cloud"
+	Path     string // local storage path; empty in cloud mode
+	Username string // empty in local mode
+	APIURL   string
+	Version  string
+	LastSync time.Time // zero if never synced or in local mode
+	Slates   int
+	Unsynced int  // offline edits waiting to be pushed
+	Locked   bool // encrypted at rest, so Slates isn't known without the passphrase
+}
+
+// ReadStatus reports the configured storage without starting the UI
+func ReadStatus() (*Status, error) {
+	app, err := New()
+	if err != nil {
+		return nil, err
+	}
+	return app.status()
+}
+
+func (app *App) status() (*Status, error) {
+	st := &Status{
+		Mode:    "local",
+		APIURL:  app.apiURL,
+		Version: updater.GetVersion(),
+	}
+
+	if app.token != "" {
+		st.Mode = "cloud"
+		st.Username = app.username
+		if cloud, ok := app.storage.(*storage.CloudStorage); ok {
+			st.Slates, _, st.LastSync = cloud.CacheStatus()
+			st.Unsynced = cloud.Pending()
+			return st, nil
+		}
+		var key *e2e.Key
+		if app.e2eKey != "" {
+			k, err := e2e.ParseKey(app.e2eKey)
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		}
+		var err error
+		st.Slates, st.Unsynced, st.LastSync, err = storage.CountCloud(app.cacheDir(), filepath.Join(app.dataDir, "temp"), key)
+		return st, lockedOK(st, err)
+	}
+
+	st.Path = app.storagePath
+	if app.storagePath == "" {
+		// Not set up yet
+		return st, nil
+	}
+	if app.storage != nil {
+		// Opened and unlocked by the running UI
+		slates, err := app.storage.List()
+		if err != nil {
+			return nil, err
+		}
+		st.Slates = len(slates)
+		return st, nil
+	}
+	var err error
+	st.Slates, err = storage.CountLocal(app.storagePath, app.backend)
+	return st, lockedOK(st, err)
+}
+
+// lockedOK marks st locked if err says the slates are encrypted at rest,
+// which isn't a failure for a status report
+func lockedOK(st *Status, err error) error {
+	if errors.Is(err, atrest.ErrLocked) {
+		st.Locked = true
+		return nil
+	}
+	return err
+}
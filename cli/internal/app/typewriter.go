@@ -0,0 +1,83 @@
+package app
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// Below this many rows the editor scrolls normally even in typewriter mode;
+// there's no middle worth keeping the cursor in
+const typewriterMinHeight = 10
+
+// toggleTypewriter turns typewriter mode on or off. Off, the editor goes
+// back to scrolling only when the cursor leaves the screen.
+func (app *App) toggleTypewriter() {
+	app.typewriter = !app.typewriter
+	app.saveConfig()
+	if app.typewriter {
+		app.centerCursor()
+		app.notifications.Info("typewriter mode on")
+	} else {
+		app.notifications.Info("typewriter mode off")
+	}
+}
+
+// centerCursor scrolls the editor so the cursor's row is in the middle
+func (app *App) centerCursor() {
+	if !app.typewriter || app.editor == nil {
+		return
+	}
+	_, _, _, height := app.editor.GetInnerRect()
+	if height < typewriterMinHeight {
+		return
+	}
+	_, _, row, _ := app.editor.GetCursor()
+	app.editor.SetOffset(max(row-height/2, 0), 0)
+}
+
+// dimOutsideFocus redraws everything in the editor but the paragraph being
+// written in the dim color. It runs after each draw, so it works from what's
+// on screen: a paragraph is the rows around the cursor up to blank ones.
+func (app *App) dimOutsideFocus(screen tcell.Screen) {
+	if !app.typewriter || app.editor == nil {
+		return
+	}
+	if front, _ := app.pages.GetFrontPage(); front != PageEditor {
+		return
+	}
+
+	x, y, width, height := app.editor.GetInnerRect()
+	offset, _ := app.editor.GetOffset()
+	_, _, row, _ := app.editor.GetCursor()
+	cursor := row - offset
+	if cursor < 0 || cursor >= height {
+		return
+	}
+
+	blank := func(r int) bool {
+		for c := range width {
+			if ch, _, _, _ := screen.GetContent(x+c, y+r); ch != ' ' && ch != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	first, last := cursor, cursor
+	if !blank(cursor) {
+		for first > 0 && !blank(first-1) {
+			first--
+		}
+		for last < height-1 && !blank(last+1) {
+			last++
+		}
+	}
+
+	for r := range height {
+		if r >= first && r <= last {
+			continue
+		}
+		for c := range width {
+			ch, comb, style, _ := screen.GetContent(x+c, y+r)
+			screen.SetContent(x+c, y+r, ch, comb, style.Foreground(colorDim))
+		}
+	}
+}
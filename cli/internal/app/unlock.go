@@ -0,0 +1,114 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/rivo/tview"
+)
+
+// localStorage returns the JSON backend when that's what's open
+func (app *App) localStorage() (*storage.LocalStorage, bool) {
+	local, ok := app.storage.(*storage.LocalStorage)
+	return local, ok
+}
+
+// showUnlock asks for the passphrase local slates are encrypted with, and
+// opens the editor once they're loaded
+func (app *App) showUnlock(local *storage.LocalStorage) {
+	message := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter).
+		SetText(tagAccent + "slates are encrypted[-]\n\n" + tagDim + "enter your passphrase[-]")
+	message.SetBackgroundColor(colorBackground)
+
+	input := tview.NewInputField().
+		SetFieldBackgroundColor(colorBackground).
+		SetFieldTextColor(colorForeground).
+		SetFieldWidth(30).
+		SetMaskCharacter('*')
+	input.SetBackgroundColor(colorBackground)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter {
+			return
+		}
+		err := local.Unlock(input.GetText())
+		input.SetText("")
+		if errors.Is(err, atrest.ErrWrongPassphrase) {
+			message.SetText(tagAccent + "slates are encrypted[-]\n\n" + tagError + "wrong passphrase[-]")
+			return
+		}
+		if err != nil {
+			message.SetText(tagAccent + "slates are encrypted[-]\n\n" + tagError + tview.Escape(err.Error()) + "[-]")
+			return
+		}
+
+		if notice := local.Recovered(); notice != "" {
+			app.notifications.Error(notice)
+		}
+		app.pages.RemovePage("unlock")
+		app.showEditor(nil)
+	})
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(message, 3, 0, false).
+			AddItem(nil, 1, 0, false).
+			AddItem(input, 1, 0, true).
+			AddItem(nil, 0, 1, false), 30, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddAndSwitchToPage("unlock", centered, true)
+	app.tviewApp.SetFocus(input)
+}
+
+// showEncryptAtRestInput sets or clears the passphrase local slates are
+// encrypted with on disk
+func (app *App) showEncryptAtRestInput(local *storage.LocalStorage) {
+	input := tview.NewInputField().
+		SetLabel("passphrase (empty to decrypt): ").
+		SetFieldWidth(24).
+		SetMaskCharacter('*')
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.pages.RemovePage("atrest-passphrase")
+		if key == tcell.KeyEnter {
+			if err := local.SetPassphrase(input.GetText()); err != nil {
+				app.showError(fmt.Sprintf("Couldn't change encryption: %v", err))
+				return
+			}
+			if input.GetText() == "" {
+				app.notifications.Info("slates are no longer encrypted")
+			} else {
+				app.notifications.Info("slates encrypted; you'll need the passphrase to open them")
+			}
+		}
+		app.showSettings()
+	})
+
+	input.SetBorder(true).
+		SetTitle(" encrypt slates on this device ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBackgroundColor(colorBackground)
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 60, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	centered.SetBackgroundColor(colorBackground)
+
+	app.pages.AddPage("atrest-passphrase", centered, true, true)
+	app.tviewApp.SetFocus(input)
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/updater"
+)
+
+// updateApp is an App with configJSON, on an account whose server
+// advertises version 99.0.0
+func updateApp(t *testing.T, configJSON string) *App {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	t.Setenv(config.APIURLEnv, "")
+	if err := os.WriteFile(filepath.Join(home, "config.json"), []byte(configJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+	app, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Prompts are queued for the UI, so it has to be running
+	app.tviewApp = runningApp(t).tviewApp
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Latest-Version", "99.0.0")
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(srv.Close)
+	cloud, err := storage.NewCloud(filepath.Join(home, "temp"), srv.URL, "token", "ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cloud.List(); err != nil {
+		t.Fatal(err)
+	}
+	app.storage = cloud
+	return app
+}
+
+func TestCheckForUpdatesPreference(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		prompt bool
+	}{
+		{"auto", `{}`, true},
+		{"notify", `{"update_mode": "notify"}`, true},
+		{"never", `{"update_mode": "never"}`, false},
+		{"skipped", `{"skipped_version": "99.0.0"}`, false},
+		{"snoozed", `{"update_snoozed_until": "` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`, false},
+		{"snooze over", `{"update_snoozed_until": "` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := updateApp(t, tt.config)
+			app.checkForUpdates()
+			if got := app.updateAvailable == "99.0.0"; got != tt.prompt {
+				t.Fatalf("prompted = %v, want %v", got, tt.prompt)
+			}
+		})
+	}
+}
+
+func TestLaterSnoozesUpdates(t *testing.T) {
+	app := updateApp(t, `{"update_mode": "notify"}`)
+	app.snoozeUpdates()
+	if left := time.Until(app.updateSnoozed); left < updater.SnoozeDuration-time.Minute || left > updater.SnoozeDuration {
+		t.Fatalf("snoozed for %v, want %v", left, updater.SnoozeDuration)
+	}
+
+	// The snooze outlasts a restart
+	restarted, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.tviewApp = app.tviewApp
+	restarted.storage = app.storage
+	restarted.checkForUpdates()
+	if restarted.updateAvailable != "" {
+		t.Fatal("prompted again after Later")
+	}
+	if !restarted.updateSnoozed.Equal(app.updateSnoozed) {
+		t.Fatalf("snooze reloaded as %v, want %v", restarted.updateSnoozed, app.updateSnoozed)
+	}
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// editorModeVim turns on the vim layer; see vimKey
+const editorModeVim = "vim"
+
+// A second esc in normal mode within this long opens the quit menu
+const vimEscWindow = time.Second
+
+// vimState is the modal editing layer over the editor, when editor_mode is
+// "vim". The TextArea itself always inserts; normal mode is just keys
+// caught before they reach it.
+type vimState struct {
+	insert   bool
+	pending  rune   // first key of dd or yy, 0 for none
+	prompt   rune   // ':' or '/' while a command or search is typed, 0 for none
+	input    string // what's been typed after the prompt
+	register string // last line deleted or yanked, with its newline
+	search   string // last search, repeated with n
+	message  string // shown in the footer until the next key
+	lastEsc  time.Time
+}
+
+// vimKey handles a key in vim mode. It returns the event for the editor
+// (sometimes a different one, like an arrow for h) or nil if it was used up.
+func (app *App) vimKey(event *tcell.EventKey) *tcell.EventKey {
+	vim := &app.vim
+	vim.message = ""
+
+	if vim.insert {
+		if event.Key() == tcell.KeyEsc {
+			vim.insert = false
+			return nil
+		}
+		return event
+	}
+
+	if vim.prompt != 0 {
+		app.vimPromptKey(event)
+		return nil
+	}
+
+	switch event.Key() {
+	case tcell.KeyEsc:
+		vim.pending = 0
+		if time.Since(vim.lastEsc) < vimEscWindow {
+			vim.lastEsc = time.Time{}
+			app.showQuitMenu()
+			return nil
+		}
+		vim.lastEsc = time.Now()
+		vim.message = "esc again or :q to quit"
+		return nil
+	case tcell.KeyEnter:
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		return tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone)
+	case tcell.KeyCtrlR:
+		// Redo, which the editor has on ctrl+y
+		return tcell.NewEventKey(tcell.KeyCtrlY, 0, tcell.ModCtrl)
+	case tcell.KeyRune:
+		// Handled below
+	default:
+		// Arrows, ctrl shortcuts and the like work as usual
+		return event
+	}
+
+	r := event.Rune()
+	if vim.pending != 0 {
+		pending := vim.pending
+		vim.pending = 0
+		switch {
+		case pending == 'd' && r == 'd':
+			app.vimDeleteLine()
+		case pending == 'y' && r == 'y':
+			app.vimYankLine()
+		}
+		return nil
+	}
+
+	switch r {
+	case 'h':
+		return tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone)
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case 'l':
+		return tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone)
+	case '0':
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case '$':
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	case 'i':
+		vim.insert = true
+	case 'a':
+		vim.insert = true
+		text, pos := app.vimCursor()
+		if pos < len(text) && text[pos] != '\n' {
+			return tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone)
+		}
+	case 'x':
+		text, pos := app.vimCursor()
+		if pos < len(text) && text[pos] != '\n' {
+			return tcell.NewEventKey(tcell.KeyDelete, 0, tcell.ModNone)
+		}
+	case 'u':
+		return tcell.NewEventKey(tcell.KeyCtrlZ, 0, tcell.ModCtrl)
+	case 'd', 'y':
+		vim.pending = r
+	case 'p':
+		app.vimPut()
+	case 'n':
+		app.vimSearch(vim.search)
+	case ':', '/':
+		vim.prompt = r
+		vim.input = ""
+	}
+	// Nothing else types in normal mode
+	return nil
+}
+
+// vimPromptKey edits the : command or / search being typed, and runs it on
+// enter
+func (app *App) vimPromptKey(event *tcell.EventKey) {
+	vim := &app.vim
+	switch event.Key() {
+	case tcell.KeyEsc:
+		vim.prompt = 0
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if vim.input == "" {
+			vim.prompt = 0
+			return
+		}
+		runes := []rune(vim.input)
+		vim.input = string(runes[:len(runes)-1])
+	case tcell.KeyRune:
+		vim.input += string(event.Rune())
+	case tcell.KeyEnter:
+		prompt, input := vim.prompt, vim.input
+		vim.prompt = 0
+		if prompt == '/' {
+			if input != "" {
+				vim.search = input
+			}
+			app.vimSearch(vim.search)
+			return
+		}
+		app.vimCommand(strings.TrimSpace(input))
+	}
+}
+
+func (app *App) vimCommand(cmd string) {
+	switch cmd {
+	case "w":
+		app.saveNow()
+	case "q":
+		app.showQuitMenu()
+	case "wq", "x":
+		app.saveNow()
+		app.showQuitMenu()
+	case "":
+	default:
+		app.vim.message = "not a command: " + cmd
+	}
+}
+
+// vimCursor returns the editor's text and the cursor's byte offset in it
+func (app *App) vimCursor() (string, int) {
+	_, start, _ := app.editor.GetSelection()
+	return app.editor.GetText(), start
+}
+
+// vimLine returns the bounds of the line holding pos, end including its
+// newline if it has one
+func vimLine(text string, pos int) (int, int) {
+	start := strings.LastIndexByte(text[:pos], '\n') + 1
+	end := len(text)
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		end = pos + i + 1
+	}
+	return start, end
+}
+
+func (app *App) vimYankLine() {
+	text, pos := app.vimCursor()
+	start, end := vimLine(text, pos)
+	app.vim.register = withNewline(text[start:end])
+}
+
+func (app *App) vimDeleteLine() {
+	text, pos := app.vimCursor()
+	start, end := vimLine(text, pos)
+	app.vim.register = withNewline(text[start:end])
+
+	if end == len(text) && !strings.HasSuffix(text, "\n") && start > 0 {
+		// The last line: take the newline before it instead, and land on
+		// the line above
+		prev, _ := vimLine(text, start-1)
+		app.editor.Replace(start-1, end, "")
+		app.editor.Select(prev, prev)
+		return
+	}
+	app.editor.Replace(start, end, "")
+}
+
+// vimPut puts the register on a new line below the cursor's
+func (app *App) vimPut() {
+	if app.vim.register == "" {
+		return
+	}
+	text, pos := app.vimCursor()
+	_, end := vimLine(text, pos)
+
+	if end == len(text) && !strings.HasSuffix(text, "\n") {
+		app.editor.Replace(end, end, "\n"+strings.TrimSuffix(app.vim.register, "\n"))
+		app.editor.Select(end+1, end+1)
+		return
+	}
+	app.editor.Replace(end, end, app.vim.register)
+	app.editor.Select(end, end)
+}
+
+// vimSearch moves to the next match of pattern after the cursor, ignoring
+// case and wrapping around the end
+func (app *App) vimSearch(pattern string) {
+	if pattern == "" {
+		return
+	}
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+	text, pos := app.vimCursor()
+
+	from := min(pos+1, len(text))
+	if loc := re.FindStringIndex(text[from:]); loc != nil {
+		app.editor.Select(from+loc[0], from+loc[0])
+	} else if loc := re.FindStringIndex(text); loc != nil {
+		app.editor.Select(loc[0], loc[0])
+	} else {
+		app.vim.message = "not found: " + pattern
+	}
+}
+
+// vimFooter is the mode shown next to the word count
+func (app *App) vimFooter() string {
+	vim := app.vim
+	switch {
+	case vim.prompt != 0:
+		return tagAccent + tview.Escape(string(vim.prompt)+vim.input) + "_[-]"
+	case vim.message != "":
+		return tagWarning + tview.Escape(vim.message) + "[-]"
+	case vim.insert:
+		return tagSuccess + "-- insert --[-]"
+	default:
+		return tagAccent + "-- normal --[-]"
+	}
+}
+
+func withNewline(line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return line
+	}
+	return line + "\n"
+}
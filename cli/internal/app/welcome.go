@@ -2,9 +2,9 @@ package app
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/justtype/cli/internal/config"
 	"github.com/rivo/tview"
 )
 
@@ -93,12 +93,10 @@ func (app *App) setupLocal() {
 	form.AddFormItem(storageField)
 
 	form.AddButton("Confirm", func() {
-		path := storageField.GetText()
-
-		// Expand ~
-		if len(path) >= 2 && path[:2] == "~/" {
-			homeDir, _ := os.UserHomeDir()
-			path = homeDir + path[1:]
+		path, err := config.ExpandHome(storageField.GetText())
+		if err != nil {
+			app.showError(err.Error())
+			return
 		}
 
 		app.storagePath = path
@@ -109,6 +107,11 @@ func (app *App) setupLocal() {
 			return
 		}
 
+		// Pointing at a folder of encrypted slates asks for the passphrase
+		if local, ok := app.localStorage(); ok && local.Locked() {
+			app.showUnlock(local)
+			return
+		}
 		app.showEditor(nil)
 	})
 
@@ -135,6 +138,8 @@ func (app *App) setupLocal() {
 }
 
 func (app *App) showError(message string) {
+	app.notifications.Error(message)
+
 	modal := tview.NewModal().
 		SetText(message).
 		AddButtons([]string{"OK"}).
@@ -0,0 +1,153 @@
+// Package atrest encrypts local files with a key derived from a passphrase.
+// The key's salt is kept in a sidecar file next to the data; the passphrase
+// itself is never stored. Changing the key rewrites every file under it,
+// which Rekey does as one change.
+package atrest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/justtype/cli/internal/e2e"
+)
+
+var (
+	// ErrLocked is returned when encrypted data is used before Unlock
+	ErrLocked = errors.New("slates are encrypted; enter your passphrase to unlock them")
+
+	// ErrWrongPassphrase is returned when the passphrase doesn't open the data
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+)
+
+// SaltPath is the sidecar holding the salt for the file at path
+func SaltPath(path string) string {
+	return path + ".salt"
+}
+
+// IsEncrypted reports whether the file at path was written encrypted.
+// A missing file isn't.
+func IsEncrypted(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return bytes.HasPrefix(data, []byte(e2e.Prefix)), nil
+}
+
+// Unlock derives the key for the encrypted file at path and checks it
+// against the file, so a wrong passphrase is reported as such rather than
+// as garbage data later
+func Unlock(path, passphrase string) (*e2e.Key, error) {
+	salt, err := os.ReadFile(SaltPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("can't read the encryption salt: %w", err)
+	}
+	key, err := e2e.DeriveKeyWithSalt(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ReadFile(path, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// RemoveKey drops the salt once the files have been rewritten in plaintext
+func RemoveKey(path string) error {
+	err := os.Remove(SaltPath(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ReadFile reads path, decrypting it with key if it was written encrypted.
+// key may be nil for plaintext files.
+func ReadFile(path string, key *e2e.Key) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(e2e.Prefix)) {
+		return data, nil
+	}
+	if key == nil {
+		return nil, ErrLocked
+	}
+
+	plain, err := key.Decrypt(string(data))
+	if errors.Is(err, e2e.ErrWrongPassphrase) {
+		return nil, ErrWrongPassphrase
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plain), nil
+}
+
+// TempPath is where WriteFile stages the file at path before moving it
+// into place
+func TempPath(path string) string {
+	return path + ".tmp"
+}
+
+// WriteFile writes data to path, encrypted if key is set. The data goes to
+// TempPath first and is synced before being renamed over path, so a crash or
+// full disk mid-write leaves the old file whole.
+func WriteFile(path string, data []byte, key *e2e.Key, perm os.FileMode) error {
+	if key != nil {
+		sealed, err := key.Encrypt(string(data))
+		if err != nil {
+			return err
+		}
+		data = []byte(sealed)
+	}
+
+	tmp := TempPath(path)
+	if err := writeSynced(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeSynced writes data to path and syncs it to disk
+func writeSynced(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SetAside moves the file at path out of the way, to a name beside it like
+// slates.corrupt-2006-01-02-150405.json, and returns where it went. It's for
+// files that can't be read: kept for a look by hand rather than overwritten
+// by the next save.
+func SetAside(path string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + ".corrupt-" + time.Now().Format("2006-01-02-150405")
+	dest := base + ext
+	for n := 2; ; n++ {
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			break
+		}
+		dest = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	return dest, os.Rename(path, dest)
+}
@@ -0,0 +1,164 @@
+package atrest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/justtype/cli/internal/e2e"
+)
+
+// Rekey rewrites the files kept under one salt with a new key as a single
+// change. Each file is staged beside the one it replaces, and nothing is
+// moved into place until Commit: a rekey cut short before then leaves the
+// old files and salt as they were, and one cut short after is finished by
+// Recover.
+type Rekey struct {
+	path   string   // the file the salt belongs to, see SaltPath
+	key    *e2e.Key // nil when encryption is being turned off
+	salt   []byte
+	staged []string
+}
+
+// rekeyRecord is what Commit writes before moving anything, so Recover can
+// finish the job
+type rekeyRecord struct {
+	Salt  []byte   `json:"salt,omitempty"` // none when encryption is off
+	Files []string `json:"files"`
+}
+
+// stagedPath is where a rekey stages the file at path until Commit
+func stagedPath(path string) string {
+	return path + ".rekey"
+}
+
+// recordPath is where Commit records a rekey of the files salted for path
+func recordPath(path string) string {
+	return path + ".rekey.json"
+}
+
+// BeginRekey starts rewriting the files salted for path with a key derived
+// from passphrase and a fresh salt, or in plaintext if passphrase is "".
+// Nothing on disk changes until Commit.
+func BeginRekey(path, passphrase string) (*Rekey, error) {
+	r := &Rekey{path: path}
+	if passphrase == "" {
+		return r, nil
+	}
+
+	r.salt = make([]byte, 16)
+	if _, err := rand.Read(r.salt); err != nil {
+		return nil, err
+	}
+	key, err := e2e.DeriveKeyWithSalt(passphrase, r.salt)
+	if err != nil {
+		return nil, err
+	}
+	r.key = key
+	return r, nil
+}
+
+// Key is the new key, nil if encryption is being turned off
+func (r *Rekey) Key() *e2e.Key {
+	return r.key
+}
+
+// WriteFile stages data for path, encrypted with the new key
+func (r *Rekey) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if r.key != nil {
+		sealed, err := r.key.Encrypt(string(data))
+		if err != nil {
+			return err
+		}
+		data = []byte(sealed)
+	}
+	return r.stage(path, data, perm)
+}
+
+// Stage stages data for path as it is, for files that encrypt their own
+// contents
+func (r *Rekey) Stage(path string, data []byte) error {
+	return r.stage(path, data, 0600)
+}
+
+func (r *Rekey) stage(path string, data []byte, perm os.FileMode) error {
+	if err := writeSynced(stagedPath(path), data, perm); err != nil {
+		return err
+	}
+	r.staged = append(r.staged, path)
+	return nil
+}
+
+// Commit moves every staged file into place and swaps in the new salt, or
+// removes the salt if encryption is off. Once the record of what's staged
+// is written, the rekey goes through even if this is cut short: Recover
+// finishes it.
+func (r *Rekey) Commit() error {
+	data, err := json.Marshal(rekeyRecord{Salt: r.salt, Files: r.staged})
+	if err != nil {
+		r.Abort()
+		return err
+	}
+	if err := WriteFile(recordPath(r.path), data, nil, 0600); err != nil {
+		r.Abort()
+		return err
+	}
+	return finishRekey(r.path)
+}
+
+// Abort drops the staged files, leaving everything as it was
+func (r *Rekey) Abort() {
+	for _, path := range r.staged {
+		os.Remove(stagedPath(path))
+	}
+	r.staged = nil
+}
+
+// Recover brings the files salted for path back to a consistent state after
+// a rekey was cut short: one that was committed is finished, and the staged
+// files of one that wasn't are removed. Call it before reading the salt.
+func Recover(path string) error {
+	if _, err := os.Stat(recordPath(path)); err == nil {
+		return finishRekey(path)
+	}
+
+	// Staged beside path, or a directory down, as the history is
+	dir := filepath.Dir(path)
+	for _, pattern := range []string{"*.rekey", filepath.Join("*", "*.rekey")} {
+		stray, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, file := range stray {
+			os.Remove(file)
+		}
+	}
+	return nil
+}
+
+// finishRekey moves the files a committed rekey staged into place, then
+// the salt, and removes the record. Each step can be repeated, so a finish
+// that's cut short is picked up again by Recover.
+func finishRekey(path string) error {
+	data, err := os.ReadFile(recordPath(path))
+	if err != nil {
+		return err
+	}
+	var record rekeyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+
+	for _, file := range record.Files {
+		err := os.Rename(stagedPath(file), file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if record.Salt == nil {
+		if err := RemoveKey(path); err != nil {
+			return err
+		}
+	} else if err := WriteFile(SaltPath(path), record.Salt, nil, 0600); err != nil {
+		return err
+	}
+	return os.Remove(recordPath(path))
+}
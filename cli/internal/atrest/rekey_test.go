@@ -0,0 +1,155 @@
+package atrest
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encrypted writes slates.json and trash.json in dir under passphrase and
+// returns slates.json's path
+func encrypted(t *testing.T, dir, passphrase string) string {
+	t.Helper()
+	path := filepath.Join(dir, "slates.json")
+	r, err := BeginRekey(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteFile(path, []byte("slates v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteFile(filepath.Join(dir, "trash.json"), []byte("trash v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// opens fails t unless passphrase opens both files with the wanted content
+func opens(t *testing.T, dir, passphrase, slates, trash string) {
+	t.Helper()
+	path := filepath.Join(dir, "slates.json")
+	if err := Recover(path); err != nil {
+		t.Fatal(err)
+	}
+	key, err := Unlock(path, passphrase)
+	if err != nil {
+		t.Fatalf("Unlock(%q): %v", passphrase, err)
+	}
+	for file, want := range map[string]string{"slates.json": slates, "trash.json": trash} {
+		got, err := ReadFile(filepath.Join(dir, file), key)
+		if err != nil || string(got) != want {
+			t.Fatalf("%s = %q, %v; want %q", file, got, err, want)
+		}
+	}
+}
+
+func TestRekeyCommit(t *testing.T) {
+	dir := t.TempDir()
+	path := encrypted(t, dir, "first")
+	opens(t, dir, "first", "slates v1", "trash v1")
+
+	r, err := BeginRekey(path, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WriteFile(path, []byte("slates v2"), 0600)
+	r.WriteFile(filepath.Join(dir, "trash.json"), []byte("trash v2"), 0600)
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	opens(t, dir, "second", "slates v2", "trash v2")
+	if _, err := Unlock(path, "first"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("old passphrase: %v, want ErrWrongPassphrase", err)
+	}
+	if stray, _ := filepath.Glob(filepath.Join(dir, "*.rekey*")); len(stray) != 0 {
+		t.Fatalf("left behind %v", stray)
+	}
+}
+
+func TestRekeyCutShortBeforeCommit(t *testing.T) {
+	dir := t.TempDir()
+	path := encrypted(t, dir, "first")
+
+	// Staged under a new salt, then the process dies
+	r, err := BeginRekey(path, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WriteFile(path, []byte("slates v2"), 0600)
+
+	opens(t, dir, "first", "slates v1", "trash v1")
+	if stray, _ := filepath.Glob(filepath.Join(dir, "*.rekey")); len(stray) != 0 {
+		t.Fatalf("Recover left %v", stray)
+	}
+}
+
+func TestRekeyAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := encrypted(t, dir, "first")
+
+	r, _ := BeginRekey(path, "")
+	r.WriteFile(path, []byte("plain"), 0600)
+	r.Abort()
+
+	if _, err := os.Stat(stagedPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("staged file still there: %v", err)
+	}
+	opens(t, dir, "first", "slates v1", "trash v1")
+}
+
+func TestRekeyCutShortAfterCommit(t *testing.T) {
+	for _, moved := range []int{0, 1, 2} {
+		dir := t.TempDir()
+		path := encrypted(t, dir, "first")
+		trash := filepath.Join(dir, "trash.json")
+
+		r, err := BeginRekey(path, "second")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.WriteFile(path, []byte("slates v2"), 0600)
+		r.WriteFile(trash, []byte("trash v2"), 0600)
+
+		// The record is written, then the process dies with some of the
+		// files moved into place and the old salt still there
+		data, _ := json.Marshal(rekeyRecord{Salt: r.salt, Files: r.staged})
+		if err := WriteFile(recordPath(path), data, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range r.staged[:moved] {
+			os.Rename(stagedPath(file), file)
+		}
+
+		opens(t, dir, "second", "slates v2", "trash v2")
+		if _, err := os.Stat(recordPath(path)); !os.IsNotExist(err) {
+			t.Fatalf("%d moved: record left after Recover", moved)
+		}
+	}
+}
+
+func TestRekeyToPlaintext(t *testing.T) {
+	dir := t.TempDir()
+	path := encrypted(t, dir, "first")
+
+	r, _ := BeginRekey(path, "")
+	if r.Key() != nil {
+		t.Fatal("a key for turning encryption off")
+	}
+	r.WriteFile(path, []byte("plain"), 0600)
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if on, _ := IsEncrypted(path); on {
+		t.Fatal("still encrypted")
+	}
+	if _, err := os.Stat(SaltPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("salt kept after turning encryption off: %v", err)
+	}
+}
@@ -2,10 +2,14 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/justtype/cli/internal/updater"
 )
 
 type DeviceCodeResponse struct {
@@ -17,19 +21,25 @@ type DeviceCodeResponse struct {
 }
 
 type TokenResponse struct {
-	Token    string `json:"token"`
-	Username string `json:"username"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Username     string `json:"username"`
 }
 
 type DeviceAuth struct {
 	apiURL string
 	client *http.Client
+
+	// second is how long a second of the server's intervals and expiry
+	// lasts; tests shorten it
+	second time.Duration
 }
 
 func NewDeviceAuth(apiURL string) *DeviceAuth {
 	return &DeviceAuth{
 		apiURL: apiURL,
-		client: &http.Client{Timeout: 10 * time.Second},
+		client: updater.NewClient(10 * time.Second),
+		second: time.Second,
 	}
 }
 
@@ -58,37 +68,77 @@ func (da *DeviceAuth) RequestDeviceCode() (*DeviceCodeResponse, error) {
 	return &dcr, nil
 }
 
+var (
+	// ErrExpired means the code ran out before it was approved; a new one
+	// has to be requested
+	ErrExpired = errors.New("the code expired")
+
+	// ErrDenied means the login was turned down in the browser
+	ErrDenied = errors.New("authorization was denied")
+
+	// errPending and errSlowDown are answers to keep polling on, the second
+	// less often
+	errPending  = errors.New("pending")
+	errSlowDown = errors.New("slow down")
+)
+
+// DefaultInterval is how many seconds to wait between polls when the
+// server doesn't say
+const DefaultInterval = 5
+
+// slowDownStep is the seconds added to the interval each time the server
+// asks to slow down, as in RFC 8628
+const slowDownStep = 5
+
 // PollForToken polls for the token until approved or expired
 func (da *DeviceAuth) PollForToken(deviceCode string, interval int, expiresIn int) (*TokenResponse, error) {
-	ticker := time.NewTicker(time.Duration(interval) * time.Second)
-	defer ticker.Stop()
+	return da.PollForTokenCtx(context.Background(), deviceCode, interval, expiresIn)
+}
+
+// PollForTokenCtx is PollForToken, given up when ctx is done. It polls
+// every interval seconds, less often each time the server answers
+// slow_down, and returns ErrExpired once expiresIn seconds have gone by or
+// ErrDenied if the login was turned down.
+func (da *DeviceAuth) PollForTokenCtx(ctx context.Context, deviceCode string, interval int, expiresIn int) (*TokenResponse, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	wait := time.Duration(interval) * da.second
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
 
-	timeout := time.After(time.Duration(expiresIn) * time.Second)
+	timeout := time.After(time.Duration(expiresIn) * da.second)
 
 	for {
 		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
 		case <-timeout:
-			return nil, fmt.Errorf("authorization expired")
-
-		case <-ticker.C:
-			token, err := da.checkToken(deviceCode)
-			if err != nil {
-				// Check if it's a "pending" error
-				if err.Error() == "pending" {
-					continue
-				}
+			return nil, ErrExpired
+
+		case <-timer.C:
+			token, err := da.checkToken(ctx, deviceCode)
+			switch {
+			case errors.Is(err, errPending):
+				// Not approved yet
+			case errors.Is(err, errSlowDown):
+				wait += slowDownStep * da.second
+			case err != nil:
 				return nil, err
+			default:
+				return token, nil
 			}
-			return token, nil
+			timer.Reset(wait)
 		}
 	}
 }
 
-func (da *DeviceAuth) checkToken(deviceCode string) (*TokenResponse, error) {
+func (da *DeviceAuth) checkToken(ctx context.Context, deviceCode string) (*TokenResponse, error) {
 	body := map[string]string{"device_code": deviceCode}
 	jsonData, _ := json.Marshal(body)
 
-	req, err := http.NewRequest("POST", da.apiURL+"/api/cli/token", bytes.NewReader(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", da.apiURL+"/api/cli/token", bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, err
 	}
@@ -100,19 +150,36 @@ func (da *DeviceAuth) checkToken(deviceCode string) (*TokenResponse, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// The poll endpoint is rate limited; that's a slow_down too
+		return nil, errSlowDown
+	}
+
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	// Check for pending status
-	if status, ok := result["status"].(string); ok && status == "pending" {
-		return nil, fmt.Errorf("pending")
+	// Still waiting: justtype answers with a status, OAuth servers with an
+	// error
+	status, _ := result["status"].(string)
+	errMsg, _ := result["error"].(string)
+	for _, code := range []string{status, errMsg} {
+		switch code {
+		case "pending", "authorization_pending":
+			return nil, errPending
+		case "slow_down":
+			return nil, errSlowDown
+		case "expired", "expired_token":
+			return nil, ErrExpired
+		case "access_denied", "denied":
+			return nil, ErrDenied
+		}
 	}
 
 	// Check for error
-	if errMsg, ok := result["error"].(string); ok {
-		return nil, fmt.Errorf(errMsg)
+	if errMsg != "" {
+		return nil, fmt.Errorf("%s", errMsg)
 	}
 
 	// Got token!
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reply is one answer of the fake token endpoint: a status code and a JSON
+// body
+type reply struct {
+	code int
+	body map[string]string
+}
+
+// tokenServer answers polls of /api/cli/token with replies in turn,
+// repeating the last one, and records when each poll came
+type tokenServer struct {
+	mu      sync.Mutex
+	replies []reply
+	polls   []time.Time
+}
+
+func (s *tokenServer) start(t *testing.T) *DeviceAuth {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/cli/token" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["device_code"] != "dev-123" {
+			t.Errorf("polled with device code %q", body["device_code"])
+		}
+
+		s.mu.Lock()
+		s.polls = append(s.polls, time.Now())
+		next := s.replies[min(len(s.polls), len(s.replies))-1]
+		s.mu.Unlock()
+
+		if next.code != 0 {
+			w.WriteHeader(next.code)
+		}
+		json.NewEncoder(w).Encode(next.body)
+	}))
+	t.Cleanup(srv.Close)
+
+	da := NewDeviceAuth(srv.URL)
+	da.second = 10 * time.Millisecond
+	return da
+}
+
+func (s *tokenServer) pollTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.polls...)
+}
+
+var (
+	pending = reply{body: map[string]string{"status": "pending"}}
+	granted = reply{body: map[string]string{"token": "tok", "username": "ada"}}
+)
+
+func TestPollForTokenOutcomes(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies []reply
+		wantErr error
+		polls   int
+	}{
+		{"approved at once", []reply{granted}, nil, 1},
+		{"approved after waiting", []reply{pending, pending, granted}, nil, 3},
+		{"oauth pending", []reply{{body: map[string]string{"error": "authorization_pending"}}, granted}, nil, 2},
+		{"rate limited keeps polling", []reply{{code: http.StatusTooManyRequests}, granted}, nil, 2},
+		{"expired", []reply{pending, {body: map[string]string{"status": "expired"}}}, ErrExpired, 2},
+		{"oauth expired", []reply{{code: http.StatusBadRequest, body: map[string]string{"error": "expired_token"}}}, ErrExpired, 1},
+		{"denied", []reply{{body: map[string]string{"status": "denied"}}}, ErrDenied, 1},
+		{"oauth denied", []reply{{code: http.StatusBadRequest, body: map[string]string{"error": "access_denied"}}}, ErrDenied, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &tokenServer{replies: tt.replies}
+			da := srv.start(t)
+
+			token, err := da.PollForToken("dev-123", 1, 60)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PollForToken error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (token.Token != "tok" || token.Username != "ada") {
+				t.Errorf("token = %+v", token)
+			}
+			if got := len(srv.pollTimes()); got != tt.polls {
+				t.Errorf("polled %d times, want %d", got, tt.polls)
+			}
+		})
+	}
+}
+
+func TestPollForTokenServerError(t *testing.T) {
+	srv := &tokenServer{replies: []reply{{code: http.StatusBadRequest, body: map[string]string{"error": "invalid device code"}}}}
+	da := srv.start(t)
+
+	_, err := da.PollForToken("dev-123", 1, 60)
+	if err == nil || err.Error() != "invalid device code" || errors.Is(err, ErrExpired) || errors.Is(err, ErrDenied) {
+		t.Fatalf("PollForToken error = %v, want the server's message", err)
+	}
+}
+
+func TestPollForTokenSlowsDown(t *testing.T) {
+	slowDown := reply{body: map[string]string{"error": "slow_down"}}
+	srv := &tokenServer{replies: []reply{pending, slowDown, pending, slowDown, granted}}
+	da := srv.start(t)
+
+	if _, err := da.PollForToken("dev-123", 1, 60); err != nil {
+		t.Fatal(err)
+	}
+
+	polls := srv.pollTimes()
+	if len(polls) != 5 {
+		t.Fatalf("polled %d times, want 5", len(polls))
+	}
+	// The interval starts at one second and grows by slowDownStep after
+	// each slow_down: 1, 1, 6, 6, then 11
+	want := []int{1, 6, 6, 11}
+	for i, secs := range want {
+		gap := polls[i+1].Sub(polls[i])
+		if floor := time.Duration(secs) * da.second; gap < floor {
+			t.Errorf("poll %d came %v after the last, want at least %v", i+2, gap, floor)
+		}
+	}
+}
+
+func TestPollForTokenRunsOut(t *testing.T) {
+	srv := &tokenServer{replies: []reply{pending}}
+	da := srv.start(t)
+
+	start := time.Now()
+	_, err := da.PollForToken("dev-123", 1, 5)
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("PollForToken error = %v, want ErrExpired", err)
+	}
+	if took := time.Since(start); took < 5*da.second {
+		t.Errorf("gave up after %v, before the code expired", took)
+	}
+	if n := len(srv.pollTimes()); n == 0 || n > 5 {
+		t.Errorf("polled %d times in 5 one-second intervals", n)
+	}
+}
+
+func TestPollForTokenCanceled(t *testing.T) {
+	srv := &tokenServer{replies: []reply{pending}}
+	da := srv.start(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(25*time.Millisecond, cancel)
+
+	_, err := da.PollForTokenCtx(ctx, "dev-123", 1, 60)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("PollForTokenCtx error = %v, want context.Canceled", err)
+	}
+}
+
+func TestPollForTokenDefaultInterval(t *testing.T) {
+	srv := &tokenServer{replies: []reply{granted}}
+	da := srv.start(t)
+
+	start := time.Now()
+	if _, err := da.PollForToken("dev-123", 0, 60); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < DefaultInterval*da.second {
+		t.Errorf("first poll after %v, want the default %d second interval", took, DefaultInterval)
+	}
+}
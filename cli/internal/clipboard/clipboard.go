@@ -0,0 +1,39 @@
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrUnavailable means no clipboard tool was found
+var ErrUnavailable = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// tools are tried in order; the first one installed is used
+var tools = [][]string{
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+// Copy puts text on the system clipboard using the platform's command line
+// tool
+func Copy(text string) error {
+	candidates := tools
+	if runtime.GOOS == "darwin" {
+		candidates = [][]string{{"pbcopy"}}
+	}
+
+	for _, tool := range candidates {
+		path, err := exec.LookPath(tool[0])
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command(path, tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return ErrUnavailable
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultAPIURL is the server used when nothing else is configured
+const DefaultAPIURL = "https://justtype.io"
+
+// APIURLEnv overrides the api_url setting, e.g. for a self-hosted instance
+const APIURLEnv = "JUSTTYPE_API_URL"
+
+// ResolveAPIURL picks the server to talk to: $JUSTTYPE_API_URL if set, then
+// configured, then DefaultAPIURL. It rejects anything that isn't an http(s)
+// URL so a typo fails at startup instead of on the first request.
+func ResolveAPIURL(configured string) (string, error) {
+	raw, source := configured, "api_url in config.json"
+	if env := os.Getenv(APIURLEnv); env != "" {
+		raw, source = env, APIURLEnv
+	}
+	if raw == "" {
+		return DefaultAPIURL, nil
+	}
+
+	if err := validateAPIURL(raw); err != nil {
+		return "", fmt.Errorf("invalid %s %q: %v", source, raw, err)
+	}
+	return strings.TrimRight(raw, "/"), nil
+}
+
+func validateAPIURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("not a URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must start with http:// or https://")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing a host name")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("can't have a query or fragment")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import "fmt"
+
+// DefaultAutosaveSeconds is how long typing has to pause before the editor
+// saves, when autosave_seconds isn't set
+const DefaultAutosaveSeconds = 2
+
+// MaxAutosaveSeconds caps autosave_seconds; 0 turns autosave off
+const MaxAutosaveSeconds = 60
+
+// autosaveSteps are the intervals cycled through in settings
+var autosaveSteps = []int{0, 1, 2, 5, 10, 30, 60}
+
+// NormalizeAutosave keeps an autosave interval within 0 to
+// MaxAutosaveSeconds. A negative one, which can only come from editing
+// config.json by hand, means the default.
+func NormalizeAutosave(seconds int) int {
+	if seconds < 0 {
+		return DefaultAutosaveSeconds
+	}
+	return min(seconds, MaxAutosaveSeconds)
+}
+
+// NextAutosave is the interval after seconds in the settings cycle
+func NextAutosave(seconds int) int {
+	for _, step := range autosaveSteps {
+		if step > seconds {
+			return step
+		}
+	}
+	return autosaveSteps[0]
+}
+
+// AutosaveLabel describes an autosave interval for the settings views
+func AutosaveLabel(seconds int) string {
+	if seconds == 0 {
+		return "off (ctrl+s saves)"
+	}
+	return fmt.Sprintf("after %ds", seconds)
+}
+
+func (c *Config) SetAutosaveSeconds(seconds int) error {
+	c.AutosaveSeconds = NormalizeAutosave(seconds)
+	return c.Save()
+}
@@ -1,63 +1,115 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
-	"path/filepath"
+	"time"
+
+	"github.com/justtype/cli/internal/jsonfields"
 )
 
 type Config struct {
-	Token      string `json:"token,omitempty"`
-	Username   string `json:"username,omitempty"`
-	APIURL     string `json:"api_url,omitempty"`
-	Editor     string `json:"editor,omitempty"`
-	FirstRun   bool   `json:"first_run"`
-	path       string
+	Token           string    `json:"token,omitempty"`
+	RefreshToken    string    `json:"refresh_token,omitempty"`
+	Username        string    `json:"username,omitempty"`
+	APIURL          string    `json:"api_url,omitempty"`
+	Editor          string    `json:"editor,omitempty"`
+	FirstRun        bool      `json:"first_run"`
+	ConfirmDelete   bool      `json:"confirm_delete"`
+	E2EKey          string    `json:"e2e_key,omitempty"`
+	UpdateMode      string    `json:"update_mode,omitempty"`
+	UpdateSnoozed   time.Time `json:"update_snoozed_until,omitzero"`
+	UpdateChannel   string    `json:"update_channel,omitempty"`  // updater.ChannelStable (default) or ChannelBeta
+	SkippedVersion  string    `json:"skipped_version,omitempty"` // don't offer this version again
+	LastCheck       time.Time `json:"last_update_check,omitzero"`
+	LatestVersion   string    `json:"latest_version,omitempty"` // what the last update check found
+	SweepMinutes    int       `json:"empty_sweep_minutes,omitempty"`
+	MinWords        int       `json:"min_words,omitempty"`
+	SpinnerStyle    string    `json:"spinner_style,omitempty"`  // dot, line, points, ... or custom
+	SpinnerFrames   []string  `json:"spinner_frames,omitempty"` // frames for the custom style
+	InboxDir        string    `json:"inbox_dir,omitempty"`
+	KeepLineEnds    bool      `json:"keep_line_endings,omitempty"` // don't convert \r\n to \n on save
+	TrimTrailing    bool      `json:"trim_trailing_whitespace,omitempty"`
+	FinalNewline    bool      `json:"final_newline,omitempty"`
+	CloudAutosave   bool      `json:"cloud_autosave"`            // false keeps autosaves local until ctrl+s or sync
+	AutosaveSeconds int       `json:"autosave_seconds"`          // pause in typing before saving, 0 for ctrl+s only
+	SearchScope     string    `json:"search_scope,omitempty"`    // "title" or "all" (default)
+	ExportWrap      int       `json:"export_wrap,omitempty"`     // hard-wrap exported text at this column, 0 for off
+	ExportMetadata  bool      `json:"export_metadata,omitempty"` // start exports with dates, word count and share link
+	ManualOrder     bool      `json:"manual_order,omitempty"`    // list slates in the order set with alt+up/down
+	StatusSeconds   int       `json:"status_seconds,omitempty"`  // how long status messages show: 0 for 3s, -1 until the next edit
+	LockMinutes     int       `json:"idle_lock_minutes,omitempty"`
+	LockHash        string    `json:"idle_lock_passphrase,omitempty"`    // idlelock.Hash of the passphrase, empty for enter only
+	RecentLimit     int       `json:"startup_recent_limit,omitempty"`    // slates listed until "load all", 0 for all
+	WordGoal        int       `json:"word_goal,omitempty"`               // words to aim for per slate, 0 for none
+	Theme           string    `json:"theme,omitempty"`                   // a name in Themes, DefaultTheme if empty
+	ThemeColors     Theme     `json:"theme_colors,omitzero"`             // hex colors overriding the theme's
+	RequestTimeout  int       `json:"request_timeout_seconds,omitempty"` // per API request, 0 for 30s
+	TitleLength     int       `json:"title_length,omitempty"`            // longest title taken from the first line, 0 for 100
+	SyncWorkers     int       `json:"sync_workers,omitempty"`            // slates downloaded at once while syncing, 0 for 5
+	CACertFile      string    `json:"ca_cert_file,omitempty"`            // PEM root certificates trusted on top of the system's
+	NoProxy         string    `json:"no_proxy,omitempty"`                // hosts reached without the proxy, added to $NO_PROXY
+	path            string
+	savedAPIURL     string           // api_url as written in config.json, before the env override
+	extra           jsonfields.Extra // keys only the editor uses, like storage_path, kept on Save
 }
 
 func Load() (*Config, error) {
-	homeDir, err := os.UserHomeDir()
+	configDir, err := Dir()
 	if err != nil {
 		return nil, err
 	}
 
-	configDir := filepath.Join(homeDir, ".justtype")
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return nil, err
 	}
 
-	configPath := filepath.Join(configDir, "config.json")
+	configPath, err := Path()
+	if err != nil {
+		return nil, err
+	}
 
 	cfg := &Config{
-		APIURL:   "https://justtype.io",
-		FirstRun: true,
-		path:     configPath,
+		FirstRun:        true,
+		ConfirmDelete:   true,
+		CloudAutosave:   true,
+		AutosaveSeconds: DefaultAutosaveSeconds,
+		path:            configPath,
 	}
 
 	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	if err == nil {
+		cfg.extra, _ = jsonfields.Split(data, cfg)
+		cfg.path = configPath
+	}
+	cfg.AutosaveSeconds = NormalizeAutosave(cfg.AutosaveSeconds)
 
-	json.Unmarshal(data, cfg)
-	cfg.path = configPath
-
-	if cfg.APIURL == "" {
-		cfg.APIURL = "https://justtype.io"
+	cfg.savedAPIURL = cfg.APIURL
+	cfg.APIURL, err = ResolveAPIURL(cfg.APIURL)
+	if err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
 func (c *Config) Save() error {
-	data, err := json.MarshalIndent(c, "", "  ")
+	// Don't write $JUSTTYPE_API_URL or the default back to the file
+	saved := *c
+	saved.APIURL = c.savedAPIURL
+	data, err := jsonfields.Join(&saved, c.extra)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(c.path, data, 0600)
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, indented.Bytes(), 0600)
 }
 
 func (c *Config) SetCredentials(token, username string) error {
@@ -66,9 +118,23 @@ func (c *Config) SetCredentials(token, username string) error {
 	return c.Save()
 }
 
+// SetTokens saves a session token and the refresh token that goes with it
+func (c *Config) SetTokens(token, refreshToken string) error {
+	c.Token = token
+	c.RefreshToken = refreshToken
+	return c.Save()
+}
+
 func (c *Config) ClearCredentials() error {
 	c.Token = ""
+	c.RefreshToken = ""
 	c.Username = ""
+	c.E2EKey = "" // derived per account
+	return c.Save()
+}
+
+func (c *Config) SetE2EKey(key string) error {
+	c.E2EKey = key
 	return c.Save()
 }
 
@@ -82,8 +148,14 @@ func (c *Config) SetEditor(editor string) error {
 }
 
 func (c *Config) GetEditor() string {
-	if c.Editor != "" {
-		return c.Editor
+	return ResolveEditor(c.Editor)
+}
+
+// ResolveEditor returns the external editor command: configured if set,
+// then $EDITOR, then $VISUAL, or "" if there's none
+func ResolveEditor(configured string) string {
+	if configured != "" {
+		return configured
 	}
 	// Check environment
 	if e := os.Getenv("EDITOR"); e != "" {
@@ -95,6 +167,58 @@ func (c *Config) GetEditor() string {
 	return ""
 }
 
+func (c *Config) SetConfirmDelete(confirm bool) error {
+	c.ConfirmDelete = confirm
+	return c.Save()
+}
+
+func (c *Config) SetUpdateMode(mode string) error {
+	c.UpdateMode = mode
+	c.UpdateSnoozed = time.Time{}
+	return c.Save()
+}
+
+// SetUpdateChannel switches channels; a skipped version on the old one no
+// longer applies
+func (c *Config) SetUpdateChannel(channel string) error {
+	c.UpdateChannel = channel
+	c.SkippedVersion = ""
+	c.LastCheck, c.LatestVersion = time.Time{}, ""
+	return c.Save()
+}
+
+// SetLastCheck records an update check, so the next run doesn't repeat it
+// within updater.CheckInterval
+func (c *Config) SetLastCheck(at time.Time, version string) error {
+	c.LastCheck, c.LatestVersion = at, version
+	return c.Save()
+}
+
+func (c *Config) SkipVersion(version string) error {
+	c.SkippedVersion = version
+	return c.Save()
+}
+
+func (c *Config) SetMinWords(n int) error {
+	c.MinWords = max(n, 0)
+	return c.Save()
+}
+
+func (c *Config) SetWordGoal(n int) error {
+	c.WordGoal = max(n, 0)
+	return c.Save()
+}
+
+func (c *Config) SetManualOrder(on bool) error {
+	c.ManualOrder = on
+	return c.Save()
+}
+
+func (c *Config) SetExportMetadata(on bool) error {
+	c.ExportMetadata = on
+	return c.Save()
+}
+
 func (c *Config) CompleteFirstRun() error {
 	c.FirstRun = false
 	return c.Save()
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveKeepsEditorSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(HomeEnv, home)
+	path := filepath.Join(home, "config.json")
+
+	// Keys the editor's own config has and Config doesn't
+	original := `{"token":"tok","storage_path":"/notes","storage_backend":"sqlite","seen_hints":["slates"],"editor_mode":"vim"}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetTokens("new", "refresh"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var saved map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved["token"] != "new" || saved["refresh_token"] != "refresh" {
+		t.Fatalf("tokens not saved: %s", data)
+	}
+	for _, key := range []string{"storage_path", "storage_backend", "seen_hints", "editor_mode"} {
+		if _, ok := saved[key]; !ok {
+			t.Errorf("%s dropped on save", key)
+		}
+	}
+}
+
+func TestLastUpdateCheckSaved(t *testing.T) {
+	t.Setenv(HomeEnv, t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := cfg.SetLastCheck(at, "2.4.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.LastCheck.Equal(at) || reloaded.LatestVersion != "2.4.0" {
+		t.Fatalf("after reloading the last check is %v, %q", reloaded.LastCheck, reloaded.LatestVersion)
+	}
+
+	// It was of the old channel, so switching forgets it
+	if err := reloaded.SetUpdateChannel("beta"); err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.LastCheck.IsZero() || reloaded.LatestVersion != "" {
+		t.Fatalf("last check kept across a channel change: %v, %q", reloaded.LastCheck, reloaded.LatestVersion)
+	}
+}
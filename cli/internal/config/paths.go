@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HomeEnv overrides the directory justtype keeps its data in
+const HomeEnv = "JUSTTYPE_HOME"
+
+// ErrNoHome is returned when there's no home directory and no override
+var ErrNoHome = errors.New("couldn't find your home directory; set " + HomeEnv + " to the directory justtype should keep its data in")
+
+// Dir returns the justtype data directory: $JUSTTYPE_HOME if set,
+// otherwise ~/.justtype, or under that the directory of the profile in use
+// (see UseProfile)
+func Dir() (string, error) {
+	base, err := baseDir()
+	if err != nil || profile == "" {
+		return base, err
+	}
+	return filepath.Join(base, profilesDir, profile), nil
+}
+
+// Path returns the config file of the profile in use: config.json in the
+// data directory, or profiles/<name>.json beside a profile's directory
+func Path() (string, error) {
+	base, err := baseDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		return filepath.Join(base, "config.json"), nil
+	}
+	return filepath.Join(base, profilesDir, profile+".json"), nil
+}
+
+// baseDir is the data directory of the default profile
+func baseDir() (string, error) {
+	if dir := os.Getenv(HomeEnv); dir != "" {
+		return filepath.Clean(dir), nil
+	}
+
+	home, err := HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".justtype"), nil
+}
+
+// HomeDir returns the user's home directory. Sandboxes and containers
+// sometimes have none, which os.UserHomeDir reports as an error or "".
+func HomeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return "", ErrNoHome
+	}
+	return home, nil
+}
+
+// ExpandHome expands a leading ~/ in path
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// noHome simulates a sandbox without a home directory
+func noHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", "")
+	t.Setenv(HomeEnv, "")
+}
+
+func TestNoHomeIsAnError(t *testing.T) {
+	noHome(t)
+
+	if dir, err := Dir(); !errors.Is(err, ErrNoHome) {
+		t.Fatalf("Dir() = %q, %v; want ErrNoHome", dir, err)
+	}
+	if path, err := Path(); !errors.Is(err, ErrNoHome) {
+		t.Fatalf("Path() = %q, %v; want ErrNoHome, not a path under /", path, err)
+	}
+	if _, err := Load(); !errors.Is(err, ErrNoHome) {
+		t.Fatalf("Load() = %v, want ErrNoHome", err)
+	}
+	if _, err := ExpandHome("~/notes"); !errors.Is(err, ErrNoHome) {
+		t.Fatalf("ExpandHome(~/notes) = %v, want ErrNoHome", err)
+	}
+	// Paths that don't need a home still work
+	if path, err := ExpandHome("/srv/notes"); err != nil || path != "/srv/notes" {
+		t.Fatalf("ExpandHome(/srv/notes) = %q, %v", path, err)
+	}
+}
+
+func TestHomeEnvStandsInForHome(t *testing.T) {
+	noHome(t)
+	override := t.TempDir()
+	t.Setenv(HomeEnv, override+"/")
+
+	dir, err := Dir()
+	if err != nil || dir != override {
+		t.Fatalf("Dir() = %q, %v; want %q", dir, err, override)
+	}
+	if path, _ := Path(); path != filepath.Join(override, "config.json") {
+		t.Fatalf("Path() = %q", path)
+	}
+
+	// Profiles live under the override too
+	if err := UseProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { UseProfile("") })
+	if dir, _ := Dir(); dir != filepath.Join(override, "profiles", "work") {
+		t.Fatalf("profile Dir() = %q", dir)
+	}
+	if path, _ := Path(); path != filepath.Join(override, "profiles", "work.json") {
+		t.Fatalf("profile Path() = %q", path)
+	}
+}
+
+func TestDirDefaultsUnderHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(HomeEnv, "")
+
+	if dir, err := Dir(); err != nil || dir != filepath.Join(home, ".justtype") {
+		t.Fatalf("Dir() = %q, %v", dir, err)
+	}
+	if path, _ := ExpandHome("~/notes"); path != filepath.Join(home, "notes") {
+		t.Fatalf("ExpandHome(~/notes) = %q", path)
+	}
+}
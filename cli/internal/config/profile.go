@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is what the profile in config.json goes by, for the
+// --profile flag and the account switcher
+const DefaultProfile = "default"
+
+// profilesDir holds each named profile's config, <name>.json, and its data
+// directory, <name>/
+const profilesDir = "profiles"
+
+// ErrProfileName is returned for a profile name that couldn't be a file name
+var ErrProfileName = errors.New("a profile name is letters, digits, - and _, starting with a letter or digit")
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// profile is the one in use, empty for the default; see UseProfile
+var profile string
+
+// UseProfile makes Load, Dir and Path use the named profile, which keeps its
+// own token, settings and slates. The profile doesn't have to exist yet; its
+// config is made on the first save. "" or DefaultProfile go back to
+// config.json. A Config loaded before keeps saving where it was loaded from.
+func UseProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		profile = ""
+		return nil
+	}
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the profile in use, DefaultProfile if none was picked
+func Profile() string {
+	if profile == "" {
+		return DefaultProfile
+	}
+	return profile
+}
+
+// CheckProfileName returns ErrProfileName if name can't be a profile
+func CheckProfileName(name string) error {
+	if name != DefaultProfile && !profileName.MatchString(name) {
+		return ErrProfileName
+	}
+	return nil
+}
+
+// ListProfiles returns the names of the profiles that have a config,
+// sorted, with DefaultProfile first
+func ListProfiles() ([]string, error) {
+	base, err := baseDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(base, profilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if ok && entry.Type().IsRegular() && name != DefaultProfile && profileName.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Theme is the colors both UIs are drawn in, as #rrggbb hex
+type Theme struct {
+	Background string `json:"background,omitempty"`
+	Foreground string `json:"foreground,omitempty"`
+	Accent     string `json:"accent,omitempty"`
+	Success    string `json:"success,omitempty"`
+	Warning    string `json:"warning,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Dim        string `json:"dim,omitempty"`
+}
+
+// DefaultTheme is used when the theme setting is empty or unknown
+const DefaultTheme = "dark"
+
+// Themes are the built-in themes, selected by name with the theme setting
+var Themes = map[string]Theme{
+	"dark": {
+		Background: "#111111",
+		Foreground: "#d4d4d4",
+		Accent:     "#8b5cf6",
+		Success:    "#10b981",
+		Warning:    "#f59e0b",
+		Error:      "#ef4444",
+		Dim:        "#666666",
+	},
+	"light": {
+		Background: "#fafafa",
+		Foreground: "#1f2937",
+		Accent:     "#7c3aed",
+		Success:    "#059669",
+		Warning:    "#b45309",
+		Error:      "#dc2626",
+		Dim:        "#9ca3af",
+	},
+	"mono": {
+		Background: "#000000",
+		Foreground: "#d4d4d4",
+		Accent:     "#ffffff",
+		Success:    "#d4d4d4",
+		Warning:    "#ffffff",
+		Error:      "#ffffff",
+		Dim:        "#666666",
+	},
+}
+
+// ResolveTheme starts from the built-in theme called name and applies the
+// custom colors over it. A name or color that isn't valid is left out and
+// reported in the error, so a typo costs one color rather than a readable
+// screen; the theme returned is always complete.
+func ResolveTheme(name string, custom Theme) (Theme, error) {
+	var errs []error
+
+	theme, ok := Themes[strings.ToLower(name)]
+	if !ok {
+		if name != "" {
+			errs = append(errs, fmt.Errorf("unknown theme %q, using %s", name, DefaultTheme))
+		}
+		theme = Themes[DefaultTheme]
+	}
+
+	for _, c := range []struct {
+		name        string
+		value, into *string
+	}{
+		{"background", &custom.Background, &theme.Background},
+		{"foreground", &custom.Foreground, &theme.Foreground},
+		{"accent", &custom.Accent, &theme.Accent},
+		{"success", &custom.Success, &theme.Success},
+		{"warning", &custom.Warning, &theme.Warning},
+		{"error", &custom.Error, &theme.Error},
+		{"dim", &custom.Dim, &theme.Dim},
+	} {
+		if *c.value == "" {
+			continue
+		}
+		hex, err := normalizeHex(*c.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("theme color %s: %w", c.name, err))
+			continue
+		}
+		*c.into = hex
+	}
+
+	return theme, errors.Join(errs...)
+}
+
+// normalizeHex accepts #rrggbb or #rgb and returns #rrggbb in lower case
+func normalizeHex(s string) (string, error) {
+	hex := strings.ToLower(strings.TrimSpace(s))
+	if !strings.HasPrefix(hex, "#") || (len(hex) != 4 && len(hex) != 7) {
+		return "", fmt.Errorf("%q isn't a #rrggbb color", s)
+	}
+	for _, r := range hex[1:] {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return "", fmt.Errorf("%q isn't a #rrggbb color", s)
+		}
+	}
+	if len(hex) == 4 {
+		hex = "#" + strings.Repeat(hex[1:2], 2) + strings.Repeat(hex[2:3], 2) + strings.Repeat(hex[3:4], 2)
+	}
+	return hex, nil
+}
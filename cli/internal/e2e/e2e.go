@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Prefix marks encrypted text so it's never mistaken for plaintext
+const Prefix = "jtenc:v1:"
+
+var (
+	// ErrWrongPassphrase is returned when ciphertext fails authentication
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+
+	// ErrNoKey is returned when encrypted content arrives but no key is set
+	ErrNoKey = errors.New("slate is end-to-end encrypted; enter your passphrase in settings")
+)
+
+// Key encrypts and decrypts slate content with AES-256-GCM
+type Key struct {
+	raw []byte
+}
+
+// DeriveKey derives a key from a passphrase. The salt is derived from the
+// username so the same passphrase unlocks the account on every device.
+func DeriveKey(passphrase, username string) (*Key, error) {
+	salt := sha256.Sum256([]byte("justtype-e2e:" + username))
+	return DeriveKeyWithSalt(passphrase, salt[:])
+}
+
+// DeriveKeyWithSalt derives a key from a passphrase and a random salt, for
+// data that stays on this device
+func DeriveKeyWithSalt(passphrase string, salt []byte) (*Key, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+
+	raw, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{raw: raw}, nil
+}
+
+// ParseKey restores a key saved with Encode
+func ParseKey(encoded string) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 32 {
+		return nil, errors.New("invalid encryption key")
+	}
+	return &Key{raw: raw}, nil
+}
+
+// Encode returns the key in a form suitable for the config file
+func (k *Key) Encode() string {
+	return base64.StdEncoding.EncodeToString(k.raw)
+}
+
+// Encrypt seals text, returning it prefixed and base64 encoded
+func (k *Key) Encrypt(text string) (string, error) {
+	gcm, err := k.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(text), nil)
+	return Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens text produced by Encrypt. Text without the prefix is
+// returned unchanged so slates saved before encryption still load.
+func (k *Key) Decrypt(text string) (string, error) {
+	if !IsEncrypted(text) {
+		return text, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(text, Prefix))
+	if err != nil {
+		return "", fmt.Errorf("corrupt ciphertext: %w", err)
+	}
+
+	gcm, err := k.gcm()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("corrupt ciphertext")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(plain), nil
+}
+
+func (k *Key) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted reports whether text was produced by Encrypt
+func IsEncrypted(text string) bool {
+	return strings.HasPrefix(text, Prefix)
+}
+
+// Open decrypts text with key, which may be nil when encryption is off
+func Open(key *Key, text string) (string, error) {
+	if key == nil {
+		if IsEncrypted(text) {
+			return "", ErrNoKey
+		}
+		return text, nil
+	}
+	return key.Decrypt(text)
+}
+
+// Seal encrypts text with key, or returns it unchanged if key is nil
+func Seal(key *Key, text string) (string, error) {
+	if key == nil {
+		return text, nil
+	}
+	return key.Encrypt(text)
+}
@@ -0,0 +1,85 @@
+package idlelock
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Idle tracks the last input and says when the screen should lock. It's
+// meant to be used from the UI's own goroutine.
+type Idle struct {
+	limit time.Duration
+	last  time.Time
+}
+
+// New starts an idle timer that expires after minutes without input.
+// minutes of 0 or less never expires.
+func New(minutes int) *Idle {
+	return &Idle{limit: time.Duration(minutes) * time.Minute, last: time.Now()}
+}
+
+// SetMinutes changes the limit, counting from the last input
+func (i *Idle) SetMinutes(minutes int) {
+	i.limit = time.Duration(minutes) * time.Minute
+}
+
+// Touch records input
+func (i *Idle) Touch() {
+	i.last = time.Now()
+}
+
+// Expired reports whether there's been no input for the whole limit
+func (i *Idle) Expired(now time.Time) bool {
+	return i.limit > 0 && now.Sub(i.last) >= i.limit
+}
+
+// Hash returns a salted hash of passphrase for the config file. An empty
+// passphrase hashes to "", which unlocks with just enter.
+func Hash(passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	sum, err := derive(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(salt) + ":" + base64.StdEncoding.EncodeToString(sum), nil
+}
+
+// Check reports whether passphrase unlocks a screen locked with hash
+func Check(hash, passphrase string) bool {
+	if hash == "" {
+		return true
+	}
+
+	saltPart, sumPart, ok := strings.Cut(hash, ":")
+	if !ok {
+		return false
+	}
+	salt, err1 := base64.StdEncoding.DecodeString(saltPart)
+	want, err2 := base64.StdEncoding.DecodeString(sumPart)
+	if err := errors.Join(err1, err2); err != nil {
+		return false
+	}
+
+	got, err := derive(passphrase, salt)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+func derive(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
@@ -0,0 +1,56 @@
+package idlelock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleExpires(t *testing.T) {
+	idle := New(5)
+	now := time.Now()
+	if idle.Expired(now.Add(4 * time.Minute)) {
+		t.Fatal("expired before the limit")
+	}
+	if !idle.Expired(now.Add(5 * time.Minute)) {
+		t.Fatal("not expired at the limit")
+	}
+
+	// Input starts the count again
+	time.Sleep(time.Millisecond)
+	idle.Touch()
+	if idle.Expired(now.Add(5 * time.Minute)) {
+		t.Fatal("expired counting from before the last input")
+	}
+
+	idle.SetMinutes(0)
+	if idle.Expired(now.Add(24 * time.Hour)) {
+		t.Fatal("expired with the lock off")
+	}
+}
+
+func TestCheckPassphrase(t *testing.T) {
+	hash, err := Hash("open sesame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Check(hash, "open sesame") {
+		t.Fatal("right passphrase refused")
+	}
+	for _, wrong := range []string{"", "open  sesame", "Open sesame"} {
+		if Check(hash, wrong) {
+			t.Errorf("%q unlocked", wrong)
+		}
+	}
+
+	if again, _ := Hash("open sesame"); again == hash {
+		t.Error("the same passphrase hashed twice to the same value; want a fresh salt")
+	}
+	if empty, err := Hash(""); err != nil || empty != "" || !Check(empty, "anything") {
+		t.Errorf("no passphrase: hash %q, %v; want enter to unlock", empty, err)
+	}
+	for _, bad := range []string{"no separator", "!!:!!", hash[:len(hash)-4]} {
+		if Check(bad, "open sesame") {
+			t.Errorf("malformed hash %q unlocked", bad)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package ids generates slate IDs
+package ids
+
+import "crypto/rand"
+
+// Alphabet is every character an ID can contain. Existing slates use it, so
+// it mustn't change.
+const Alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Length is how many characters New returns
+const Length = 12
+
+// New returns a random, URL-safe slate ID. With 36^12 possibilities two
+// slates created at the same moment won't collide.
+func New() string {
+	// Bytes at or above this would favour the first few characters
+	const limit = 256 - 256%len(Alphabet)
+
+	id := make([]byte, 0, Length)
+	buf := make([]byte, Length*2)
+	for len(id) < Length {
+		rand.Read(buf) // never fails; see crypto/rand.Read
+		for _, b := range buf {
+			if int(b) < limit && len(id) < Length {
+				id = append(id, Alphabet[int(b)%len(Alphabet)])
+			}
+		}
+	}
+	return string(id)
+}
@@ -0,0 +1,52 @@
+package ids
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewConcurrentUnique(t *testing.T) {
+	const workers = 8
+	const perWorker = 100000 / workers
+
+	results := make([][]string, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]string, perWorker)
+			for i := range ids {
+				ids[i] = New()
+			}
+			results[w] = ids
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, workers*perWorker)
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate ID %q", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != 100000 {
+		t.Fatalf("got %d IDs, want 100000", len(seen))
+	}
+}
+
+func TestNewFormat(t *testing.T) {
+	for range 1000 {
+		id := New()
+		if len(id) != Length {
+			t.Fatalf("%q is %d characters, want %d", id, len(id), Length)
+		}
+		if strings.Trim(id, Alphabet) != "" {
+			t.Fatalf("%q has characters outside the alphabet", id)
+		}
+	}
+}
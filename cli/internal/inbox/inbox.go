@@ -0,0 +1,233 @@
+package inbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ProcessedDir is the subfolder imported files are moved into
+const ProcessedDir = "processed"
+
+// DefaultQuiet is how long a file must go unchanged before it's imported,
+// so we don't pick up a file that's still being written
+const DefaultQuiet = 2 * time.Second
+
+// maxFileSize keeps a stray binary or log dump from becoming a slate
+const maxFileSize = 5 << 20
+
+// ImportFunc turns a dropped file into a slate. name is the file's base name.
+type ImportFunc func(name, content string) error
+
+// Watcher imports text files dropped into a directory
+type Watcher struct {
+	dir      string
+	quiet    time.Duration
+	importFn ImportFunc
+	onError  func(error)
+
+	fs      *fsnotify.Watcher
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+	closed  bool
+	done    chan struct{}
+}
+
+// Watch starts watching dir. Files already sitting in it are imported too.
+// onError, if set, is called for files that couldn't be imported; they're
+// left in place so nothing is lost.
+func Watch(dir string, quiet time.Duration, importFn ImportFunc, onError func(error)) (*Watcher, error) {
+	if quiet <= 0 {
+		quiet = DefaultQuiet
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ProcessedDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create inbox: %w", err)
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := fsw.Add(dir); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	w := &Watcher{
+		dir:      dir,
+		quiet:    quiet,
+		importFn: importFn,
+		onError:  onError,
+		fs:       fsw,
+		pending:  make(map[string]*time.Timer),
+		done:     make(chan struct{}),
+	}
+
+	// Pick up anything dropped while the app was closed
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			w.schedule(filepath.Join(dir, e.Name()))
+		}
+	}
+
+	go w.loop()
+	return w, nil
+}
+
+// Close stops watching. Files waiting for their quiet period are left alone.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	for path, t := range w.pending {
+		t.Stop()
+		delete(w.pending, path)
+	}
+	w.mu.Unlock()
+
+	err := w.fs.Close()
+	<-w.done
+	return err
+}
+
+func (w *Watcher) loop() {
+	defer close(w.done)
+
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				w.schedule(event.Name)
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			w.reportError(err)
+		}
+	}
+}
+
+// schedule (re)starts the quiet-period timer for path. Every write resets
+// it, so the import only happens once the file has settled.
+func (w *Watcher) schedule(path string) {
+	if !wanted(path) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	if t, ok := w.pending[path]; ok {
+		t.Reset(w.quiet)
+		return
+	}
+	w.pending[path] = time.AfterFunc(w.quiet, func() {
+		w.settle(path)
+	})
+}
+
+// settle imports path if it hasn't changed since the last check
+func (w *Watcher) settle(path string) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, path)
+	w.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		// Moved away or deleted before it settled
+		return
+	}
+
+	// Some writers don't trigger events for every chunk; go by mtime too
+	if time.Since(info.ModTime()) < w.quiet {
+		w.schedule(path)
+		return
+	}
+
+	if err := w.importFile(path, info); err != nil {
+		w.reportError(err)
+	}
+}
+
+func (w *Watcher) importFile(path string, info os.FileInfo) error {
+	name := filepath.Base(path)
+
+	if info.Size() > maxFileSize {
+		return fmt.Errorf("%s: file is too large to import", name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if !utf8.Valid(data) {
+		return fmt.Errorf("%s: not a text file", name)
+	}
+
+	if err := w.importFn(name, string(data)); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return os.Rename(path, processedPath(w.dir, name))
+}
+
+func (w *Watcher) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// processedPath picks a free name in the processed folder
+func processedPath(dir, name string) string {
+	target := filepath.Join(dir, ProcessedDir, name)
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		return target
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	stamp := time.Now().Format("20060102-150405")
+	for i := 1; ; i++ {
+		candidate := filepath.Join(dir, ProcessedDir, fmt.Sprintf("%s-%s-%d%s", stem, stamp, i, ext))
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// wanted reports whether path looks like a finished text file. Editor swap
+// files and partial downloads are skipped.
+func wanted(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+		return false
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".txt", ".md", ".markdown", ".text":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package inbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testQuiet = 50 * time.Millisecond
+
+type imported struct {
+	name, content string
+}
+
+// watch starts a watcher on a fresh dir that reports imports and errors on
+// channels. importErr, if set, is returned for every import.
+func watch(t *testing.T, dir string, importErr error) (<-chan imported, <-chan error) {
+	t.Helper()
+	imports := make(chan imported, 10)
+	errs := make(chan error, 10)
+	w, err := Watch(dir, testQuiet, func(name, content string) error {
+		imports <- imported{name, content}
+		return importErr
+	}, func(err error) { errs <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return imports, errs
+}
+
+func drop(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitImport(t *testing.T, imports <-chan imported) imported {
+	t.Helper()
+	select {
+	case got := <-imports:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing imported")
+	}
+	return imported{}
+}
+
+// waitGone waits for the import to finish moving path away
+func waitGone(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s wasn't moved out of the inbox", filepath.Base(path))
+}
+
+func TestDroppedFileIsImportedAndMoved(t *testing.T) {
+	dir := t.TempDir()
+	imports, _ := watch(t, dir, nil)
+
+	path := filepath.Join(dir, "groceries.txt")
+	drop(t, path, "eggs\nmilk")
+
+	got := waitImport(t, imports)
+	if got.name != "groceries.txt" || got.content != "eggs\nmilk" {
+		t.Fatalf("imported %+v", got)
+	}
+	waitGone(t, path)
+	if data, err := os.ReadFile(filepath.Join(dir, ProcessedDir, "groceries.txt")); err != nil || string(data) != "eggs\nmilk" {
+		t.Fatalf("processed copy = %q, %v", data, err)
+	}
+}
+
+func TestWaitsForWritesToSettle(t *testing.T) {
+	dir := t.TempDir()
+	imports, _ := watch(t, dir, nil)
+
+	path := filepath.Join(dir, "draft.md")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for range 5 {
+		line := "still writing\n"
+		f.WriteString(line)
+		want.WriteString(line)
+		time.Sleep(testQuiet / 2)
+	}
+	f.Close()
+
+	got := waitImport(t, imports)
+	if got.content != want.String() {
+		t.Fatalf("imported %q before the file was finished, want %q", got.content, want.String())
+	}
+	select {
+	case again := <-imports:
+		t.Fatalf("imported twice: %+v", again)
+	case <-time.After(4 * testQuiet):
+	}
+}
+
+func TestImportsFilesAlreadyThere(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "waiting.txt")
+	drop(t, path, "dropped while closed")
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path, old, old)
+
+	imports, _ := watch(t, dir, nil)
+	if got := waitImport(t, imports); got.name != "waiting.txt" {
+		t.Fatalf("imported %+v", got)
+	}
+	waitGone(t, path)
+}
+
+func TestSkipsFilesThatArentNotes(t *testing.T) {
+	dir := t.TempDir()
+	imports, _ := watch(t, dir, nil)
+
+	skipped := []string{"photo.png", ".hidden.txt", "notes.txt~", "notes.txt.part"}
+	for _, name := range skipped {
+		drop(t, filepath.Join(dir, name), "x")
+	}
+	drop(t, filepath.Join(dir, "binary.txt"), "\xff\xfe\x00")
+
+	select {
+	case got := <-imports:
+		t.Fatalf("imported %+v", got)
+	case <-time.After(6 * testQuiet):
+	}
+	for _, name := range append(skipped, "binary.txt") {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was moved: %v", name, err)
+		}
+	}
+}
+
+func TestFailedImportStaysInInbox(t *testing.T) {
+	dir := t.TempDir()
+	imports, errs := watch(t, dir, errors.New("disk full"))
+
+	path := filepath.Join(dir, "keep.txt")
+	drop(t, path, "don't lose me")
+	waitImport(t, imports)
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "keep.txt") || !strings.Contains(err.Error(), "disk full") {
+			t.Fatalf("error = %q, want the file name and cause", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed import wasn't reported")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("file moved after a failed import: %v", err)
+	}
+}
+
+func TestProcessedNameClash(t *testing.T) {
+	dir := t.TempDir()
+	imports, _ := watch(t, dir, nil)
+
+	path := filepath.Join(dir, "daily.txt")
+	for _, content := range []string{"monday", "tuesday"} {
+		drop(t, path, content)
+		waitImport(t, imports)
+		waitGone(t, path)
+	}
+
+	moved, _ := os.ReadDir(filepath.Join(dir, ProcessedDir))
+	if len(moved) != 2 {
+		t.Fatalf("processed holds %d files, want both kept", len(moved))
+	}
+}
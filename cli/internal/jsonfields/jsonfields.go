@@ -0,0 +1,93 @@
+package jsonfields
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Extra holds the fields of a JSON object that its Go type has no place
+// for, so data written by a newer version survives being rewritten by an
+// older one
+type Extra map[string]json.RawMessage
+
+// Split decodes the JSON object data into v, a pointer to a struct, and
+// returns the fields v doesn't have. It returns nil when there are none.
+func Split(data []byte, v any) (Extra, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	fieldNames(reflect.TypeOf(v).Elem(), known)
+
+	var extra Extra
+	for key, raw := range all {
+		// encoding/json matches keys case-insensitively, so this does too
+		if known[strings.ToLower(key)] {
+			continue
+		}
+		if extra == nil {
+			extra = make(Extra)
+		}
+		extra[key] = raw
+	}
+	return extra, nil
+}
+
+// Join encodes v with extra's fields added back. Fields v encodes itself
+// take precedence.
+func Join(v any, extra Extra) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, raw := range extra {
+		if _, ok := all[key]; !ok {
+			all[key] = raw
+		}
+	}
+	return json.Marshal(all)
+}
+
+// fieldNames collects the lowercased JSON keys struct type t decodes
+func fieldNames(t reflect.Type, known map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs contribute their own fields
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fieldNames(ft, known)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		known[strings.ToLower(name)] = true
+	}
+}
@@ -0,0 +1,105 @@
+package jsonfields
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type record struct {
+	base
+	Title   string `json:"title"`
+	Count   int    `json:"count,omitempty"`
+	Ignored string `json:"-"`
+	Plain   bool
+}
+
+func TestSplit(t *testing.T) {
+	data := []byte(`{
+		"id": "a1",
+		"TITLE": "Notes",
+		"plain": true,
+		"Ignored": "x",
+		"mood": "calm",
+		"tags": ["one", "two"]
+	}`)
+	var r record
+	extra, err := Split(data, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != "a1" || r.Title != "Notes" || !r.Plain {
+		t.Fatalf("decoded %+v", r)
+	}
+	// The embedded struct's key and keys in another case are known; a
+	// field tagged "-" has no key, so it's kept like any other unknown
+	want := Extra{
+		"Ignored": json.RawMessage(`"x"`),
+		"mood":    json.RawMessage(`"calm"`),
+		"tags":    json.RawMessage(`["one", "two"]`),
+	}
+	if !reflect.DeepEqual(extra, want) {
+		t.Fatalf("extra = %s, want %s", fmtExtra(extra), fmtExtra(want))
+	}
+
+	if extra, err := Split([]byte(`{"id": "a2", "title": "t"}`), &r); err != nil || extra != nil {
+		t.Fatalf("no unknown fields: got %v, %v; want nil", extra, err)
+	}
+	if _, err := Split([]byte(`{"id": 3}`), &r); err == nil {
+		t.Fatal("want the decode error")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	r := record{base: base{ID: "a1"}, Title: "Renamed"}
+	extra := Extra{
+		"mood":  json.RawMessage(`"calm"`),
+		"title": json.RawMessage(`"Old title"`),
+	}
+	data, err := Join(r, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"id": "a1", "title": "Renamed", "Plain": false, "mood": "calm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("joined %s", data)
+	}
+
+	// Without extras it's plain encoding
+	plain, _ := json.Marshal(r)
+	if data, _ := Join(r, nil); string(data) != string(plain) {
+		t.Fatalf("Join(nil) = %s, want %s", data, plain)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	newer := []byte(`{"id":"a1","title":"Notes","Plain":false,"mood":"calm","nested":{"deep":[1,2]}}`)
+	var r record
+	extra, err := Split(newer, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Title = "Notes, edited"
+	data, err := Join(r, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]json.RawMessage
+	json.Unmarshal(data, &got)
+	if string(got["mood"]) != `"calm"` || string(got["nested"]) != `{"deep":[1,2]}` || string(got["title"]) != `"Notes, edited"` {
+		t.Fatalf("round trip gave %s", data)
+	}
+}
+
+func fmtExtra(e Extra) string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
@@ -0,0 +1,112 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	imagePattern    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	refLinkPattern  = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	refDefPattern   = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*\S+`)
+	autolinkPattern = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	htmlPattern     = regexp.MustCompile(`<!--.*?-->|</?[A-Za-z][^>]*>`)
+	listPattern     = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?`)
+	rulePattern     = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	tableRowPattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?$`)
+)
+
+// Strip takes the markup out of content, leaving what a reader would read.
+// Fenced code blocks come back separately, without their fences, so they can
+// be counted apart from the prose. Headings, list bullets, block quotes,
+// emphasis, link targets and HTML tags are dropped; link and image text stay.
+func Strip(content string) (prose, code string) {
+	var proseLines, codeLines []string
+	fence := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				continue
+			}
+			codeLines = append(codeLines, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		// Link definitions, rules and the line under a table's header
+		if refDefPattern.MatchString(line) || rulePattern.MatchString(trimmed) ||
+			(strings.Contains(trimmed, "-") && tableRowPattern.MatchString(trimmed)) {
+			continue
+		}
+		proseLines = append(proseLines, stripLine(trimmed))
+	}
+
+	return strings.Join(proseLines, "\n"), strings.Join(codeLines, "\n")
+}
+
+// stripLine takes the block markers off the front of a line and the inline
+// markup out of the rest
+func stripLine(line string) string {
+	for {
+		before := line
+		line = strings.TrimSpace(strings.TrimLeft(line, ">"))
+		line = strings.TrimSpace(listPattern.ReplaceAllString(line, ""))
+		if line == before {
+			break
+		}
+	}
+	if strings.HasPrefix(line, "#") {
+		line = strings.TrimSpace(strings.TrimRight(strings.TrimLeft(line, "#"), "# "))
+	}
+
+	line = imagePattern.ReplaceAllString(line, "$1")
+	line = linkPattern.ReplaceAllString(line, "$1")
+	line = refLinkPattern.ReplaceAllString(line, "$1")
+	line = autolinkPattern.ReplaceAllString(line, "$1")
+	line = htmlPattern.ReplaceAllString(line, "")
+	line = strings.ReplaceAll(line, "|", " ")
+	return stripEmphasis(line)
+}
+
+// stripEmphasis drops *, ~~ and backticks, and underscores at the edge of a
+// word, so snake_case keeps its own
+func stripEmphasis(line string) string {
+	runes := []rune(line)
+	var b strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '*', '`':
+			continue
+		case '~':
+			if (i > 0 && runes[i-1] == '~') || (i+1 < len(runes) && runes[i+1] == '~') {
+				continue
+			}
+		case '_':
+			start, end := i, i+1
+			for start > 0 && runes[start-1] == '_' {
+				start--
+			}
+			for end < len(runes) && runes[end] == '_' {
+				end++
+			}
+			if start == 0 || end == len(runes) || !isWordRune(runes[start-1]) || !isWordRune(runes[end]) {
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,31 @@
+package markdown
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name, in, prose, code string
+	}{
+		{"heading", "## Plans for **May** ##", "Plans for May", ""},
+		{"bullets and tasks", "- one\n* two\n1. three\n- [x] done", "one\ntwo\nthree\ndone", ""},
+		{"quote in a list", "> - quoted item", "quoted item", ""},
+		{"emphasis", "*a* **b** ~~c~~ `d` _e_", "a b c d e", ""},
+		{"snake_case kept", "call read_file_now", "call read_file_now", ""},
+		{"link text, not the url", "see [the docs](https://example.com/a/b)", "see the docs", ""},
+		{"image alt", "![a cat](cat.png)", "a cat", ""},
+		{"reference link", "[text][1]\n[1]: https://example.com", "text", ""},
+		{"autolink", "mail <mailto:ada@example.com>", "mail mailto:ada@example.com", ""},
+		{"html", "<b>bold</b><!-- note -->", "bold", ""},
+		{"rule", "above\n---\nbelow", "above\nbelow", ""},
+		{"table", "| a | b |\n|---|:-:|\n| 1 | 2 |", "  a   b  \n  1   2  ", ""},
+		{"code block", "text\n```go\nfunc main() {}\n```\nmore", "text\nmore", "func main() {}"},
+		{"tilde fence", "~~~\nx := 1\n~~~", "", "x := 1"},
+		{"unclosed fence", "```\nstill code", "", "still code"},
+	}
+	for _, tt := range tests {
+		prose, code := Strip(tt.in)
+		if prose != tt.prose || code != tt.code {
+			t.Errorf("%s: Strip = %q, %q; want %q, %q", tt.name, prose, code, tt.prose, tt.code)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	TOCStart = "<!-- toc -->"
+	TOCEnd   = "<!-- /toc -->"
+)
+
+// Heading is a Markdown ATX heading found in a document
+type Heading struct {
+	Level  int
+	Text   string
+	Anchor string
+}
+
+// Headings returns the ATX headings in content, skipping fenced code blocks
+// and anything inside an existing table of contents
+func Headings(content string) []Heading {
+	var headings []Heading
+	seen := make(map[string]int)
+	inFence := false
+	inTOC := false
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if trimmed == TOCStart {
+			inTOC = true
+			continue
+		}
+		if trimmed == TOCEnd {
+			inTOC = false
+			continue
+		}
+		if inTOC {
+			continue
+		}
+
+		level, text, ok := ParseHeading(line)
+		if !ok {
+			continue
+		}
+
+		anchor := Slug(text)
+		if n := seen[anchor]; n > 0 {
+			seen[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			seen[anchor] = 1
+		}
+
+		headings = append(headings, Heading{Level: level, Text: text, Anchor: anchor})
+	}
+
+	return headings
+}
+
+// ParseHeading parses an ATX heading like "## Title ##" into its level and text
+func ParseHeading(line string) (int, string, bool) {
+	// Up to three spaces of indentation are allowed
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 {
+		return 0, "", false
+	}
+	line = line[indent:]
+
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+
+	text := strings.TrimSpace(rest)
+	// Strip an optional closing sequence of #s
+	if stripped := strings.TrimRight(text, "#"); stripped != text {
+		if stripped == "" || strings.HasSuffix(stripped, " ") {
+			text = strings.TrimSpace(stripped)
+		}
+	}
+	if text == "" {
+		return 0, "", false
+	}
+
+	return level, text, true
+}
+
+// Slug turns heading text into a GitHub-style anchor
+func Slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' || r == '-':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// TOC renders a nested list of links to the headings
+func TOC(headings []Heading) string {
+	if len(headings) == 0 {
+		return ""
+	}
+
+	// Indent relative to the shallowest heading
+	minLevel := headings[0].Level
+	for _, h := range headings {
+		if h.Level < minLevel {
+			minLevel = h.Level
+		}
+	}
+
+	var b strings.Builder
+	for _, h := range headings {
+		b.WriteString(strings.Repeat("  ", h.Level-minLevel))
+		b.WriteString(fmt.Sprintf("- [%s](#%s)\n", h.Text, h.Anchor))
+	}
+	return b.String()
+}
+
+// InsertTOC inserts or refreshes the table of contents block in content.
+// An existing block between the toc markers is replaced in place; otherwise
+// the block goes after the first line, which is the slate's title. Content
+// without headings is returned as it is.
+func InsertTOC(content string) string {
+	headings := Headings(content)
+	if len(headings) == 0 {
+		return content
+	}
+	block := TOCStart + "\n" + TOC(headings) + TOCEnd
+
+	start := strings.Index(content, TOCStart)
+	if start >= 0 {
+		if end := strings.Index(content[start:], TOCEnd); end >= 0 {
+			end += start + len(TOCEnd)
+			return content[:start] + block + content[end:]
+		}
+	}
+
+	lines := strings.SplitN(content, "\n", 2)
+	if len(lines) == 1 {
+		return content + "\n\n" + block + "\n"
+	}
+	return lines[0] + "\n\n" + block + "\n\n" + strings.TrimLeft(lines[1], "\n")
+}
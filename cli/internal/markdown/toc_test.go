@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeadings(t *testing.T) {
+	content := "Title line\n" +
+		"# Intro\n" +
+		"   ## Indented ok ##\n" +
+		"    # four spaces is code\n" +
+		"#no space isn't a heading\n" +
+		"```\n# inside a fence\n```\n" +
+		TOCStart + "\n- [Intro](#intro)\n" + TOCEnd + "\n" +
+		"## Intro\n" +
+		"###### Deepest\n" +
+		"####### too deep\n" +
+		"#\n"
+
+	want := []Heading{
+		{1, "Intro", "intro"},
+		{2, "Indented ok", "indented-ok"},
+		{2, "Intro", "intro-1"},
+		{6, "Deepest", "deepest"},
+	}
+	if got := Headings(content); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Headings:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":         "hello-world",
+		"What's new?":         "whats-new",
+		"snake_case and-dash": "snake_case-and-dash",
+		"Ünïcode Títle":       "ünïcode-títle",
+		"日本語 見出し":             "日本語-見出し",
+		"C++ & Go":            "c--go",
+	}
+	for text, want := range tests {
+		if got := Slug(text); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestInsertTOC(t *testing.T) {
+	content := "Notes\n\n# One\ntext\n## Two\n"
+	want := "Notes\n\n" + TOCStart + "\n- [One](#one)\n  - [Two](#two)\n" + TOCEnd + "\n\n# One\ntext\n## Two\n"
+
+	got := InsertTOC(content)
+	if got != want {
+		t.Fatalf("InsertTOC:\n got %q\nwant %q", got, want)
+	}
+	if again := InsertTOC(got); again != got {
+		t.Fatalf("second InsertTOC changed it:\n got %q\nwant %q", again, got)
+	}
+
+	// A new heading refreshes the block in place
+	added := got + "# Three\n"
+	refreshed := InsertTOC(added)
+	wantRefreshed := "Notes\n\n" + TOCStart + "\n- [One](#one)\n  - [Two](#two)\n- [Three](#three)\n" + TOCEnd + "\n\n# One\ntext\n## Two\n# Three\n"
+	if refreshed != wantRefreshed {
+		t.Fatalf("refresh:\n got %q\nwant %q", refreshed, wantRefreshed)
+	}
+}
+
+func TestInsertTOCWithoutHeadings(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"just a line",
+		"Title\n\nsome text\n```\n# in code\n```\n",
+	} {
+		if got := InsertTOC(content); got != content {
+			t.Errorf("InsertTOC(%q) = %q, want it unchanged", content, got)
+		}
+	}
+}
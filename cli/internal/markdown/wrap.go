@@ -0,0 +1,111 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Wrap hard-wraps lines of content longer than width columns, breaking only
+// between words. Fenced and indented code, headings and table rows are left
+// as they are, as is any word longer than width. List items and quotes keep
+// their indentation on the lines they wrap onto. A width below 1 returns
+// content unchanged.
+func Wrap(content string, width int) string {
+	if width < 1 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence || utf8.RuneCountInString(line) <= width || !wrappable(line) {
+			out = append(out, line)
+			continue
+		}
+
+		first, rest := linePrefix(line)
+		out = append(out, fill(strings.Fields(line[len(first):]), first, rest, width)...)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// wrappable reports whether line is prose, as opposed to code, a heading or
+// a table row
+func wrappable(line string) bool {
+	if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+		return false
+	}
+	if _, _, ok := ParseHeading(line); ok {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// linePrefix splits off the indentation, quote markers and list marker at
+// the start of line. first is that prefix as written; rest is what the
+// lines it wraps onto start with.
+func linePrefix(line string) (first, rest string) {
+	i := 0
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	for i+1 < len(line) && line[i] == '>' && line[i+1] == ' ' {
+		i += 2
+	}
+	quote := line[:i]
+
+	if n := listMarker(line[i:]); n > 0 {
+		i += n
+		return line[:i], quote + strings.Repeat(" ", n)
+	}
+	return quote, quote
+}
+
+// listMarker returns the length of a "- ", "* ", "+ " or "1. " marker at the
+// start of s, including the space after it, or 0
+func listMarker(s string) int {
+	if len(s) >= 2 && strings.ContainsRune("-*+", rune(s[0])) && s[1] == ' ' {
+		return 2
+	}
+
+	digits := 0
+	for digits < len(s) && digits < 9 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits+1 < len(s) && (s[digits] == '.' || s[digits] == ')') && s[digits+1] == ' ' {
+		return digits + 2
+	}
+	return 0
+}
+
+// fill lays words out greedily in lines of at most width columns
+func fill(words []string, first, rest string, width int) []string {
+	var lines []string
+	current := first
+	empty := true
+
+	for _, word := range words {
+		if !empty && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = rest
+			empty = true
+		}
+		if !empty {
+			current += " "
+		}
+		current += word
+		empty = false
+	}
+
+	return append(lines, current)
+}
@@ -0,0 +1,74 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapBetweenWords(t *testing.T) {
+	in := "the quick brown fox jumps over the lazy dog"
+	want := "the quick brown\nfox jumps over\nthe lazy dog"
+	if got := Wrap(in, 15); got != want {
+		t.Fatalf("Wrap:\n got %q\nwant %q", got, want)
+	}
+	for _, line := range strings.Split(Wrap(in, 15), "\n") {
+		if utf8.RuneCountInString(line) > 15 {
+			t.Fatalf("line %q is longer than 15", line)
+		}
+	}
+}
+
+func TestWrapKeepsCode(t *testing.T) {
+	long := "this line is far too long to fit but it is code and must stay"
+	in := "```go\n" + long + "\n```\n" +
+		"~~~\n" + long + "\n~~~\n" +
+		"    " + long + "\n" +
+		"\t" + long + "\n" +
+		"## " + long + "\n" +
+		"| " + long + " |"
+	if got := Wrap(in, 20); got != in {
+		t.Fatalf("code, headings or tables rewrapped:\n%s", got)
+	}
+
+	// Prose after a fence closes is wrapped again
+	in = "```\ncode\n```\nprose that runs on past the width"
+	want := "```\ncode\n```\nprose that runs on\npast the width"
+	if got := Wrap(in, 20); got != want {
+		t.Fatalf("Wrap:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestWrapLongWord(t *testing.T) {
+	in := "see https://example.com/a/very/long/path/that/cannot/break here"
+	want := "see\nhttps://example.com/a/very/long/path/that/cannot/break\nhere"
+	if got := Wrap(in, 20); got != want {
+		t.Fatalf("Wrap:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestWrapKeepsPrefixes(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"bullet", "- one two three four five", "- one two three\n  four five"},
+		{"numbered", "10. one two three four", "10. one two\n    three four"},
+		{"quote", "> one two three four five", "> one two three\n> four five"},
+		{"quoted list", "> * one two three four", "> * one two\n>   three four"},
+		{"indented", "  one two three four five", "  one two three\n  four five"},
+	}
+	for _, tt := range tests {
+		if got := Wrap(tt.in, 15); got != tt.want {
+			t.Errorf("%s: Wrap(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWrapOff(t *testing.T) {
+	in := "a line that would otherwise be wrapped somewhere"
+	for _, width := range []int{0, -1} {
+		if got := Wrap(in, width); got != in {
+			t.Errorf("Wrap at %d = %q, want it unchanged", width, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package model is the slate every part of the CLI agrees on, and the
+// conversions to and from the server's wire format
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/tags"
+)
+
+// Slate is what the store, the storage backends and the server all know
+// about a slate. The store and storage slates embed it and add their own
+// (sync state, cursor position); the JSON keys are the ones they've always
+// written to disk.
+type Slate struct {
+	ID          string    `json:"id"` // "cloud-N" for slates that came from the server
+	Title       string    `json:"title"`
+	Content     string    `json:"content"` // empty when only listed by the server
+	WordCount   int       `json:"word_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CloudID     int       `json:"cloud_id,omitempty"` // 0 until the server has it
+	IsPublished bool      `json:"is_published"`
+	ShareID     string    `json:"share_id,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`   // #tags in the content, see tags.Parse
+	Pinned      bool      `json:"pinned,omitempty"` // kept above the rest in lists
+}
+
+// TimeLayout is how the server writes timestamps, and how they're sent
+// back; see ParseTime for what's read
+const TimeLayout = time.RFC3339
+
+// CloudSlateID is the ID a slate from the server goes by before it has one
+// of its own
+func CloudSlateID(cloudID int) string {
+	return fmt.Sprintf("cloud-%d", cloudID)
+}
+
+// FromAPI converts a slate from the server. Tags come from the content,
+// since the server doesn't keep them. The slate returned is always usable:
+// a timestamp that can't be read is filled in from the other one, or the
+// current time, and the error says so for the caller to log.
+func FromAPI(s api.Slate) (Slate, error) {
+	createdAt, updatedAt, err := slateTimes(s.CreatedAt, s.UpdatedAt, time.Now())
+	if err != nil {
+		err = fmt.Errorf("slate %d: %w", s.ID, err)
+	}
+
+	return Slate{
+		ID:          CloudSlateID(s.ID),
+		CloudID:     s.ID,
+		Title:       s.Title,
+		Content:     s.Content,
+		WordCount:   s.WordCount,
+		Tags:        tags.Parse(s.Content),
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		IsPublished: s.IsPublished == 1,
+		ShareID:     s.ShareID,
+		Pinned:      s.PinnedAt != nil,
+	}, err
+}
+
+// ToAPI converts a slate to the server's format
+func (s Slate) ToAPI() api.Slate {
+	published := 0
+	if s.IsPublished {
+		published = 1
+	}
+	return api.Slate{
+		ID:          s.CloudID,
+		Title:       s.Title,
+		Content:     s.Content,
+		WordCount:   s.WordCount,
+		IsPublished: published,
+		ShareID:     s.ShareID,
+		CreatedAt:   s.CreatedAt.Format(TimeLayout),
+		UpdatedAt:   s.UpdatedAt.Format(TimeLayout),
+	}
+}
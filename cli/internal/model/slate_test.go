@@ -0,0 +1,71 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/api"
+)
+
+func TestAPIRoundTrip(t *testing.T) {
+	pinned := int64(1760000000000)
+	sent := api.Slate{
+		ID:          42,
+		Title:       "Trip",
+		Content:     "Trip\n\npack the #camping gear",
+		WordCount:   5,
+		IsPublished: 1,
+		ShareID:     "abc123",
+		CreatedAt:   "2026-03-01T08:30:00Z",
+		UpdatedAt:   "2026-03-02T19:45:10+02:00",
+		PinnedAt:    &pinned,
+	}
+
+	m, err := FromAPI(sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != "cloud-42" || !m.IsPublished || !m.Pinned || !reflect.DeepEqual(m.Tags, []string{"camping"}) {
+		t.Fatalf("FromAPI = %+v", m)
+	}
+
+	back := m.ToAPI()
+	sent.PinnedAt = nil // pinning is only read, never sent
+	if back != sent {
+		t.Fatalf("round trip changed the slate:\n got %+v\nwant %+v", back, sent)
+	}
+}
+
+func TestFromAPIReportsUnreadableTimes(t *testing.T) {
+	tests := []struct {
+		name             string
+		created, updated string
+		wantErr          bool
+		wantCreated      string // RFC 3339, or "" for about now
+	}{
+		{"both fine", "2026-01-01T00:00:00Z", "2026-01-02T00:00:00Z", false, "2026-01-01T00:00:00Z"},
+		{"sqlite layout", "2026-01-01 00:00:00", "2026-01-02 00:00:00", false, "2026-01-01T00:00:00Z"},
+		{"bad created", "yesterday", "2026-01-02T00:00:00Z", true, "2026-01-02T00:00:00Z"},
+		{"missing updated", "2026-01-01T00:00:00Z", "", true, "2026-01-01T00:00:00Z"},
+		{"neither", "", "soon", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := FromAPI(api.Slate{ID: 1, CreatedAt: tt.created, UpdatedAt: tt.updated})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCreated == "" {
+				if time.Since(m.CreatedAt) > time.Minute || !m.UpdatedAt.Equal(m.CreatedAt) {
+					t.Fatalf("times = %v, %v; want now for both", m.CreatedAt, m.UpdatedAt)
+				}
+				return
+			}
+			want, _ := time.Parse(time.RFC3339, tt.wantCreated)
+			if !m.CreatedAt.Equal(want) {
+				t.Fatalf("CreatedAt = %v, want %v", m.CreatedAt, want)
+			}
+		})
+	}
+}
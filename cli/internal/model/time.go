@@ -0,0 +1,54 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the timestamp formats ParseTime accepts, in the order
+// it tries them. The server writes RFC 3339; the rest are what databases
+// and older servers have been seen to send. Layouts without a zone are UTC.
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	time.DateOnly,
+}
+
+// ParseTime reads a timestamp from the server in any of the formats it's
+// known to use
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("no timestamp")
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
+
+// slateTimes parses a slate's two timestamps. One that can't be read is
+// replaced by the other, or by now if neither can, so the slate still sorts
+// somewhere sensible; the error says which were replaced.
+func slateTimes(created, updated string, now time.Time) (time.Time, time.Time, error) {
+	createdAt, createdErr := ParseTime(created)
+	updatedAt, updatedErr := ParseTime(updated)
+
+	switch {
+	case createdErr == nil && updatedErr == nil:
+		return createdAt, updatedAt, nil
+	case createdErr == nil:
+		return createdAt, createdAt, fmt.Errorf("updated_at: %w, using created_at", updatedErr)
+	case updatedErr == nil:
+		return updatedAt, updatedAt, fmt.Errorf("created_at: %w, using updated_at", createdErr)
+	default:
+		return now, now, fmt.Errorf("created_at: %w; updated_at: %w; using the current time", createdErr, updatedErr)
+	}
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2026, 3, 2, 17, 45, 10, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-03-02T17:45:10Z", want},
+		{"2026-03-02T19:45:10+02:00", want},
+		{"2026-03-02T17:45:10.123456789Z", want.Add(123456789)},
+		{"2026-03-02T17:45:10", want},
+		{"2026-03-02 17:45:10", want},
+		{"2026-03-02 19:45:10+02:00", want},
+		{"  2026-03-02T17:45:10Z\n", want},
+		{"2026-03-02", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "   ", "yesterday", "1772473510", "03/02/2026", "2026-13-02"} {
+		if got, err := ParseTime(bad); err == nil {
+			t.Errorf("ParseTime(%q) = %v, want an error", bad, got)
+		}
+	}
+}
+
+func TestSlateTimesSortSensibly(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	slates := []struct {
+		name             string
+		created, updated string
+	}{
+		{"old", "2026-01-01T00:00:00Z", "2026-01-02T00:00:00Z"},
+		{"newer, other format", "2026-04-01 00:00:00", "2026-05-01 09:30:00"},
+		{"unreadable update", "2026-03-01T00:00:00Z", "last tuesday"},
+		{"nothing readable", "", "soon"},
+	}
+	var times []struct {
+		name    string
+		updated time.Time
+	}
+	for _, s := range slates {
+		created, updated, _ := slateTimes(s.created, s.updated, now)
+		if created.IsZero() || updated.IsZero() {
+			t.Fatalf("%s: zero time, which would sort it last and show it as year 1", s.name)
+		}
+		if updated.Before(created) {
+			t.Fatalf("%s: updated %v before created %v", s.name, updated, created)
+		}
+		times = append(times, struct {
+			name    string
+			updated time.Time
+		}{s.name, updated})
+	}
+
+	// Newest first, as the slate list sorts
+	sort.Slice(times, func(i, j int) bool { return times[i].updated.After(times[j].updated) })
+	var order []string
+	for _, tt := range times {
+		order = append(order, tt.name)
+	}
+	want := []string{"nothing readable", "newer, other format", "unreadable update", "old"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("sorted %q, want %q", order, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package normalize
+
+import "strings"
+
+// Options controls how slate content is cleaned up on save. The zero value
+// is the default behaviour.
+type Options struct {
+	KeepLineEndings bool // store \r\n and \r as written instead of converting to \n
+	TrimTrailing    bool // strip spaces and tabs from the end of each line
+	FinalNewline    bool // end non-empty content with exactly one newline
+}
+
+// Apply cleans up content according to opts
+func Apply(content string, opts Options) string {
+	if !opts.KeepLineEndings {
+		content = LineEndings(content)
+	}
+	if opts.TrimTrailing {
+		content = TrimTrailing(content)
+	}
+	if opts.FinalNewline {
+		content = FinalNewline(content)
+	}
+	return content
+}
+
+// TrimTrailing strips trailing spaces and tabs from every line. Lines that
+// are only whitespace become empty; line endings are left as they are.
+func TrimTrailing(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+		if cr {
+			line += "\r"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FinalNewline collapses trailing blank lines so s ends with a single
+// newline. Empty and whitespace-only content is returned unchanged.
+func FinalNewline(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return s
+	}
+
+	newline := "\n"
+	if strings.Contains(s, "\r\n") {
+		newline = "\r\n"
+	}
+	return strings.TrimRight(s, " \t\r\n") + newline
+}
+
+// LineEndings converts Windows (\r\n) and old Mac (\r) line endings to \n
+func LineEndings(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
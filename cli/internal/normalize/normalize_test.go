@@ -0,0 +1,81 @@
+package normalize
+
+import "testing"
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"unix", "one\ntwo\n", "one\ntwo\n"},
+		{"windows", "one\r\ntwo\r\n", "one\ntwo\n"},
+		{"old mac", "one\rtwo\r", "one\ntwo\n"},
+		{"mixed", "one\r\ntwo\rthree\n", "one\ntwo\nthree\n"},
+		{"blank lines", "a\r\n\r\nb", "a\n\nb"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		if got := LineEndings(tt.in); got != tt.want {
+			t.Errorf("%s: LineEndings(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLineEndings(t *testing.T) {
+	crlf := "pasted\r\nfrom windows\r\n"
+	if got := Apply(crlf, Options{}); got != "pasted\nfrom windows\n" {
+		t.Errorf("default Apply = %q, want \\n line endings", got)
+	}
+	if got := Apply(crlf, Options{KeepLineEndings: true}); got != crlf {
+		t.Errorf("Apply with KeepLineEndings = %q, want it unchanged", got)
+	}
+}
+
+func TestTrimTrailing(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"spaces and tabs", "one  \ntwo\t\nthree", "one\ntwo\nthree"},
+		{"whitespace-only lines", "a\n   \n\t\t\nb", "a\n\n\nb"},
+		{"only whitespace", "  \t ", ""},
+		{"leading kept", "  indented  \n\tcode\t", "  indented\n\tcode"},
+		{"inner kept", "two  spaces  inside ", "two  spaces  inside"},
+		{"crlf kept", "one \r\ntwo\t\r\n", "one\r\ntwo\r\n"},
+		{"trailing newlines kept", "end  \n\n\n", "end\n\n\n"},
+		{"nothing to trim", "clean\ntext", "clean\ntext"},
+	}
+	for _, tt := range tests {
+		if got := TrimTrailing(tt.in); got != tt.want {
+			t.Errorf("%s: TrimTrailing(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFinalNewline(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"missing", "text", "text\n"},
+		{"already one", "text\n", "text\n"},
+		{"several blank lines", "text\n\n\n", "text\n"},
+		{"trailing whitespace lines", "text\n  \n\t\n", "text\n"},
+		{"crlf", "text\r\n\r\n", "text\r\n"},
+		{"empty", "", ""},
+		{"only whitespace", " \n\n", " \n\n"},
+	}
+	for _, tt := range tests {
+		if got := FinalNewline(tt.in); got != tt.want {
+			t.Errorf("%s: FinalNewline(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTrimIsOptIn(t *testing.T) {
+	poem := "a line  \n    \n  and another  "
+	if got := Apply(poem, Options{}); got != poem {
+		t.Errorf("default Apply trimmed: %q", got)
+	}
+	got := Apply(poem+"\r\n\r\n", Options{TrimTrailing: true, FinalNewline: true})
+	if want := "a line\n\n  and another\n"; got != want {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+}
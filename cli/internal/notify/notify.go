@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSize is how many messages a log keeps
+const DefaultSize = 20
+
+// Level says whether an entry is routine or something went wrong
+type Level int
+
+const (
+	Info Level = iota
+	Error
+)
+
+// Entry is one message in the log
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+}
+
+// Log keeps the most recent status messages so ones that flashed by can be
+// reviewed later. It's safe to use from multiple goroutines.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	size    int
+}
+
+// New creates a log that keeps the last size messages
+func New(size int) *Log {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Log{size: size}
+}
+
+// Info records a routine message
+func (l *Log) Info(message string) {
+	l.add(Info, message)
+}
+
+// Error records a failure
+func (l *Log) Error(message string) {
+	l.add(Error, message)
+}
+
+func (l *Log) add(level Level, message string) {
+	if message == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, Entry{Time: time.Now(), Level: level, Message: message})
+	if len(l.entries) > l.size {
+		// Copy so the backing array doesn't grow forever
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.size:]...)
+	}
+}
+
+// Entries returns the messages, newest first
+func (l *Log) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]Entry, len(l.entries))
+	for i, e := range l.entries {
+		entries[len(l.entries)-1-i] = e
+	}
+	return entries
+}
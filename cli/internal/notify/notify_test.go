@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestLogKeepsNewestFirst(t *testing.T) {
+	l := New(DefaultSize)
+	l.Info("saved to cloud")
+	l.Error("sync failed")
+	l.Info("")
+	l.Info("synced")
+
+	entries := l.Entries()
+	want := []struct {
+		level   Level
+		message string
+	}{
+		{Info, "synced"},
+		{Error, "sync failed"},
+		{Info, "saved to cloud"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d (empty messages are dropped)", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].Level != w.level || entries[i].Message != w.message {
+			t.Errorf("entry %d = %v %q, want %v %q", i, entries[i].Level, entries[i].Message, w.level, w.message)
+		}
+		if i > 0 && entries[i].Time.After(entries[i-1].Time) {
+			t.Errorf("entry %d is newer than the one listed before it", i)
+		}
+	}
+}
+
+func TestLogCapsAtSize(t *testing.T) {
+	l := New(0)
+	total := DefaultSize + 5
+	for i := range total {
+		l.Info(fmt.Sprintf("message %d", i))
+	}
+
+	entries := l.Entries()
+	if len(entries) != DefaultSize {
+		t.Fatalf("kept %d entries, want %d", len(entries), DefaultSize)
+	}
+	if got, want := entries[0].Message, fmt.Sprintf("message %d", total-1); got != want {
+		t.Fatalf("newest = %q, want %q", got, want)
+	}
+	if got, want := entries[DefaultSize-1].Message, fmt.Sprintf("message %d", total-DefaultSize); got != want {
+		t.Fatalf("oldest kept = %q, want %q", got, want)
+	}
+}
+
+func TestEntriesIsACopy(t *testing.T) {
+	l := New(3)
+	l.Info("one")
+	entries := l.Entries()
+	entries[0].Message = "changed"
+	if got := l.Entries()[0].Message; got != "one" {
+		t.Fatalf("editing the returned slice changed the log to %q", got)
+	}
+}
+
+func TestLogConcurrentUse(t *testing.T) {
+	l := New(5)
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				l.Error(fmt.Sprintf("worker %d: %d", w, i))
+				l.Entries()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(l.Entries()); n != 5 {
+		t.Fatalf("kept %d entries, want 5", n)
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justtype/cli/internal/storage"
+)
+
+// DefaultAddr is where `justtype serve` listens unless told otherwise
+const DefaultAddr = "127.0.0.1:7317"
+
+// Source is the read-only part of storage.Storage the server needs. Search
+// goes through storage.Searcher if the source has it, and otherwise matches
+// titles and content from List.
+type Source interface {
+	List() ([]*storage.Slate, error)
+	Load(id string) (*storage.Slate, error)
+}
+
+// Summary is a slate without its content, used in list responses
+type Summary struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	WordCount   int       `json:"word_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	IsPublished bool      `json:"is_published"`
+}
+
+type handler struct {
+	mu    sync.Mutex // storages aren't all safe for concurrent use
+	src   Source
+	token string
+}
+
+// NewHandler returns the HTTP API over src, which is opened once by the
+// caller and only read. If token is set, requests must send it as a bearer
+// token.
+func NewHandler(src Source, token string) http.Handler {
+	h := &handler{src: src, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /slates", h.list)
+	mux.HandleFunc("GET /slates/{id}", h.get)
+	mux.HandleFunc("GET /search", h.search)
+
+	return h.guard(mux)
+}
+
+// ListenAndServe serves the API on addr, which must be a loopback address
+func ListenAndServe(addr string, h http.Handler) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return &net.AddrError{Err: "only loopback addresses are allowed", Addr: addr}
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
+
+// guard rejects requests from other hosts and checks the token
+func (h *handler) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Block DNS rebinding: browsers send the attacker's host name here
+		host := r.Host
+		if hh, _, err := net.SplitHostPort(host); err == nil {
+			host = hh
+		}
+		if host != "localhost" {
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				writeError(w, http.StatusForbidden, "forbidden host")
+				return
+			}
+		}
+
+		auth := []byte(r.Header.Get("Authorization"))
+		if h.token != "" && subtle.ConstantTimeCompare(auth, []byte("Bearer "+h.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	slates, err := h.src.List()
+	h.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summarize(slates))
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	slate, err := h.src.Load(r.PathValue("id"))
+	h.mu.Unlock()
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "slate not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, slate)
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+
+	h.mu.Lock()
+	slates, err := h.find(query)
+	h.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summarize(slates))
+}
+
+// find searches with the source's own index if it has one, or else for
+// query anywhere in a slate's title or content, ignoring case
+func (h *handler) find(query string) ([]*storage.Slate, error) {
+	if searcher, ok := h.src.(storage.Searcher); ok {
+		return searcher.Search(query)
+	}
+
+	slates, err := h.src.List()
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(query)
+	var found []*storage.Slate
+	for _, slate := range slates {
+		if strings.Contains(strings.ToLower(slate.Title), query) ||
+			strings.Contains(strings.ToLower(slate.Content), query) {
+			found = append(found, slate)
+		}
+	}
+	return found, nil
+}
+
+func summarize(slates []*storage.Slate) []Summary {
+	summaries := make([]Summary, 0, len(slates))
+	for _, s := range slates {
+		summaries = append(summaries, Summary{
+			ID:          s.ID,
+			Title:       s.Title,
+			WordCount:   s.WordCount,
+			CreatedAt:   s.CreatedAt,
+			UpdatedAt:   s.UpdatedAt,
+			IsPublished: s.IsPublished,
+		})
+	}
+	return summaries
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
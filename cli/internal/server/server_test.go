@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/storage"
+)
+
+// fixture saves slates with the given contents into src, oldest first, and
+// returns their IDs in the same order
+func fixture(t *testing.T, src storage.Storage, contents ...string) []string {
+	t.Helper()
+	var ids []string
+	for _, content := range contents {
+		slate := &storage.Slate{Slate: model.Slate{Content: content}}
+		if err := src.Save(slate); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, slate.ID)
+		time.Sleep(time.Millisecond)
+	}
+	return ids
+}
+
+func localFixture(t *testing.T) (storage.Storage, []string) {
+	t.Helper()
+	ls, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ls, fixture(t, ls, "Shopping\n\neggs and milk", "Meeting notes\n\nbudget review")
+}
+
+func sqliteFixture(t *testing.T) (storage.Storage, []string) {
+	t.Helper()
+	ss, err := storage.NewSQLite(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+	return ss, fixture(t, ss, "Shopping\n\neggs and milk", "Meeting notes\n\nbudget review")
+}
+
+// get requests path from h as a local client would, returning the status
+// and body
+func get(t *testing.T, h http.Handler, path string, header http.Header) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "http://127.0.0.1:7317"+path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestHandlers(t *testing.T) {
+	for name, open := range map[string]func(*testing.T) (storage.Storage, []string){
+		"json":   localFixture,
+		"sqlite": sqliteFixture,
+	} {
+		t.Run(name, func(t *testing.T) {
+			src, ids := open(t)
+			h := NewHandler(src, "")
+
+			status, body := get(t, h, "/slates", nil)
+			if status != http.StatusOK {
+				t.Fatalf("GET /slates: %d %s", status, body)
+			}
+			var list []map[string]any
+			if err := json.Unmarshal([]byte(body), &list); err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != 2 || list[0]["title"] != "Meeting notes" || list[1]["title"] != "Shopping" {
+				t.Fatalf("GET /slates = %s, want both, newest first", body)
+			}
+			if _, ok := list[0]["content"]; ok {
+				t.Fatal("list includes content")
+			}
+
+			status, body = get(t, h, "/slates/"+ids[0], nil)
+			var slate storage.Slate
+			json.Unmarshal([]byte(body), &slate)
+			if status != http.StatusOK || slate.Content != "Shopping\n\neggs and milk" {
+				t.Fatalf("GET /slates/{id}: %d %s", status, body)
+			}
+
+			if status, _ := get(t, h, "/slates/missing", nil); status != http.StatusNotFound {
+				t.Fatalf("GET /slates/missing: %d, want 404", status)
+			}
+
+			status, body = get(t, h, "/search?q=budget", nil)
+			if status != http.StatusOK || !strings.Contains(body, ids[1]) || strings.Contains(body, ids[0]) {
+				t.Fatalf("GET /search?q=budget: %d %s", status, body)
+			}
+			if status, _ := get(t, h, "/search?q=+", nil); status != http.StatusBadRequest {
+				t.Fatalf("empty query: %d, want 400", status)
+			}
+		})
+	}
+}
+
+func TestWritesNotAllowed(t *testing.T) {
+	src, ids := localFixture(t)
+	h := NewHandler(src, "")
+	for _, method := range []string{"POST", "PUT", "DELETE"} {
+		req := httptest.NewRequest(method, "http://127.0.0.1:7317/slates/"+ids[0], strings.NewReader(`{}`))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: %d, want 405", method, rec.Code)
+		}
+	}
+	if slates, _ := src.List(); len(slates) != 2 {
+		t.Fatalf("%d slates after write attempts, want 2", len(slates))
+	}
+}
+
+func TestToken(t *testing.T) {
+	src, _ := localFixture(t)
+	h := NewHandler(src, "s3cret")
+
+	tests := []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer s3cre", http.StatusUnauthorized},
+		{"s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.auth != "" {
+			header.Set("Authorization", tt.auth)
+		}
+		if status, _ := get(t, h, "/slates", header); status != tt.want {
+			t.Errorf("Authorization %q: %d, want %d", tt.auth, status, tt.want)
+		}
+	}
+}
+
+func TestForeignHost(t *testing.T) {
+	src, _ := localFixture(t)
+	h := NewHandler(src, "")
+
+	for host, want := range map[string]int{
+		"127.0.0.1:7317":    http.StatusOK,
+		"localhost:7317":    http.StatusOK,
+		"[::1]:7317":        http.StatusOK,
+		"evil.example:7317": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/slates", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Host %s: %d, want %d", host, rec.Code, want)
+		}
+	}
+}
+
+func TestListenRefusesOtherAddresses(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:7317", "192.168.1.5:7317", "example.com:7317"} {
+		if err := ListenAndServe(addr, http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: listened", addr)
+		}
+	}
+}
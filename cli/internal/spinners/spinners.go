@@ -0,0 +1,43 @@
+// Package spinners picks the loading animation named by the spinner_style
+// setting, for both UIs
+package spinners
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+)
+
+// Default is the spinner for an empty or unknown style
+var Default = spinner.Dot
+
+var styles = map[string]spinner.Spinner{
+	"dot":       spinner.Dot,
+	"minidot":   spinner.MiniDot,
+	"line":      spinner.Line,
+	"points":    spinner.Points,
+	"pulse":     spinner.Pulse,
+	"jump":      spinner.Jump,
+	"meter":     spinner.Meter,
+	"ellipsis":  spinner.Ellipsis,
+	"hamburger": spinner.Hamburger,
+}
+
+// For returns the spinner named by style. "custom" uses frames; anything
+// unknown or empty, or custom without frames, falls back to Default.
+func For(style string, frames []string) spinner.Spinner {
+	style = strings.ToLower(strings.TrimSpace(style))
+
+	if style == "custom" && len(frames) > 0 {
+		return spinner.Spinner{
+			Frames: append([]string(nil), frames...),
+			FPS:    time.Second / 10,
+		}
+	}
+
+	if s, ok := styles[style]; ok {
+		return s
+	}
+	return Default
+}
@@ -0,0 +1,40 @@
+package spinners
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForFallsBackToDefault(t *testing.T) {
+	for _, style := range []string{"", "nope", "custom", "  "} {
+		s := For(style, nil)
+		if !reflect.DeepEqual(s.Frames, Default.Frames) || s.FPS != Default.FPS {
+			t.Errorf("For(%q) = %v, want the default", style, s.Frames)
+		}
+	}
+}
+
+func TestForNamedAndCustom(t *testing.T) {
+	if s := For(" Line ", nil); s.Frames[0] != "|" {
+		t.Fatalf("For(\" Line \") = %v, want the line spinner", s.Frames)
+	}
+
+	frames := []string{"a", "b"}
+	s := For("custom", frames)
+	if !reflect.DeepEqual(s.Frames, frames) || s.FPS <= 0 {
+		t.Fatalf("custom = %v at %v", s.Frames, s.FPS)
+	}
+	frames[0] = "changed"
+	if s.Frames[0] != "a" {
+		t.Fatal("custom spinner shares the setting's frames")
+	}
+}
+
+func TestEveryStyleAnimates(t *testing.T) {
+	for name := range styles {
+		s := For(name, nil)
+		if len(s.Frames) == 0 || s.FPS <= 0 {
+			t.Errorf("%s has %d frames at %v", name, len(s.Frames), s.FPS)
+		}
+	}
+}
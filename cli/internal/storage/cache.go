@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/store"
+	"github.com/justtype/cli/internal/tags"
+)
+
+// ErrOffline matches errors from requests that never reached the server,
+// the same ones api.ErrOffline does
+var ErrOffline = api.ErrOffline
+
+// cache keeps a copy of cloud slates on disk so account mode keeps working
+// offline. Entries that aren't Synced are edits waiting to be pushed, and
+// Unavailable ones have only been listed: their content is downloaded the
+// first time they're opened online.
+type cache struct {
+	mu  sync.Mutex
+	st  *store.Store
+	dir string
+}
+
+// lastSyncFile records when the cache last matched the server
+const lastSyncFile = "last_sync"
+
+// newCache opens the cache in dir, encrypted with key if it's set, as
+// slates are with end-to-end encryption on. A cache written with another
+// key, or none, is set aside and started again, since the server has
+// everything in it but edits that can't be read anyway.
+func newCache(dir string, key *e2e.Key) (*cache, error) {
+	st, err := store.OpenWithKey(dir, key)
+	if errors.Is(err, atrest.ErrWrongPassphrase) || errors.Is(err, atrest.ErrLocked) {
+		if _, asideErr := atrest.SetAside(filepath.Join(dir, "slates.json")); asideErr != nil {
+			return nil, asideErr
+		}
+		os.Remove(filepath.Join(dir, "trash.json"))
+		st, err = store.OpenWithKey(dir, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cache{st: st, dir: dir}, nil
+}
+
+// lastSync returns when refresh last ran, or the zero time if it never has
+func (c *cache) lastSync() time.Time {
+	data, err := os.ReadFile(filepath.Join(c.dir, lastSyncFile))
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return t
+}
+
+// key returns the cache entry for a slate, matching on cloud ID first since
+// slates created offline keep their local ID in the cache after upload
+func (c *cache) key(slate *Slate) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e := c.findCloud(slate.CloudID); e != nil {
+		return e.ID
+	}
+	if e := c.st.Get(slate.ID); e != nil {
+		return e.ID
+	}
+	return ""
+}
+
+func (c *cache) findCloud(cloudID int) *store.Slate {
+	if cloudID == 0 {
+		return nil
+	}
+	for _, e := range c.st.List() {
+		if e.CloudID == cloudID {
+			return e
+		}
+	}
+	return nil
+}
+
+// put stores slate under key, or a new key if it's empty, and returns the key
+func (c *cache) put(slate *Slate, key string, synced bool) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key == "" {
+		key = slate.ID
+	}
+	if key == "" {
+		key = fmt.Sprintf("local-%d", time.Now().UnixNano())
+	}
+
+	createdAt := slate.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	updatedAt := slate.UpdatedAt
+	if !synced || updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	title := slate.Title
+	if !synced || title == "" {
+		title = ExtractTitle(slate.Content)
+	}
+
+	m := slate.Model()
+	m.ID = key
+	m.Title = title
+	m.WordCount = CountWords(slate.Content)
+	m.Tags = tags.Parse(slate.Content)
+	m.CreatedAt = createdAt
+	m.UpdatedAt = updatedAt
+
+	entry := store.FromModel(m)
+	entry.Synced = synced
+	entry.CursorOffset = slate.CursorOffset
+	c.st.Put(entry)
+	return key
+}
+
+// setPinned pins or unpins the cached copy of a slate, leaving any edits
+// waiting to be pushed as they are
+func (c *cache) setPinned(cloudID int, pinned bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e := c.findCloud(cloudID); e != nil {
+		c.st.SetPinned(e.ID, pinned)
+	}
+}
+
+// get finds a cached slate by its app ID or cloud ID. Slates that have only
+// been listed aren't returned, since their content isn't here.
+func (c *cache) get(id string, cloudID int) *Slate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.findCloud(cloudID)
+	if e == nil {
+		e = c.st.Get(id)
+	}
+	if e == nil || e.Unavailable {
+		return nil
+	}
+	return fromCache(e)
+}
+
+func (c *cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.st.Remove(key)
+}
+
+func (c *cache) list() []*Slate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := c.st.List()
+	slates := make([]*Slate, 0, len(entries))
+	for _, e := range entries {
+		slates = append(slates, fromCache(e))
+	}
+	return slates
+}
+
+// pending returns edits made offline that haven't reached the server
+func (c *cache) pending() []*store.Slate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var pending []*store.Slate
+	for _, e := range c.st.List() {
+		if !e.Synced {
+			copied := *e
+			pending = append(pending, &copied)
+		}
+	}
+	return pending
+}
+
+// refresh brings the cache in line with the server's list without
+// downloading anything. Slates new to the cache are added as listed, and
+// the rest keep the copy they have, which Load replaces when they're next
+// opened online. Queued edits are never overwritten.
+func (c *cache) refresh(remote []*Slate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[int]bool, len(remote))
+	for _, r := range remote {
+		seen[r.CloudID] = true
+		if c.findCloud(r.CloudID) != nil {
+			continue
+		}
+
+		entry := store.FromModel(r.Model())
+		entry.Synced = true
+		entry.Unavailable = true
+		c.st.Put(entry)
+	}
+
+	// Drop slates deleted on another device
+	for _, e := range c.st.List() {
+		if e.CloudID > 0 && e.Synced && !seen[e.CloudID] {
+			c.st.Remove(e.ID)
+		}
+	}
+
+	os.WriteFile(filepath.Join(c.dir, lastSyncFile), []byte(time.Now().Format(time.RFC3339)), 0600)
+}
+
+func fromCache(e *store.Slate) *Slate {
+	m := e.Model()
+	if e.CloudID > 0 {
+		m.ID = model.CloudSlateID(e.CloudID)
+	}
+	slate := FromModel(m)
+	slate.CursorOffset = e.CursorOffset
+	return slate
+}
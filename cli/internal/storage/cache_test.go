@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/model"
+)
+
+// accountServer is an account's slates on a fake server that can be taken
+// offline, which drops every connection
+type accountServer struct {
+	fakeServer
+	down   atomic.Bool
+	slates map[int]api.Slate
+	nextID int
+}
+
+func newAccountServer(t *testing.T, slates ...api.Slate) (*accountServer, string) {
+	a := &accountServer{slates: make(map[int]api.Slate), nextID: 100}
+	for _, s := range slates {
+		a.slates[s.ID] = s
+	}
+	a.handle = func(w http.ResponseWriter, r *http.Request, body string) {
+		if a.down.Load() {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+
+		var id int
+		fmt.Sscanf(r.URL.Path, "/api/slates/%d", &id)
+		switch {
+		case r.Method == "GET" && id == 0:
+			list := []api.Slate{}
+			for _, s := range a.slates {
+				s.Content = ""
+				list = append(list, s)
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == "GET":
+			json.NewEncoder(w).Encode(a.slates[id])
+		case r.Method == "POST":
+			a.nextID++
+			a.store(a.nextID, body)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]int{"id": a.nextID})
+		case r.Method == "PUT":
+			a.store(id, body)
+		}
+	}
+	return a, a.start(t)
+}
+
+func (a *accountServer) store(id int, body string) {
+	var s api.Slate
+	json.Unmarshal([]byte(body), &s)
+	s.ID = id
+	s.UpdatedAt = "2026-10-16T12:00:00Z"
+	a.slates[id] = s
+}
+
+// fetches counts the downloads of single slates the server has seen
+func (a *accountServer) fetches() int {
+	n := 0
+	for _, req := range a.seen() {
+		if strings.HasPrefix(req, "GET /api/slates/") {
+			n++
+		}
+	}
+	return n
+}
+
+func newCachedCloud(t *testing.T, apiURL string, key *e2e.Key) (*CloudStorage, string) {
+	t.Helper()
+	cs := newTestCloud(t, apiURL)
+	cs.SetEncryptionKey(key)
+	dir := t.TempDir()
+	if err := cs.EnableCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	return cs, dir
+}
+
+func TestCacheReadsOffline(t *testing.T) {
+	a, url := newAccountServer(t,
+		api.Slate{ID: 1, Title: "opened", Content: "opened\n\nread on the train", UpdatedAt: "2026-10-01T09:00:00Z"},
+		api.Slate{ID: 2, Title: "listed", Content: "listed\n\nnever opened", UpdatedAt: "2026-10-02T09:00:00Z"},
+	)
+	cs, _ := newCachedCloud(t, url, nil)
+
+	slates, err := cs.List()
+	if err != nil || len(slates) != 2 {
+		t.Fatalf("List = %d slates, %v; want 2", len(slates), err)
+	}
+	if n := a.fetches(); n != 0 {
+		t.Fatalf("listing downloaded %d slates, want none until they're opened", n)
+	}
+	if _, err := cs.Load("cloud-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	a.down.Store(true)
+	slates, err = cs.List()
+	if err != nil || len(slates) != 2 {
+		t.Fatalf("offline List = %d slates, %v; want both from the cache", len(slates), err)
+	}
+	if !cs.Offline() {
+		t.Fatal("not reported offline")
+	}
+	opened, err := cs.Load("cloud-1")
+	if err != nil || opened.Content != "opened\n\nread on the train" {
+		t.Fatalf("offline Load = %+v, %v; want the cached copy", opened, err)
+	}
+	if _, err := cs.Load("cloud-2"); !errors.Is(err, ErrOffline) {
+		t.Fatalf("offline Load of a slate never opened = %v, want ErrOffline", err)
+	}
+}
+
+func TestCacheQueuesOfflineWritesThenFlushes(t *testing.T) {
+	a, url := newAccountServer(t,
+		api.Slate{ID: 1, Title: "draft", Content: "draft", UpdatedAt: "2026-10-01T09:00:00Z"},
+	)
+	cs, _ := newCachedCloud(t, url, nil)
+	if _, err := cs.List(); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := cs.Load("cloud-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.down.Store(true)
+	existing.Content = "draft, finished offline"
+	if err := cs.Save(existing); err != nil {
+		t.Fatalf("offline edit: %v", err)
+	}
+	created := &Slate{Slate: model.Slate{Content: "written offline"}}
+	if err := cs.Save(created); err != nil {
+		t.Fatalf("offline create: %v", err)
+	}
+	if n := cs.Pending(); n != 2 {
+		t.Fatalf("Pending = %d, want 2", n)
+	}
+	again, err := cs.Load(created.ID)
+	if err != nil || again.Content != "written offline" {
+		t.Fatalf("offline slate reads back as %+v, %v", again, err)
+	}
+
+	a.down.Store(false)
+	if _, err := cs.List(); err != nil {
+		t.Fatal(err)
+	}
+	if n := cs.Pending(); n != 0 {
+		t.Fatalf("Pending = %d after reconnecting, want 0", n)
+	}
+	if got := a.slates[1].Content; got != "draft, finished offline" {
+		t.Fatalf("server has %q for the edited slate", got)
+	}
+	if len(a.slates) != 2 {
+		t.Fatalf("server has %d slates, want the offline one created", len(a.slates))
+	}
+}
+
+func TestCacheEncryptedWithE2EKey(t *testing.T) {
+	key, err := e2e.DeriveKey("correct horse", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, dir := newCachedCloud(t, "http://127.0.0.1:1", key)
+	if err := cs.Save(&Slate{Slate: model.Slate{Content: "the secret plan"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "slates.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret plan") || !e2e.IsEncrypted(string(data)) {
+		t.Fatalf("cache written in plaintext: %.60s", data)
+	}
+
+	// The same key opens it again
+	reopened := newTestCloud(t, "http://127.0.0.1:1")
+	reopened.SetEncryptionKey(key)
+	if err := reopened.EnableCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.Pending(); n != 1 {
+		t.Fatalf("Pending = %d with the same key, want 1", n)
+	}
+
+	// Another key can't, so the unreadable copy is set aside for a fresh one
+	other, _ := e2e.DeriveKey("another", "writer")
+	rekeyed := newTestCloud(t, "http://127.0.0.1:1")
+	rekeyed.SetEncryptionKey(other)
+	if err := rekeyed.EnableCache(dir); err != nil {
+		t.Fatalf("cache with another key didn't open: %v", err)
+	}
+	if n := rekeyed.Pending(); n != 0 {
+		t.Fatalf("Pending = %d with another key, want an empty cache", n)
+	}
+	if aside, _ := filepath.Glob(filepath.Join(dir, "slates.corrupt-*.json")); len(aside) != 1 {
+		t.Fatalf("set aside %v, want the old cache kept", aside)
+	}
+}
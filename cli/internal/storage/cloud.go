@@ -2,27 +2,47 @@ package storage
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/store"
+	"github.com/justtype/cli/internal/tags"
 	"github.com/justtype/cli/internal/updater"
 )
 
 // CloudStorage is cloud-first with minimal local caching
 type CloudStorage struct {
 	apiURL        string
-	token         string
 	username      string
 	client        *http.Client
 	tempDir       string
 	currentFile   string // temp file for current slate
+	session       bool   // this is the editor's storage; see StartSession
 	latestVersion string // latest CLI version from server
+	trash         *trash // local copies of deleted slates for undo
+	key           *e2e.Key
+	cache         *cache         // offline copy of the account's slates, if enabled
+	readOnly      bool           // see NewCloudReader
+	cached        []*store.Slate // the offline copy as read, when readOnly
+	queue         *queue         // writes that couldn't reach the server
+	offline       bool           // last request couldn't reach the server
+	norm          normalize.Options
+	api           *api.Client // shared calls, such as publishing
+
+	warnMu   sync.Mutex
+	warnings []string // see Warnings
 }
 
 // NewCloud creates cloud storage
@@ -32,55 +52,378 @@ func NewCloud(tempDir, apiURL, token, username string) (*CloudStorage, error) {
 		return nil, err
 	}
 
+	t, err := newTrash(filepath.Join(tempDir, "trash.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := newQueue(filepath.Join(tempDir, "queue.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
 	cs := &CloudStorage{
 		apiURL:   apiURL,
-		token:    token,
 		username: username,
-		client:   &http.Client{Timeout: 30 * time.Second},
+		client:   updater.NewClient(api.DefaultTimeout),
+		api:      api.New(apiURL, token),
 		tempDir:  tempDir,
+		trash:    t,
+		queue:    q,
 	}
 
 	return cs, nil
 }
 
+// NewCloudReader creates cloud storage for commands that only read: List
+// and Load go to the server without pushing queued or offline edits, and
+// EnableCache reads the offline copy, as a fallback, without refreshing or
+// re-encrypting it. Nothing is written under tempDir, which may not exist.
+func NewCloudReader(tempDir, apiURL, token, username string) (*CloudStorage, error) {
+	q, err := newQueue(filepath.Join(tempDir, "queue.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloudStorage{
+		apiURL:   apiURL,
+		username: username,
+		client:   updater.NewClient(api.DefaultTimeout),
+		api:      api.New(apiURL, token),
+		tempDir:  tempDir,
+		queue:    q,
+		readOnly: true,
+	}, nil
+}
+
+// SetRefreshToken lets a rejected session be renewed without logging in
+// again; see api.Client.SetRefreshToken
+func (cs *CloudStorage) SetRefreshToken(refreshToken string) {
+	cs.api.SetRefreshToken(refreshToken)
+}
+
+// OnTokenRefresh registers fn to be called with the new tokens after a
+// silent refresh, so they can be saved; see api.Client.OnTokenRefresh
+func (cs *CloudStorage) OnTokenRefresh(fn func(token, refreshToken string)) {
+	cs.api.OnTokenRefresh(fn)
+}
+
+// SetTokens switches to a session another client renewed
+func (cs *CloudStorage) SetTokens(token, refreshToken string) {
+	cs.api.SetToken(token)
+	cs.api.SetRefreshToken(refreshToken)
+}
+
+// send makes a request with the session token. A 401 gets one silent
+// refresh and replay, as requests through api.Client do; errors reaching
+// the server wrap ErrOffline.
+func (cs *CloudStorage) send(method, url string, body []byte) (*http.Response, error) {
+	for refreshed := false; ; refreshed = true {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, url, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		token := cs.api.Token()
+		req.Header.Set("Authorization", "Bearer "+token)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := cs.client.Do(req)
+		if err != nil {
+			return nil, api.Offline(cs.apiURL, err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || refreshed || !cs.api.Refresh(context.Background(), token) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// SetTimeout sets how long each request to the server may take; 0 means
+// api.DefaultTimeout
+func (cs *CloudStorage) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = api.DefaultTimeout
+	}
+	cs.client.Timeout = timeout
+	cs.api.SetTimeout(timeout)
+}
+
+// SetEncryptionKey turns on end-to-end encryption; nil turns it off
+func (cs *CloudStorage) SetEncryptionKey(key *e2e.Key) {
+	cs.key = key
+}
+
+// EnableCache keeps a copy of the account's slates in dir, so they can be
+// read and edited while offline. Offline edits are pushed on reconnect.
+// With end-to-end encryption on, the copy is encrypted with the same key,
+// so SetEncryptionKey goes first.
+func (cs *CloudStorage) EnableCache(dir string) error {
+	if cs.readOnly {
+		cs.readCache(dir)
+		return nil
+	}
+	c, err := newCache(dir, cs.key)
+	if err != nil {
+		return err
+	}
+	cs.cache = c
+	return nil
+}
+
+// CacheStatus reports what the offline cache holds, without going to the
+// network: how many slates, how many edits are waiting to be pushed, and
+// when it last synced. All zero if the cache isn't enabled.
+func (cs *CloudStorage) CacheStatus() (slates, pending int, lastSync time.Time) {
+	if cs.cache == nil {
+		return 0, 0, time.Time{}
+	}
+	return len(cs.cache.list()), len(cs.cache.pending()), cs.cache.lastSync()
+}
+
+// Offline reports whether the last request failed to reach the server
+func (cs *CloudStorage) Offline() bool {
+	return cs.offline
+}
+
+// OfflineError is the error for cs's server being out of reach, naming it
+func (cs *CloudStorage) OfflineError() error {
+	return api.Offline(cs.apiURL, nil)
+}
+
+// Pending returns how many writes are waiting to reach the server
+func (cs *CloudStorage) Pending() int {
+	n := cs.queue.len()
+	if cs.cache != nil {
+		n += len(cs.cache.pending())
+	}
+	return n
+}
+
+// SetNormalize sets how content is cleaned up on save
+func (cs *CloudStorage) SetNormalize(opts normalize.Options) {
+	cs.norm = opts
+}
+
+// IsEncrypted reports whether slates are encrypted before upload
+func (cs *CloudStorage) IsEncrypted() bool {
+	return cs.key != nil
+}
+
 func (cs *CloudStorage) Save(slate *Slate) error {
+	slate.Content = normalize.Apply(slate.Content, cs.norm)
+	markPristine(slate, slate.CloudID == 0)
+
 	// Save to temp file (for current editing session only)
 	cs.saveTempFile(slate)
 
+	if cs.cache == nil {
+		if cs.queue.len() > 0 {
+			cs.FlushQueue()
+		}
+		cs.adoptQueued(slate)
+		if cs.queue.len() > 0 {
+			// Still offline; stay in line behind the earlier writes
+			return cs.enqueue(slate)
+		}
+
+		err := cs.push(slate)
+		if errors.Is(err, ErrOffline) {
+			return cs.enqueue(slate)
+		}
+		if err == nil {
+			cs.offline = false
+		}
+		return err
+	}
+
+	key := cs.cache.key(slate)
+	if slate.CloudID == 0 {
+		// A slate created offline may have been uploaded by a flush since
+		if cached := cs.cache.get(key, 0); cached != nil && cached.CloudID > 0 {
+			slate.CloudID = cached.CloudID
+			slate.ID = cached.ID
+		}
+	}
+
+	err := cs.push(slate)
+	if errors.Is(err, ErrOffline) {
+		// Queue it; the next List pushes it
+		cs.offline = true
+		newKey := cs.cache.put(slate, key, false)
+		if slate.ID == "" {
+			slate.ID = newKey
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cs.offline = false
+	cs.cache.put(slate, key, true)
+	return nil
+}
+
+// enqueue records a save that couldn't reach the server. A new slate gets
+// a local ID until FlushQueue creates it.
+func (cs *CloudStorage) enqueue(slate *Slate) error {
+	cs.offline = true
+
+	op := queuedOp{Op: opUpdate, ID: slate.ID, CloudID: slate.CloudID, Content: slate.Content}
+	if slate.CloudID == 0 {
+		if slate.ID == "" {
+			slate.ID = fmt.Sprintf("queued-%d", time.Now().UnixNano())
+		}
+		op.Op = opCreate
+		op.ID = slate.ID
+	}
+	return cs.queue.add(op)
+}
+
+// adoptQueued gives a slate created offline the cloud ID FlushQueue
+// created it with, so it's updated from now on instead of created again
+func (cs *CloudStorage) adoptQueued(slate *Slate) {
+	if slate.CloudID != 0 {
+		return
+	}
+	if id := cs.queue.cloudID(slate.ID); id > 0 {
+		slate.CloudID = id
+		slate.ID = model.CloudSlateID(id)
+	}
+}
+
+// statusError is a request the server answered with a status other than
+// success
+type statusError struct {
+	action string // "save" or "delete"
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s failed: %d", e.action, e.code)
+}
+
+// FlushQueue replays writes made offline, in the order they were made, and
+// returns how many reached the server. It stops at the first one that
+// can't reach it, leaving the rest queued. A write is only dropped once the
+// server has it, or has refused it outright (a 4xx), in which case it's
+// kept in the recovered folder instead, since it may be the only copy of
+// what was written. Anything else, such as a server error, stays queued
+// for the next flush. The first error is returned.
+func (cs *CloudStorage) FlushQueue() (int, error) {
+	flushed := 0
+	var firstErr error
+
+	for _, op := range cs.queue.list() {
+		var err error
+		cloudID := op.CloudID
+		if op.Op == opDelete {
+			err = cs.deleteRemote(op.CloudID)
+		} else {
+			slate := &Slate{Slate: model.Slate{ID: op.ID, CloudID: op.CloudID, Content: op.Content}}
+			err = cs.push(slate)
+			cloudID = slate.CloudID
+		}
+
+		if errors.Is(err, ErrOffline) {
+			cs.offline = true
+			return flushed, firstErr
+		}
+		if errors.Is(err, api.ErrSessionExpired) {
+			// Kept for after logging in again
+			return flushed, err
+		}
+		cs.offline = false
+
+		var status *statusError
+		refused := errors.As(err, &status) && status.code >= 400 && status.code < 500
+		if err != nil && !refused {
+			// Worth trying again later
+			if firstErr == nil {
+				firstErr = fmt.Errorf("queued %s of %s, kept for the next sync: %w", op.Op, op.ID, err)
+			}
+			continue
+		}
+		if refused {
+			path, setErr := cs.setAsideOp(op)
+			if setErr != nil {
+				// Left queued rather than lost
+				return flushed, fmt.Errorf("queued %s of %s: %w, and it couldn't be set aside: %v", op.Op, op.ID, err, setErr)
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("queued %s of %s: %w; it was kept in %s", op.Op, op.ID, err, path)
+			}
+		}
+
+		if err := cs.queue.done(op, cloudID); err != nil {
+			return flushed, err
+		}
+		if !refused {
+			flushed++
+		}
+	}
+	return flushed, firstErr
+}
+
+// setAsideOp keeps a queued write the server refused in the recovered
+// folder, named for the slate and when it was queued, and returns where
+func (cs *CloudStorage) setAsideOp(op queuedOp) (string, error) {
+	dir := filepath.Join(cs.tempDir, recoveredDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("queued-%s-%s.json", filepath.Base(op.ID), op.QueuedAt.Format("2006-01-02-150405"))
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, data, 0600)
+}
+
+// push uploads a slate, creating it on the server if it has no cloud ID
+func (cs *CloudStorage) push(slate *Slate) error {
 	// Extract title from first line if not set
 	title := slate.Title
 	if title == "" && slate.Content != "" {
-		lines := strings.Split(slate.Content, "\n")
-		if len(lines) > 0 {
-			title = strings.TrimSpace(lines[0])
-			if len(title) > 100 {
-				title = title[:100]
-			}
-		}
+		title = ExtractTitle(slate.Content)
+	}
+
+	// Count words before encrypting so the server's metadata stays accurate
+	wordCount := CountWords(slate.Content)
+
+	sealedTitle, err := e2e.Seal(cs.key, title)
+	if err != nil {
+		return err
+	}
+	sealedContent, err := e2e.Seal(cs.key, slate.Content)
+	if err != nil {
+		return err
 	}
 
 	// Push to cloud immediately (not in background)
-	body := map[string]string{
-		"title":   title,
-		"content": slate.Content,
+	body := map[string]interface{}{
+		"title":      sealedTitle,
+		"content":    sealedContent,
+		"word_count": wordCount,
 	}
 
 	jsonData, _ := json.Marshal(body)
 
-	var req *http.Request
+	// Create new, or update existing
+	method, url := "POST", cs.apiURL+"/api/slates"
 	if slate.CloudID > 0 {
-		// Update existing
-		req, _ = http.NewRequest("PUT", fmt.Sprintf("%s/api/slates/%d", cs.apiURL, slate.CloudID), bytes.NewReader(jsonData))
-	} else {
-		// Create new
-		req, _ = http.NewRequest("POST", cs.apiURL+"/api/slates", bytes.NewReader(jsonData))
+		method, url = "PUT", fmt.Sprintf("%s/api/slates/%d", cs.apiURL, slate.CloudID)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+cs.token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "justtype-cli/2.2")
-
-	resp, err := cs.client.Do(req)
+	resp, err := cs.send(method, url, jsonData)
 	if err != nil {
 		return err
 	}
@@ -94,7 +437,7 @@ func (cs *CloudStorage) Save(slate *Slate) error {
 			}
 			if err := json.NewDecoder(resp.Body).Decode(&result); err == nil {
 				slate.CloudID = result.ID
-				slate.ID = fmt.Sprintf("cloud-%d", result.ID)
+				slate.ID = model.CloudSlateID(result.ID)
 				// Update temp file with cloud ID
 				cs.saveTempFile(slate)
 			}
@@ -107,10 +450,13 @@ func (cs *CloudStorage) Save(slate *Slate) error {
 
 	// Check if session expired (401 Unauthorized)
 	if resp.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("SESSION_EXPIRED")
+		return api.ErrSessionExpired
+	}
+	if err := api.CheckRateLimit(resp); err != nil {
+		return err
 	}
 
-	return fmt.Errorf("save failed: %d", resp.StatusCode)
+	return &statusError{action: "save", code: resp.StatusCode}
 }
 
 func (cs *CloudStorage) Load(id string) (*Slate, error) {
@@ -123,22 +469,128 @@ func (cs *CloudStorage) Load(id string) (*Slate, error) {
 	var cloudID int
 	fmt.Sscanf(id, "cloud-%d", &cloudID)
 
+	if cloudID == 0 {
+		if queued := cs.queue.cloudID(id); queued > 0 {
+			cloudID = queued
+		} else if op, ok := cs.queue.get(id); ok && op.Op == opCreate {
+			// Created offline and not uploaded yet
+			return &Slate{
+				Slate: model.Slate{
+					ID:        op.ID,
+					Title:     ExtractTitle(op.Content),
+					Content:   op.Content,
+					WordCount: CountWords(op.Content),
+					Tags:      tags.Parse(op.Content),
+					CreatedAt: op.QueuedAt,
+					UpdatedAt: op.QueuedAt,
+				},
+			}, nil
+		}
+	}
+
+	if cs.cache != nil {
+		// Offline edits are newer than the server's copy
+		if cached := cs.cache.get(id, cloudID); cached != nil && (cloudID == 0 || cs.hasPending(cloudID)) {
+			return cached, nil
+		}
+	}
+
+	if cs.readOnly {
+		if cached := cs.cachedCopy(id, cloudID, true); cached != nil {
+			return cached, nil
+		}
+	}
+
 	if cloudID == 0 {
 		return nil, fmt.Errorf("invalid slate ID")
 	}
 
 	// Fetch from cloud
-	return cs.fetchOne(cloudID)
+	slate, err := cs.fetchOne(cloudID)
+	if cs.readOnly && errors.Is(err, ErrOffline) {
+		if cached := cs.cachedCopy(id, cloudID, false); cached != nil {
+			return cached, nil
+		}
+	}
+	if cs.cache == nil {
+		return slate, err
+	}
+
+	if errors.Is(err, ErrOffline) {
+		cs.offline = true
+		if cached := cs.cache.get(id, cloudID); cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cs.offline = false
+	cs.cache.put(slate, cs.cache.key(slate), true)
+	return slate, nil
+}
+
+func (cs *CloudStorage) hasPending(cloudID int) bool {
+	for _, p := range cs.cache.pending() {
+		if p.CloudID == cloudID {
+			return true
+		}
+	}
+	return false
 }
 
 func (cs *CloudStorage) List() ([]*Slate, error) {
-	// Fetch metadata only from cloud
-	req, _ := http.NewRequest("GET", cs.apiURL+"/api/slates", nil)
-	req.Header.Set("Authorization", "Bearer "+cs.token)
-	req.Header.Set("User-Agent", "justtype-cli/2.3")
-	cs.addVersionHeader(req)
+	if cs.readOnly {
+		return cs.listReadOnly()
+	}
+
+	if cs.queue.len() > 0 {
+		cs.FlushQueue()
+	}
+
+	if cs.cache == nil {
+		slates, err := cs.listRemote()
+		SortSlates(slates)
+		return slates, err
+	}
+
+	cs.flush()
 
-	resp, err := cs.client.Do(req)
+	slates, err := cs.listRemote()
+	if errors.Is(err, ErrOffline) {
+		cs.offline = true
+		return cs.cache.list(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cs.offline = false
+	cs.cache.refresh(slates)
+	SortSlates(slates)
+	return slates, nil
+}
+
+// flush pushes edits made while offline. It stops at the first request
+// that can't reach the server; the rest stay queued.
+func (cs *CloudStorage) flush() {
+	for _, p := range cs.cache.pending() {
+		slate := fromCache(p)
+		if err := cs.push(slate); err != nil {
+			if errors.Is(err, ErrOffline) {
+				return
+			}
+			continue
+		}
+		cs.cache.put(slate, p.ID, true)
+	}
+}
+
+func (cs *CloudStorage) listRemote() ([]*Slate, error) {
+	// Fetch metadata only from cloud
+	resp, err := cs.send("GET", cs.apiURL+"/api/slates", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -146,41 +598,35 @@ func (cs *CloudStorage) List() ([]*Slate, error) {
 
 	cs.checkVersionHeader(resp)
 
+	if err := api.CheckRateLimit(resp); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, api.ErrSessionExpired
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("failed to list slates: %d", resp.StatusCode)
 	}
 
-	var cloudSlates []struct {
-		ID          int    `json:"id"`
-		Title       string `json:"title"`
-		WordCount   int    `json:"word_count"`
-		IsPublished int    `json:"is_published"`
-		ShareID     string `json:"share_id"`
-		CreatedAt   string `json:"created_at"`
-		UpdatedAt   string `json:"updated_at"`
-	}
-
+	var cloudSlates []api.Slate
 	if err := json.NewDecoder(resp.Body).Decode(&cloudSlates); err != nil {
 		return nil, err
 	}
 
 	// Convert to Slate objects (metadata only, no content)
 	slates := make([]*Slate, 0, len(cloudSlates))
-	for _, cs := range cloudSlates {
-		createdAt, _ := time.Parse(time.RFC3339, cs.CreatedAt)
-		updatedAt, _ := time.Parse(time.RFC3339, cs.UpdatedAt)
-
-		slates = append(slates, &Slate{
-			ID:          fmt.Sprintf("cloud-%d", cs.ID),
-			Title:       cs.Title,
-			Content:     "", // No content in list view
-			WordCount:   cs.WordCount,
-			CreatedAt:   createdAt,
-			UpdatedAt:   updatedAt,
-			CloudID:     cs.ID,
-			IsPublished: cs.IsPublished == 1,
-			ShareID:     cs.ShareID,
-		})
+	for _, c := range cloudSlates {
+		m, err := model.FromAPI(c)
+		if err != nil {
+			cs.warn(err)
+		}
+
+		m.Title, err = e2e.Open(cs.key, c.Title)
+		if err != nil {
+			m.Title = "encrypted slate"
+		}
+
+		slates = append(slates, FromModel(m))
 	}
 
 	return slates, nil
@@ -191,23 +637,53 @@ func (cs *CloudStorage) Delete(id string) error {
 	var cloudID int
 	fmt.Sscanf(id, "cloud-%d", &cloudID)
 
+	if cloudID == 0 {
+		if queued := cs.queue.cloudID(id); queued > 0 {
+			cloudID = queued
+		} else if op, ok := cs.queue.get(id); ok && op.Op == opCreate {
+			// Created offline and never uploaded: dropping it from the
+			// queue is the whole delete
+			if full, err := cs.Load(id); err == nil {
+				cs.trash.add(full)
+			}
+			return cs.queue.add(queuedOp{Op: opDelete, ID: id})
+		}
+	}
+
+	if cloudID == 0 && cs.cache != nil {
+		// Created offline and never uploaded: it only exists in the cache
+		if cached := cs.cache.get(id, 0); cached != nil && cached.CloudID == 0 {
+			cs.trash.add(cached)
+			cs.cache.remove(id)
+			return nil
+		}
+	}
+
 	if cloudID == 0 {
 		return fmt.Errorf("invalid slate ID")
 	}
 
-	// Delete from cloud
-	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/slates/%d", cs.apiURL, cloudID), nil)
-	req.Header.Set("Authorization", "Bearer "+cs.token)
-	req.Header.Set("User-Agent", "justtype-cli/2.2")
+	// Keep a local copy so the delete can be undone
+	full, fetchErr := cs.Load(id)
 
-	resp, err := cs.client.Do(req)
+	// Delete from cloud, or queue it for when we're back online
+	err := cs.deleteRemote(cloudID)
+	if errors.Is(err, ErrOffline) {
+		cs.offline = true
+		err = cs.queue.add(queuedOp{Op: opDelete, ID: id, CloudID: cloudID})
+	}
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("delete failed: %d", resp.StatusCode)
+	if fetchErr == nil {
+		cs.trash.add(full)
+	}
+
+	if cs.cache != nil {
+		if key := cs.cache.key(&Slate{Slate: model.Slate{ID: id, CloudID: cloudID}}); key != "" {
+			cs.cache.remove(key)
+		}
 	}
 
 	// Delete temp file if it matches
@@ -218,19 +694,62 @@ func (cs *CloudStorage) Delete(id string) error {
 	return nil
 }
 
+// deleteRemote deletes a slate on the server. One that's already gone
+// counts as deleted.
+func (cs *CloudStorage) deleteRemote(cloudID int) error {
+	resp, err := cs.send("DELETE", fmt.Sprintf("%s/api/slates/%d", cs.apiURL, cloudID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return nil
+	case http.StatusUnauthorized:
+		return api.ErrSessionExpired
+	}
+	return &statusError{action: "delete", code: resp.StatusCode}
+}
+
+// Restore re-creates a deleted slate in the cloud from its local copy
+func (cs *CloudStorage) Restore(id string) (*Slate, error) {
+	slate, err := cs.trash.take(id)
+	if err != nil {
+		return nil, err
+	}
+
+	original := *slate
+
+	// The cloud copy is gone, so this becomes a new slate
+	slate.ID = ""
+	slate.CloudID = 0
+	slate.IsPublished = false
+	slate.ShareID = ""
+
+	if err := cs.Save(slate); err != nil {
+		cs.trash.add(&original)
+		return nil, err
+	}
+
+	// Restoring is deliberate, so don't sweep it up again as blank
+	slate.Pristine = false
+
+	return slate, nil
+}
+
 func (cs *CloudStorage) Close() error {
+	if !cs.session {
+		return nil
+	}
 	// Clean up temp file on exit
 	cs.deleteTempFile()
-	return nil
+	cs.session = false
+	return os.Remove(filepath.Join(cs.tempDir, sessionFileName))
 }
 
 func (cs *CloudStorage) fetchOne(cloudID int) (*Slate, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/slates/%d", cs.apiURL, cloudID), nil)
-	req.Header.Set("Authorization", "Bearer "+cs.token)
-	req.Header.Set("User-Agent", "justtype-cli/2.3")
-	cs.addVersionHeader(req)
-
-	resp, err := cs.client.Do(req)
+	resp, err := cs.send("GET", fmt.Sprintf("%s/api/slates/%d", cs.apiURL, cloudID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -238,48 +757,38 @@ func (cs *CloudStorage) fetchOne(cloudID int) (*Slate, error) {
 
 	cs.checkVersionHeader(resp)
 
+	if err := api.CheckRateLimit(resp); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Expired, or ENCRYPTION_KEY_MISSING, and the refresh didn't help
+		return nil, api.ErrSessionExpired
+	}
 	if resp.StatusCode != http.StatusOK {
-		// Check if it's an encryption key error
-		body, _ := io.ReadAll(resp.Body)
-		var errResp struct {
-			Error string `json:"error"`
-			Code  string `json:"code"`
-		}
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Code == "ENCRYPTION_KEY_MISSING" {
-			return nil, fmt.Errorf("SESSION_EXPIRED")
-		}
 		return nil, fmt.Errorf("failed to fetch slate: %d", resp.StatusCode)
 	}
 
-	var apiSlate struct {
-		ID          int    `json:"id"`
-		Title       string `json:"title"`
-		Content     string `json:"content"`
-		WordCount   int    `json:"word_count"`
-		IsPublished int    `json:"is_published"`
-		ShareID     string `json:"share_id"`
-		CreatedAt   string `json:"created_at"`
-		UpdatedAt   string `json:"updated_at"`
-	}
-
+	var apiSlate api.Slate
 	if err := json.NewDecoder(resp.Body).Decode(&apiSlate); err != nil {
 		return nil, err
 	}
 
-	createdAt, _ := time.Parse(time.RFC3339, apiSlate.CreatedAt)
-	updatedAt, _ := time.Parse(time.RFC3339, apiSlate.UpdatedAt)
+	title, err := e2e.Open(cs.key, apiSlate.Title)
+	if err != nil {
+		return nil, err
+	}
+	content, err := e2e.Open(cs.key, apiSlate.Content)
+	if err != nil {
+		return nil, err
+	}
+	apiSlate.Title, apiSlate.Content = title, content
 
-	return &Slate{
-		ID:          fmt.Sprintf("cloud-%d", apiSlate.ID),
-		Title:       apiSlate.Title,
-		Content:     apiSlate.Content,
-		WordCount:   apiSlate.WordCount,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
-		CloudID:     apiSlate.ID,
-		IsPublished: apiSlate.IsPublished == 1,
-		ShareID:     apiSlate.ShareID,
-	}, nil
+	// Tags come from the content; the server doesn't keep them
+	m, err := model.FromAPI(apiSlate)
+	if err != nil {
+		cs.warn(err)
+	}
+	return FromModel(m), nil
 }
 
 // Publish publishes a slate and returns share URL
@@ -287,49 +796,37 @@ func (cs *CloudStorage) Publish(slate *Slate) (string, error) {
 	if slate.CloudID == 0 {
 		return "", fmt.Errorf("slate must be saved to cloud first")
 	}
+	if cs.key != nil {
+		return "", fmt.Errorf("end-to-end encrypted slates can't be published")
+	}
 
-	body := map[string]interface{}{"isPublished": true}
-	jsonData, _ := json.Marshal(body)
-
-	req, _ := http.NewRequest("PATCH", fmt.Sprintf("%s/api/slates/%d/publish", cs.apiURL, slate.CloudID), bytes.NewReader(jsonData))
-	req.Header.Set("Authorization", "Bearer "+cs.token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "justtype-cli/2.2")
-
-	resp, err := cs.client.Do(req)
+	result, err := cs.api.PublishSlate(slate.CloudID)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
 
-		// Try to parse as JSON to check for ENCRYPTION_KEY_MISSING
-		var errResp struct {
-			Error string `json:"error"`
-			Code  string `json:"code"`
-		}
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Code == "ENCRYPTION_KEY_MISSING" {
-			return "", fmt.Errorf("SESSION_EXPIRED")
-		}
+	slate.IsPublished = true
+	slate.ShareID = result.ShareID
+	return result.ShareURL, nil
+}
 
-		return "", fmt.Errorf("publish failed: %s", string(body))
+// SetPinned pins a slate above the rest of List, or unpins it. The pin is
+// kept by the server, so it needs the slate uploaded and a connection.
+func (cs *CloudStorage) SetPinned(id string, pinned bool) error {
+	var cloudID int
+	fmt.Sscanf(id, "cloud-%d", &cloudID)
+	if cloudID == 0 {
+		return fmt.Errorf("slate must be saved to cloud first")
 	}
 
-	var result struct {
-		ShareID  string `json:"shareId"`
-		ShareURL string `json:"shareUrl"`
+	if err := cs.api.SetPinned(cloudID, pinned); err != nil {
+		return err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	if cs.cache != nil {
+		cs.cache.setPinned(cloudID, pinned)
 	}
-
-	slate.IsPublished = true
-	slate.ShareID = result.ShareID
-
-	return result.ShareURL, nil
+	return nil
 }
 
 // Unpublish unpublishes a slate
@@ -338,23 +835,9 @@ func (cs *CloudStorage) Unpublish(slate *Slate) error {
 		return fmt.Errorf("slate must be saved to cloud first")
 	}
 
-	body := map[string]interface{}{"isPublished": false}
-	jsonData, _ := json.Marshal(body)
-
-	req, _ := http.NewRequest("PATCH", fmt.Sprintf("%s/api/slates/%d/publish", cs.apiURL, slate.CloudID), bytes.NewReader(jsonData))
-	req.Header.Set("Authorization", "Bearer "+cs.token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "justtype-cli/2.2")
-
-	resp, err := cs.client.Do(req)
-	if err != nil {
+	if err := cs.api.UnpublishSlate(slate.CloudID); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unpublish failed")
-	}
 
 	slate.IsPublished = false
 	slate.ShareID = ""
@@ -362,9 +845,13 @@ func (cs *CloudStorage) Unpublish(slate *Slate) error {
 	return nil
 }
 
-// Temp file management for current editing session
+// Temp file management for current editing session. Without one the temp
+// file is another process's, and left alone.
 func (cs *CloudStorage) saveTempFile(slate *Slate) error {
-	tempFile := filepath.Join(cs.tempDir, "current.json")
+	if !cs.session {
+		return nil
+	}
+	tempFile := filepath.Join(cs.tempDir, tempFileName)
 	data, err := json.MarshalIndent(slate, "", "  ")
 	if err != nil {
 		return err
@@ -374,7 +861,10 @@ func (cs *CloudStorage) saveTempFile(slate *Slate) error {
 }
 
 func (cs *CloudStorage) loadTempFile() (*Slate, error) {
-	tempFile := filepath.Join(cs.tempDir, "current.json")
+	if !cs.session {
+		return nil, os.ErrNotExist
+	}
+	tempFile := filepath.Join(cs.tempDir, tempFileName)
 	data, err := os.ReadFile(tempFile)
 	if err != nil {
 		return nil, err
@@ -389,21 +879,37 @@ func (cs *CloudStorage) loadTempFile() (*Slate, error) {
 }
 
 func (cs *CloudStorage) deleteTempFile() error {
-	tempFile := filepath.Join(cs.tempDir, "current.json")
+	if !cs.session {
+		return nil
+	}
+	tempFile := filepath.Join(cs.tempDir, tempFileName)
 	cs.currentFile = ""
 	return os.Remove(tempFile)
 }
 
+// Warnings returns what went wrong, but not badly enough to fail a request,
+// since it was last called: timestamps from the server that had to be
+// filled in, for now
+func (cs *CloudStorage) Warnings() []string {
+	cs.warnMu.Lock()
+	defer cs.warnMu.Unlock()
+	warnings := cs.warnings
+	cs.warnings = nil
+	return warnings
+}
+
+func (cs *CloudStorage) warn(err error) {
+	cs.warnMu.Lock()
+	defer cs.warnMu.Unlock()
+	cs.warnings = append(cs.warnings, err.Error())
+}
+
 // GetLatestVersion returns the latest version from server (if checked)
 func (cs *CloudStorage) GetLatestVersion() string {
 	return cs.latestVersion
 }
 
-// addVersionHeader adds CLI version to request and checks response for latest version
-func (cs *CloudStorage) addVersionHeader(req *http.Request) {
-	req.Header.Set("X-CLI-Version", updater.GetVersion())
-}
-
+// checkVersionHeader records the latest version the server advertises
 func (cs *CloudStorage) checkVersionHeader(resp *http.Response) {
 	if latestVersion := resp.Header.Get("X-Latest-Version"); latestVersion != "" {
 		cs.latestVersion = latestVersion
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/updater"
+)
+
+// fakeServer records each request's method, path and body, and answers it
+// with handle
+type fakeServer struct {
+	mu       sync.Mutex
+	requests []string // "METHOD /path body"
+	handle   func(w http.ResponseWriter, r *http.Request, body string)
+}
+
+func (f *fakeServer) start(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path+" "+string(body))
+		f.mu.Unlock()
+		f.handle(w, r, string(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func (f *fakeServer) seen() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func newTestCloud(t *testing.T, apiURL string) *CloudStorage {
+	t.Helper()
+	cs, err := NewCloud(t.TempDir(), apiURL, "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
+
+func TestCloudPublishMatchesClient(t *testing.T) {
+	f := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, body string) {
+		io.WriteString(w, `{"share_id":"abc","share_url":"https://justtype.io/s/abc"}`)
+	}}
+	cs := newTestCloud(t, f.start(t))
+
+	slate := &Slate{Slate: model.Slate{ID: "cloud-7", CloudID: 7}}
+	url, err := cs.Publish(slate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://justtype.io/s/abc" || !slate.IsPublished || slate.ShareID != "abc" {
+		t.Fatalf("got %q, %+v", url, slate)
+	}
+	if err := cs.Unpublish(slate); err != nil {
+		t.Fatal(err)
+	}
+	if slate.IsPublished || slate.ShareID != "" {
+		t.Fatalf("still published after Unpublish: %+v", slate)
+	}
+
+	want := []string{
+		`PATCH /api/slates/7/publish {"isPublished":true}`,
+		`PATCH /api/slates/7/publish {"isPublished":false}`,
+	}
+	got := f.seen()
+	if len(got) != len(want) {
+		t.Fatalf("requests = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFlushQueueKeepsWhatTheServerDidntTake(t *testing.T) {
+	serverDown := true
+	f := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, body string) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/slates":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": 9}`)
+		case "PUT /api/slates/5": // deleted on the server meanwhile
+			w.WriteHeader(http.StatusNotFound)
+		case "PUT /api/slates/6":
+			if serverDown {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			io.WriteString(w, `{}`)
+		case "DELETE /api/slates/8":
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}}
+	cs := newTestCloud(t, f.start(t))
+
+	for _, s := range []*Slate{
+		{Slate: model.Slate{Content: "written offline"}},
+		{Slate: model.Slate{ID: "cloud-5", CloudID: 5, Content: "edit to a slate that's gone"}},
+		{Slate: model.Slate{ID: "cloud-6", CloudID: 6, Content: "edit during an outage"}},
+	} {
+		if err := cs.enqueue(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cs.queue.add(queuedOp{Op: opDelete, ID: "cloud-8", CloudID: 8}); err != nil {
+		t.Fatal(err)
+	}
+
+	flushed, err := cs.FlushQueue()
+	if flushed != 1 {
+		t.Fatalf("flushed %d, want only the create", flushed)
+	}
+	if err == nil {
+		t.Fatal("want the first failure reported")
+	}
+
+	// The server error stays queued to try again
+	left := cs.queue.list()
+	if len(left) != 1 || left[0].ID != "cloud-6" {
+		t.Fatalf("still queued: %+v, want only cloud-6", left)
+	}
+
+	// The refused ones are kept, content and all
+	kept, _ := filepath.Glob(filepath.Join(cs.tempDir, recoveredDir, "queued-*.json"))
+	if len(kept) != 2 {
+		t.Fatalf("set aside %v, want the refused update and delete", kept)
+	}
+	found := false
+	for _, path := range kept {
+		data, _ := os.ReadFile(path)
+		var op queuedOp
+		if err := json.Unmarshal(data, &op); err != nil {
+			t.Fatal(err)
+		}
+		if op.ID == "cloud-5" {
+			found = op.Content == "edit to a slate that's gone"
+		}
+	}
+	if !found {
+		t.Fatal("the refused edit's content wasn't kept")
+	}
+
+	// Once the server's back the rest goes through
+	serverDown = false
+	if flushed, err := cs.FlushQueue(); flushed != 1 || err != nil {
+		t.Fatalf("second flush = %d, %v; want 1, nil", flushed, err)
+	}
+	if n := cs.queue.len(); n != 0 {
+		t.Fatalf("%d still queued", n)
+	}
+}
+
+func TestFlushQueueStopsOffline(t *testing.T) {
+	cs := newTestCloud(t, "http://127.0.0.1:1")
+	if err := cs.enqueue(&Slate{Slate: model.Slate{ID: "cloud-3", CloudID: 3, Content: "edit"}}); err != nil {
+		t.Fatal(err)
+	}
+	flushed, err := cs.FlushQueue()
+	if flushed != 0 || err != nil {
+		t.Fatalf("FlushQueue = %d, %v; want 0, nil while offline", flushed, err)
+	}
+	if cs.queue.len() != 1 || !cs.Offline() {
+		t.Fatal("offline flush lost the queued write")
+	}
+}
+
+func TestCloudRefreshesExpiredSession(t *testing.T) {
+	f := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, body string) {
+		switch {
+		case r.URL.Path == "/api/auth/refresh":
+			io.WriteString(w, `{"token":"fresh","refresh_token":"r2"}`)
+		case r.Header.Get("Authorization") != "Bearer fresh":
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"code":"ENCRYPTION_KEY_MISSING"}`)
+		default:
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": 4}`)
+		}
+	}}
+	cs := newTestCloud(t, f.start(t))
+	cs.SetRefreshToken("r1")
+	var saved string
+	cs.OnTokenRefresh(func(token, refreshToken string) { saved = token + " " + refreshToken })
+
+	slate := &Slate{Slate: model.Slate{Content: "written as the session ran out"}}
+	if err := cs.Save(slate); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if slate.CloudID != 4 {
+		t.Fatalf("CloudID = %d, want the server's 4", slate.CloudID)
+	}
+	if saved != "fresh r2" {
+		t.Fatalf("saved %q, want the refreshed tokens", saved)
+	}
+	want := []string{
+		"POST /api/slates",
+		"POST /api/auth/refresh",
+		"POST /api/slates",
+	}
+	seen := f.seen()
+	if len(seen) != len(want) {
+		t.Fatalf("requests %q, want %q", seen, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(seen[i], want[i]+" ") {
+			t.Fatalf("requests %q, want %q", seen, want)
+		}
+	}
+}
+
+func TestCloudSessionExpiredWithoutRefreshToken(t *testing.T) {
+	f := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, body string) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}}
+	cs := newTestCloud(t, f.start(t))
+
+	if err := cs.Save(&Slate{Slate: model.Slate{Content: "hello"}}); !errors.Is(err, api.ErrSessionExpired) {
+		t.Fatalf("got %v, want ErrSessionExpired", err)
+	}
+	for _, req := range f.seen() {
+		if strings.Contains(req, "/api/auth/refresh") {
+			t.Fatal("tried to refresh without a refresh token")
+		}
+	}
+}
+
+func TestCloudSendsVersion(t *testing.T) {
+	var headers []http.Header
+	f := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, body string) {
+		headers = append(headers, r.Header.Clone())
+		io.WriteString(w, `[]`)
+	}}
+	cs := newTestCloud(t, f.start(t))
+	if _, err := cs.List(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(headers) == 0 {
+		t.Fatal("List made no requests")
+	}
+	for _, h := range headers {
+		if h.Get("User-Agent") != updater.UserAgent() || h.Get("X-CLI-Version") != updater.GetVersion() {
+			t.Fatalf("sent User-Agent %q, X-CLI-Version %q", h.Get("User-Agent"), h.Get("X-CLI-Version"))
+		}
+	}
+}
+
+func TestCloudListFillsUnreadableTimes(t *testing.T) {
+	server := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, _ string) {
+		json.NewEncoder(w).Encode([]api.Slate{
+			{ID: 1, Title: "old", CreatedAt: "2026-01-01T00:00:00Z", UpdatedAt: "2026-01-02T00:00:00Z"},
+			{ID: 2, Title: "sqlite format", CreatedAt: "2026-04-01 00:00:00", UpdatedAt: "2026-05-01 09:30:00"},
+			{ID: 3, Title: "no update time", CreatedAt: "2026-03-01T00:00:00Z", UpdatedAt: "garbled"},
+		})
+	}}
+	cs := newTestCloud(t, server.start(t))
+
+	slates, err := cs.List()
+	if err != nil {
+		t.Fatalf("one bad timestamp failed the whole list: %v", err)
+	}
+	var order []string
+	for _, slate := range slates {
+		if slate.UpdatedAt.IsZero() || slate.CreatedAt.IsZero() {
+			t.Fatalf("%s has a zero time", slate.Title)
+		}
+		order = append(order, slate.Title)
+	}
+	if got := strings.Join(order, ", "); got != "sqlite format, no update time, old" {
+		t.Fatalf("listed %s, want newest first with the filled-in time", got)
+	}
+
+	warnings := cs.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "slate 3") {
+		t.Fatalf("warnings %q, want one for slate 3", warnings)
+	}
+	if again := cs.Warnings(); len(again) != 0 {
+		t.Fatalf("warnings repeated: %q", again)
+	}
+}
+
+func TestCloudOfflineNamesServer(t *testing.T) {
+	cs := newTestCloud(t, "http://127.0.0.1:1")
+	_, err := cs.fetchOne(3)
+	if !errors.Is(err, ErrOffline) || !errors.Is(err, api.ErrOffline) {
+		t.Fatalf("got %v, want ErrOffline", err)
+	}
+	for _, err := range []error{err, cs.OfflineError()} {
+		if msg := err.Error(); !strings.HasPrefix(msg, "can't reach 127.0.0.1:1") {
+			t.Fatalf("message %q, want it to name the configured server", msg)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/e2e"
+)
+
+// CountLocal counts the slates stored at storagePath by backend without
+// changing anything there: no migration, recovery or setting aside, which
+// opening the storage may do. It returns atrest.ErrLocked if they're
+// encrypted at rest.
+func CountLocal(storagePath, backend string) (int, error) {
+	if backend == BackendSQLite {
+		dbPath := filepath.Join(storagePath, "slates.db")
+		if _, err := os.Stat(dbPath); err == nil {
+			return countSQLite(dbPath)
+		}
+		// Not opened yet: it starts with what slates.json holds
+	}
+	return countJSON(filepath.Join(storagePath, "slates.json"))
+}
+
+// CountCloud reports what the offline cache in cacheDir and the write
+// queue in tempDir hold, without changing either: how many slates, how many
+// edits are waiting for the server, and when the cache last synced. key
+// opens a cache encrypted end-to-end; without it that's atrest.ErrLocked.
+func CountCloud(cacheDir, tempDir string, key *e2e.Key) (slates, pending int, lastSync time.Time, err error) {
+	q, err := newQueue(filepath.Join(tempDir, "queue.jsonl"))
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	pending = len(q.ops)
+
+	raw, err := readRaw(filepath.Join(cacheDir, "slates.json"), key)
+	if os.IsNotExist(err) {
+		return 0, pending, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, pending, time.Time{}, err
+	}
+	for _, r := range raw {
+		var entry struct {
+			Synced bool `json:"synced"`
+		}
+		if err := json.Unmarshal(r, &entry); err == nil && !entry.Synced {
+			pending++
+		}
+	}
+	c := &cache{dir: cacheDir}
+	return len(raw), pending, c.lastSync(), nil
+}
+
+// countJSON counts the slates in a slates.json, or its staged copy if a
+// save was cut short, as loading would find them
+func countJSON(path string) (int, error) {
+	raw, err := readRaw(path, nil)
+	switch {
+	case err == nil:
+		return len(raw), nil
+	case os.IsNotExist(err):
+		return 0, nil
+	case errors.Is(err, atrest.ErrLocked):
+		return 0, err
+	}
+	if staged, tmpErr := readRaw(atrest.TempPath(path), nil); tmpErr == nil {
+		return len(staged), nil
+	}
+	return 0, fmt.Errorf("%s can't be read: %w", filepath.Base(path), err)
+}
+
+// readRaw reads a JSON array of slates, decrypting it with key, and fails
+// with atrest.ErrLocked if it's encrypted and key is nil
+func readRaw(path string, key *e2e.Key) ([]json.RawMessage, error) {
+	data, err := atrest.ReadFile(path, key)
+	if err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// countSQLite counts the rows in a slates.db, opened read-only
+func countSQLite(dbPath string) (int, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM slates`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s can't be read: %w", filepath.Base(dbPath), err)
+	}
+	return n, nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+)
+
+// DuplicateGroup is a set of slates with the same content. Keep is the one
+// a merge holds on to; Extra are trashed.
+type DuplicateGroup struct {
+	Keep  *Slate
+	Extra []*Slate
+}
+
+// FindDuplicates loads every slate and groups those whose content matches,
+// ignoring surrounding whitespace and line endings. Empty slates are left
+// out. Groups are ordered by the kept slate, newest first.
+func FindDuplicates(s Storage) ([]DuplicateGroup, error) {
+	slates, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[[sha256.Size]byte][]*Slate)
+	var order [][sha256.Size]byte
+	for _, meta := range slates {
+		// Listings can leave content out (cloud storage does)
+		slate, err := s.Load(meta.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		content := strings.TrimSpace(strings.ReplaceAll(slate.Content, "\r\n", "\n"))
+		if content == "" {
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(content))
+		if _, ok := byHash[sum]; !ok {
+			order = append(order, sum)
+		}
+		byHash[sum] = append(byHash[sum], slate)
+	}
+
+	var groups []DuplicateGroup
+	for _, sum := range order {
+		if same := byHash[sum]; len(same) > 1 {
+			groups = append(groups, groupDuplicates(same))
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Keep.UpdatedAt.After(groups[j].Keep.UpdatedAt)
+	})
+	return groups, nil
+}
+
+// groupDuplicates picks which copy to keep: one that's synced to the cloud
+// if there is one, then the most recently updated
+func groupDuplicates(same []*Slate) DuplicateGroup {
+	sort.SliceStable(same, func(i, j int) bool {
+		a, b := same[i], same[j]
+		if (a.CloudID > 0) != (b.CloudID > 0) {
+			return a.CloudID > 0
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	return DuplicateGroup{Keep: same[0], Extra: same[1:]}
+}
+
+// MergeDuplicates trashes the extra copies in groups and returns how many
+// it removed. It stops at the first delete that fails.
+func MergeDuplicates(s Storage, groups []DuplicateGroup) (int, error) {
+	removed := 0
+	for _, g := range groups {
+		for _, extra := range g.Extra {
+			if err := s.Delete(extra.ID); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+func TestFindDuplicatesGroups(t *testing.T) {
+	ls, _ := newTestLocal(t)
+	base := time.Now().Add(-time.Hour)
+	save := func(id, content string, age time.Duration, cloudID int) {
+		t.Helper()
+		slate := &Slate{Slate: model.Slate{ID: id, Content: content, CloudID: cloudID}}
+		if err := ls.Save(slate); err != nil {
+			t.Fatal(err)
+		}
+		// Save stamps UpdatedAt; set it back to when this copy was written
+		ls.slates[id].UpdatedAt = base.Add(-age)
+	}
+
+	save("old", "Groceries\n\neggs", 3*time.Minute, 0)
+	save("synced", "Groceries\n\neggs\n", 2*time.Minute, 7)      // trailing newline doesn't matter
+	save("new", "  Groceries\r\n\r\neggs", time.Minute, 0)       // nor line endings or edge spaces
+	save("journal-a", "Journal\n\nquiet day", 10*time.Minute, 0) // newest journal copy
+	save("journal-b", "Journal\n\nquiet day", 20*time.Minute, 0)
+	save("unique", "Something else", 0, 0)
+	save("blank-a", "   ", 0, 0)
+	save("blank-b", "", 0, 0)
+
+	groups, err := FindDuplicates(ls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("found %d groups, want groceries and journal", len(groups))
+	}
+
+	// Ordered by the kept slate, newest first
+	groceries, journal := groups[0], groups[1]
+	if groceries.Keep.ID != "synced" {
+		t.Errorf("kept %s of the groceries, want the synced copy over a newer one", groceries.Keep.ID)
+	}
+	if got := slateIDs(groceries.Extra); got != "new old" {
+		t.Errorf("groceries extras = %s, want new old", got)
+	}
+	if journal.Keep.ID != "journal-a" || slateIDs(journal.Extra) != "journal-b" {
+		t.Errorf("journal kept %s, extras %s; want the newest kept", journal.Keep.ID, slateIDs(journal.Extra))
+	}
+}
+
+func TestMergeDuplicatesTrashesExtras(t *testing.T) {
+	ls, _ := newTestLocal(t)
+	for _, content := range []string{"same", "same", "same", "different"} {
+		if err := ls.Save(&Slate{Slate: model.Slate{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := FindDuplicates(ls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := MergeDuplicates(ls, groups)
+	if err != nil || removed != 2 {
+		t.Fatalf("MergeDuplicates = %d, %v; want 2 removed", removed, err)
+	}
+
+	slates, _ := ls.List()
+	if len(slates) != 2 {
+		t.Fatalf("%d slates left, want one of each", len(slates))
+	}
+	if _, err := ls.Load(groups[0].Keep.ID); err != nil {
+		t.Fatalf("kept slate gone: %v", err)
+	}
+	if again, _ := FindDuplicates(ls); len(again) != 0 {
+		t.Fatalf("still %d duplicate groups after merging", len(again))
+	}
+	for _, extra := range groups[0].Extra {
+		if _, err := ls.Restore(extra.ID); err != nil {
+			t.Fatalf("trashed copy can't be restored: %v", err)
+		}
+	}
+}
+
+func slateIDs(slates []*Slate) string {
+	var s string
+	for i, slate := range slates {
+		if i > 0 {
+			s += " "
+		}
+		s += slate.ID
+	}
+	return s
+}
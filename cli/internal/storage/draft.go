@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Files in CloudStorage's temp directory
+const (
+	tempFileName    = "current.json" // the slate being edited, until a save reaches the server
+	draftFileName   = "draft.json"   // a temp file a crashed session left, waiting on Draft
+	recoveredDir    = "recovered"    // drafts set aside instead of restored
+	sessionFileName = "session.pid"  // the process whose editor owns the temp file
+)
+
+// ErrSessionRunning is returned by StartSession when another justtype is
+// editing with the same account
+var ErrSessionRunning = errors.New("justtype is already running for this account; its unsaved edits are left to it")
+
+// Draft is what a session that ended without closing left in the temp
+// file: the slate as it was being edited, and when it was written
+type Draft struct {
+	Slate   *Slate
+	SavedAt time.Time
+}
+
+// StartSession makes this the editor's storage: saves keep the slate being
+// edited in the temp file until they reach the server, and Close clears it.
+// A temp file left by a session that's no longer running becomes the draft
+// (see Draft) first. Headless commands don't call it, so they never touch
+// a running editor's temp file. If that editor is another justtype still
+// running, it returns ErrSessionRunning and the storage works without one.
+func (cs *CloudStorage) StartSession() error {
+	path := filepath.Join(cs.tempDir, sessionFileName)
+	if data, err := os.ReadFile(path); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && pid != os.Getpid() && processAlive(pid) {
+			return ErrSessionRunning
+		}
+	}
+
+	// Left by a session that crashed before its save got through
+	cs.takeDraft()
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		return err
+	}
+	cs.session = true
+	return nil
+}
+
+// processAlive reports whether the process pid is still running
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens the process there, which fails once it's gone
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// takeDraft moves a temp file left by a session that didn't close out of
+// the way, so saves made in this one can't write over it before it's been
+// looked at
+func (cs *CloudStorage) takeDraft() {
+	current := filepath.Join(cs.tempDir, tempFileName)
+	if _, err := os.Stat(current); err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(cs.tempDir, draftFileName)); err == nil {
+		// An older one nobody answered for
+		cs.SetAsideDraft()
+	}
+	os.Rename(current, filepath.Join(cs.tempDir, draftFileName))
+}
+
+// Draft returns the draft a crashed session left, or nil if there isn't
+// one or its content is safe already: on the server, in the offline queue
+// or in the cache. A draft returned stays until RestoreDraft or
+// SetAsideDraft is called with it.
+func (cs *CloudStorage) Draft() (*Draft, error) {
+	path := filepath.Join(cs.tempDir, draftFileName)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var slate Slate
+	if err := json.Unmarshal(data, &slate); err != nil {
+		// Keep it for someone to read by hand
+		cs.SetAsideDraft()
+		return nil, fmt.Errorf("unsaved draft couldn't be read: %w", err)
+	}
+
+	if strings.TrimSpace(slate.Content) == "" || cs.hasCopy(&slate) {
+		return nil, os.Remove(path)
+	}
+	return &Draft{Slate: &slate, SavedAt: info.ModTime()}, nil
+}
+
+// hasCopy reports whether slate's content is kept somewhere besides the
+// draft. When the server can't be reached it isn't, as far as anyone knows.
+func (cs *CloudStorage) hasCopy(slate *Slate) bool {
+	if op, ok := cs.queue.get(slate.ID); ok && op.Content == slate.Content {
+		return true
+	}
+
+	cloudID := slate.CloudID
+	if cloudID == 0 {
+		fmt.Sscanf(slate.ID, "cloud-%d", &cloudID)
+	}
+	if cs.cache != nil {
+		if cached := cs.cache.get(slate.ID, cloudID); cached != nil && cached.Content == slate.Content {
+			return true
+		}
+	}
+	if cloudID == 0 {
+		return false
+	}
+
+	remote, err := cs.fetchOne(cloudID)
+	return err == nil && remote.Content == slate.Content
+}
+
+// RestoreDraft makes the draft the slate being edited again, so Load gives
+// it back until a save reaches the server
+func (cs *CloudStorage) RestoreDraft(d *Draft) error {
+	if err := cs.saveTempFile(d.Slate); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(cs.tempDir, draftFileName))
+}
+
+// SetAsideDraft moves the draft into the recovered folder, named for when
+// it was written, rather than deleting it. It returns where it went.
+func (cs *CloudStorage) SetAsideDraft() (string, error) {
+	path := filepath.Join(cs.tempDir, draftFileName)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(cs.tempDir, recoveredDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, "draft-"+info.ModTime().Format("2006-01-02-150405")+".json")
+	return dest, os.Rename(path, dest)
+}
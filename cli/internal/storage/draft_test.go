@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+// crashedSession leaves slate in tempDir's temp file as a session that
+// died mid-edit would, its session.pid naming a process that's gone
+func crashedSession(t *testing.T, tempDir, apiURL string, slate *Slate) {
+	t.Helper()
+	cs, err := NewCloud(tempDir, apiURL, "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.StartSession(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.saveTempFile(slate); err != nil {
+		t.Fatal(err)
+	}
+
+	// A pid nothing runs under any more: a child that has exited
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	pid := []byte(strconv.Itoa(cmd.Process.Pid))
+	if err := os.WriteFile(filepath.Join(tempDir, sessionFileName), pid, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serverWith answers GET /api/slates/4 with content, and anything else
+// with a 404
+func serverWith(t *testing.T, content string) string {
+	t.Helper()
+	f := &fakeServer{handle: func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.Method+" "+r.URL.Path != "GET /api/slates/4" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `{"id": 4, "content": "`+content+`"}`)
+	}}
+	return f.start(t)
+}
+
+func TestStartSessionOffersDraftAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	url := serverWith(t, "what the server has")
+	crashedSession(t, dir, url, &Slate{Slate: model.Slate{ID: "cloud-4", CloudID: 4, Content: "typed before the crash"}})
+
+	cs, err := NewCloud(dir, url, "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.StartSession(); err != nil {
+		t.Fatalf("StartSession with a stale session.pid: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, sessionFileName)); string(data) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("session.pid = %q, want this process", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, tempFileName)); !os.IsNotExist(err) {
+		t.Fatalf("temp file not taken as the draft: %v", err)
+	}
+
+	draft, err := cs.Draft()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft == nil || draft.Slate.Content != "typed before the crash" {
+		t.Fatalf("draft = %+v, want the crashed session's edit", draft)
+	}
+
+	if err := cs.RestoreDraft(draft); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := cs.Load("cloud-4")
+	if err != nil || loaded.Content != "typed before the crash" {
+		t.Fatalf("Load after RestoreDraft = %+v, %v", loaded, err)
+	}
+	if again, _ := cs.Draft(); again != nil {
+		t.Fatal("draft offered again after it was restored")
+	}
+}
+
+func TestDraftSkipsWhatTheServerHas(t *testing.T) {
+	dir := t.TempDir()
+	url := serverWith(t, "already pushed")
+	crashedSession(t, dir, url, &Slate{Slate: model.Slate{ID: "cloud-4", CloudID: 4, Content: "already pushed"}})
+
+	cs, err := NewCloud(dir, url, "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.StartSession(); err != nil {
+		t.Fatal(err)
+	}
+	if draft, err := cs.Draft(); draft != nil || err != nil {
+		t.Fatalf("Draft = %+v, %v; want none for content the server has", draft, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, draftFileName)); !os.IsNotExist(err) {
+		t.Fatalf("draft kept although the server has it: %v", err)
+	}
+}
+
+func TestSetAsideDraft(t *testing.T) {
+	dir := t.TempDir()
+	url := serverWith(t, "")
+	crashedSession(t, dir, url, &Slate{Slate: model.Slate{Content: "never pushed"}})
+
+	cs, err := NewCloud(dir, url, "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.StartSession(); err != nil {
+		t.Fatal(err)
+	}
+	if draft, _ := cs.Draft(); draft == nil {
+		t.Fatal("no draft offered")
+	}
+
+	path, err := cs.SetAsideDraft()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != filepath.Join(dir, recoveredDir) {
+		t.Fatalf("set aside in %s, want the recovered folder", path)
+	}
+	if data, err := os.ReadFile(path); err != nil || !json.Valid(data) {
+		t.Fatalf("set-aside draft unreadable: %v", err)
+	}
+	if draft, _ := cs.Draft(); draft != nil {
+		t.Fatal("draft offered again after it was set aside")
+	}
+}
+
+func TestStartSessionLeavesRunningSessionAlone(t *testing.T) {
+	dir := t.TempDir()
+	url := serverWith(t, "")
+	crashedSession(t, dir, url, &Slate{Slate: model.Slate{Content: "another justtype's edit"}})
+
+	// This test's parent is still running
+	pid := []byte(strconv.Itoa(os.Getppid()))
+	if err := os.WriteFile(filepath.Join(dir, sessionFileName), pid, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := NewCloud(dir, url, "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.StartSession(); !errors.Is(err, ErrSessionRunning) {
+		t.Fatalf("StartSession = %v, want ErrSessionRunning", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, tempFileName)); err != nil {
+		t.Fatalf("running session's temp file touched: %v", err)
+	}
+	if draft, _ := cs.Draft(); draft != nil {
+		t.Fatal("running session's edit offered as a draft")
+	}
+}
+
+func TestDraftUnreadableIsSetAside(t *testing.T) {
+	dir := t.TempDir()
+	cs, err := NewCloud(dir, serverWith(t, ""), "token", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, draftFileName), []byte(`{"content": "cut sh`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cs.Draft(); err == nil {
+		t.Fatal("unreadable draft not reported")
+	}
+	if set, _ := filepath.Glob(filepath.Join(dir, recoveredDir, "draft-*.json")); len(set) != 1 {
+		t.Fatalf("unreadable draft not set aside: %v", set)
+	}
+}
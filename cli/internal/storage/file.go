@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+// fileSlateID is the ID of the one slate in a FileStorage
+const fileSlateID = "file"
+
+// FileStorage edits a single text file in place, for `justtype <path>`.
+// Saves go straight back to the file; nothing is synced or kept anywhere
+// else.
+type FileStorage struct {
+	path  string
+	mode  os.FileMode
+	slate *Slate
+}
+
+// NewFile opens the text file at path for editing
+func NewFile(path string) (*FileStorage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s is not a text file", path)
+	}
+
+	content := string(data)
+	return &FileStorage{
+		path: path,
+		mode: info.Mode().Perm(),
+		slate: &Slate{
+			Slate: model.Slate{
+				ID:        fileSlateID,
+				Title:     filepath.Base(path),
+				Content:   content,
+				WordCount: CountWords(content),
+				CreatedAt: info.ModTime(),
+				UpdatedAt: info.ModTime(),
+			},
+		},
+	}, nil
+}
+
+// Path is the file being edited
+func (fs *FileStorage) Path() string {
+	return fs.path
+}
+
+// Slate is the file's content as a slate
+func (fs *FileStorage) Slate() *Slate {
+	return fs.slate
+}
+
+// Save writes the slate back to the file. Content is written exactly as
+// given; the file is the user's, so no cleanup is applied.
+func (fs *FileStorage) Save(slate *Slate) error {
+	if slate.ID != fileSlateID {
+		return fmt.Errorf("only %s can be edited in file mode", filepath.Base(fs.path))
+	}
+
+	if err := os.WriteFile(fs.path, []byte(slate.Content), fs.mode); err != nil {
+		return err
+	}
+
+	slate.UpdatedAt = time.Now()
+	slate.WordCount = CountWords(slate.Content)
+	fs.slate = slate
+	return nil
+}
+
+func (fs *FileStorage) Load(id string) (*Slate, error) {
+	if id != fileSlateID {
+		return nil, ErrNotFound
+	}
+	return fs.slate, nil
+}
+
+func (fs *FileStorage) List() ([]*Slate, error) {
+	return []*Slate{fs.slate}, nil
+}
+
+// Delete refuses; removing the user's file isn't the editor's job
+func (fs *FileStorage) Delete(id string) error {
+	return errors.New("files can't be deleted from the editor")
+}
+
+func (fs *FileStorage) Close() error {
+	return nil
+}
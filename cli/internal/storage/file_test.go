@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	// Written as the user left it: CRLF, trailing spaces, no final newline
+	original := "# Notes  \r\n\r\nfirst draft"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate := fs.Slate()
+	if slate.Title != "notes.md" || slate.Content != original || slate.WordCount != 3 {
+		t.Fatalf("loaded %+v", slate.Slate)
+	}
+
+	slate.Content = "# Notes  \r\n\r\nsecond draft, longer"
+	if err := fs.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != slate.Content {
+		t.Fatalf("file holds %q, want exactly what was saved", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Fatalf("file mode changed to %v", info.Mode().Perm())
+	}
+	if slate.WordCount != 4 {
+		t.Fatalf("word count %d after save", slate.WordCount)
+	}
+
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Slate().Content != slate.Content {
+		t.Fatalf("reopened %q", reopened.Slate().Content)
+	}
+	if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Fatalf("%d files next to the edited one, want nothing else written", len(files))
+	}
+}
+
+func TestFileOnlyEditsItsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	if err := os.WriteFile(path, []byte("water plants"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Save(&Slate{}); err == nil {
+		t.Fatal("saved a second slate in file mode")
+	}
+	if err := fs.Delete(fileSlateID); err == nil {
+		t.Fatal("Delete succeeded")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("file gone: %v", err)
+	}
+	if _, err := fs.Load("other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load(other) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestNewFileRefuses(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "photo.png")
+	os.WriteFile(binary, []byte{0x89, 'P', 'N', 'G', 0xff, 0xfe}, 0600)
+
+	for name, path := range map[string]string{
+		"missing":   filepath.Join(dir, "nope.txt"),
+		"directory": dir,
+		"binary":    binary,
+	} {
+		if _, err := NewFile(path); err == nil {
+			t.Errorf("%s: opened without an error", name)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+// ErrNothingToImport is returned by Import for empty content
+var ErrNothingToImport = errors.New("nothing to import")
+
+// Import saves content from outside the app (a dropped file, an export
+// from elsewhere) as a new slate. The title comes from the content.
+func Import(s Storage, content string) (*Slate, error) {
+	if trimSpaces(content) == "" {
+		return nil, ErrNothingToImport
+	}
+
+	slate := &Slate{Slate: model.Slate{Content: content}}
+	if err := s.Save(slate); err != nil {
+		return nil, err
+	}
+	return slate, nil
+}
+
+// ImportReport summarizes a batch import
+type ImportReport struct {
+	Imported []*Slate
+	Skipped  []string // files that were empty or not text
+	Failed   []error
+}
+
+// textExts are the files ImportDir picks up
+var textExts = map[string]bool{
+	".txt":      true,
+	".md":       true,
+	".markdown": true,
+	".text":     true,
+}
+
+// ImportFile imports a single text file
+func ImportFile(s Storage, path string) (*Slate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, errors.New("not a text file")
+	}
+	return Import(s, string(data))
+}
+
+// ImportFiles imports each of paths as a text file
+func ImportFiles(s Storage, paths ...string) *ImportReport {
+	report := &ImportReport{}
+	for _, path := range paths {
+		slate, err := ImportFile(s, path)
+		report.add(path, slate, err)
+	}
+	return report
+}
+
+// ImportDir imports every text file under dir. Hidden files and folders
+// are skipped.
+func ImportDir(s Storage, dir string) (*ImportReport, error) {
+	report := &ImportReport{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !textExts[strings.ToLower(filepath.Ext(path))] {
+			report.Skipped = append(report.Skipped, path)
+			return nil
+		}
+
+		slate, err := ImportFile(s, path)
+		report.add(path, slate, err)
+		return nil
+	})
+	return report, err
+}
+
+// ImportBundle imports a JSON array of slates, such as slates.json from
+// local storage. Only content is kept; titles come from the content.
+func ImportBundle(s Storage, path string) (*ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: not a slates bundle: %w", path, err)
+	}
+
+	report := &ImportReport{}
+	for i, e := range entries {
+		slate, err := Import(s, e.Content)
+		report.add(fmt.Sprintf("%s[%d]", path, i), slate, err)
+	}
+	return report, nil
+}
+
+func (r *ImportReport) add(name string, slate *Slate, err error) {
+	switch {
+	case errors.Is(err, ErrNothingToImport):
+		r.Skipped = append(r.Skipped, name)
+	case err != nil:
+		r.Failed = append(r.Failed, fmt.Errorf("%s: %w", name, err))
+	default:
+		r.Imported = append(r.Imported, slate)
+	}
+}
@@ -2,17 +2,32 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/ids"
+	"github.com/justtype/cli/internal/jsonfields"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/tags"
+	"github.com/justtype/cli/internal/versions"
 )
 
 // LocalStorage stores slates in a JSON file
 type LocalStorage struct {
-	path   string
-	slates map[string]*Slate
+	path      string
+	slates    map[string]*Slate
+	extra     map[string]jsonfields.Extra // fields from newer versions, by slate ID
+	trash     *trash
+	norm      normalize.Options
+	versions  *versions.Log
+	key       *e2e.Key // set when slates are encrypted at rest
+	locked    bool     // encrypted and not unlocked yet; nothing is loaded
+	recovered string   // set when slates.json was damaged, and slates.json.tmp loaded or it was set aside
 }
 
 // NewLocal creates a new local storage at the given path
@@ -23,8 +38,28 @@ func NewLocal(storagePath string) (*LocalStorage, error) {
 	}
 
 	ls := &LocalStorage{
-		path:   filepath.Join(storagePath, "slates.json"),
-		slates: make(map[string]*Slate),
+		path:     filepath.Join(storagePath, "slates.json"),
+		slates:   make(map[string]*Slate),
+		extra:    make(map[string]jsonfields.Extra),
+		versions: versions.New(filepath.Join(storagePath, "versions")),
+	}
+
+	// Finish or undo a change of passphrase that was cut short
+	if err := atrest.Recover(ls.path); err != nil {
+		return nil, err
+	}
+	encrypted, err := atrest.IsEncrypted(ls.path)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		// Loaded by Unlock once the UI has the passphrase
+		ls.locked = true
+		ls.trash = &trash{
+			path:   filepath.Join(storagePath, "trash.json"),
+			slates: make(map[string]*TrashedSlate),
+		}
+		return ls, nil
 	}
 
 	// Load existing slates
@@ -32,10 +67,103 @@ func NewLocal(storagePath string) (*LocalStorage, error) {
 		return nil, err
 	}
 
+	t, err := newTrash(filepath.Join(storagePath, "trash.json"))
+	if err != nil {
+		return nil, err
+	}
+	ls.trash = t
+
 	return ls, nil
 }
 
+// Locked reports whether the slates are encrypted and waiting on Unlock
+func (ls *LocalStorage) Locked() bool {
+	return ls.locked
+}
+
+// Unlock decrypts and loads encrypted slates. A wrong passphrase returns
+// atrest.ErrWrongPassphrase and leaves the storage locked.
+func (ls *LocalStorage) Unlock(passphrase string) error {
+	if !ls.locked {
+		return nil
+	}
+
+	key, err := atrest.Unlock(ls.path, passphrase)
+	if err != nil {
+		return err
+	}
+	ls.key = key
+	ls.trash.key = key
+	ls.versions.SetKey(key)
+	if err := ls.load(); err != nil {
+		return err
+	}
+	if err := ls.trash.load(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	ls.locked = false
+	return nil
+}
+
+// SetPassphrase encrypts the slates, trash and history on disk with a key
+// derived from passphrase, re-encrypting them if they already were. An
+// empty passphrase turns encryption off.
+func (ls *LocalStorage) SetPassphrase(passphrase string) error {
+	if ls.locked {
+		return atrest.ErrLocked
+	}
+
+	rekey, err := atrest.BeginRekey(ls.path, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := ls.stageRekey(rekey); err != nil {
+		rekey.Abort()
+		return err
+	}
+	if err := rekey.Commit(); err != nil {
+		return err
+	}
+
+	key := rekey.Key()
+	ls.key = key
+	ls.trash.key = key
+	ls.versions.SetKey(key)
+	return nil
+}
+
+// stageRekey stages the slates, trash and history under rekey's key
+func (ls *LocalStorage) stageRekey(rekey *atrest.Rekey) error {
+	data, err := ls.encode()
+	if err != nil {
+		return err
+	}
+	if err := rekey.WriteFile(ls.path, data, 0644); err != nil {
+		return err
+	}
+	data, err = ls.trash.encode()
+	if err != nil {
+		return err
+	}
+	if err := rekey.WriteFile(ls.trash.path, data, 0600); err != nil {
+		return err
+	}
+	return ls.versions.Rekey(rekey.Key(), rekey.Stage)
+}
+
+// Encrypted reports whether slates are written encrypted
+func (ls *LocalStorage) Encrypted() bool {
+	return ls.key != nil || ls.locked
+}
+
+// SetNormalize sets how content is cleaned up on save
+func (ls *LocalStorage) SetNormalize(opts normalize.Options) {
+	ls.norm = opts
+}
+
 func (ls *LocalStorage) Save(slate *Slate) error {
+	slate.Content = normalize.Apply(slate.Content, ls.norm)
+	markPristine(slate, slate.ID == "")
 	if slate.ID == "" {
 		slate.ID = generateID()
 		slate.CreatedAt = time.Now()
@@ -44,80 +172,207 @@ func (ls *LocalStorage) Save(slate *Slate) error {
 	slate.UpdatedAt = time.Now()
 	slate.Title = ExtractTitle(slate.Content)
 	slate.WordCount = CountWords(slate.Content)
+	slate.Tags = tags.Parse(slate.Content)
 
 	ls.slates[slate.ID] = slate
-	return ls.persist()
+	if err := ls.persist(); err != nil {
+		return err
+	}
+	// History is a nice-to-have; it never fails a save
+	ls.versions.Record(slate.ID, slate.Content, slate.UpdatedAt)
+	return nil
+}
+
+// Versions returns the saved history of a slate's content, newest first
+func (ls *LocalStorage) Versions(id string) ([]versions.Version, error) {
+	return ls.versions.List(id)
 }
 
 func (ls *LocalStorage) Load(id string) (*Slate, error) {
+	if ls.locked {
+		return nil, atrest.ErrLocked
+	}
 	slate, ok := ls.slates[id]
 	if !ok {
-		return nil, fmt.Errorf("slate not found")
+		return nil, ErrNotFound
 	}
 	return slate, nil
 }
 
 func (ls *LocalStorage) List() ([]*Slate, error) {
+	if ls.locked {
+		return nil, atrest.ErrLocked
+	}
 	slates := make([]*Slate, 0, len(ls.slates))
 	for _, slate := range ls.slates {
 		slates = append(slates, slate)
 	}
 
-	// Sort by updated_at desc
-	sort.Slice(slates, func(i, j int) bool {
-		return slates[i].UpdatedAt.After(slates[j].UpdatedAt)
-	})
-
+	SortSlates(slates)
 	return slates, nil
 }
 
+// Recent returns the first n slates in List order without sorting the rest
+func (ls *LocalStorage) Recent(n int) ([]*Slate, int, error) {
+	if ls.locked {
+		return nil, 0, atrest.ErrLocked
+	}
+	return firstSlates(ls.slates, n), len(ls.slates), nil
+}
+
+// SetPinned pins a slate above the rest of List, or unpins it, without
+// changing its update time
+func (ls *LocalStorage) SetPinned(id string, pinned bool) error {
+	if ls.locked {
+		return atrest.ErrLocked
+	}
+	slate, ok := ls.slates[id]
+	if !ok {
+		return ErrNotFound
+	}
+	slate.Pinned = pinned
+	return ls.persist()
+}
+
+// Delete moves a slate to the trash
 func (ls *LocalStorage) Delete(id string) error {
+	if ls.locked {
+		return atrest.ErrLocked
+	}
+	if slate, ok := ls.slates[id]; ok {
+		if err := ls.trash.add(slate); err != nil {
+			return err
+		}
+	}
 	delete(ls.slates, id)
 	return ls.persist()
 }
 
+// Restore moves a slate out of the trash
+func (ls *LocalStorage) Restore(id string) (*Slate, error) {
+	if ls.locked {
+		return nil, atrest.ErrLocked
+	}
+	slate, err := ls.trash.take(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restoring is deliberate, so don't sweep it up again as blank
+	slate.Pristine = false
+	ls.slates[slate.ID] = slate
+	if err := ls.persist(); err != nil {
+		return nil, err
+	}
+
+	return slate, nil
+}
+
 func (ls *LocalStorage) Close() error {
+	if ls.locked {
+		return nil
+	}
 	return ls.persist()
 }
 
 func (ls *LocalStorage) load() error {
-	data, err := os.ReadFile(ls.path)
+	raw, err := ls.readSlates(ls.path)
 	if err != nil {
-		return err
+		// A save cut short may have left a whole copy staged beside it
+		tmp := atrest.TempPath(ls.path)
+		if staged, tmpErr := ls.readSlates(tmp); tmpErr == nil {
+			raw, err = staged, nil
+			ls.recovered = fmt.Sprintf("%s couldn't be read, recovered %d slates from %s",
+				filepath.Base(ls.path), len(staged), filepath.Base(tmp))
+		}
 	}
-
-	var slates []*Slate
-	if err := json.Unmarshal(data, &slates); err != nil {
-		return err
+	if err != nil {
+		return ls.setAside(err)
 	}
 
-	for _, slate := range slates {
+	for _, r := range raw {
+		slate := &Slate{}
+		extra, err := jsonfields.Split(r, slate)
+		if err != nil {
+			return ls.setAside(err)
+		}
 		ls.slates[slate.ID] = slate
+		if extra != nil {
+			ls.extra[slate.ID] = extra
+		}
 	}
 
 	return nil
 }
 
+// setAside handles err from loading the slates. If they aren't valid JSON,
+// the file is moved aside and the slates start out empty, with Recovered
+// saying where it went; any other error is returned as it is.
+func (ls *LocalStorage) setAside(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+		return err
+	}
+
+	dest, moveErr := atrest.SetAside(ls.path)
+	if moveErr != nil {
+		return fmt.Errorf("%s is damaged (%v) and couldn't be moved aside: %w", filepath.Base(ls.path), err, moveErr)
+	}
+	ls.slates = make(map[string]*Slate)
+	ls.extra = make(map[string]jsonfields.Extra)
+	ls.recovered = fmt.Sprintf("%s couldn't be read and was moved to %s; your slates are there, not deleted",
+		filepath.Base(ls.path), filepath.Base(dest))
+	return nil
+}
+
+// readSlates reads the slates at path without decoding each one
+func (ls *LocalStorage) readSlates(path string) ([]json.RawMessage, error) {
+	data, err := atrest.ReadFile(path, ls.key)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// Recovered describes the recovery if the slates were loaded from a save
+// that was cut short or set aside as damaged, or returns "" if they loaded
+// normally
+func (ls *LocalStorage) Recovered() string {
+	return ls.recovered
+}
+
 func (ls *LocalStorage) persist() error {
-	slates := make([]*Slate, 0, len(ls.slates))
-	for _, slate := range ls.slates {
-		slates = append(slates, slate)
+	if ls.locked {
+		// Writing now would replace the encrypted slates with nothing
+		return atrest.ErrLocked
 	}
 
-	data, err := json.MarshalIndent(slates, "", "  ")
+	data, err := ls.encode()
 	if err != nil {
 		return err
 	}
+	return atrest.WriteFile(ls.path, data, ls.key, 0644)
+}
 
-	return os.WriteFile(ls.path, data, 0644)
+// encode is slates.json's content, before any encryption
+func (ls *LocalStorage) encode() ([]byte, error) {
+	slates := make([]json.RawMessage, 0, len(ls.slates))
+	for id, slate := range ls.slates {
+		encoded, err := jsonfields.Join(slate, ls.extra[id])
+		if err != nil {
+			return nil, err
+		}
+		slates = append(slates, encoded)
+	}
+	return json.MarshalIndent(slates, "", "  ")
 }
 
 func generateID() string {
-	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, 12)
-	for i := range b {
-		b[i] = chars[time.Now().UnixNano()%int64(len(chars))]
-		time.Sleep(time.Nanosecond)
-	}
-	return string(b)
+	return ids.New()
 }
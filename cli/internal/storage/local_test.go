@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/normalize"
+)
+
+// newTestLocal opens local storage in a temp dir, with its slates.json path
+func newTestLocal(t *testing.T) (*LocalStorage, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ls, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ls, filepath.Join(dir, "slates.json")
+}
+
+func TestLocalRecoversFromTruncatedWrite(t *testing.T) {
+	ls, path := newTestLocal(t)
+	want := map[string]string{}
+	for _, content := range []string{"first\n\none", "second\n\ntwo", "third\n\nthree"} {
+		slate := &Slate{Slate: model.Slate{Content: content}}
+		if err := ls.Save(slate); err != nil {
+			t.Fatal(err)
+		}
+		want[slate.ID] = content
+	}
+
+	// A crash mid-rename: the staged copy is whole, slates.json cut short
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(atrest.TempPath(path), good, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, good[:len(good)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewLocal(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slates, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slates) != len(want) {
+		t.Fatalf("loaded %d slates, want %d", len(slates), len(want))
+	}
+	for _, slate := range slates {
+		if slate.Content != want[slate.ID] {
+			t.Errorf("slate %s: got %q, want %q", slate.ID, slate.Content, want[slate.ID])
+		}
+	}
+	if reopened.Recovered() == "" {
+		t.Error("recovery from the staged copy wasn't reported")
+	}
+}
+
+func TestWriteFileLeavesNoStagedCopy(t *testing.T) {
+	ls, path := newTestLocal(t)
+	if err := ls.Save(&Slate{Slate: model.Slate{Content: "hello"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(atrest.TempPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("staged copy left behind after a whole write: %v", err)
+	}
+}
+
+func TestLocalSetsAsideMalformedJSON(t *testing.T) {
+	tests := map[string]string{
+		"cut short":      `[{"id": "abc", "content": "hel`,
+		"not an array":   `{"id": "abc"}`,
+		"wrong type":     `[{"id": 42}]`,
+		"hand-edit typo": "[{\"id\": \"abc\",}]",
+	}
+	for name, bad := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "slates.json")
+			if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			ls, err := NewLocal(dir)
+			if err != nil {
+				t.Fatalf("didn't start: %v", err)
+			}
+			slates, err := ls.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(slates) != 0 {
+				t.Fatalf("started with %d slates, want none", len(slates))
+			}
+
+			aside, _ := filepath.Glob(filepath.Join(dir, "slates.corrupt-*.json"))
+			if len(aside) != 1 {
+				t.Fatalf("found %v set aside, want one file", aside)
+			}
+			if data, _ := os.ReadFile(aside[0]); string(data) != bad {
+				t.Fatalf("set-aside file holds %q, want the original", data)
+			}
+			if !strings.Contains(ls.Recovered(), filepath.Base(aside[0])) {
+				t.Fatalf("Recovered() = %q, want it to name %s", ls.Recovered(), filepath.Base(aside[0]))
+			}
+
+			// Saving now starts a fresh file and leaves the damaged one be
+			if err := ls.Save(&Slate{Slate: model.Slate{Content: "new"}}); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(aside[0]); string(data) != bad {
+				t.Fatal("set-aside file changed after a save")
+			}
+		})
+	}
+}
+
+func TestLocalKeepsOtherLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	// A directory where the file should be can't be read, but isn't bad JSON
+	if err := os.Mkdir(filepath.Join(dir, "slates.json"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLocal(dir); err == nil {
+		t.Fatal("want an error for an unreadable slates.json")
+	}
+	if aside, _ := filepath.Glob(filepath.Join(dir, "slates.corrupt-*")); len(aside) != 0 {
+		t.Fatalf("set aside %v for an error that isn't bad JSON", aside)
+	}
+}
+
+func TestLocalKeepsEveryField(t *testing.T) {
+	ls, path := newTestLocal(t)
+	created := time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)
+	slate := &Slate{
+		Slate: model.Slate{
+			ID:          "cloud-3",
+			Content:     "Notes\n\n#work items",
+			CreatedAt:   created,
+			CloudID:     3,
+			IsPublished: true,
+			ShareID:     "s3",
+			Pinned:      true,
+		},
+		CursorOffset: 7,
+	}
+	if err := ls.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewLocal(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Load("cloud-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.UpdatedAt.Equal(slate.UpdatedAt) {
+		t.Fatalf("UpdatedAt = %v, want %v", got.UpdatedAt, slate.UpdatedAt)
+	}
+	got.UpdatedAt = slate.UpdatedAt
+	if !reflect.DeepEqual(got, slate) {
+		t.Fatalf("reloaded:\n got %+v\nwant %+v", got, slate)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, key := range []string{`"cloud_id"`, `"share_id"`, `"is_published"`, `"cursor_offset"`, `"word_count"`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("slates.json has no %s key", key)
+		}
+	}
+}
+
+func TestIsBlankOnlyForNeverWritten(t *testing.T) {
+	ls, _ := newTestLocal(t)
+	save := func(content string) *Slate {
+		t.Helper()
+		slate := &Slate{Slate: model.Slate{Content: content}}
+		if err := ls.Save(slate); err != nil {
+			t.Fatal(err)
+		}
+		return slate
+	}
+
+	empty := save("")
+	spaces := save("  \n\t\n")
+	written := save("a real note")
+	cleared := save("soon to be gone")
+	cleared.Content = "   "
+	if err := ls.Save(cleared); err != nil {
+		t.Fatal(err)
+	}
+	late := save("")
+	late.Content = "written at last"
+	ls.Save(late)
+	late.Content = ""
+	ls.Save(late)
+
+	for _, tt := range []struct {
+		name  string
+		slate *Slate
+		blank bool
+	}{
+		{"created empty", empty, true},
+		{"only whitespace", spaces, true},
+		{"written in", written, false},
+		{"cleared after writing", cleared, false},
+		{"written in once, then emptied", late, false},
+	} {
+		if got := IsBlank(tt.slate); got != tt.blank {
+			t.Errorf("%s: IsBlank = %v, want %v", tt.name, got, tt.blank)
+		}
+	}
+
+	// A blank slate brought back from the trash was wanted after all
+	if err := ls.Delete(empty.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ls.Restore(empty.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsBlank(restored) {
+		t.Error("a restored slate is still blank")
+	}
+}
+
+func TestLocalNormalizesLineEndings(t *testing.T) {
+	ls, path := newTestLocal(t)
+	slate := &Slate{Slate: model.Slate{Content: "Pasted\r\n\r\nfrom a windows\r\neditor"}}
+	if err := ls.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	if slate.Content != "Pasted\n\nfrom a windows\neditor" {
+		t.Fatalf("saved %q, want \\n line endings", slate.Content)
+	}
+	if slate.Title != "Pasted" || slate.WordCount != 5 {
+		t.Fatalf("title %q, %d words", slate.Title, slate.WordCount)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), `\r`) {
+		t.Fatalf("slates.json still holds a carriage return: %s", data)
+	}
+
+	// Asked to keep them, the content is stored as written and counts the same
+	ls.SetNormalize(normalize.Options{KeepLineEndings: true})
+	kept := &Slate{Slate: model.Slate{Content: "Pasted\r\n\r\nfrom a windows\r\neditor"}}
+	if err := ls.Save(kept); err != nil {
+		t.Fatal(err)
+	}
+	if kept.Content != "Pasted\r\n\r\nfrom a windows\r\neditor" {
+		t.Fatalf("saved %q, want the line endings kept", kept.Content)
+	}
+	if kept.Title != "Pasted" || kept.WordCount != 5 {
+		t.Fatalf("with \\r\\n kept: title %q, %d words", kept.Title, kept.WordCount)
+	}
+}
+
+func TestLocalKeepsUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slates.json")
+	// Written by a newer version, with fields this one doesn't know
+	newer := `[{"id": "a1", "title": "Notes", "content": "Notes\n\nfirst", "mood": "calm", "reminder": {"at": "2026-03-01T09:00:00Z"}}]`
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ls, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate, err := ls.Load("a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate.Content = "Notes\n\nfirst, then more"
+	if err := ls.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Save(&Slate{Slate: model.Slate{Content: "another"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var saved []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range saved {
+		if string(s["id"]) != `"a1"` {
+			if _, ok := s["mood"]; ok {
+				t.Fatal("another slate picked up a1's unknown field")
+			}
+			continue
+		}
+		if string(s["mood"]) != `"calm"` || !strings.Contains(string(s["reminder"]), "2026-03-01T09:00:00Z") {
+			t.Fatalf("unknown fields lost on save: %s", data)
+		}
+		if !strings.Contains(string(s["content"]), "then more") {
+			t.Fatalf("the edit wasn't saved: %s", s["content"])
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Operations recorded in the offline queue
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// queuedOp is a write that couldn't reach the server
+type queuedOp struct {
+	Op       string    `json:"op"`
+	ID       string    `json:"id"`
+	CloudID  int       `json:"cloud_id,omitempty"`
+	Content  string    `json:"content,omitempty"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// queue keeps writes made offline in queue.jsonl, one per line, so they
+// outlive the temp file and a restart. Writes to the same slate are folded
+// together, so a long offline session leaves one entry per slate.
+type queue struct {
+	mu   sync.Mutex
+	path string
+	ops  []queuedOp
+
+	// Cloud IDs given to slates created offline, by their queued ID
+	created map[string]int
+}
+
+func newQueue(path string) (*queue, error) {
+	q := &queue{path: path, created: make(map[string]int)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var op queuedOp
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			// A line cut short by a crash; the rest are still good
+			continue
+		}
+		q.ops = append(q.ops, op)
+	}
+	return q, scanner.Err()
+}
+
+// add queues op, folding it into an earlier write to the same slate
+func (q *queue) add(op queuedOp) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	op.QueuedAt = time.Now()
+	for i, prev := range q.ops {
+		if prev.ID != op.ID {
+			continue
+		}
+		if op.Op != opDelete {
+			// Newer content replaces the older; a create stays a create
+			q.ops[i].Content = op.Content
+			q.ops[i].QueuedAt = op.QueuedAt
+			return q.persist()
+		}
+		q.ops = append(q.ops[:i], q.ops[i+1:]...)
+		if prev.Op == opCreate {
+			// Never reached the server, so there's nothing to delete there
+			return q.persist()
+		}
+		break
+	}
+
+	q.ops = append(q.ops, op)
+	return q.persist()
+}
+
+// get returns the queued write for id, if there is one
+func (q *queue) get(id string) (queuedOp, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, op := range q.ops {
+		if op.ID == id {
+			return op, true
+		}
+	}
+	return queuedOp{}, false
+}
+
+func (q *queue) list() []queuedOp {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]queuedOp(nil), q.ops...)
+}
+
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ops)
+}
+
+// done drops a replayed write. A create remembers its new cloud ID, so the
+// editor's copy of the slate updates it instead of creating it again.
+func (q *queue) done(op queuedOp, cloudID int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if op.Op == opCreate && cloudID > 0 {
+		q.created[op.ID] = cloudID
+	}
+	for i, o := range q.ops {
+		if o.ID != op.ID {
+			continue
+		}
+		if o.QueuedAt.Equal(op.QueuedAt) {
+			q.ops = append(q.ops[:i], q.ops[i+1:]...)
+		} else if o.Op == opCreate && cloudID > 0 {
+			// Edited again while it was being created; what's left to
+			// send is an update
+			q.ops[i].Op = opUpdate
+			q.ops[i].CloudID = cloudID
+		}
+		break
+	}
+	return q.persist()
+}
+
+// cloudID returns the cloud ID a slate queued as id was created with
+func (q *queue) cloudID(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.created[id]
+}
+
+func (q *queue) persist() error {
+	if len(q.ops) == 0 {
+		err := os.Remove(q.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, op := range q.ops {
+		line, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(q.path, buf.Bytes(), 0600)
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/store"
+)
+
+// Reader is the part of Storage that only reads, for commands that must
+// leave the slates on disk as they are
+type Reader interface {
+	// Load loads a specific slate by ID
+	Load(id string) (*Slate, error)
+
+	// List returns all slates in the order Storage.List does
+	List() ([]*Slate, error)
+}
+
+// ReadLocal opens the local slates in storagePath for reading only, the way
+// CountLocal counts them: nothing is created, migrated or set aside. A
+// SQLite backend that hasn't been opened yet reads slates.json, which it
+// starts with. A damaged slates.json is an error here, since recovering it
+// is left to the editor, and slates encrypted at rest are atrest.ErrLocked.
+func ReadLocal(storagePath, backend string) (Reader, error) {
+	if backend == BackendSQLite {
+		dbPath := filepath.Join(storagePath, "slates.db")
+		if _, err := os.Stat(dbPath); err == nil {
+			return readSQLite(dbPath)
+		}
+	}
+	return readJSON(filepath.Join(storagePath, "slates.json"))
+}
+
+// readSQLite opens slates.db read-only. It has no trash or history, which
+// nothing reading needs.
+func readSQLite(dbPath string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s can't be read: %w", filepath.Base(dbPath), err)
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// snapshot is a Reader over slates read once from slates.json
+type snapshot struct {
+	slates map[string]*Slate
+}
+
+// readJSON reads slates.json, or its staged copy if a save was cut short,
+// as LocalStorage would load it
+func readJSON(path string) (*snapshot, error) {
+	s := &snapshot{slates: make(map[string]*Slate)}
+	raw, err := readRaw(path, nil)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		staged, tmpErr := readRaw(atrest.TempPath(path), nil)
+		if tmpErr != nil {
+			return nil, damaged(path, err)
+		}
+		raw = staged
+	}
+
+	for _, r := range raw {
+		slate := &Slate{}
+		if err := json.Unmarshal(r, slate); err != nil {
+			return nil, damaged(path, err)
+		}
+		s.slates[slate.ID] = slate
+	}
+	return s, nil
+}
+
+// damaged explains an error reading the slates at path. ErrLocked and
+// errors other than bad JSON are returned as they are.
+func damaged(path string, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+		return err
+	}
+	return fmt.Errorf("%s can't be read (%v); run justtype to recover it", filepath.Base(path), err)
+}
+
+func (s *snapshot) Load(id string) (*Slate, error) {
+	slate, ok := s.slates[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slate, nil
+}
+
+func (s *snapshot) List() ([]*Slate, error) {
+	slates := make([]*Slate, 0, len(s.slates))
+	for _, slate := range s.slates {
+		slates = append(slates, slate)
+	}
+	SortSlates(slates)
+	return slates, nil
+}
+
+// readCache reads the offline cache in dir as it is on disk. One that can't
+// be read, say with another key, is just not used; the editor sets it
+// aside.
+func (cs *CloudStorage) readCache(dir string) {
+	raw, err := readRaw(filepath.Join(dir, "slates.json"), cs.key)
+	if err != nil {
+		return
+	}
+	for _, r := range raw {
+		entry := &store.Slate{}
+		if err := json.Unmarshal(r, entry); err == nil {
+			cs.cached = append(cs.cached, entry)
+		}
+	}
+}
+
+// cachedCopy finds the offline copy of a slate by its app ID or cloud ID,
+// as cache.get does, or nil if its content isn't there. With unsynced, only
+// an edit that hasn't reached the server is returned.
+func (cs *CloudStorage) cachedCopy(id string, cloudID int, unsynced bool) *Slate {
+	for _, e := range cs.cached {
+		if e.ID != id && (cloudID == 0 || e.CloudID != cloudID) {
+			continue
+		}
+		if e.Unavailable || (unsynced && e.Synced) {
+			return nil
+		}
+		return fromCache(e)
+	}
+	return nil
+}
+
+// listReadOnly is List for NewCloudReader: the server's list, or the
+// offline copy when it can't be reached
+func (cs *CloudStorage) listReadOnly() ([]*Slate, error) {
+	slates, err := cs.listRemote()
+	if errors.Is(err, ErrOffline) && cs.cached != nil {
+		cs.offline = true
+		slates = make([]*Slate, 0, len(cs.cached))
+		for _, e := range cs.cached {
+			slates = append(slates, fromCache(e))
+		}
+		err = nil
+	}
+	SortSlates(slates)
+	return slates, err
+}
@@ -0,0 +1,454 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/jsonfields"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/tags"
+	"github.com/justtype/cli/internal/versions"
+	_ "modernc.org/sqlite"
+)
+
+// Storage backends for local mode, set with storage_backend in config.json
+const (
+	BackendJSON   = "json" // slates.json, the default
+	BackendSQLite = "sqlite"
+)
+
+// Searcher is implemented by storages with their own full-text search
+type Searcher interface {
+	// Search returns slates matching every word in query, best match first
+	Search(query string) ([]*Slate, error)
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS slates (
+	id           TEXT PRIMARY KEY,
+	title        TEXT NOT NULL,
+	content      TEXT NOT NULL,
+	word_count   INTEGER NOT NULL,
+	created_at   INTEGER NOT NULL,
+	updated_at   INTEGER NOT NULL,
+	cloud_id     INTEGER NOT NULL DEFAULT 0,
+	is_published INTEGER NOT NULL DEFAULT 0,
+	share_id     TEXT NOT NULL DEFAULT '',
+	pristine     INTEGER NOT NULL DEFAULT 0,
+	cursor       INTEGER NOT NULL DEFAULT 0,
+	pinned       INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS slates_updated ON slates (updated_at);
+CREATE VIRTUAL TABLE IF NOT EXISTS slates_fts USING fts5 (id UNINDEXED, title, content);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// metaMigrated is set in the meta table once MigrateJSON has run, so it
+// never runs again, even on a database emptied since
+const metaMigrated = "migrated_json"
+
+const slateColumns = `id, title, content, word_count, created_at, updated_at, cloud_id, is_published, share_id, pristine, cursor, pinned`
+
+// SQLiteStorage stores slates in a SQLite database, for collections too big
+// to rewrite as one JSON file on every save. Search goes through an FTS5
+// index kept next to the slates.
+type SQLiteStorage struct {
+	db       *sql.DB
+	trash    *trash
+	norm     normalize.Options
+	versions *versions.Log
+}
+
+// NewSQLite opens (or creates) slates.db in the given directory
+func NewSQLite(storagePath string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	dsn := "file:" + filepath.Join(storagePath, "slates.db") + "?_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// One connection: writes are serialised anyway, and it keeps the
+	// database locked for as short as possible for other processes
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set up database: %w", err)
+	}
+	// Databases made before the cursor was kept, or slates could be pinned
+	for _, column := range []string{"cursor", "pinned"} {
+		if err := addColumn(db, "slates", column, "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set up database: %w", err)
+		}
+	}
+
+	// The trash is shared with the JSON backend, unless that's encrypted at
+	// rest, which this backend can't read
+	trashPath := filepath.Join(storagePath, "trash.json")
+	if encrypted, err := atrest.IsEncrypted(trashPath); err == nil && encrypted {
+		trashPath = filepath.Join(storagePath, "slates.db.trash.json")
+	}
+	t, err := newTrash(trashPath)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStorage{
+		db:       db,
+		trash:    t,
+		versions: versions.New(filepath.Join(storagePath, "versions")),
+	}, nil
+}
+
+// SetNormalize sets how content is cleaned up on save
+func (ss *SQLiteStorage) SetNormalize(opts normalize.Options) {
+	ss.norm = opts
+}
+
+func (ss *SQLiteStorage) Save(slate *Slate) error {
+	slate.Content = normalize.Apply(slate.Content, ss.norm)
+	markPristine(slate, slate.ID == "")
+	if slate.ID == "" {
+		slate.ID = generateID()
+		slate.CreatedAt = time.Now()
+	}
+
+	slate.UpdatedAt = time.Now()
+	slate.Title = ExtractTitle(slate.Content)
+	slate.WordCount = CountWords(slate.Content)
+	slate.Tags = tags.Parse(slate.Content)
+
+	if err := ss.put(slate); err != nil {
+		return err
+	}
+	// History is a nice-to-have; it never fails a save
+	ss.versions.Record(slate.ID, slate.Content, slate.UpdatedAt)
+	return nil
+}
+
+// Versions returns the saved history of a slate's content, newest first
+func (ss *SQLiteStorage) Versions(id string) ([]versions.Version, error) {
+	return ss.versions.List(id)
+}
+
+func (ss *SQLiteStorage) Load(id string) (*Slate, error) {
+	row := ss.db.QueryRow(`SELECT `+slateColumns+` FROM slates WHERE id = ?`, id)
+	slate, err := scanSlate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return slate, err
+}
+
+func (ss *SQLiteStorage) List() ([]*Slate, error) {
+	rows, err := ss.db.Query(`SELECT ` + slateColumns + ` FROM slates ORDER BY pinned DESC, updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanSlates(rows)
+}
+
+// Recent returns the first n slates in List order, reading only those
+func (ss *SQLiteStorage) Recent(n int) ([]*Slate, int, error) {
+	var total int
+	if err := ss.db.QueryRow(`SELECT COUNT(*) FROM slates`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := ss.db.Query(`SELECT `+slateColumns+` FROM slates ORDER BY pinned DESC, updated_at DESC LIMIT ?`, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	slates, err := scanSlates(rows)
+	return slates, total, err
+}
+
+// SetPinned pins a slate above the rest of List, or unpins it, without
+// counting as an edit
+func (ss *SQLiteStorage) SetPinned(id string, pinned bool) error {
+	res, err := ss.db.Exec(`UPDATE slates SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Search matches each word of query as a prefix, in titles and content
+func (ss *SQLiteStorage) Search(query string) ([]*Slate, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := ss.db.Query(`SELECT s.`+strings.ReplaceAll(slateColumns, ", ", ", s.")+`
+		FROM slates_fts f JOIN slates s ON s.id = f.id
+		WHERE slates_fts MATCH ?
+		ORDER BY f.rank`, match)
+	if err != nil {
+		return nil, err
+	}
+	return scanSlates(rows)
+}
+
+// Delete moves a slate to the trash
+func (ss *SQLiteStorage) Delete(id string) error {
+	slate, err := ss.Load(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := ss.trash.add(slate); err != nil {
+		return err
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM slates WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM slates_fts WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore moves a slate out of the trash
+func (ss *SQLiteStorage) Restore(id string) (*Slate, error) {
+	slate, err := ss.trash.take(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restoring is deliberate, so don't sweep it up again as blank
+	slate.Pristine = false
+	if err := ss.put(slate); err != nil {
+		return nil, err
+	}
+
+	return slate, nil
+}
+
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
+
+// put writes a slate as it is, keeping its ID and timestamps
+func (ss *SQLiteStorage) put(slate *Slate) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := putSlate(tx, slate); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func putSlate(tx *sql.Tx, slate *Slate) error {
+	_, err := tx.Exec(`INSERT INTO slates (`+slateColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			title = excluded.title,
+			content = excluded.content,
+			word_count = excluded.word_count,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at,
+			cloud_id = excluded.cloud_id,
+			is_published = excluded.is_published,
+			share_id = excluded.share_id,
+			pristine = excluded.pristine,
+			cursor = excluded.cursor,
+			pinned = excluded.pinned`,
+		slate.ID, slate.Title, slate.Content, slate.WordCount,
+		slate.CreatedAt.UnixNano(), slate.UpdatedAt.UnixNano(),
+		slate.CloudID, slate.IsPublished, slate.ShareID, slate.Pristine, slate.CursorOffset, slate.Pinned)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM slates_fts WHERE id = ?`, slate.ID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO slates_fts (id, title, content) VALUES (?, ?, ?)`,
+		slate.ID, slate.Title, slate.Content)
+	return err
+}
+
+// addColumn adds a column to an existing table unless it's already there
+func addColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSlate(row rowScanner) (*Slate, error) {
+	var slate Slate
+	var created, updated int64
+	err := row.Scan(&slate.ID, &slate.Title, &slate.Content, &slate.WordCount,
+		&created, &updated, &slate.CloudID, &slate.IsPublished, &slate.ShareID, &slate.Pristine, &slate.CursorOffset, &slate.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	slate.CreatedAt = time.Unix(0, created)
+	slate.UpdatedAt = time.Unix(0, updated)
+	// Tags aren't stored; they come from the content
+	slate.Tags = tags.Parse(slate.Content)
+	return &slate, nil
+}
+
+func scanSlates(rows *sql.Rows) ([]*Slate, error) {
+	defer rows.Close()
+
+	var slates []*Slate
+	for rows.Next() {
+		slate, err := scanSlate(rows)
+		if err != nil {
+			return nil, err
+		}
+		slates = append(slates, slate)
+	}
+	return slates, rows.Err()
+}
+
+// ftsQuery turns what the user typed into an FTS5 query: every word must
+// match, as a prefix, and nothing in it is treated as query syntax
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// MigrateJSON copies the slates in dir/slates.json into ss, keeping their
+// IDs and timestamps, the first time ss is opened. slates.json is left as it
+// is, as a backup. It returns how many slates were copied.
+//
+// Once done, it's recorded in the database and never done again, so slates
+// deleted from ss don't come back. A slates.json that can't be read, such
+// as one encrypted at rest, isn't copied and isn't recorded either: the
+// error says why, and the next open tries again.
+func MigrateJSON(dir string, ss *SQLiteStorage) (int, error) {
+	var done int
+	if err := ss.db.QueryRow(`SELECT COUNT(*) FROM meta WHERE key = ?`, metaMigrated).Scan(&done); err != nil {
+		return 0, err
+	}
+	if done > 0 {
+		return 0, nil
+	}
+
+	var count int
+	if err := ss.db.QueryRow(`SELECT COUNT(*) FROM slates`).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		// Copied before it was recorded, or in use without slates.json
+		return 0, ss.markMigrated(nil)
+	}
+
+	path := filepath.Join(dir, "slates.json")
+	encrypted, err := atrest.IsEncrypted(path)
+	if err != nil {
+		return 0, err
+	}
+	if encrypted {
+		return 0, fmt.Errorf("slates.json is encrypted, so its slates weren't copied to slates.db; turn off \"encrypt slates on disk\" with the json backend first")
+	}
+
+	// Read directly rather than with LocalStorage.load, which would set a
+	// damaged file aside
+	ls := &LocalStorage{path: path}
+	raw, err := ls.readSlates(path)
+	if os.IsNotExist(err) {
+		return 0, ss.markMigrated(nil)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("slates.json couldn't be read, so its slates weren't copied to slates.db: %w", err)
+	}
+	slates := make([]*Slate, 0, len(raw))
+	for _, r := range raw {
+		slate := &Slate{}
+		if _, err := jsonfields.Split(r, slate); err != nil {
+			return 0, fmt.Errorf("slates.json couldn't be read, so its slates weren't copied to slates.db: %w", err)
+		}
+		slates = append(slates, slate)
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, slate := range slates {
+		if err := putSlate(tx, slate); err != nil {
+			return 0, err
+		}
+	}
+	if err := ss.markMigrated(tx); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(slates), nil
+}
+
+// markMigrated records that MigrateJSON is done, in tx if it's set
+func (ss *SQLiteStorage) markMigrated(tx *sql.Tx) error {
+	const query = `INSERT OR REPLACE INTO meta (key, value) VALUES (?, ?)`
+	value := time.Now().UTC().Format(time.RFC3339)
+	var err error
+	if tx != nil {
+		_, err = tx.Exec(query, metaMigrated, value)
+	} else {
+		_, err = ss.db.Exec(query, metaMigrated, value)
+	}
+	return err
+}
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+func newTestSQLite(t *testing.T, dir string) *SQLiteStorage {
+	t.Helper()
+	ss, err := NewSQLite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+	return ss
+}
+
+func TestSQLiteCRUD(t *testing.T) {
+	ss := newTestSQLite(t, t.TempDir())
+
+	slate := &Slate{Slate: model.Slate{Content: "Groceries\n\neggs and milk"}}
+	if err := ss.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	if slate.ID == "" || slate.Title != "Groceries" || slate.WordCount != 4 {
+		t.Fatalf("saved slate not filled in: %+v", slate)
+	}
+
+	loaded, err := ss.Load(slate.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Content != slate.Content || !loaded.CreatedAt.Equal(slate.CreatedAt) {
+		t.Fatalf("loaded %+v, want %+v", loaded, slate)
+	}
+
+	loaded.Content = "Groceries\n\neggs, milk and bread"
+	if err := ss.Save(loaded); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := ss.Load(slate.ID)
+	if again.Content != loaded.Content || !again.CreatedAt.Equal(slate.CreatedAt) {
+		t.Fatalf("update lost: %+v", again)
+	}
+
+	if _, err := ss.Load("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load(missing) = %v, want ErrNotFound", err)
+	}
+
+	if err := ss.Delete(slate.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ss.Load(slate.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("deleted slate still loads: %v", err)
+	}
+	if found, _ := ss.Search("groceries"); len(found) != 0 {
+		t.Fatalf("deleted slate still in the search index: %v", found)
+	}
+
+	restored, err := ss.Restore(slate.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Content != loaded.Content {
+		t.Fatalf("restored %q", restored.Content)
+	}
+	if found, _ := ss.Search("groceries"); len(found) != 1 {
+		t.Fatal("restored slate isn't searchable")
+	}
+}
+
+func TestSQLiteListOrder(t *testing.T) {
+	ss := newTestSQLite(t, t.TempDir())
+
+	var ids []string
+	for _, content := range []string{"oldest", "middle", "newest"} {
+		slate := &Slate{Slate: model.Slate{Content: content}}
+		if err := ss.Save(slate); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, slate.ID)
+		time.Sleep(time.Millisecond)
+	}
+	if err := ss.SetPinned(ids[0], true); err != nil {
+		t.Fatal(err)
+	}
+
+	slates, err := ss.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range slates {
+		got = append(got, s.Content)
+	}
+	want := []string{"oldest", "newest", "middle"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("List order = %v, want %v", got, want)
+		}
+	}
+
+	if err := ss.SetPinned("missing", true); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SetPinned(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteSearch(t *testing.T) {
+	ss := newTestSQLite(t, t.TempDir())
+	for _, content := range []string{
+		"Meeting notes\n\nbudget review with finance",
+		"Recipes\n\nbread, budget friendly",
+		"Travel\n\npacking list",
+	} {
+		if err := ss.Save(&Slate{Slate: model.Slate{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"budget", 2},
+		{"budg", 2},               // words match as prefixes
+		{"budget finance", 1},     // every word has to match
+		{"meeting", 1},            // titles are indexed
+		{"PACKING", 1},            // case doesn't matter
+		{`"budget" OR travel`, 0}, // typed syntax is matched literally, not run
+		{"  ", 0},
+		{"nothing", 0},
+	}
+	for _, tt := range tests {
+		found, err := ss.Search(tt.query)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		if len(found) != tt.want {
+			t.Errorf("Search(%q) found %d, want %d", tt.query, len(found), tt.want)
+		}
+	}
+}
+
+func TestMigrateJSONOnce(t *testing.T) {
+	dir := t.TempDir()
+	ls, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"one", "two"} {
+		if err := ls.Save(&Slate{Slate: model.Slate{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss := newTestSQLite(t, dir)
+	n, err := MigrateJSON(dir, ss)
+	if err != nil || n != 2 {
+		t.Fatalf("MigrateJSON = %d, %v; want 2 slates", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "slates.json")); err != nil {
+		t.Fatalf("slates.json not kept as a backup: %v", err)
+	}
+
+	// Everything deleted in SQLite mode stays deleted
+	slates, _ := ss.List()
+	for _, s := range slates {
+		if err := ss.Delete(s.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ss.Close()
+	reopened := newTestSQLite(t, dir)
+	if n, err := MigrateJSON(dir, reopened); err != nil || n != 0 {
+		t.Fatalf("second MigrateJSON = %d, %v; want nothing copied", n, err)
+	}
+	if slates, _ := reopened.List(); len(slates) != 0 {
+		t.Fatalf("deleted slates came back: %d", len(slates))
+	}
+}
+
+func TestMigrateJSONEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	ls, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Save(&Slate{Slate: model.Slate{Content: "secret"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.SetPassphrase("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	ss := newTestSQLite(t, dir)
+	n, err := MigrateJSON(dir, ss)
+	if err == nil || n != 0 {
+		t.Fatalf("MigrateJSON = %d, %v; want an error saying it's encrypted", n, err)
+	}
+
+	// The database still works, and the next open tries again
+	if err := ss.Save(&Slate{Slate: model.Slate{Content: "new"}}); err != nil {
+		t.Fatal(err)
+	}
+	var done int
+	ss.db.QueryRow(`SELECT COUNT(*) FROM meta WHERE key = ?`, metaMigrated).Scan(&done)
+	if done != 0 {
+		t.Fatal("a migration that didn't happen was recorded")
+	}
+}
+
+func TestMigrateJSONLeavesDamagedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slates.json")
+	if err := os.WriteFile(path, []byte(`[{"id": "a"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ss := newTestSQLite(t, dir)
+	if _, err := MigrateJSON(dir, ss); err == nil {
+		t.Fatal("want an error for a damaged slates.json")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("damaged slates.json was moved: %v", err)
+	}
+}
+
+// recentStorage is a backend that can read only its most recent slates
+type recentStorage interface {
+	Storage
+	RecentLister
+	Pinner
+}
+
+func TestRecentReadsOnlyN(t *testing.T) {
+	backends := map[string]func(t *testing.T) recentStorage{
+		"json": func(t *testing.T) recentStorage {
+			ls, _ := newTestLocal(t)
+			return ls
+		},
+		"sqlite": func(t *testing.T) recentStorage {
+			return newTestSQLite(t, t.TempDir())
+		},
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			st := open(t)
+			var oldest string
+			for i := range 10 {
+				slate := &Slate{Slate: model.Slate{Content: "slate " + string(rune('a'+i))}}
+				if err := st.Save(slate); err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					oldest = slate.ID
+				}
+				time.Sleep(time.Millisecond)
+			}
+			// Pinned slates lead however old they are
+			if err := st.SetPinned(oldest, true); err != nil {
+				t.Fatal(err)
+			}
+
+			all, _ := st.List()
+			recent, total, err := st.Recent(3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recent) != 3 || total != 10 {
+				t.Fatalf("Recent(3) = %d slates of %d, want 3 of 10", len(recent), total)
+			}
+			for i := range recent {
+				if recent[i].ID != all[i].ID {
+					t.Fatalf("Recent(3)[%d] = %q, want List's %q", i, recent[i].Content, all[i].Content)
+				}
+			}
+
+			if recent, total, _ := st.Recent(50); len(recent) != 10 || total != 10 {
+				t.Fatalf("Recent(50) = %d slates of %d, want all 10", len(recent), total)
+			}
+		})
+	}
+}
+
+func TestReadLocalSQLiteIsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	ss, err := NewSQLite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate := &Slate{Slate: model.Slate{Content: "Groceries\n\neggs and milk"}}
+	if err := ss.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	ss.Close()
+	// Left for MigrateJSON, which reading mustn't run
+	if err := os.WriteFile(filepath.Join(dir, "slates.json"), []byte(`[{"id": "old", "content": "json"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(filepath.Join(dir, "slates.db"))
+
+	r, err := ReadLocal(dir, BackendSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slates, err := r.List()
+	if err != nil || len(slates) != 1 || slates[0].ID != slate.ID {
+		t.Fatalf("List = %v, %v; want the one slate in the database", slates, err)
+	}
+	if got, err := r.Load(slate.ID); err != nil || got.Content != slate.Content {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+	if found, err := r.(Searcher).Search("eggs"); err != nil || len(found) != 1 {
+		t.Fatalf("Search = %v, %v", found, err)
+	}
+	if err := r.(Storage).Save(&Slate{Slate: model.Slate{Content: "sneaky"}}); err == nil {
+		t.Error("saved through a read-only database")
+	}
+	r.(Storage).Close()
+
+	after, _ := os.ReadFile(filepath.Join(dir, "slates.db"))
+	if string(after) != string(before) {
+		t.Error("slates.db changed by reading it")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "slates.db.trash.json")); err == nil {
+		t.Error("reading created a trash")
+	}
+}
@@ -1,20 +1,35 @@
 package storage
 
 import (
-	"time"
+	"errors"
+	"sort"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/textstats"
+	"github.com/justtype/cli/internal/versions"
 )
 
-// Slate represents a writing slate
+// ErrNotFound is returned for a slate ID that doesn't exist
+var ErrNotFound = errors.New("slate not found")
+
+// Slate represents a writing slate: the shared model.Slate, and what only
+// the storage backends keep
 type Slate struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Content     string    `json:"content"`
-	WordCount   int       `json:"word_count"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CloudID     int       `json:"cloud_id,omitempty"`
-	IsPublished bool      `json:"is_published"`
-	ShareID     string    `json:"share_id,omitempty"`
+	model.Slate
+	Pristine bool `json:"pristine,omitempty"` // created empty and never written in
+
+	// Byte offset of the editor cursor when the slate was last saved
+	CursorOffset int `json:"cursor_offset,omitempty"`
+}
+
+// Model is the part of the slate every package shares
+func (s *Slate) Model() model.Slate {
+	return s.Slate
+}
+
+// FromModel makes a slate of the shared part, with no saved cursor
+func FromModel(m model.Slate) *Slate {
+	return &Slate{Slate: m}
 }
 
 // Storage interface for both local and cloud storage
@@ -25,7 +40,8 @@ type Storage interface {
 	// Load loads a specific slate by ID
 	Load(id string) (*Slate, error)
 
-	// List returns all slates, sorted by updated_at desc
+	// List returns all slates, pinned ones first, each sorted by
+	// updated_at desc
 	List() ([]*Slate, error)
 
 	// Delete removes a slate
@@ -35,7 +51,71 @@ type Storage interface {
 	Close() error
 }
 
-// ExtractTitle gets first non-empty line as title
+// Warner is implemented by storages that can have something to report about
+// a request that still succeeded
+type Warner interface {
+	// Warnings returns the warnings since it was last called
+	Warnings() []string
+}
+
+// Pinner is implemented by storages that can keep slates pinned to the top
+// of List
+type Pinner interface {
+	// SetPinned pins or unpins a slate without counting as an edit
+	SetPinned(id string, pinned bool) error
+}
+
+// RecentLister is implemented by storages that can read only the top of
+// List, so showing the most recent slates doesn't load every one
+type RecentLister interface {
+	// Recent returns the first n slates in List order, and how many List
+	// would return
+	Recent(n int) (slates []*Slate, total int, err error)
+}
+
+// SortSlates puts slates in List order: pinned first, then most recently
+// updated
+func SortSlates(slates []*Slate) {
+	sort.SliceStable(slates, func(i, j int) bool {
+		return listsBefore(slates[i], slates[j])
+	})
+}
+
+// listsBefore reports whether a comes before b in List order
+func listsBefore(a, b *Slate) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	return a.UpdatedAt.After(b.UpdatedAt)
+}
+
+// firstSlates returns the first n of slates in List order, keeping only
+// those n as it goes rather than sorting them all
+func firstSlates(slates map[string]*Slate, n int) []*Slate {
+	first := make([]*Slate, 0, n)
+	for _, slate := range slates {
+		i := sort.Search(len(first), func(i int) bool { return listsBefore(slate, first[i]) })
+		if i == n {
+			continue
+		}
+		if len(first) < n {
+			first = append(first, nil)
+		}
+		copy(first[i+1:], first[i:len(first)-1])
+		first[i] = slate
+	}
+	return first
+}
+
+// Versioned is implemented by storages that keep a history of each slate's
+// content
+type Versioned interface {
+	// Versions returns a slate's earlier contents, newest first
+	Versions(id string) ([]versions.Version, error)
+}
+
+// ExtractTitle gets first non-empty line as title, shortened as capTitle
+// does. It only reads content; the slate keeps all of it.
 func ExtractTitle(content string) string {
 	if content == "" {
 		return "untitled"
@@ -45,35 +125,106 @@ func ExtractTitle(content string) string {
 	for _, line := range lines {
 		trimmed := trimSpaces(line)
 		if trimmed != "" {
-			if len(trimmed) > 100 {
-				return trimmed[:100]
-			}
-			return trimmed
+			return capTitle(trimmed, titleLength)
 		}
 	}
 
 	return "untitled"
 }
 
-// CountWords counts words in content
-func CountWords(content string) int {
-	if content == "" {
-		return 0
+// MaxTitleLength is the longest title taken from content, in characters,
+// unless SetTitleLength changes it
+const MaxTitleLength = 100
+
+// MinTitleLength is the shortest length SetTitleLength accepts
+const MinTitleLength = 20
+
+var titleLength = MaxTitleLength
+
+// SetTitleLength sets the longest title ExtractTitle makes, for every
+// storage. 0 means MaxTitleLength; below MinTitleLength is raised to it.
+func SetTitleLength(n int) {
+	switch {
+	case n == 0:
+		n = MaxTitleLength
+	case n < MinTitleLength:
+		n = MinTitleLength
 	}
+	titleLength = n
+}
 
-	count := 0
-	inWord := false
+// capTitle shortens a first line longer than limit characters. It stops after
+// the first sentence, if that's long enough to say something (a third of
+// limit) and short enough to fit, or else after the last whole word that fits,
+// with an ellipsis. A line with no break to stop at, like a pasted URL or
+// hash, makes no sense cut short, so it gives "untitled" instead.
+func capTitle(line string, limit int) string {
+	runes := []rune(line)
+	if len(runes) <= limit {
+		return line
+	}
+
+	for i := limit / 3; i < limit; i++ {
+		if (runes[i] == '.' || runes[i] == '?' || runes[i] == '!') && isSpace(runes[i+1]) {
+			return string(runes[:i+1])
+		}
+	}
 
-	for _, r := range content {
-		if isSpace(r) {
-			inWord = false
-		} else if !inWord {
-			inWord = true
-			count++
+	// Leave room for the ellipsis
+	for i := limit - 1; i > 0; i-- {
+		if isSpace(runes[i]) {
+			return trimSpaces(string(runes[:i])) + "…"
 		}
 	}
 
-	return count
+	return "untitled"
+}
+
+// SuggestedMinWords is the "only keep real notes" preset for new slates
+const SuggestedMinWords = 10
+
+// ShouldSave decides whether editor content gets persisted. Existing slates
+// are always saved; new ones wait until they have at least minWords words,
+// so stray keystrokes don't pile up as slates. minWords 0 saves everything.
+func ShouldSave(content string, isNew bool, minWords int) bool {
+	if content == "" {
+		return false
+	}
+	if !isNew {
+		return true
+	}
+	return CountWords(content) >= minWords
+}
+
+// IsBlank reports whether a slate was created without any words and has
+// never had any since. Slates the user cleared after writing are kept.
+func IsBlank(slate *Slate) bool {
+	return slate.Pristine && trimSpaces(slate.Content) == ""
+}
+
+// markPristine updates the pristine flag before a save
+func markPristine(slate *Slate, isNew bool) {
+	if isNew {
+		slate.Pristine = true
+	}
+	if trimSpaces(slate.Content) != "" {
+		slate.Pristine = false
+	}
+}
+
+// Stats is how long a slate is: its words, with and without code, and its
+// characters, with and without spaces
+type Stats = textstats.Counts
+
+// CountStats counts content as Markdown, leaving the markup out, so
+// **bold** is one word and a link counts its text but not its URL
+func CountStats(content string) Stats {
+	return textstats.Count(content, true)
+}
+
+// CountWords counts words in content; see CountStats
+func CountWords(content string) int {
+	return CountStats(content).Words
 }
 
 func splitLines(s string) []string {
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+func TestExtractTitleCapsLongFirstLine(t *testing.T) {
+	words := strings.Repeat("word ", 30) // 150 characters
+	tests := []struct {
+		name, content, want string
+	}{
+		{"short", "Groceries\n\neggs", "Groceries"},
+		{"leading blank lines", "\n  \n  Plans  \nbody", "Plans"},
+		{"empty", "", "untitled"},
+		{"only whitespace", " \n\t\n", "untitled"},
+		{
+			"stops after a sentence",
+			"Met with the team about the launch plan today. " + words,
+			"Met with the team about the launch plan today.",
+		},
+		{
+			"sentence too short to say much",
+			"Hi. " + words,
+			"Hi. " + strings.TrimSpace(strings.Repeat("word ", 19)) + "…",
+		},
+		{
+			"word boundary",
+			words,
+			strings.TrimSpace(strings.Repeat("word ", 20)) + "…",
+		},
+		{"nothing to break at", strings.Repeat("x", 150), "untitled"},
+		{"exactly the limit", strings.Repeat("y", 100), strings.Repeat("y", 100)},
+		{
+			"counts characters, not bytes",
+			strings.Repeat("日本 ", 40),
+			strings.TrimSpace(strings.Repeat("日本 ", 33)) + "…",
+		},
+	}
+	for _, tt := range tests {
+		got := ExtractTitle(tt.content)
+		if got != tt.want {
+			t.Errorf("%s: ExtractTitle = %q, want %q", tt.name, got, tt.want)
+		}
+		if n := utf8.RuneCountInString(got); n > MaxTitleLength {
+			t.Errorf("%s: title is %d characters", tt.name, n)
+		}
+	}
+}
+
+func TestSetTitleLength(t *testing.T) {
+	t.Cleanup(func() { SetTitleLength(0) })
+	line := strings.Repeat("word ", 30)
+
+	SetTitleLength(30)
+	if got := ExtractTitle(line); got != "word word word word word word…" {
+		t.Errorf("at 30: %q", got)
+	}
+	SetTitleLength(5)
+	if got := ExtractTitle(line); got != "word word word word…" {
+		t.Errorf("below the minimum: %q, want it held at %d", got, MinTitleLength)
+	}
+	SetTitleLength(0)
+	if got := ExtractTitle(line); utf8.RuneCountInString(got) != MaxTitleLength {
+		t.Errorf("reset: %q is %d characters", got, utf8.RuneCountInString(got))
+	}
+}
+
+func TestSaveKeepsWholeFirstLine(t *testing.T) {
+	ls, _ := newTestLocal(t)
+	first := "Met with the team about the launch plan today. " + strings.Repeat("More detail follows here. ", 5)
+	slate := &Slate{Slate: model.Slate{Content: first + "\n\nbody"}}
+	if err := ls.Save(slate); err != nil {
+		t.Fatal(err)
+	}
+	if slate.Title != "Met with the team about the launch plan today." {
+		t.Fatalf("title = %q", slate.Title)
+	}
+	if !strings.HasPrefix(slate.Content, first+"\n") {
+		t.Fatal("first line cut short in the content")
+	}
+}
+
+func TestTitleLeavesContentAlone(t *testing.T) {
+	t.Cleanup(func() { SetTitleLength(0) })
+	SetTitleLength(40)
+
+	blob := strings.Repeat("x", 300)
+	tests := []struct {
+		name, content, title string
+	}{
+		{"multi-line", "Shopping list\n\neggs\nmilk\nbread", "Shopping list"},
+		{"long single line", "The meeting ran long, so we moved the review to Friday and " + strings.Repeat("kept talking ", 10), "The meeting ran long, so we moved the…"},
+		{"one long unbroken line", blob + "\n\nthe rest", "untitled"},
+		{"empty", "", "untitled"},
+	}
+	backends := map[string]func(t *testing.T) Storage{
+		"json":   func(t *testing.T) Storage { ls, _ := newTestLocal(t); return ls },
+		"sqlite": func(t *testing.T) Storage { return newTestSQLite(t, t.TempDir()) },
+	}
+	for name, open := range backends {
+		st := open(t)
+		for _, tt := range tests {
+			slate := &Slate{Slate: model.Slate{Content: tt.content}}
+			if err := st.Save(slate); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := st.Load(slate.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Title != tt.title {
+				t.Errorf("%s, %s: title %q, want %q", name, tt.name, loaded.Title, tt.title)
+			}
+			if loaded.Content != tt.content {
+				t.Errorf("%s, %s: content changed to %q", name, tt.name, loaded.Content)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/e2e"
+)
+
+// TrashedSlate is a deleted slate kept around so it can be restored
+type TrashedSlate struct {
+	Slate
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Restorer is implemented by storages that keep deleted slates in a trash
+type Restorer interface {
+	// Restore brings a deleted slate back and returns it
+	Restore(id string) (*Slate, error)
+}
+
+// trash stores deleted slates in a JSON file next to the slates
+type trash struct {
+	path   string
+	slates map[string]*TrashedSlate
+	key    *e2e.Key // set when slates are encrypted at rest
+}
+
+func newTrash(path string) (*trash, error) {
+	t := &trash{
+		path:   path,
+		slates: make(map[string]*TrashedSlate),
+	}
+
+	if err := t.load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *trash) add(slate *Slate) error {
+	t.slates[slate.ID] = &TrashedSlate{Slate: *slate, DeletedAt: time.Now()}
+	return t.persist()
+}
+
+func (t *trash) take(id string) (*Slate, error) {
+	trashed, ok := t.slates[id]
+	if !ok {
+		return nil, fmt.Errorf("%w in trash", ErrNotFound)
+	}
+
+	delete(t.slates, id)
+	if err := t.persist(); err != nil {
+		return nil, err
+	}
+
+	slate := trashed.Slate
+	return &slate, nil
+}
+
+func (t *trash) load() error {
+	data, err := atrest.ReadFile(t.path, t.key)
+	if err != nil {
+		return err
+	}
+
+	var trashed []*TrashedSlate
+	if err := json.Unmarshal(data, &trashed); err != nil {
+		return err
+	}
+
+	for _, s := range trashed {
+		t.slates[s.ID] = s
+	}
+
+	return nil
+}
+
+func (t *trash) persist() error {
+	data, err := t.encode()
+	if err != nil {
+		return err
+	}
+	return atrest.WriteFile(t.path, data, t.key, 0600)
+}
+
+// encode is the trash file's content, before any encryption
+func (t *trash) encode() ([]byte, error) {
+	trashed := make([]*TrashedSlate, 0, len(t.slates))
+	for _, s := range t.slates {
+		trashed = append(trashed, s)
+	}
+	return json.MarshalIndent(trashed, "", "  ")
+}
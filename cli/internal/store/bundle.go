@@ -0,0 +1,110 @@
+package store
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/justtype/cli/internal/markdown"
+)
+
+// ExportAllMarkdown writes every slate, in list order, to one Markdown file
+// at path: each a ## section under its front matter block, whether
+// metadata is on or not (see SetExportMetadata), the sections separated by
+// ---. An existing file is never overwritten; see WriteNew.
+func (s *Store) ExportAllMarkdown(path string) (*ExportResult, error) {
+	slates := s.All()
+	sections := make([]string, 0, len(slates))
+	for _, slate := range slates {
+		sections = append(sections, s.markdownSection(slate))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	written, err := WriteNew(path, strings.Join(sections, "\n---\n\n"))
+	if err != nil {
+		return nil, err
+	}
+	return &ExportResult{Dir: filepath.Dir(written), File: written, Written: len(slates)}, nil
+}
+
+// markdownSection is one slate in ExportAllMarkdown's file
+func (s *Store) markdownSection(slate *Slate) string {
+	title := slate.Title
+	if title == "" {
+		title = "untitled"
+	}
+	content := slate.Content
+	if first, rest, ok := splitTitle(slate.Title, content); ok {
+		if _, text, heading := markdown.ParseHeading(first); heading {
+			title = text
+		}
+		content = strings.TrimLeft(rest, "\n")
+	}
+
+	var b strings.Builder
+	b.WriteString(s.frontMatter(slate, title) + "\n")
+	b.WriteString("## " + title + "\n\n")
+	b.WriteString(strings.TrimRight(markdown.Wrap(content, s.wrap), "\n") + "\n")
+	return b.String()
+}
+
+// ExportAllZip writes every slate to a zip archive at path, each as the
+// .txt file ExportAll would write, dated by its last update. Slates with the
+// same title get -2, -3 and so on, as WriteNew does for files. An existing
+// archive is never overwritten.
+func (s *Store) ExportAllZip(path string) (*ExportResult, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	slates := s.All()
+	names := s.exportFilenames()
+	for i, slate := range slates {
+		header := &zip.FileHeader{
+			Name:     names[i],
+			Method:   zip.Deflate,
+			Modified: slate.UpdatedAt,
+		}
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(s.exportContent(slate))); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	written, err := WriteNew(path, buf.String())
+	if err != nil {
+		return nil, err
+	}
+	return &ExportResult{Dir: filepath.Dir(written), File: written, Written: len(slates)}, nil
+}
+
+// uniqueName returns name, or name with a number before the extension if
+// taken has it already, and adds what it returns to taken. Names differing
+// only in case count as the same, since they are on most desktops.
+func uniqueName(name string, taken map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		candidate := name
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		if key := strings.ToLower(candidate); !taken[key] {
+			taken[key] = true
+			return candidate
+		}
+	}
+}
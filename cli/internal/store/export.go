@@ -0,0 +1,178 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/markdown"
+)
+
+// Formats ExportOne can write; each is also the file extension
+const (
+	FormatText     = "txt"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+)
+
+// ExportFormats lists the formats in the order the export dialog cycles them
+var ExportFormats = []string{FormatText, FormatMarkdown, FormatHTML}
+
+// ExportOne writes one slate to path in format. An existing file is never
+// overwritten: a number goes before the extension instead ("notes-2.md").
+// It returns the path written.
+func (s *Store) ExportOne(id, path, format string) (string, error) {
+	slate := s.slates[id]
+	if slate == nil {
+		return "", os.ErrNotExist
+	}
+
+	var content string
+	switch format {
+	case FormatText:
+		content = s.exportContent(slate)
+	case FormatMarkdown:
+		content = s.exportHeader(slate) + markdownExport(slate.Title, markdown.Wrap(slate.Content, s.wrap))
+	case FormatHTML:
+		content = htmlExport(slate.Title, slate.Content)
+	default:
+		return "", fmt.Errorf("unknown export format %q", format)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return WriteNew(path, content)
+}
+
+// WriteNew writes content to path, or to path with -2, -3 and so on before
+// the extension if that's taken, and returns the path it used
+func WriteNew(path, content string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for n := 1; ; n++ {
+		target := path
+		if n > 1 {
+			target = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.WriteString(content); err != nil {
+			f.Close()
+			return "", err
+		}
+		return target, f.Close()
+	}
+}
+
+// exportHeader is the front matter an export starts with, or "" if
+// metadata is off; see SetExportMetadata
+func (s *Store) exportHeader(slate *Slate) string {
+	if !s.metadata {
+		return ""
+	}
+	return s.frontMatter(slate, slate.Title) + "\n"
+}
+
+// frontMatter is the block of what an export records about slate, titled
+// title, between --- lines. parseFrontMatter reads it back.
+func (s *Store) frontMatter(slate *Slate, title string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("title: " + strconv.Quote(title) + "\n")
+	b.WriteString("created: " + slate.CreatedAt.Format(time.RFC3339) + "\n")
+	b.WriteString("updated: " + slate.UpdatedAt.Format(time.RFC3339) + "\n")
+	fmt.Fprintf(&b, "words: %d\n", slate.WordCount)
+	if len(slate.Tags) > 0 {
+		b.WriteString("tags: [" + strings.Join(slate.Tags, ", ") + "]\n")
+	}
+	if slate.IsPublished && slate.ShareID != "" {
+		b.WriteString("share: " + api.ShareURL(s.shareBase, slate.ShareID) + "\n")
+	}
+	b.WriteString("---\n")
+	return b.String()
+}
+
+// splitTitle separates the first line of content when it's the title (or
+// a heading already), so exports don't show the title twice
+func splitTitle(title, content string) (first, rest string, ok bool) {
+	first, rest, _ = strings.Cut(content, "\n")
+	if isHeading(first) {
+		return first, rest, true
+	}
+	if title != "" && strings.HasPrefix(strings.TrimSpace(first), title) {
+		return first, rest, true
+	}
+	return "", content, false
+}
+
+func isHeading(line string) bool {
+	_, _, ok := markdown.ParseHeading(line)
+	return ok
+}
+
+// markdownExport keeps the title as a # heading
+func markdownExport(title, content string) string {
+	first, rest, ok := splitTitle(title, content)
+	switch {
+	case !ok:
+		return "# " + title + "\n\n" + content
+	case isHeading(first):
+		return content
+	default:
+		return "# " + strings.TrimSpace(first) + "\n" + rest
+	}
+}
+
+// htmlExport wraps content in a small standalone page. Blank lines split
+// paragraphs and Markdown headings become headings; the rest is plain text.
+func htmlExport(title, content string) string {
+	if title == "" {
+		title = "untitled"
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>body{max-width:40em;margin:3em auto;padding:0 1em;font:18px/1.6 Georgia,serif;color:#222}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+
+	if first, rest, ok := splitTitle(title, content); !ok || !isHeading(first) {
+		b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+		if ok {
+			content = rest
+		}
+	}
+
+	for _, para := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		para = strings.Trim(para, "\n")
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		if level, text, ok := markdown.ParseHeading(para); ok && !strings.Contains(para, "\n") {
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/tags"
+)
+
+// ImportExtensions are the files ImportDir picks up
+var ImportExtensions = []string{".txt", ".md"}
+
+// Titles taken from an imported file's first line are cut to this many
+// characters
+const importTitleLength = 100
+
+var (
+	// ErrAlreadyImported means a slate with the same content is already in
+	// the store, so importing again doesn't make a second one
+	ErrAlreadyImported = errors.New("already imported")
+
+	// ErrEmptyFile means the file has nothing in it to make a slate of
+	ErrEmptyFile = errors.New("file is empty")
+)
+
+// ImportFile makes a slate of a text or markdown file, titled by its first
+// line (or its name, if that's blank) and dated by its modification time.
+// A file exported with metadata (see SetExportMetadata) gets its title and
+// dates from that instead. If a slate already has the same content it
+// returns that one with ErrAlreadyImported.
+func (s *Store) ImportFile(path string) (*Slate, error) {
+	slate, err := s.importFile(path, s.contentHashes())
+	if err != nil {
+		return slate, err
+	}
+	if err := s.save(); err != nil {
+		delete(s.slates, slate.ID)
+		return nil, err
+	}
+	s.versions.Record(slate.ID, slate.Content, slate.UpdatedAt)
+	return slate, nil
+}
+
+// ImportDir imports the .txt and .md files directly in dir. It returns the
+// slates made, oldest first, and how many files were skipped because they're
+// empty or already imported. A file that can't be read doesn't stop the
+// rest; the errors are joined.
+func (s *Store) ImportDir(dir string) ([]*Slate, int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isImportable(entry.Name()) {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+
+	hashes := s.contentHashes()
+	var imported []*Slate
+	var errs []error
+	skipped := 0
+	for _, path := range paths {
+		slate, err := s.importFile(path, hashes)
+		switch {
+		case errors.Is(err, ErrAlreadyImported), errors.Is(err, ErrEmptyFile):
+			skipped++
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			imported = append(imported, slate)
+		}
+	}
+
+	if len(imported) > 0 {
+		if err := s.save(); err != nil {
+			for _, slate := range imported {
+				delete(s.slates, slate.ID)
+			}
+			return nil, skipped, err
+		}
+		for _, slate := range imported {
+			s.versions.Record(slate.ID, slate.Content, slate.UpdatedAt)
+		}
+	}
+
+	// Oldest first, the order they were written in
+	sort.Slice(imported, func(i, j int) bool {
+		return imported[i].CreatedAt.Before(imported[j].CreatedAt)
+	})
+	return imported, skipped, errors.Join(errs...)
+}
+
+// importFile adds path to the store without saving it. hashes maps the
+// content hash of every slate to it, and takes the new one too so a
+// directory with two copies of a file imports it once.
+func (s *Store) importFile(path string, hashes map[string]*Slate) (*Slate, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := normalize.Apply(strings.TrimPrefix(string(data), "\ufeff"), s.norm)
+	created, updated := info.ModTime(), info.ModTime()
+	title := ""
+	if meta, body, ok := parseFrontMatter(content); ok {
+		content = exportBody(body, meta.title)
+		created, updated, title = meta.created, meta.updated, meta.title
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), ErrEmptyFile)
+	}
+	hash := contentHash(content)
+	if existing := hashes[hash]; existing != nil {
+		return existing, fmt.Errorf("%s: %w as \"%s\"", filepath.Base(path), ErrAlreadyImported, existing.Title)
+	}
+
+	if title == "" {
+		title = importTitle(content, path)
+	}
+	slate := &Slate{
+		Slate: model.Slate{
+			ID:        generateID(),
+			Title:     title,
+			Content:   content,
+			WordCount: countWords(content),
+			Tags:      tags.Parse(content),
+			CreatedAt: created,
+			UpdatedAt: updated,
+		},
+		Origin: OriginLocal,
+	}
+	s.slates[slate.ID] = slate
+	hashes[hash] = slate
+	return slate, nil
+}
+
+// contentHashes maps the hash of each slate's content to the slate. Slates
+// whose content isn't downloaded aren't in it.
+func (s *Store) contentHashes() map[string]*Slate {
+	hashes := make(map[string]*Slate, len(s.slates))
+	for _, slate := range s.slates {
+		if !slate.Unavailable {
+			hashes[contentHash(slate.Content)] = slate
+		}
+	}
+	return hashes
+}
+
+// importTitle is the first non-blank line of content, without a markdown
+// heading's #s, or else the file's name
+func importTitle(content, path string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > importTitleLength {
+			line = strings.TrimSpace(string(runes[:importTitleLength-1])) + "…"
+		}
+		return line
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// exportMeta is what parseFrontMatter reads back from an export
+type exportMeta struct {
+	title            string
+	created, updated time.Time
+}
+
+// parseFrontMatter reads the block an export with metadata starts with (see
+// frontMatter) and returns what follows it. Only a block with created,
+// updated and words lines counts, so another tool's front matter stays part
+// of the slate; ok is false then and body is content whole.
+func parseFrontMatter(content string) (meta exportMeta, body string, ok bool) {
+	rest, found := strings.CutPrefix(content, "---\n")
+	if !found {
+		return meta, content, false
+	}
+	block, body, found := strings.Cut(rest, "\n---\n")
+	if !found {
+		return meta, content, false
+	}
+
+	fields := make(map[string]string)
+	for _, line := range strings.Split(block, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return meta, content, false
+		}
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	var errCreated, errUpdated, errWords error
+	meta.created, errCreated = time.Parse(time.RFC3339, fields["created"])
+	meta.updated, errUpdated = time.Parse(time.RFC3339, fields["updated"])
+	_, errWords = strconv.Atoi(fields["words"])
+	if errCreated != nil || errUpdated != nil || errWords != nil {
+		return exportMeta{}, content, false
+	}
+
+	meta.title = fields["title"]
+	if title, err := strconv.Unquote(meta.title); err == nil {
+		meta.title = title
+	}
+	return meta, strings.TrimPrefix(body, "\n"), true
+}
+
+// exportBody takes off the title line a .txt or .md export put above the
+// slate's content
+func exportBody(body, title string) string {
+	if title == "" {
+		return body
+	}
+	for _, prefix := range []string{title + "\n\n", "# " + title + "\n\n"} {
+		if rest, ok := strings.CutPrefix(body, prefix); ok {
+			return rest
+		}
+	}
+	return body
+}
+
+func isImportable(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, importable := range ImportExtensions {
+		if ext == importable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Relevance weights for SearchRanked. Each occurrence of the query scores
+// its field's weight, tripled when it's a whole word.
+const (
+	titleWeight     = 10
+	contentWeight   = 1
+	wholeWordFactor = 3
+)
+
+// snippetContext is how many runes of the matching line are kept either
+// side of the match
+const snippetContext = 30
+
+// SearchResult is a slate matching a search, with where it matched
+type SearchResult struct {
+	Slate *Slate
+	Score int
+
+	// Snippet is the first content line that matched, cut down to the
+	// text around the match, which is Snippet[MatchStart:MatchEnd]. It's
+	// empty when only the title matched.
+	Snippet    string
+	MatchStart int
+	MatchEnd   int
+}
+
+// SearchRanked is Search sorted by relevance, most relevant first
+func (s *Store) SearchRanked(query string) []SearchResult {
+	return s.SearchRankedIn(query, ScopeAll)
+}
+
+// SearchRankedIn scores every slate matching query within scope: title
+// matches count for more than content ones, every occurrence adds up, and
+// whole words beat parts of words. Ties go to the most recently updated.
+func (s *Store) SearchRankedIn(query, scope string) []SearchResult {
+	if query == "" {
+		return nil
+	}
+
+	var results []SearchResult
+	for _, slate := range s.slates {
+		if !s.searchable(slate) {
+			continue
+		}
+		result := SearchResult{Slate: slate}
+		result.Score = titleWeight * scoreMatches(slate.Title, query)
+		if scope != ScopeTitle {
+			result.Score += contentWeight * scoreMatches(slate.Content, query)
+			result.Snippet, result.MatchStart, result.MatchEnd = snippet(slate.Content, query)
+		}
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Slate.UpdatedAt.After(results[j].Slate.UpdatedAt)
+	})
+	return results
+}
+
+// SearchRegex returns the slates whose title or content matches pattern,
+// most recently updated first. An invalid pattern is returned as an error;
+// an empty one matches everything.
+func (s *Store) SearchRegex(pattern string, caseSensitive bool) ([]*Slate, error) {
+	if strings.TrimSpace(pattern) == "" {
+		if s.archived {
+			return s.All(), nil
+		}
+		return s.List(), nil
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*Slate
+	for _, slate := range s.slates {
+		if !s.searchable(slate) {
+			continue
+		}
+		if re.MatchString(slate.Title) || re.MatchString(slate.Content) {
+			results = append(results, slate)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].UpdatedAt.After(results[j].UpdatedAt)
+	})
+	return results, nil
+}
+
+// searchable reports whether searches look at slate: archived slates only
+// when SetSearchArchived is on
+func (s *Store) searchable(slate *Slate) bool {
+	return s.archived || !slate.Archived
+}
+
+// scoreMatches counts the occurrences of query in text, whole words counting
+// wholeWordFactor times
+func scoreMatches(text, query string) int {
+	score := 0
+	for from := 0; ; {
+		start, end := indexFold(text, query, from)
+		if start < 0 {
+			return score
+		}
+		if wholeWord(text, start, end) {
+			score += wholeWordFactor
+		} else {
+			score++
+		}
+		from = end
+	}
+}
+
+// indexFold finds query in text from byte offset from, ignoring case, and
+// returns the byte range it matched in text or -1, -1. Case folding can
+// change a rune's length, so the range is measured in text itself.
+func indexFold(text, query string, from int) (int, int) {
+	for i := from; i < len(text); {
+		if end := prefixFold(text[i:], query); end >= 0 {
+			return i, i + end
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return -1, -1
+}
+
+// prefixFold returns how many bytes of text match query, ignoring case, or
+// -1 if text doesn't start with it
+func prefixFold(text, query string) int {
+	n := 0
+	for _, q := range query {
+		if n >= len(text) {
+			return -1
+		}
+		r, size := utf8.DecodeRuneInString(text[n:])
+		if r != q && unicode.ToLower(r) != unicode.ToLower(q) {
+			return -1
+		}
+		n += size
+	}
+	return n
+}
+
+// wholeWord reports whether text[start:end] isn't part of a longer word
+func wholeWord(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// snippet returns the line holding the first match of query in content,
+// trimmed to snippetContext runes either side of it, and where the match is
+// in the result
+func snippet(content, query string) (string, int, int) {
+	start, end := indexFold(content, query, 0)
+	if start < 0 {
+		return "", 0, 0
+	}
+
+	lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+	lineEnd := len(content)
+	if i := strings.IndexByte(content[end:], '\n'); i >= 0 {
+		lineEnd = end + i
+	}
+
+	before := content[lineStart:start]
+	after := content[end:lineEnd]
+	prefix, suffix := "", ""
+	if utf8.RuneCountInString(before) > snippetContext {
+		runes := []rune(before)
+		before = string(runes[len(runes)-snippetContext:])
+		prefix = "…"
+	}
+	if utf8.RuneCountInString(after) > snippetContext {
+		after = string([]rune(after)[:snippetContext])
+		suffix = "…"
+	}
+
+	before = strings.TrimLeft(prefix+before, " \t")
+	text := before + content[start:end] + strings.TrimRight(after, " \t\r") + suffix
+	return text, len(before), len(before) + end - start
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+)
+
+// fullSlate has every field set, so a round trip that drops one shows
+func fullSlate() *Slate {
+	now := time.Date(2026, 5, 4, 3, 2, 1, 0, time.UTC)
+	return &Slate{
+		Slate: model.Slate{
+			ID:          "cloud-9",
+			Title:       "Plans",
+			Content:     "Plans\n\n#garden beds",
+			WordCount:   3,
+			CreatedAt:   now.Add(-time.Hour),
+			UpdatedAt:   now,
+			CloudID:     9,
+			IsPublished: true,
+			ShareID:     "s9",
+			Tags:        []string{"garden"},
+			Pinned:      true,
+		},
+		Synced:       true,
+		LastSyncedAt: now,
+		SyncedHash:   "hash",
+		Pristine:     true,
+		Origin:       OriginCloud,
+		Unavailable:  true,
+		Order:        4,
+		Archived:     true,
+		CursorOffset: 12,
+	}
+}
+
+func TestSlateJSONKeysUnchanged(t *testing.T) {
+	data, err := json.Marshal(fullSlate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+
+	// What slates.json has always held; renaming one loses it on upgrade
+	want := []string{
+		"archived", "cloud_id", "content", "content_unavailable", "created_at",
+		"cursor_offset", "id", "is_published", "last_synced_at", "order",
+		"origin", "pinned", "pristine", "share_id", "synced", "synced_hash",
+		"tags", "title", "updated_at", "word_count",
+	}
+	var got []string
+	for key := range fields {
+		got = append(got, key)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("keys %v, want %v", got, want)
+	}
+}
+
+func TestSlateRoundTrip(t *testing.T) {
+	orig := fullSlate()
+
+	data, _ := json.Marshal(orig)
+	var decoded Slate
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, orig) {
+		t.Fatalf("JSON round trip:\n got %+v\nwant %+v", decoded, *orig)
+	}
+
+	// Through the shared model, only the store's own fields are left behind
+	back := FromModel(orig.Model())
+	if !reflect.DeepEqual(back.Slate, orig.Slate) {
+		t.Fatalf("model round trip:\n got %+v\nwant %+v", back.Slate, orig.Slate)
+	}
+	if back.Synced || back.Origin != "" || back.CursorOffset != 0 {
+		t.Fatalf("FromModel set the store's own fields: %+v", back)
+	}
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StreakGrace is how many days in a row can go by without a save before a
+// writing streak ends, so missing a day now and then doesn't reset it
+const StreakGrace = 1
+
+// statsDays is how many days Stats breaks words down by
+const statsDays = 7
+
+// dayLayout keys days in stats.json, in local time
+const dayLayout = "2006-01-02"
+
+// Stats sums up how much has been written
+type Stats struct {
+	TotalWords int
+	Slates     int
+	WordsToday int
+	Streak     int        // days written on, up to today, with gaps no longer than StreakGrace
+	Days       []DayWords // the last statsDays days, oldest first, ending today
+}
+
+// DayWords is the words written on one local calendar day
+type DayWords struct {
+	Day   time.Time // local midnight
+	Words int
+}
+
+// statsLog is stats.json: the words added on each day there was a save.
+// A day with a save but no new words is still listed, with 0.
+type statsLog struct {
+	Days map[string]int `json:"days"`
+}
+
+func (s *Store) statsPath() string {
+	return filepath.Join(s.baseDir, "stats.json")
+}
+
+func (s *Store) loadStats() statsLog {
+	log := statsLog{Days: make(map[string]int)}
+	if data, err := os.ReadFile(s.statsPath()); err == nil {
+		json.Unmarshal(data, &log)
+		if log.Days == nil {
+			log.Days = make(map[string]int)
+		}
+	}
+	return log
+}
+
+// recordWords adds the words a save added (deleting words doesn't take any
+// away) to the day it happened
+func (s *Store) recordWords(at time.Time, added int) {
+	log := s.loadStats()
+	log.Days[dayKey(at)] += max(added, 0)
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(s.statsPath(), data, 0600)
+}
+
+// Stats works out the writing statistics as of now. Days before stats.json
+// was kept count as written on if a slate was created or updated then.
+func (s *Store) Stats(now time.Time) Stats {
+	log := s.loadStats()
+
+	var st Stats
+	saved := make(map[string]bool, len(log.Days))
+	for day := range log.Days {
+		saved[day] = true
+	}
+	for _, slate := range s.slates {
+		st.TotalWords += slate.WordCount
+		st.Slates++
+		saved[dayKey(slate.CreatedAt)] = true
+		saved[dayKey(slate.UpdatedAt)] = true
+	}
+
+	today := midnight(now)
+	st.WordsToday = log.Days[dayKey(today)]
+
+	for i := statsDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		st.Days = append(st.Days, DayWords{Day: day, Words: log.Days[dayKey(day)]})
+	}
+
+	st.Streak = streak(saved, today)
+	return st
+}
+
+// streak counts the days written on going back from today, stopping at the
+// first gap longer than StreakGrace. A streak isn't over just because
+// today hasn't been written on yet.
+func streak(saved map[string]bool, today time.Time) int {
+	n, gap := 0, 0
+	for day := today; gap <= StreakGrace; day = day.AddDate(0, 0, -1) {
+		if saved[dayKey(day)] {
+			n++
+			gap = 0
+		} else if n > 0 || !day.Equal(today) {
+			gap++
+		}
+	}
+	return n
+}
+
+// midnight is the start of t's day in local time. AddDate on it moves by
+// calendar days, whatever daylight saving does to their length.
+func midnight(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func dayKey(t time.Time) string {
+	return t.Local().Format(dayLayout)
+}
@@ -2,115 +2,587 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/ids"
+	"github.com/justtype/cli/internal/jsonfields"
+	"github.com/justtype/cli/internal/markdown"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/tags"
+	"github.com/justtype/cli/internal/textstats"
+	"github.com/justtype/cli/internal/versions"
 )
 
+// Slate is a slate in the store: the shared model.Slate, and its sync state
+// and place in the list
 type Slate struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Content     string    `json:"content"`
-	WordCount   int       `json:"word_count"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CloudID     int       `json:"cloud_id,omitempty"`
-	IsPublished bool      `json:"is_published"`
-	ShareID     string    `json:"share_id,omitempty"`
-	Synced      bool      `json:"synced"`
+	model.Slate
+	Synced bool `json:"synced"`
+	// The cloud copy's update time and content hash when the two last
+	// matched, for telling which side changed since; see Compare
+	LastSyncedAt time.Time `json:"last_synced_at,omitzero"`
+	SyncedHash   string    `json:"synced_hash,omitempty"`
+	Pristine     bool      `json:"pristine,omitempty"` // created empty and never written in
+	Origin       string    `json:"origin,omitempty"`
+	Unavailable  bool      `json:"content_unavailable,omitempty"` // listed by the cloud, content not downloaded yet
+	Order        int       `json:"order,omitempty"`               // position in manual order, 0 until placed
+	Archived     bool      `json:"archived,omitempty"`            // left out of List, see ListArchived
+	// Byte offset of the editor cursor when the slate was last saved
+	CursorOffset int `json:"cursor_offset,omitempty"`
+}
+
+// Model is the part of the slate every package shares
+func (s *Slate) Model() model.Slate {
+	return s.Slate
+}
+
+// FromModel makes a slate of the shared part. Sync state and origin are
+// left for the caller to set.
+func FromModel(m model.Slate) *Slate {
+	return &Slate{Slate: m}
+}
+
+// Where a slate came from. Sync only creates slates on the server that
+// started on this device, so a cloud copy that lost its CloudID isn't
+// pushed back as a duplicate.
+const (
+	OriginLocal    = "local"    // written on this device
+	OriginCloud    = "cloud"    // pulled from the account
+	OriginImported = "imported" // brought in from a file
+)
+
+// TrashedSlate is a deleted slate kept around so it can be restored
+type TrashedSlate struct {
+	Slate
+	DeletedAt    time.Time `json:"deleted_at"`
+	CloudPending bool      `json:"cloud_delete_pending,omitempty"` // cloud copy still needs deleting
 }
 
 type Store struct {
-	baseDir string
-	slates  map[string]*Slate
+	baseDir   string
+	slates    map[string]*Slate
+	extra     map[string]jsonfields.Extra // fields from newer versions, by slate ID
+	trash     map[string]*TrashedSlate
+	norm      normalize.Options
+	wrap      int    // hard-wrap exports at this column, 0 for off
+	metadata  bool   // exports start with a front matter block
+	shareBase string // the server share links in that block point to
+	manual    bool
+	archived  bool // searches include archived slates
+	versions  *versions.Log
+	key       *e2e.Key // set when slates are encrypted at rest
+	locked    bool     // encrypted and not unlocked yet; nothing is loaded
+	recovered string   // set when slates.json was damaged, and slates.json.tmp loaded or it was set aside
 }
 
 func New() (*Store, error) {
-	homeDir, err := os.UserHomeDir()
+	baseDir, err := config.Dir()
 	if err != nil {
 		return nil, err
 	}
 
-	baseDir := filepath.Join(homeDir, ".justtype")
+	s, err := Open(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	s.PurgeTrash(TrashRetention)
+	return s, nil
+}
+
+// Open loads the store kept in baseDir instead of the justtype data directory
+func Open(baseDir string) (*Store, error) {
 	if err := os.MkdirAll(baseDir, 0700); err != nil {
 		return nil, err
 	}
 
 	s := &Store{
-		baseDir: baseDir,
-		slates:  make(map[string]*Slate),
+		baseDir:  baseDir,
+		slates:   make(map[string]*Slate),
+		extra:    make(map[string]jsonfields.Extra),
+		trash:    make(map[string]*TrashedSlate),
+		versions: versions.New(filepath.Join(baseDir, "versions")),
 	}
 
-	if err := s.load(); err != nil && !os.IsNotExist(err) {
+	// Finish or undo a change of passphrase that was cut short
+	if err := atrest.Recover(s.path()); err != nil {
+		return nil, err
+	}
+	encrypted, err := atrest.IsEncrypted(s.path())
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		// Loaded by Unlock once the UI has the passphrase
+		s.locked = true
+		return s, nil
+	}
+
+	if err := s.loadAll(); err != nil {
 		return nil, err
 	}
 
 	return s, nil
 }
 
-func (s *Store) load() error {
-	data, err := os.ReadFile(filepath.Join(s.baseDir, "slates.json"))
+// OpenWithKey is Open for slates encrypted with key rather than a
+// passphrase, such as the offline copy of an account's slates, sealed with
+// its end-to-end key. Slates written in plaintext before are encrypted
+// right away. Slates encrypted with another key return
+// atrest.ErrWrongPassphrase.
+func OpenWithKey(baseDir string, key *e2e.Key) (*Store, error) {
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		baseDir:  baseDir,
+		slates:   make(map[string]*Slate),
+		extra:    make(map[string]jsonfields.Extra),
+		trash:    make(map[string]*TrashedSlate),
+		versions: versions.New(filepath.Join(baseDir, "versions")),
+		key:      key,
+	}
+	s.versions.SetKey(key)
+
+	encrypted, err := atrest.IsEncrypted(s.path())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadAll(); err != nil {
+		return nil, err
+	}
+	if !encrypted && len(s.slates) > 0 {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+		if err := s.saveTrash(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) path() string {
+	return filepath.Join(s.baseDir, "slates.json")
+}
+
+func (s *Store) trashPath() string {
+	return filepath.Join(s.baseDir, "trash.json")
+}
+
+func (s *Store) loadAll() error {
+	if err := s.load(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := s.loadTrash(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Locked reports whether the slates are encrypted and waiting on Unlock.
+// A locked store is empty and doesn't write anything.
+func (s *Store) Locked() bool {
+	return s.locked
+}
+
+// Unlock decrypts and loads an encrypted store. A wrong passphrase returns
+// atrest.ErrWrongPassphrase and leaves the store locked.
+func (s *Store) Unlock(passphrase string) error {
+	if !s.locked {
+		return nil
+	}
+
+	key, err := atrest.Unlock(s.path(), passphrase)
 	if err != nil {
 		return err
 	}
+	s.key = key
+	s.versions.SetKey(key)
+	if err := s.loadAll(); err != nil {
+		return err
+	}
+	s.locked = false
+	return nil
+}
 
-	var slates []*Slate
-	if err := json.Unmarshal(data, &slates); err != nil {
+// SetPassphrase encrypts the slates, trash and history on disk with a key
+// derived from passphrase, re-encrypting them if they already were. An
+// empty passphrase turns encryption off and writes everything in plaintext.
+func (s *Store) SetPassphrase(passphrase string) error {
+	if s.locked {
+		return atrest.ErrLocked
+	}
+
+	rekey, err := atrest.BeginRekey(s.path(), passphrase)
+	if err != nil {
+		return err
+	}
+	if err := s.stageRekey(rekey); err != nil {
+		rekey.Abort()
+		return err
+	}
+	if err := rekey.Commit(); err != nil {
+		return err
+	}
+
+	s.key = rekey.Key()
+	s.versions.SetKey(s.key)
+	return nil
+}
+
+// stageRekey stages the slates, trash and history under rekey's key
+func (s *Store) stageRekey(rekey *atrest.Rekey) error {
+	data, err := s.encodeSlates()
+	if err != nil {
+		return err
+	}
+	if err := rekey.WriteFile(s.path(), data, 0600); err != nil {
+		return err
+	}
+	data, err = s.encodeTrash()
+	if err != nil {
+		return err
+	}
+	if err := rekey.WriteFile(s.trashPath(), data, 0600); err != nil {
 		return err
 	}
+	return s.versions.Rekey(rekey.Key(), rekey.Stage)
+}
+
+func (s *Store) load() error {
+	raw, err := s.readSlates(s.path())
+	if err != nil {
+		// A save cut short may have left a whole copy staged beside it
+		tmp := atrest.TempPath(s.path())
+		if staged, tmpErr := s.readSlates(tmp); tmpErr == nil {
+			raw, err = staged, nil
+			s.recovered = fmt.Sprintf("%s couldn't be read, recovered %d slates from %s",
+				filepath.Base(s.path()), len(staged), filepath.Base(tmp))
+		}
+	}
+	if err != nil {
+		return s.setAside(err)
+	}
 
-	for _, slate := range slates {
+	for _, r := range raw {
+		slate := &Slate{}
+		extra, err := jsonfields.Split(r, slate)
+		if err != nil {
+			return s.setAside(err)
+		}
 		s.slates[slate.ID] = slate
+		if extra != nil {
+			s.extra[slate.ID] = extra
+		}
 	}
 
 	return nil
 }
 
 func (s *Store) save() error {
-	slates := s.List()
-	data, err := json.MarshalIndent(slates, "", "  ")
+	if s.locked {
+		// Writing now would replace the encrypted slates with nothing
+		return atrest.ErrLocked
+	}
+
+	data, err := s.encodeSlates()
+	if err != nil {
+		return err
+	}
+	return atrest.WriteFile(s.path(), data, s.key, 0600)
+}
+
+// encodeSlates is slates.json's content, before any encryption
+func (s *Store) encodeSlates() ([]byte, error) {
+	var slates []json.RawMessage
+	for _, slate := range s.All() {
+		encoded, err := jsonfields.Join(slate, s.extra[slate.ID])
+		if err != nil {
+			return nil, err
+		}
+		slates = append(slates, encoded)
+	}
+	return json.MarshalIndent(slates, "", "  ")
+}
+
+// setAside handles err from loading the slates. If they aren't valid JSON,
+// the file is moved aside and the slates start out empty, with Recovered
+// saying where it went; any other error is returned as it is.
+func (s *Store) setAside(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+		return err
+	}
+
+	dest, moveErr := atrest.SetAside(s.path())
+	if moveErr != nil {
+		return fmt.Errorf("%s is damaged (%v) and couldn't be moved aside: %w", filepath.Base(s.path()), err, moveErr)
+	}
+	s.slates = make(map[string]*Slate)
+	s.extra = make(map[string]jsonfields.Extra)
+	s.recovered = fmt.Sprintf("%s couldn't be read and was moved to %s; your slates are there, not deleted",
+		filepath.Base(s.path()), filepath.Base(dest))
+	return nil
+}
+
+// readSlates reads the slates at path without decoding each one
+func (s *Store) readSlates(path string) ([]json.RawMessage, error) {
+	data, err := atrest.ReadFile(path, s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// Recovered describes the recovery if the slates were loaded from a save
+// that was cut short or set aside as damaged, or returns "" if they loaded
+// normally
+func (s *Store) Recovered() string {
+	return s.recovered
+}
+
+func (s *Store) loadTrash() error {
+	data, err := atrest.ReadFile(s.trashPath(), s.key)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filepath.Join(s.baseDir, "slates.json"), data, 0600)
+	var trashed []*TrashedSlate
+	if err := json.Unmarshal(data, &trashed); err != nil {
+		return err
+	}
+
+	for _, t := range trashed {
+		s.trash[t.ID] = t
+	}
+
+	return nil
 }
 
+func (s *Store) saveTrash() error {
+	if s.locked {
+		return atrest.ErrLocked
+	}
+
+	data, err := s.encodeTrash()
+	if err != nil {
+		return err
+	}
+	return atrest.WriteFile(s.trashPath(), data, s.key, 0600)
+}
+
+// encodeTrash is trash.json's content, before any encryption
+func (s *Store) encodeTrash() ([]byte, error) {
+	trashed := make([]*TrashedSlate, 0, len(s.trash))
+	for _, t := range s.trash {
+		trashed = append(trashed, t)
+	}
+	return json.MarshalIndent(trashed, "", "  ")
+}
+
+// List returns every slate that isn't archived, most recently updated
+// first, or in manual order when that's on. Pinned slates come before the
+// rest, in the same order among themselves.
 func (s *Store) List() []*Slate {
+	return s.sorted(func(slate *Slate) bool { return !slate.Archived })
+}
+
+// ListArchived returns the archived slates, in List order
+func (s *Store) ListArchived() []*Slate {
+	return s.sorted(func(slate *Slate) bool { return slate.Archived })
+}
+
+// All returns every slate, archived ones too, in List order
+func (s *Store) All() []*Slate {
+	return s.sorted(func(*Slate) bool { return true })
+}
+
+// sorted returns the slates keep is true for, in List order
+func (s *Store) sorted(keep func(*Slate) bool) []*Slate {
 	var slates []*Slate
 	for _, slate := range s.slates {
-		slates = append(slates, slate)
+		if keep(slate) {
+			slates = append(slates, slate)
+		}
 	}
 
 	sort.Slice(slates, func(i, j int) bool {
-		return slates[i].UpdatedAt.After(slates[j].UpdatedAt)
+		return s.before(slates[i], slates[j])
 	})
 
 	return slates
 }
 
+// before reports whether a comes before b in List order
+func (s *Store) before(a, b *Slate) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	// Slates that haven't been placed yet (new ones) go first
+	if s.manual && a.Order != b.Order {
+		if a.Order == 0 || b.Order == 0 {
+			return a.Order == 0
+		}
+		return a.Order < b.Order
+	}
+	return a.UpdatedAt.After(b.UpdatedAt)
+}
+
+// Recent returns the first n slates in List order, or all of them when n
+// is 0. Only those n are kept and sorted, so it's quick on a long list.
+func (s *Store) Recent(n int) []*Slate {
+	if n <= 0 {
+		return s.List()
+	}
+
+	recent := make([]*Slate, 0, n)
+	for _, slate := range s.slates {
+		if slate.Archived {
+			continue
+		}
+		i := sort.Search(len(recent), func(i int) bool { return s.before(slate, recent[i]) })
+		if i == n {
+			continue
+		}
+		if len(recent) < n {
+			recent = append(recent, nil)
+		}
+		copy(recent[i+1:], recent[i:len(recent)-1])
+		recent[i] = slate
+	}
+	return recent
+}
+
+// Len is the number of slates, not counting the trash
+func (s *Store) Len() int {
+	return len(s.slates)
+}
+
+// SetManualOrder makes List use the order set with Move instead of sorting
+// by last update
+func (s *Store) SetManualOrder(on bool) {
+	s.manual = on
+}
+
+// Move shifts a slate up (negative delta) or down the manual order and
+// reports whether it moved. Every slate is numbered from the current
+// order, so new slates get a place the first time anything moves.
+func (s *Store) Move(id string, delta int) bool {
+	slates := s.List()
+
+	from := -1
+	for i, slate := range slates {
+		if slate.ID == id {
+			from = i
+			break
+		}
+	}
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(slates) {
+		return false
+	}
+	if slates[from].Pinned != slates[to].Pinned {
+		// Pinned slates stay above the rest whatever their order
+		return false
+	}
+
+	slate := slates[from]
+	slates = append(slates[:from], slates[from+1:]...)
+	slates = append(slates[:to], append([]*Slate{slate}, slates[to:]...)...)
+
+	for i, slate := range slates {
+		slate.Order = i + 1
+	}
+	s.save()
+	return true
+}
+
 func (s *Store) Get(id string) *Slate {
 	return s.slates[id]
 }
 
+// SetNormalize sets how content is cleaned up on save
+func (s *Store) SetNormalize(opts normalize.Options) {
+	s.norm = opts
+}
+
 func (s *Store) Create(title, content string) *Slate {
+	content = normalize.Apply(content, s.norm)
 	id := generateID()
 	now := time.Now()
 
 	slate := &Slate{
-		ID:        id,
-		Title:     title,
-		Content:   content,
-		WordCount: countWords(content),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Synced:    false,
+		Slate: model.Slate{
+			ID:        id,
+			Title:     title,
+			Content:   content,
+			WordCount: countWords(content),
+			Tags:      tags.Parse(content),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Synced:   false,
+		Pristine: strings.TrimSpace(content) == "",
+		Origin:   OriginLocal,
 	}
 
 	s.slates[id] = slate
-	s.save()
+	if s.save() == nil {
+		s.versions.Record(id, content, now)
+		s.recordWords(now, slate.WordCount)
+	}
+
+	return slate
+}
+
+// Duplicate copies a slate into a new local one titled "<title> (copy)".
+// The copy has no cloud ID and isn't synced or published, so the next sync
+// uploads it as a new slate rather than over the original. It returns nil
+// if there's no such slate or its content hasn't been downloaded.
+func (s *Store) Duplicate(id string) *Slate {
+	orig := s.slates[id]
+	if orig == nil || orig.Unavailable {
+		return nil
+	}
+	now := time.Now()
+
+	slate := &Slate{
+		Slate: model.Slate{
+			ID:        generateID(),
+			Title:     orig.Title + " (copy)",
+			Content:   orig.Content,
+			WordCount: orig.WordCount,
+			Tags:      append([]string(nil), orig.Tags...),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Synced:   false,
+		Pristine: orig.Pristine,
+		Origin:   OriginLocal,
+	}
+
+	s.slates[slate.ID] = slate
+	if s.save() == nil {
+		// Nothing new was written, so no words are recorded for today
+		s.versions.Record(slate.ID, slate.Content, now)
+	}
 
 	return slate
 }
@@ -121,28 +593,224 @@ func (s *Store) Update(id, title, content string) *Slate {
 		return nil
 	}
 
+	content = normalize.Apply(content, s.norm)
+	before := slate.WordCount
 	slate.Title = title
 	slate.Content = content
 	slate.WordCount = countWords(content)
+	slate.Tags = tags.Parse(content)
 	slate.UpdatedAt = time.Now()
 	slate.Synced = false
+	if strings.TrimSpace(content) != "" {
+		slate.Pristine = false
+	}
 
-	s.save()
+	if s.save() == nil {
+		s.versions.Record(id, content, slate.UpdatedAt)
+		s.recordWords(slate.UpdatedAt, slate.WordCount-before)
+	}
 	return slate
 }
 
-func (s *Store) Delete(id string) {
+// Versions returns the saved history of a slate's content, newest first
+func (s *Store) Versions(id string) []versions.Version {
+	list, _ := s.versions.List(id)
+	return list
+}
+
+// RestoreVersion puts back the content a slate had at the version saved at
+// t. The current content stays in the history.
+func (s *Store) RestoreVersion(id string, t time.Time) *Slate {
+	slate := s.slates[id]
+	if slate == nil {
+		return nil
+	}
+	v, err := s.versions.Get(id, t)
+	if err != nil {
+		return nil
+	}
+	return s.Update(id, slate.Title, v.Content)
+}
+
+// Delete moves a slate to the trash. The trash is written first, and if
+// either write fails the slate is put back, so it's never in neither.
+func (s *Store) Delete(id string) error {
+	return s.trashSlate(id, false)
+}
+
+// QueueDelete trashes a slate whose cloud copy couldn't be deleted yet. It's
+// retried on the next sync, see PendingDeletes.
+func (s *Store) QueueDelete(id string) error {
+	return s.trashSlate(id, true)
+}
+
+func (s *Store) trashSlate(id string, cloudPending bool) error {
+	slate := s.slates[id]
+	if slate == nil {
+		return nil
+	}
+
+	s.trash[id] = &TrashedSlate{Slate: *slate, DeletedAt: time.Now(), CloudPending: cloudPending}
+	if err := s.saveTrash(); err != nil {
+		delete(s.trash, id)
+		return err
+	}
 	delete(s.slates, id)
+	if err := s.save(); err != nil {
+		s.slates[id] = slate
+		delete(s.trash, id)
+		s.saveTrash()
+		return err
+	}
+	return nil
+}
+
+// PendingDeletes returns trashed slates whose cloud copy still needs deleting
+func (s *Store) PendingDeletes() []*TrashedSlate {
+	var pending []*TrashedSlate
+	for _, t := range s.trash {
+		if t.CloudPending && t.CloudID > 0 {
+			pending = append(pending, t)
+		}
+	}
+	return pending
+}
+
+// DeletePending reports whether a trashed slate is waiting on a cloud delete
+func (s *Store) DeletePending(id string) bool {
+	t := s.trash[id]
+	return t != nil && t.CloudPending
+}
+
+// ClearPending records that a queued cloud delete went through
+func (s *Store) ClearPending(id string) {
+	if t := s.trash[id]; t != nil && t.CloudPending {
+		t.CloudPending = false
+		s.saveTrash()
+	}
+}
+
+// TrashRetention is how long deleted slates stay restorable
+const TrashRetention = 30 * 24 * time.Hour
+
+// ListTrash returns the deleted slates, most recently deleted first
+func (s *Store) ListTrash() []*TrashedSlate {
+	trashed := make([]*TrashedSlate, 0, len(s.trash))
+	for _, t := range s.trash {
+		trashed = append(trashed, t)
+	}
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+	return trashed
+}
+
+// PurgeTrash permanently drops slates deleted more than olderThan ago and
+// returns how many went. Slates still waiting on a cloud delete are kept.
+func (s *Store) PurgeTrash(olderThan time.Duration) int {
+	cutoff := time.Now().Add(-olderThan)
+	purged := 0
+	for id, t := range s.trash {
+		if !t.CloudPending && t.DeletedAt.Before(cutoff) {
+			delete(s.trash, id)
+			s.versions.Remove(id)
+			purged++
+		}
+	}
+	if purged > 0 {
+		s.saveTrash()
+	}
+	return purged
+}
+
+// Restore moves a slate out of the trash and back into the list
+func (s *Store) Restore(id string) *Slate {
+	t := s.trash[id]
+	if t == nil {
+		return nil
+	}
+
+	slate := t.Slate
+	// Restoring is deliberate, so don't sweep it up again as blank
+	slate.Pristine = false
+	s.slates[id] = &slate
+	delete(s.trash, id)
+	s.saveTrash()
+	s.save()
+
+	return &slate
+}
+
+// IsBlank reports whether a slate was created without any words and has
+// never had any since. Slates the user cleared after writing are kept.
+func IsBlank(slate *Slate) bool {
+	return slate.Pristine && strings.TrimSpace(slate.Content) == ""
+}
+
+// FromCloud reports whether a slate was pulled from the account rather than
+// created on this device. Slates saved before origins were tracked go by
+// their ID.
+func FromCloud(slate *Slate) bool {
+	if slate.Origin != "" {
+		return slate.Origin == OriginCloud
+	}
+	return strings.HasPrefix(slate.ID, "cloud-")
+}
+
+// Import creates a slate from a file's contents
+func (s *Store) Import(title, content string) *Slate {
+	slate := s.Create(title, content)
+	slate.Origin = OriginImported
 	s.save()
+	return slate
 }
 
+// Blank returns the slates IsBlank reports as never written in
+func (s *Store) Blank() []*Slate {
+	var blank []*Slate
+	for _, slate := range s.slates {
+		if IsBlank(slate) {
+			blank = append(blank, slate)
+		}
+	}
+	return blank
+}
+
+// ListByTag returns the slates tagged #tag, in List order. The leading '#'
+// and case don't matter.
+func (s *Store) ListByTag(tag string) []*Slate {
+	tag = tags.Normalize(tag)
+	var tagged []*Slate
+	for _, slate := range s.List() {
+		if tags.Has(slate.Tags, tag) {
+			tagged = append(tagged, slate)
+		}
+	}
+	return tagged
+}
+
+// Search scopes: what a query is matched against
+const (
+	ScopeAll   = "all"   // title and content
+	ScopeTitle = "title" // title only, faster and less noisy
+)
+
+// Search matches query against titles and content
 func (s *Store) Search(query string) []*Slate {
+	return s.SearchIn(query, ScopeAll)
+}
+
+// SearchIn matches query within scope. Unknown scopes search everything.
+func (s *Store) SearchIn(query, scope string) []*Slate {
 	query = strings.ToLower(query)
 	var results []*Slate
 
 	for _, slate := range s.slates {
+		if !s.searchable(slate) {
+			continue
+		}
 		if strings.Contains(strings.ToLower(slate.Title), query) ||
-			strings.Contains(strings.ToLower(slate.Content), query) {
+			(scope != ScopeTitle && strings.Contains(strings.ToLower(slate.Content), query)) {
 			results = append(results, slate)
 		}
 	}
@@ -160,32 +828,165 @@ func (s *Store) Export(id, path string) error {
 		return os.ErrNotExist
 	}
 
-	content := slate.Title + "\n\n" + slate.Content
-	return os.WriteFile(path, []byte(content), 0644)
+	return os.WriteFile(path, []byte(s.exportContent(slate)), 0644)
+}
+
+// What ExportAll does when a file it would write already exists
+const (
+	ExportOverwrite = "overwrite" // replace it
+	ExportSkip      = "skip"      // leave it and don't export that slate
+	ExportSubdir    = "subdir"    // export everything into a new timestamped folder
+)
+
+// ExportResult says where an export went and what it wrote
+type ExportResult struct {
+	Dir     string
+	File    string // the file written, for exports to a single file
+	Written int
+	Skipped int
+}
+
+// ExportConflicts lists the files in dir that ExportAll would overwrite
+func (s *Store) ExportConflicts(dir string) []string {
+	var existing []string
+	for _, name := range s.exportFilenames() {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			existing = append(existing, name)
+		}
+	}
+	return existing
 }
 
-func (s *Store) ExportAll(dir string) error {
+// ExportAll writes every slate to dir as a .txt file, handling files that
+// are already there as onConflict says. Slates with the same title get -2,
+// -3 and so on, as in ExportAllZip, so none writes over another.
+func (s *Store) ExportAll(dir, onConflict string) (*ExportResult, error) {
+	if onConflict == ExportSubdir {
+		dir = filepath.Join(dir, "justtype-"+time.Now().Format("2006-01-02-150405"))
+	}
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, slate := range s.slates {
-		filename := sanitizeFilename(slate.Title) + ".txt"
-		path := filepath.Join(dir, filename)
+	result := &ExportResult{Dir: dir}
+	names := s.exportFilenames()
+	for i, slate := range s.All() {
+		path := filepath.Join(dir, names[i])
+
+		if onConflict == ExportSkip {
+			if _, err := os.Stat(path); err == nil {
+				result.Skipped++
+				continue
+			}
+		}
 
-		content := slate.Title + "\n\n" + slate.Content
-		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-			return err
+		if err := os.WriteFile(path, []byte(s.exportContent(slate)), 0644); err != nil {
+			return result, err
 		}
+		result.Written++
 	}
 
-	return nil
+	return result, nil
+}
+
+func exportFilename(slate *Slate) string {
+	return SanitizeFilename(slate.Title) + ".txt"
+}
+
+// exportFilenames names the file each slate in All goes to in one export,
+// numbering titles that come up more than once
+func (s *Store) exportFilenames() []string {
+	slates := s.All()
+	names := make([]string, len(slates))
+	taken := make(map[string]bool, len(slates))
+	for i, slate := range slates {
+		names[i] = uniqueName(exportFilename(slate), taken)
+	}
+	return names
+}
+
+// SetExportWrap makes exports hard-wrap prose at column, leaving code
+// blocks alone. 0 exports content as written.
+func (s *Store) SetExportWrap(column int) {
+	s.wrap = column
+}
+
+// SetExportMetadata makes .txt and .md exports start with a front matter
+// block of the slate's dates, word count and, if it's published, its share
+// link on the server at apiURL. ImportFile reads the dates back.
+func (s *Store) SetExportMetadata(on bool, apiURL string) {
+	s.metadata = on
+	s.shareBase = apiURL
+}
+
+func (s *Store) exportContent(slate *Slate) string {
+	return s.exportHeader(slate) + slate.Title + "\n\n" + markdown.Wrap(slate.Content, s.wrap)
+}
+
+// Put inserts or replaces a slate as-is, for callers that track sync state
+// themselves
+func (s *Store) Put(slate *Slate) {
+	s.slates[slate.ID] = slate
+	s.save()
+}
+
+// Remove drops a slate without keeping it in the trash
+func (s *Store) Remove(id string) {
+	if _, ok := s.slates[id]; ok {
+		delete(s.slates, id)
+		s.save()
+	}
 }
 
 func (s *Store) SetCloudID(id string, cloudID int) {
 	if slate := s.slates[id]; slate != nil {
 		slate.CloudID = cloudID
-		slate.Synced = true
+		markSynced(slate, time.Now())
+		s.save()
+	}
+}
+
+// Detach clears a slate's cloud link so the next sync pushes it as new
+func (s *Store) Detach(id string) {
+	if slate := s.slates[id]; slate != nil {
+		slate.CloudID = 0
+		slate.Synced = false
+		slate.IsPublished = false
+		slate.ShareID = ""
+		slate.Origin = OriginLocal
+		s.save()
+	}
+}
+
+// SetCursor remembers where the editor cursor was in a slate
+func (s *Store) SetCursor(id string, offset int) {
+	if slate := s.slates[id]; slate != nil && slate.CursorOffset != offset {
+		slate.CursorOffset = offset
+		s.save()
+	}
+}
+
+// SetArchived moves a slate out of List into ListArchived, or back. Like
+// pinning it isn't an edit, and it stays on this device: the server has no
+// archive, so sync leaves the flag as it is.
+func (s *Store) SetArchived(id string, archived bool) {
+	if slate := s.slates[id]; slate != nil && slate.Archived != archived {
+		slate.Archived = archived
+		s.save()
+	}
+}
+
+// SetSearchArchived makes searches include archived slates, or leave them
+// out as List does
+func (s *Store) SetSearchArchived(on bool) {
+	s.archived = on
+}
+
+// SetPinned pins a slate above the rest of List, or unpins it. It isn't an
+// edit, so the slate keeps its update time and sync state.
+func (s *Store) SetPinned(id string, pinned bool) {
+	if slate := s.slates[id]; slate != nil && slate.Pinned != pinned {
+		slate.Pinned = pinned
 		s.save()
 	}
 }
@@ -198,48 +999,78 @@ func (s *Store) SetPublished(id string, isPublished bool, shareID string) {
 	}
 }
 
-func (s *Store) ImportFromCloud(cloudSlate *Slate) {
+// ImportFromCloud brings in a slate from the account. A local copy is only
+// overwritten if it hasn't been edited since the last sync; otherwise it's
+// kept and the returned state says whether to push it or ask which to keep.
+func (s *Store) ImportFromCloud(cloudSlate *Slate) SyncState {
 	if cloudSlate.CloudID == 0 {
-		return // Can't import without a cloud ID
+		return SyncClean // Can't import without a cloud ID
+	}
+
+	// Deleted here: the trash entry is a tombstone, so a server that still
+	// lists the slate (the delete is pending, or was made while logged out)
+	// doesn't bring it back
+	for _, t := range s.trash {
+		if t.CloudID == cloudSlate.CloudID {
+			return SyncClean
+		}
 	}
 
 	// Check if we already have this cloud slate
-	for _, local := range s.slates {
-		if local.CloudID > 0 && local.CloudID == cloudSlate.CloudID {
-			// Update existing
-			local.Title = cloudSlate.Title
-			local.Content = cloudSlate.Content
-			local.WordCount = cloudSlate.WordCount
-			local.UpdatedAt = cloudSlate.UpdatedAt
+	if local := s.ByCloudID(cloudSlate.CloudID); local != nil {
+		if cloudSlate.Unavailable {
+			// Never replace content we have with content we don't. A slate
+			// that's only been listed so far takes the new listing, so its
+			// title and word count are right before it's opened.
+			if local.Unavailable {
+				local.Title = cloudSlate.Title
+				local.WordCount = cloudSlate.WordCount
+				local.UpdatedAt = cloudSlate.UpdatedAt
+				local.IsPublished = cloudSlate.IsPublished
+				local.ShareID = cloudSlate.ShareID
+				local.Pinned = cloudSlate.Pinned
+				s.save()
+			}
+			return SyncClean
+		}
+
+		state := Compare(local, cloudSlate)
+		switch state {
+		case SyncLocalAhead, SyncConflict:
+			return state
+		case SyncClean:
+			// Pick up publish state and such, and note the sync
 			local.IsPublished = cloudSlate.IsPublished
 			local.ShareID = cloudSlate.ShareID
-			local.Synced = true
-			s.save()
-			return
+			local.Pinned = cloudSlate.Pinned
+			local.Unavailable = false
+			markSynced(local, cloudSlate.UpdatedAt)
+		default:
+			applyRemote(local, cloudSlate)
 		}
+		s.save()
+		return state
 	}
 
 	// Create new
-	cloudSlate.Synced = true
+	cloudSlate.Tags = tags.Parse(cloudSlate.Content)
+	cloudSlate.Origin = OriginCloud
+	markSynced(cloudSlate, cloudSlate.UpdatedAt)
 	s.slates[cloudSlate.ID] = cloudSlate
 	s.save()
+	return SyncRemoteAhead
 }
 
 func generateID() string {
-	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, 8)
-	for i := range b {
-		b[i] = chars[time.Now().UnixNano()%int64(len(chars))]
-		time.Sleep(time.Nanosecond)
-	}
-	return string(b)
+	return ids.New()
 }
 
 func countWords(s string) int {
-	return len(strings.Fields(s))
+	return textstats.Count(s, true).Words
 }
 
-func sanitizeFilename(s string) string {
+// SanitizeFilename turns a title into a file name safe on every platform
+func SanitizeFilename(s string) string {
 	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
 	result := s
 	for _, char := range invalid {
@@ -249,8 +1080,10 @@ func sanitizeFilename(s string) string {
 	if result == "" {
 		result = "untitled"
 	}
-	if len(result) > 50 {
-		result = result[:50]
+	// Cut on a character, not a byte, so a long title in any script stays
+	// valid UTF-8
+	if runes := []rune(result); len(runes) > 50 {
+		result = string(runes[:50])
 	}
 	return result
 }
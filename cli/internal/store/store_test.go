@@ -0,0 +1,498 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/model"
+)
+
+func TestOpenRecoversFromTruncatedWrite(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{}
+	for _, content := range []string{"first\n\none", "second\n\ntwo", "third\n\nthree"} {
+		slate := s.Create("", content)
+		want[slate.ID] = slate.Content
+	}
+
+	path := filepath.Join(dir, "slates.json")
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(atrest.TempPath(path), good, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, good[:len(good)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := reopened.All()
+	if len(all) != len(want) {
+		t.Fatalf("loaded %d slates, want %d", len(all), len(want))
+	}
+	for _, slate := range all {
+		if slate.Content != want[slate.ID] {
+			t.Errorf("slate %s: got %q, want %q", slate.ID, slate.Content, want[slate.ID])
+		}
+	}
+	if reopened.Recovered() == "" {
+		t.Error("recovery from the staged copy wasn't reported")
+	}
+}
+
+func TestOpenSetsAsideMalformedJSON(t *testing.T) {
+	dir := t.TempDir()
+	bad := `[{"id": "abc", "title": "notes", "content": "unfinished`
+	if err := os.WriteFile(filepath.Join(dir, "slates.json"), []byte(bad), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("didn't start: %v", err)
+	}
+	if n := len(s.All()); n != 0 {
+		t.Fatalf("started with %d slates, want none", n)
+	}
+	aside, _ := filepath.Glob(filepath.Join(dir, "slates.corrupt-*.json"))
+	if len(aside) != 1 {
+		t.Fatalf("found %v set aside, want one file", aside)
+	}
+	if data, _ := os.ReadFile(aside[0]); string(data) != bad {
+		t.Fatalf("set-aside file holds %q, want the original", data)
+	}
+	if s.Recovered() == "" {
+		t.Fatal("setting the file aside wasn't reported")
+	}
+
+	s.Create("", "a fresh start")
+	if _, err := os.Stat(filepath.Join(dir, "slates.json")); err != nil {
+		t.Fatalf("no new slates.json after a create: %v", err)
+	}
+}
+
+func TestRecentMatchesList(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now()
+	for i := range 20 {
+		slate := s.Create("", fmt.Sprintf("slate %d", i))
+		slate.UpdatedAt = base.Add(time.Duration(i*7%20) * time.Minute)
+		slate.Pinned = i == 3
+		slate.Archived = i == 19
+	}
+
+	for _, manual := range []bool{false, true} {
+		s.SetManualOrder(manual)
+		list := s.List()
+		recent := s.Recent(5)
+		if len(recent) != 5 {
+			t.Fatalf("manual %v: Recent(5) returned %d slates", manual, len(recent))
+		}
+		for i := range recent {
+			if recent[i] != list[i] {
+				t.Fatalf("manual %v: Recent(5)[%d] = %q, want List's %q", manual, i, recent[i].Content, list[i].Content)
+			}
+		}
+	}
+	if n := len(s.Recent(0)); n != 19 {
+		t.Fatalf("Recent(0) = %d slates, want every unarchived one", n)
+	}
+}
+
+func TestDeleteKeepsSlateWhenTrashFails(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate := s.Create("", "keep me")
+
+	// A directory where trash.json goes can't be written over
+	trash := filepath.Join(dir, "trash.json")
+	if err := os.Mkdir(trash, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(slate.ID); err == nil {
+		t.Fatal("Delete succeeded without writing the trash")
+	}
+	if s.Get(slate.ID) == nil {
+		t.Fatal("slate dropped after a failed delete")
+	}
+	if n := len(s.ListTrash()); n != 0 {
+		t.Fatalf("%d slates in the trash after a failed delete", n)
+	}
+
+	os.Remove(trash)
+	if err := s.Delete(slate.ID); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Get(slate.ID) != nil || len(reopened.ListTrash()) != 1 {
+		t.Fatal("deleted slate isn't in the trash after reopening")
+	}
+}
+
+func TestCreateAndUpdateNormalizeLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slate := s.Create("", "one\r\ntwo\r\nthree")
+	if slate.Content != "one\ntwo\nthree" || slate.WordCount != 3 {
+		t.Fatalf("created %q with %d words", slate.Content, slate.WordCount)
+	}
+	s.Update(slate.ID, "", "one\rtwo\r\n")
+	if slate.Content != "one\ntwo\n" {
+		t.Fatalf("updated to %q", slate.Content)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Get(slate.ID).Content; got != "one\ntwo\n" {
+		t.Fatalf("stored %q", got)
+	}
+}
+
+func TestUnavailableNeverReplacesContent(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := time.Now().Add(-time.Hour).Truncate(time.Second)
+	s.ImportFromCloud(&Slate{Slate: model.Slate{
+		ID: "cloud-5", CloudID: 5, Title: "Plans", Content: "Plans\n\nthe real text", UpdatedAt: updated,
+	}})
+
+	// A later sync lists it but can't download it
+	s.ImportFromCloud(&Slate{
+		Slate:       model.Slate{ID: "cloud-5", CloudID: 5, Title: "Plans, renamed", UpdatedAt: updated.Add(time.Minute)},
+		Unavailable: true,
+	})
+	got := s.Get("cloud-5")
+	if got.Content != "Plans\n\nthe real text" || got.Unavailable {
+		t.Fatalf("content %q, unavailable %v; want the downloaded text kept", got.Content, got.Unavailable)
+	}
+}
+
+func TestMoveManualOrder(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Hour)
+	ids := map[string]string{}
+	for i, name := range []string{"a", "b", "c", "pinned"} {
+		slate := s.Create("", name)
+		slate.UpdatedAt = base.Add(time.Duration(i) * time.Minute)
+		ids[name] = slate.ID
+	}
+	s.SetPinned(ids["pinned"], true)
+	order := func(s *Store) string {
+		var names []string
+		for _, slate := range s.List() {
+			names = append(names, slate.Content)
+		}
+		return strings.Join(names, " ")
+	}
+
+	s.SetManualOrder(true)
+	if got := order(s); got != "pinned c b a" {
+		t.Fatalf("manual order before any move = %q, want the update order", got)
+	}
+	if !s.Move(ids["a"], -1) || !s.Move(ids["a"], -1) {
+		t.Fatal("a didn't move up")
+	}
+	if got := order(s); got != "pinned a c b" {
+		t.Fatalf("after moving a up twice: %q", got)
+	}
+	if s.Move(ids["a"], -1) {
+		t.Fatal("a moved above the pinned slate")
+	}
+	if s.Move(ids["b"], 1) {
+		t.Fatal("b moved past the end")
+	}
+
+	// Editing doesn't reorder, and new slates start at the top
+	s.Update(ids["b"], "", "b edited")
+	s.Create("", "new")
+	if got := order(s); got != "pinned new a c b edited" {
+		t.Fatalf("after an edit and a new slate: %q", got)
+	}
+
+	// The order is kept, and automatic sorting still works without it
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.SetManualOrder(true)
+	if got := order(reopened); got != "pinned new a c b edited" {
+		t.Fatalf("reopened manual order = %q", got)
+	}
+	reopened.SetManualOrder(false)
+	if got := order(reopened); got != "pinned new b edited c a" {
+		t.Fatalf("automatic order = %q, want it sorted by update", got)
+	}
+}
+
+func TestKeepsUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slates.json")
+	newer := `[{"id": "a1", "title": "Notes", "content": "Notes\n\nfirst", "mood": "calm", "reminder": {"at": "2026-03-01T09:00:00Z"}}]`
+	if err := os.WriteFile(path, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Update("a1", "", "Notes\n\nfirst, then more")
+	s.Create("", "another")
+
+	// Through a second load and save too
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Create("", "one more")
+
+	data, _ := os.ReadFile(path)
+	var saved []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 3 {
+		t.Fatalf("saved %d slates, want 3", len(saved))
+	}
+	for _, slate := range saved {
+		_, hasMood := slate["mood"]
+		if string(slate["id"]) != `"a1"` {
+			if hasMood {
+				t.Fatal("another slate picked up a1's unknown field")
+			}
+			continue
+		}
+		if string(slate["mood"]) != `"calm"` || !strings.Contains(string(slate["reminder"]), "2026-03-01T09:00:00Z") {
+			t.Fatalf("unknown fields lost on save: %s", data)
+		}
+		if !strings.Contains(string(slate["content"]), "then more") {
+			t.Fatalf("the edit wasn't saved: %s", slate["content"])
+		}
+	}
+}
+
+func TestExportAllCollisions(t *testing.T) {
+	// An earlier export of Alpha sits in the folder, next to a file of the user's
+	setup := func(t *testing.T) (*Store, string) {
+		t.Helper()
+		s, err := Open(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Create("Alpha", "new text")
+		s.Create("Beta", "other text")
+		dir := t.TempDir()
+		for name, content := range map[string]string{"Alpha.txt": "old export", "mine.txt": "not an export"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return s, dir
+	}
+	read := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	t.Run("conflicts", func(t *testing.T) {
+		s, dir := setup(t)
+		if got := s.ExportConflicts(dir); len(got) != 1 || got[0] != "Alpha.txt" {
+			t.Fatalf("ExportConflicts = %v, want [Alpha.txt]", got)
+		}
+		if got := s.ExportConflicts(filepath.Join(dir, "missing")); len(got) != 0 {
+			t.Fatalf("conflicts in a folder that isn't there: %v", got)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		s, dir := setup(t)
+		result, err := s.ExportAll(dir, ExportOverwrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Dir != dir || result.Written != 2 || result.Skipped != 0 {
+			t.Fatalf("result %+v", result)
+		}
+		if !strings.Contains(read(t, filepath.Join(dir, "Alpha.txt")), "new text") {
+			t.Fatal("Alpha.txt wasn't overwritten")
+		}
+		if read(t, filepath.Join(dir, "mine.txt")) != "not an export" {
+			t.Fatal("an unrelated file changed")
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		s, dir := setup(t)
+		result, err := s.ExportAll(dir, ExportSkip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Written != 1 || result.Skipped != 1 {
+			t.Fatalf("result %+v, want 1 written and 1 skipped", result)
+		}
+		if read(t, filepath.Join(dir, "Alpha.txt")) != "old export" {
+			t.Fatal("skipping still overwrote Alpha.txt")
+		}
+		if !strings.Contains(read(t, filepath.Join(dir, "Beta.txt")), "other text") {
+			t.Fatal("Beta.txt wasn't written")
+		}
+	})
+
+	t.Run("same title", func(t *testing.T) {
+		for _, onConflict := range []string{ExportOverwrite, ExportSkip, ExportSubdir} {
+			s, dir := setup(t)
+			s.Create("Beta", "second beta")
+			s.Create("beta", "third beta")
+			result, err := s.ExportAll(dir, onConflict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Written+result.Skipped != 4 {
+				t.Fatalf("%s: result %+v, want all 4 slates", onConflict, result)
+			}
+			files, _ := filepath.Glob(filepath.Join(result.Dir, "[Bb]eta*.txt"))
+			if len(files) != 3 {
+				t.Fatalf("%s: wrote %v, want a file for each Beta", onConflict, files)
+			}
+			var betas []string
+			for _, file := range files {
+				betas = append(betas, read(t, file))
+			}
+			for _, want := range []string{"other text", "second beta", "third beta"} {
+				if !strings.Contains(strings.Join(betas, "\n"), want) {
+					t.Fatalf("%s: %q written over by another Beta", onConflict, want)
+				}
+			}
+		}
+	})
+
+	t.Run("subdir", func(t *testing.T) {
+		s, dir := setup(t)
+		result, err := s.ExportAll(dir, ExportSubdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(result.Dir) != dir || !strings.HasPrefix(filepath.Base(result.Dir), "justtype-") {
+			t.Fatalf("exported to %s, want a justtype- folder in %s", result.Dir, dir)
+		}
+		if result.Written != 2 || result.Skipped != 0 {
+			t.Fatalf("result %+v", result)
+		}
+		if read(t, filepath.Join(dir, "Alpha.txt")) != "old export" {
+			t.Fatal("the earlier export was overwritten")
+		}
+		if !strings.Contains(read(t, filepath.Join(result.Dir, "Alpha.txt")), "new text") {
+			t.Fatal("Alpha.txt not in the new folder")
+		}
+	})
+}
+
+func TestSetPassphraseCutShortKeepsOldKey(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := s.Create("", "kept")
+	trashed := s.Create("", "trashed")
+	if err := s.Delete(trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPassphrase("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// slates.json is staged under the new salt, then staging the trash fails
+	if err := os.Mkdir(filepath.Join(dir, "trash.json.rekey"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPassphrase("second"); err == nil {
+		t.Fatal("SetPassphrase succeeded without staging the trash")
+	}
+	os.Remove(filepath.Join(dir, "trash.json.rekey"))
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock("first"); err != nil {
+		t.Fatalf("old passphrase after a failed change: %v", err)
+	}
+	if reopened.Get(kept.ID) == nil || len(reopened.ListTrash()) != 1 {
+		t.Fatal("slates or trash unreadable after a failed change")
+	}
+
+	if err := reopened.SetPassphrase("second"); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := again.Unlock("second"); err != nil {
+		t.Fatalf("new passphrase: %v", err)
+	}
+	if again.Get(kept.ID) == nil || len(again.ListTrash()) != 1 {
+		t.Fatal("slates or trash unreadable under the new passphrase")
+	}
+	if stray, _ := filepath.Glob(filepath.Join(dir, "*.rekey*")); len(stray) != 0 {
+		t.Fatalf("left behind %v", stray)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	for _, tt := range []struct{ title, want string }{
+		{"notes: a/b?", "notes- a-b-"},
+		{" .. ", "untitled"},
+		{strings.Repeat("a", 60), strings.Repeat("a", 50)},
+		// Multi-byte characters are kept whole
+		{strings.Repeat("ç", 60), strings.Repeat("ç", 50)},
+		{strings.Repeat("日記", 30), strings.Repeat("日記", 25)},
+		{"a" + strings.Repeat("🙂", 60), "a" + strings.Repeat("🙂", 49)},
+	} {
+		got := SanitizeFilename(tt.title)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
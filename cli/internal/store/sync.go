@@ -0,0 +1,168 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/tags"
+)
+
+// SyncState is how a slate and its cloud copy have changed since they were
+// last in sync
+type SyncState int
+
+const (
+	SyncClean       SyncState = iota // the same on both sides
+	SyncLocalAhead                   // edited here; push it
+	SyncRemoteAhead                  // edited on another device; pull it
+	SyncConflict                     // edited in both places
+)
+
+// ConflictSuffix marks the copy KeepBoth makes of the local side
+const ConflictSuffix = " (conflict)"
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// markSynced records that slate matches its cloud copy as of at
+func markSynced(slate *Slate, at time.Time) {
+	slate.Synced = true
+	slate.LastSyncedAt = at
+	slate.SyncedHash = contentHash(slate.Content)
+}
+
+// RemoteChanged reports whether a cloud copy last updated at updatedAt may
+// have changed since local was last synced. It only needs the slate list;
+// Compare confirms it against the content.
+func RemoteChanged(local *Slate, updatedAt time.Time) bool {
+	since := local.LastSyncedAt
+	if since.IsZero() {
+		// Synced before this was tracked
+		since = local.UpdatedAt
+	}
+	return updatedAt.After(since)
+}
+
+// Compare works out which side changed since local and remote were last in
+// sync
+func Compare(local, remote *Slate) SyncState {
+	if local.Content == remote.Content {
+		return SyncClean
+	}
+
+	localChanged := !local.Synced
+	remoteChanged := RemoteChanged(local, remote.UpdatedAt)
+	if remoteChanged && local.SyncedHash != "" && local.SyncedHash == contentHash(remote.Content) {
+		// Only the timestamp moved, from our own push landing after we
+		// noted the sync
+		remoteChanged = false
+	}
+
+	switch {
+	case localChanged && remoteChanged:
+		return SyncConflict
+	case localChanged:
+		return SyncLocalAhead
+	default:
+		return SyncRemoteAhead
+	}
+}
+
+// ByCloudID finds the local copy of a cloud slate
+func (s *Store) ByCloudID(cloudID int) *Slate {
+	if cloudID == 0 {
+		return nil
+	}
+	for _, slate := range s.slates {
+		if slate.CloudID == cloudID {
+			return slate
+		}
+	}
+	return nil
+}
+
+// applyRemote overwrites local with the cloud's copy
+func applyRemote(local, remote *Slate) {
+	local.Unavailable = false
+	local.Title = remote.Title
+	local.Content = remote.Content
+	local.WordCount = remote.WordCount
+	// The server doesn't keep tags; they come from the content
+	local.Tags = tags.Parse(remote.Content)
+	local.UpdatedAt = remote.UpdatedAt
+	local.IsPublished = remote.IsPublished
+	local.ShareID = remote.ShareID
+	local.Pinned = remote.Pinned
+	if local.Origin == "" {
+		local.Origin = OriginCloud
+	}
+	markSynced(local, remote.UpdatedAt)
+}
+
+// KeepLocal settles a conflict in favour of this device. The cloud copy
+// counts as seen, and the local one is left unsynced to be pushed over it.
+func (s *Store) KeepLocal(remote *Slate) *Slate {
+	local := s.ByCloudID(remote.CloudID)
+	if local == nil {
+		return nil
+	}
+	local.Synced = false
+	local.LastSyncedAt = remote.UpdatedAt
+	local.SyncedHash = contentHash(remote.Content)
+	s.save()
+	return local
+}
+
+// KeepCloud settles a conflict by replacing the local copy with the cloud's.
+// The local content stays in the slate's history.
+func (s *Store) KeepCloud(remote *Slate) *Slate {
+	local := s.ByCloudID(remote.CloudID)
+	if local == nil {
+		return nil
+	}
+	applyRemote(local, remote)
+	if s.save() == nil {
+		s.versions.Record(local.ID, local.Content, time.Now())
+	}
+	return local
+}
+
+// KeepBoth settles a conflict by taking the cloud's copy and saving the
+// local edits as a new slate, titled with ConflictSuffix, for the next sync
+// to upload
+func (s *Store) KeepBoth(remote *Slate) *Slate {
+	local := s.ByCloudID(remote.CloudID)
+	if local == nil {
+		return nil
+	}
+
+	title := local.Title
+	if title == "" {
+		title = "untitled"
+	}
+	now := time.Now()
+	copied := &Slate{
+		Slate: model.Slate{
+			ID:        generateID(),
+			Title:     title + ConflictSuffix,
+			Content:   local.Content,
+			WordCount: local.WordCount,
+			Tags:      local.Tags,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Origin: OriginLocal,
+	}
+	s.slates[copied.ID] = copied
+
+	applyRemote(local, remote)
+	if s.save() == nil {
+		s.versions.Record(copied.ID, copied.Content, now)
+		s.versions.Record(local.ID, local.Content, now)
+	}
+	return copied
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/versions"
+)
+
+// synced opens a store holding one slate, "Plans", pulled from the cloud at
+// base, and returns it with a maker for later cloud copies of it
+func synced(t *testing.T, base time.Time) (*Store, *Slate, func(content string, at time.Time) *Slate) {
+	t.Helper()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := func(content string, at time.Time) *Slate {
+		return &Slate{Slate: model.Slate{
+			ID: "cloud-5", CloudID: 5, Title: "Plans", Content: content,
+			WordCount: len(strings.Fields(content)), UpdatedAt: at,
+		}}
+	}
+	if state := s.ImportFromCloud(remote("first draft", base)); state != SyncRemoteAhead {
+		t.Fatalf("first pull = %v, want SyncRemoteAhead", state)
+	}
+	local := s.ByCloudID(5)
+	if local == nil || !local.Synced {
+		t.Fatal("pulled slate missing or not marked synced")
+	}
+	return s, local, remote
+}
+
+func TestImportFromCloudStates(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	t.Run("clean", func(t *testing.T) {
+		s, local, remote := synced(t, base)
+		// Nothing changed, or only the cloud's timestamp did
+		for _, at := range []time.Time{base, base.Add(time.Minute)} {
+			if state := s.ImportFromCloud(remote("first draft", at)); state != SyncClean {
+				t.Fatalf("unchanged at %v = %v, want SyncClean", at, state)
+			}
+		}
+		if local.Content != "first draft" || !local.Synced {
+			t.Fatalf("content %q, synced %v", local.Content, local.Synced)
+		}
+	})
+
+	t.Run("local ahead", func(t *testing.T) {
+		s, local, remote := synced(t, base)
+		s.Update(local.ID, "Plans", "first draft, edited here")
+		if state := s.ImportFromCloud(remote("first draft", base)); state != SyncLocalAhead {
+			t.Fatalf("got %v, want SyncLocalAhead", state)
+		}
+		if local.Content != "first draft, edited here" || local.Synced {
+			t.Fatal("the local edit was overwritten or marked synced")
+		}
+	})
+
+	t.Run("remote ahead", func(t *testing.T) {
+		s, local, remote := synced(t, base)
+		later := base.Add(time.Minute)
+		if state := s.ImportFromCloud(remote("edited on the laptop", later)); state != SyncRemoteAhead {
+			t.Fatalf("got %v, want SyncRemoteAhead", state)
+		}
+		if local.Content != "edited on the laptop" || !local.UpdatedAt.Equal(later) || !local.Synced {
+			t.Fatalf("not fast-forwarded: %q at %v, synced %v", local.Content, local.UpdatedAt, local.Synced)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		s, local, remote := synced(t, base)
+		s.Update(local.ID, "Plans", "edited here")
+		if state := s.ImportFromCloud(remote("edited on the laptop", base.Add(time.Minute))); state != SyncConflict {
+			t.Fatalf("got %v, want SyncConflict", state)
+		}
+		if local.Content != "edited here" {
+			t.Fatalf("a conflict replaced the local edit with %q", local.Content)
+		}
+	})
+}
+
+func TestComparePushedTimestamp(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	s, local, remote := synced(t, base)
+	s.Update(local.ID, "Plans", "pushed from here")
+	markSynced(local, base)
+	s.Update(local.ID, "Plans", "pushed from here, then edited")
+
+	// The server stamps our own push later than we noted; it holds what we
+	// pushed, so that's not an edit from elsewhere
+	if state := Compare(local, remote("pushed from here", base.Add(time.Minute))); state != SyncLocalAhead {
+		t.Fatalf("got %v, want SyncLocalAhead", state)
+	}
+	if state := Compare(local, remote("edited on the laptop", base.Add(time.Minute))); state != SyncConflict {
+		t.Fatalf("other content: got %v, want SyncConflict", state)
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	conflicted := func(t *testing.T) (*Store, *Slate, *Slate) {
+		s, local, remote := synced(t, base)
+		s.Update(local.ID, "Plans", "edited here")
+		cloud := remote("edited on the laptop", base.Add(time.Minute))
+		if s.ImportFromCloud(cloud) != SyncConflict {
+			t.Fatal("no conflict to resolve")
+		}
+		return s, local, cloud
+	}
+
+	t.Run("keep local", func(t *testing.T) {
+		s, local, cloud := conflicted(t)
+		if got := s.KeepLocal(cloud); got != local {
+			t.Fatal("KeepLocal didn't return the local slate")
+		}
+		if local.Content != "edited here" || local.Synced {
+			t.Fatal("local copy not kept to be pushed")
+		}
+		// The cloud copy counts as seen, so it's no longer a conflict
+		if state := Compare(local, cloud); state != SyncLocalAhead {
+			t.Fatalf("after keeping local: %v, want SyncLocalAhead", state)
+		}
+	})
+
+	t.Run("keep cloud", func(t *testing.T) {
+		s, local, cloud := conflicted(t)
+		s.KeepCloud(cloud)
+		if local.Content != "edited on the laptop" || !local.Synced {
+			t.Fatalf("content %q, synced %v", local.Content, local.Synced)
+		}
+		if !containsVersion(s.Versions(local.ID), "edited here") {
+			t.Fatal("the local edit isn't in the slate's history")
+		}
+	})
+
+	t.Run("save both", func(t *testing.T) {
+		s, local, cloud := conflicted(t)
+		copied := s.KeepBoth(cloud)
+		if copied == nil || copied.Title != "Plans"+ConflictSuffix || copied.Content != "edited here" {
+			t.Fatalf("copy %+v", copied)
+		}
+		if copied.CloudID != 0 || copied.Synced {
+			t.Fatal("the copy isn't a new slate waiting to upload")
+		}
+		if local.Content != "edited on the laptop" || !local.Synced {
+			t.Fatalf("original holds %q, synced %v; want the cloud's", local.Content, local.Synced)
+		}
+		if s.Len() != 2 {
+			t.Fatalf("%d slates, want the original and the copy", s.Len())
+		}
+	})
+}
+
+func containsVersion(list []versions.Version, content string) bool {
+	for _, v := range list {
+		if v.Content == content {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+// Package tags finds #hashtags in slate content
+package tags
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Parse returns the distinct #tags in content, lowercased and sorted. A tag
+// starts a word and holds letters, digits, '-' and '_', with at least one
+// letter, so "# Heading", "#1" and "a#b" aren't tags. Fenced code is skipped.
+func Parse(content string) []string {
+	seen := make(map[string]bool)
+	inFence := false
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		for _, word := range strings.Fields(line) {
+			if tag, ok := parseWord(word); ok {
+				seen[tag] = true
+			}
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// parseWord reads a tag from a word like "#draft," or "(#draft)"
+func parseWord(word string) (string, bool) {
+	word = strings.TrimLeft(word, "([{\"'")
+	rest, ok := strings.CutPrefix(word, "#")
+	if !ok {
+		return "", false
+	}
+
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+	})
+	if end >= 0 {
+		rest = rest[:end]
+	}
+	rest = strings.TrimRight(rest, "-_")
+	if !strings.ContainsFunc(rest, unicode.IsLetter) {
+		return "", false
+	}
+	return strings.ToLower(rest), true
+}
+
+// Normalize turns what the user typed ("#Draft" or "draft") into the form
+// Parse returns
+func Normalize(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// Has reports whether tag (as returned by Normalize) is among tags
+func Has(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
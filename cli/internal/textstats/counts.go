@@ -0,0 +1,76 @@
+package textstats
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/justtype/cli/internal/markdown"
+)
+
+// WordsPerMinute is the reading speed ReadingTime assumes
+const WordsPerMinute = 200
+
+// Counts is how long a piece of writing is
+type Counts struct {
+	Words              int
+	CodeWords          int // of Words, the ones in fenced code blocks
+	Characters         int // as a reader sees them, so an emoji is one; line breaks aren't counted
+	CharactersNoSpaces int
+}
+
+// Count counts the words and characters in content. With stripMarkup set the
+// markup is left out first, so **bold** is one word and a link counts its
+// text but not its URL, and only tokens with a letter or digit in them are
+// words; without it every run of non-space is one.
+func Count(content string, stripMarkup bool) Counts {
+	var c Counts
+	prose, code := content, ""
+	if stripMarkup {
+		prose, code = markdown.Strip(content)
+	}
+
+	c.CodeWords = countWords(code, stripMarkup)
+	c.Words = countWords(prose, stripMarkup) + c.CodeWords
+	for _, text := range []string{prose, code} {
+		eachCharacter(text, func(r rune) {
+			switch {
+			case r == '\n' || r == '\r':
+			case unicode.IsSpace(r):
+				c.Characters++
+			default:
+				c.Characters++
+				c.CharactersNoSpaces++
+			}
+		})
+	}
+	return c
+}
+
+// ReadingTime estimates how long the words take to read at WordsPerMinute,
+// rounded up to a whole minute. Code is skimmed rather than read, so it
+// isn't counted.
+func (c Counts) ReadingTime() time.Duration {
+	words := c.Words - c.CodeWords
+	if words <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(float64(words)/WordsPerMinute)) * time.Minute
+}
+
+// countWords counts the tokens between spaces, except that Chinese and
+// Japanese, which don't put spaces between words, count a word for each
+// character. A token of emoji alone is a word.
+func countWords(text string, stripMarkup bool) int {
+	count := 0
+	for _, token := range strings.Fields(text) {
+		switch {
+		case strings.IndexFunc(token, isCJK) >= 0:
+			count += countCJKWords(token)
+		case !stripMarkup || hasWord(token) || strings.IndexFunc(token, isEmoji) >= 0:
+			count++
+		}
+	}
+	return count
+}
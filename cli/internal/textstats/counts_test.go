@@ -0,0 +1,81 @@
+package textstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountWordsMixedScripts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"english", "The quick brown fox jumps.", 5},
+		{"chinese", "我今天去了图书馆。", 8},
+		{"japanese", "東京に行きました", 8},
+		{"korean keeps its spaces", "안녕하세요 세계", 2},
+		{"cjk inside an english sentence", "I read 三体 on the train", 7},
+		{"scripts in one token", "Go言語で", 4},
+		{"paragraphs of each", "Meeting notes.\n\n会议记录很长。\n\nNext steps for 来週.", 13},
+		{"emoji line", "🎉🎉 👨‍👩‍👧 👍🏽 🇯🇵", 4},
+		{"markup left out", "**bold** [link](https://example.com) 漢字", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Count(tt.content, true).Words; got != tt.want {
+				t.Fatalf("Count(%q).Words = %d, want %d", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountCharactersAsSeen(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"abc", 3},
+		{"日本語", 3},
+		{"👨‍👩‍👧", 1},    // a family joined with zero-width joiners
+		{"👍🏽", 1},       // with a skin tone
+		{"🇯🇵🇫🇷", 2},     // two flags
+		{"é", 1},       // a combining accent
+		{"a b\nc", 4},   // the line break isn't counted
+		{"a b\r\nc", 4}, // nor a Windows one
+	}
+	for _, tt := range tests {
+		if got := Count(tt.content, false).Characters; got != tt.want {
+			t.Errorf("Count(%q).Characters = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCountMarkdownDocument(t *testing.T) {
+	doc := "# Release notes\n\n" +
+		"Read the [full changelog](https://example.com/changelog/v2) first.\n\n" +
+		"```go\n" + strings.Repeat("fmt.Println(\"hello\", world)\n", 500) + "```\n"
+
+	got := Count(doc, true)
+	if prose := got.Words - got.CodeWords; prose != 7 {
+		t.Errorf("prose words = %d, want 7 (heading marks and link target left out)", prose)
+	}
+	if got.CodeWords != 1000 {
+		t.Errorf("CodeWords = %d, want 1000", got.CodeWords)
+	}
+	if raw := Count(doc, false); raw.Words <= got.Words {
+		t.Errorf("raw Words = %d, want more than the stripped count", raw.Words)
+	}
+	if got.ReadingTime() != time.Minute {
+		t.Errorf("ReadingTime = %v, want 1m: code should not count as reading", got.ReadingTime())
+	}
+
+	prose := strings.Repeat("word ", 201) + "\n```\n" + strings.Repeat("x ", 2000) + "\n```"
+	if rt := Count(prose, true).ReadingTime(); rt != 2*time.Minute {
+		t.Errorf("ReadingTime of 201 prose words = %v, want 2m", rt)
+	}
+	if rt := Count("", true).ReadingTime(); rt != 0 {
+		t.Errorf("ReadingTime of an empty slate = %v, want 0", rt)
+	}
+}
@@ -0,0 +1,93 @@
+package textstats
+
+import "unicode"
+
+const (
+	zeroWidthJoiner = '\u200d'
+	keycap          = '\u20e3'
+)
+
+// isCJK reports whether r is Chinese or Japanese, written without spaces
+// between words. Korean is left out: it puts spaces between words like
+// Latin text does.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// isEmoji reports whether r is a pictograph, counted as a word of its own
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff, // pictographs, emoticons, flags and the like
+		r >= 0x2600 && r <= 0x27bf: // miscellaneous symbols and dingbats
+		return true
+	}
+	return false
+}
+
+// isRegional reports whether r is one of the regional indicators, a pair of
+// which is a flag
+func isRegional(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// extends reports whether r is drawn as part of the character before it: a
+// variation selector, skin tone, combining mark or keycap
+func extends(r rune) bool {
+	switch {
+	case r == zeroWidthJoiner, r == keycap,
+		r >= 0xfe00 && r <= 0xfe0f,   // variation selectors
+		r >= 0x1f3fb && r <= 0x1f3ff, // skin tones
+		r >= 0xe0020 && r <= 0xe007f: // tag sequences, as in subdivision flags
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+// eachCharacter calls fn with the first rune of every character in text as
+// a reader sees them, so an emoji made of several (a family joined with
+// zero-width joiners, a flag, a thumbs up with a skin tone) is one
+func eachCharacter(text string, fn func(r rune)) {
+	joined := false   // the last rune was a zero-width joiner
+	regional := false // the last character is a lone regional indicator
+	for _, r := range text {
+		switch {
+		case joined:
+			joined = false
+		case r == zeroWidthJoiner:
+			joined = true
+		case extends(r):
+		case regional && isRegional(r):
+			regional = false
+		default:
+			regional = isRegional(r)
+			fn(r)
+		}
+	}
+}
+
+// countCJKWords counts a token with Chinese or Japanese in it: each of
+// those characters is a word, as is each run of anything else with a letter,
+// digit or emoji in it
+func countCJKWords(token string) int {
+	count := 0
+	inWord := false
+	eachCharacter(token, func(r rune) {
+		switch {
+		case isCJK(r):
+			count++
+			inWord = false
+		case isWordRune(r) || isEmoji(r):
+			if !inWord {
+				count++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	})
+	return count
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,119 @@
+package textstats
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Stats describes a piece of prose
+type Stats struct {
+	Words      int
+	Sentences  int
+	Paragraphs int
+	Syllables  int
+}
+
+// Compute counts words, sentences, paragraphs and syllables in text.
+// Sentences end at runs of . ? or !; text without any still counts as one.
+// Paragraphs are separated by blank lines.
+func Compute(text string) Stats {
+	var s Stats
+
+	for _, word := range strings.Fields(text) {
+		s.Words++
+		s.Syllables += syllables(word)
+	}
+
+	for _, sentence := range strings.FieldsFunc(text, isSentenceEnd) {
+		if hasWord(sentence) {
+			s.Sentences++
+		}
+	}
+
+	inParagraph := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			inParagraph = false
+		} else if !inParagraph {
+			inParagraph = true
+			s.Paragraphs++
+		}
+	}
+
+	return s
+}
+
+// AvgSentenceLength is the mean number of words per sentence
+func (s Stats) AvgSentenceLength() float64 {
+	if s.Sentences == 0 {
+		return 0
+	}
+	return float64(s.Words) / float64(s.Sentences)
+}
+
+// Flesch is the Flesch reading ease score. Higher is easier; most prose
+// lands between 0 and 100.
+func (s Stats) Flesch() float64 {
+	if s.Words == 0 || s.Sentences == 0 {
+		return 0
+	}
+	return 206.835 - 1.015*s.AvgSentenceLength() - 84.6*float64(s.Syllables)/float64(s.Words)
+}
+
+// Level describes the Flesch score in words
+func (s Stats) Level() string {
+	if s.Words == 0 {
+		return "-"
+	}
+
+	switch score := s.Flesch(); {
+	case score >= 90:
+		return "very easy"
+	case score >= 70:
+		return "easy"
+	case score >= 60:
+		return "plain"
+	case score >= 50:
+		return "fairly difficult"
+	case score >= 30:
+		return "difficult"
+	default:
+		return "very difficult"
+	}
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '?' || r == '!'
+}
+
+func hasWord(s string) bool {
+	return strings.IndexFunc(s, isWordRune) >= 0
+}
+
+// syllables estimates the syllables in an English word by counting vowel
+// groups. It's rough, but close enough for a readability score.
+func syllables(word string) int {
+	word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}))
+	if word == "" {
+		return 0
+	}
+
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+
+	// Silent e, as in "make", but not "table"
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		count--
+	}
+
+	return max(count, 1)
+}
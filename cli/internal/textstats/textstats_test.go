@@ -0,0 +1,91 @@
+package textstats
+
+import (
+	"math"
+	"testing"
+)
+
+const sample = `The cat sat on the mat. It was warm!
+
+Was the dog jealous? Nobody asked the dog...
+It slept anyway.
+
+
+Fin.`
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Stats
+	}{
+		{"empty", "", Stats{}},
+		{"only whitespace", " \n\n\t", Stats{}},
+		{"no end mark", "just some words", Stats{Words: 3, Sentences: 1, Paragraphs: 1, Syllables: 3}},
+		{"one sentence", "The cat sat on the mat.", Stats{Words: 6, Sentences: 1, Paragraphs: 1, Syllables: 6}},
+		{"sample", sample, Stats{Words: 21, Sentences: 6, Paragraphs: 3, Syllables: 27}},
+		{"runs of marks", "What?! Really?!? Yes.", Stats{Words: 3, Sentences: 3, Paragraphs: 1, Syllables: 4}},
+		{"stray marks", "... ! ?", Stats{Words: 3, Sentences: 0, Paragraphs: 1, Syllables: 0}},
+	}
+	for _, tt := range tests {
+		if got := Compute(tt.text); got != tt.want {
+			t.Errorf("%s: Compute = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSyllables(t *testing.T) {
+	tests := map[string]int{
+		"cat":         1,
+		"make":        1, // silent e
+		"the":         1,
+		"table":       2, // but not before l
+		"water":       2,
+		"beautiful":   3,
+		"readability": 5,
+		"Rhythm.":     1,
+		"42":          0,
+	}
+	for word, want := range tests {
+		if got := syllables(word); got != want {
+			t.Errorf("syllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestAvgSentenceLength(t *testing.T) {
+	if got := Compute(sample).AvgSentenceLength(); got != 3.5 {
+		t.Errorf("sample: %v words a sentence, want 3.5", got)
+	}
+	if got := (Stats{}).AvgSentenceLength(); got != 0 {
+		t.Errorf("no sentences: %v, want 0", got)
+	}
+}
+
+func TestFlesch(t *testing.T) {
+	// 206.835 - 1.015*6 - 84.6*(6/6)
+	if got := Compute("The cat sat on the mat.").Flesch(); math.Abs(got-116.145) > 1e-9 {
+		t.Errorf("Flesch = %v, want 116.145", got)
+	}
+	if got := (Stats{}).Flesch(); got != 0 {
+		t.Errorf("empty Flesch = %v, want 0", got)
+	}
+
+	easy := Compute("I like it. We go now. The sun is up.")
+	hard := Compute("Institutional accountability necessitates comprehensive organizational transparency considerations.")
+	if easy.Flesch() <= hard.Flesch() {
+		t.Errorf("short words scored %v, long ones %v; want the short ones easier", easy.Flesch(), hard.Flesch())
+	}
+}
+
+func TestLevel(t *testing.T) {
+	if got := (Stats{}).Level(); got != "-" {
+		t.Errorf("empty Level = %q", got)
+	}
+	if got := Compute("I like it. We go now. The sun is up.").Level(); got != "very easy" {
+		t.Errorf("short words: %q, want very easy", got)
+	}
+	if got := Compute("Institutional accountability necessitates comprehensive organizational transparency considerations.").Level(); got != "very difficult" {
+		t.Errorf("long words: %q, want very difficult", got)
+	}
+}
@@ -1,8 +1,10 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,9 +15,21 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/clipboard"
 	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/e2e"
+	"github.com/justtype/cli/internal/idlelock"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/normalize"
+	"github.com/justtype/cli/internal/notify"
+	"github.com/justtype/cli/internal/spinners"
+	"github.com/justtype/cli/internal/storage"
 	"github.com/justtype/cli/internal/store"
+	"github.com/justtype/cli/internal/tags"
+	"github.com/justtype/cli/internal/textstats"
 	"github.com/justtype/cli/internal/updater"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
 )
 
 type View int
@@ -30,6 +44,14 @@ const (
 	ViewSettings
 	ViewExport
 	ViewConfirm
+	ViewLog
+	ViewTrash
+	ViewConflict
+	ViewExportOne
+	ViewStats
+	ViewImport
+	ViewUnsaved
+	ViewProfiles
 )
 
 // Mode represents whether user is in local or account mode
@@ -62,9 +84,10 @@ type Model struct {
 	currentSlate *store.Slate
 
 	// Built-in editor
-	titleInput textinput.Model
-	textarea   textarea.Model
-	lastSave   time.Time
+	layout        editorLayout
+	titleInput    textinput.Model
+	textarea      textarea.Model
+	lastSave      time.Time
 	autoSaveTimer *time.Timer
 
 	// Login/Register inputs
@@ -73,12 +96,39 @@ type Model struct {
 	emailInput    textinput.Model
 	inputFocus    int
 
+	// Ends the login, register or page of slates being waited on; see
+	// requestContext
+	cancelRequest context.CancelFunc
+
+	// Slates downloaded at once while syncing; see cloudSlates
+	syncWorkers int
+
+	// The editor has edits that aren't saved; see confirmUnsaved
+	dirty       bool
+	unsavedNext func(*Model) (tea.Model, tea.Cmd) // what confirmUnsaved is leaving for
+
 	// Export
-	exportInput textinput.Model
+	exportInput     textinput.Model
+	exportPath      string   // target waiting on a collision choice
+	exportConflicts []string // files there that the export would overwrite
+	exportAll       int      // index into exportAllFormats
+
+	// Exporting one slate; see exportone.go
+	exportSlate     *store.Slate
+	exportFormat    int // index into store.ExportFormats
+	exportFileInput textinput.Model
 
 	// Search
-	searchInput textinput.Model
-	searching   bool
+	searchInput    textinput.Model
+	searching      bool
+	searchScope    string                        // store.ScopeAll or store.ScopeTitle
+	searchArchived bool                          // searches include archived slates, see store.SetSearchArchived
+	searchHits     map[string]store.SearchResult // where each listed slate matched, by ID
+	searchErr      string                        // why a /regex/ query didn't compile
+
+	// Pages of the cloud slate list pulled so far, and whether there are more
+	cloudPage int
+	cloudMore bool
 
 	// UI state
 	spinner       spinner.Model
@@ -88,11 +138,52 @@ type Model struct {
 	statusTime    time.Time
 	errorMsg      string
 	confirmMsg    string
-	confirmAction func()
+	confirmAction func() tea.Cmd
+
+	// Recent status and error messages, viewable with ctrl+l
+	notifications *notify.Log
+	logReturn     View
+
+	// Cloud operations that failed, by slate ID, shown in the list
+	slateErrors map[string]string
+
+	// Slate whose share link is shown in the list
+	linkSlateID string
+
+	// Past startup_recent_limit once "load all" is pressed
+	showAllSlates bool
+	showArchived  bool // the slates view lists the archive instead
+
+	// Undo for deletes without confirmation
+	undoSlate *store.Slate
+	undoUntil time.Time
+
+	// Deleted slates shown in the trash view
+	trashed []*store.TrashedSlate
+
+	// Profiles shown by "switch account"; see profiles.go
+	profiles     []string
+	profileInput textinput.Model
+	naming       bool // typing a new profile's name
+
+	// Cloud copies of slates edited on both sides, waiting on a decision;
+	// see conflict.go
+	conflicts      []*store.Slate
+	conflictReturn View
 
 	// Login state
 	loginError string
 
+	// Idle lock; see lock.go
+	idle      *idlelock.Idle
+	locked    bool
+	lockInput textinput.Model
+	lockError string
+
+	// The store is encrypted at rest and waiting on its passphrase; the
+	// lock screen asks for it
+	storeLocked bool
+
 	// Update state
 	updateAvailable bool
 	latestVersion   string
@@ -103,44 +194,110 @@ type (
 	updateCheckMsg struct {
 		available bool
 		version   string
+		manual    bool // asked for in settings, so the answer is shown either way
 		err       error
 	}
+	updateDoneMsg struct {
+		err error
+	}
 	cloudSyncMsg struct {
 		slates []*store.Slate
+		page   int  // the page of the slate list pulled, 0 for a full sync
+		more   bool // the server has pages after it
+		failed int  // slates listed whose content couldn't be downloaded
 		err    error
+
+		warnings []string // slates that came with timestamps that had to be filled in
+	}
+	retryPullMsg struct {
+		ctx  context.Context
+		page int
 	}
 	cloudSaveMsg struct {
 		slateID string
 		cloudID int
 		err     error
 	}
+	cloudDeleteMsg struct {
+		slate *store.Slate
+		undo  bool
+		err   error
+	}
+	slateFetchMsg struct {
+		slate *store.Slate // the unavailable slate that was opened
+		full  *store.Slate
+	}
 	loginResultMsg struct {
-		success  bool
-		username string
-		token    string
-		err      error
+		success      bool
+		username     string
+		token        string
+		refreshToken string
+		err          error
 	}
 	registerResultMsg struct {
-		success  bool
-		username string
-		token    string
-		err      error
-	}
-	autoSaveMsg struct{}
+		success      bool
+		username     string
+		token        string
+		refreshToken string
+		err          error
+	}
+	logoutMsg struct {
+		everywhere bool // every session on the account, not just this one
+		err        error
+	}
+	autoSaveMsg    struct{}
+	undoExpiredMsg struct{}
+	sweepMsg       struct{}
 )
 
+// undoWindow is how long a delete can be undone when confirmation is off
+const undoWindow = 5 * time.Second
+
 func NewModel() (*Model, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, err
 	}
+	updater.SetAPIURL(cfg.APIURL)
+	if err := updater.ConfigureTransport(cfg.CACertFile, cfg.NoProxy); err != nil {
+		return nil, err
+	}
+	updater.SetChannel(cfg.UpdateChannel)
+	updater.SetLastCheck(cfg.LastCheck, cfg.LatestVersion)
+
+	// The built-in palette stays unless a theme is set
+	var themeErr error
+	if cfg.Theme != "" || cfg.ThemeColors != (config.Theme{}) {
+		var theme config.Theme
+		theme, themeErr = config.ResolveTheme(cfg.Theme, cfg.ThemeColors)
+		ApplyTheme(theme)
+	}
 
 	st, err := store.New()
 	if err != nil {
 		return nil, err
 	}
+	st.SetNormalize(normalize.Options{
+		KeepLineEndings: cfg.KeepLineEnds,
+		TrimTrailing:    cfg.TrimTrailing,
+		FinalNewline:    cfg.FinalNewline,
+	})
+	st.SetExportWrap(cfg.ExportWrap)
+	st.SetExportMetadata(cfg.ExportMetadata, cfg.APIURL)
+	storage.SetTitleLength(cfg.TitleLength)
+	st.SetManualOrder(cfg.ManualOrder)
 
 	client := api.New(cfg.APIURL, cfg.Token)
+	client.SetTimeout(time.Duration(cfg.RequestTimeout) * time.Second)
+	client.SetRefreshToken(cfg.RefreshToken)
+	client.OnTokenRefresh(func(token, refreshToken string) {
+		cfg.SetTokens(token, refreshToken)
+	})
+	if cfg.E2EKey != "" {
+		if key, err := e2e.ParseKey(cfg.E2EKey); err == nil {
+			client.SetEncryptionKey(key)
+		}
+	}
 
 	// Title input for editor
 	ti := textinput.New()
@@ -152,6 +309,9 @@ func NewModel() (*Model, error) {
 	ta := textarea.New()
 	ta.Placeholder = "start writing..."
 	ta.ShowLineNumbers = false
+	// The defaults cap slates at 400 characters and 99 lines
+	ta.CharLimit = 0
+	ta.MaxHeight = 0
 	ta.SetWidth(80)
 	ta.SetHeight(20)
 	ta.Focus()
@@ -168,6 +328,11 @@ func NewModel() (*Model, error) {
 	passInput.CharLimit = 100
 	passInput.Width = 40
 
+	lockInput := textinput.New()
+	lockInput.Placeholder = "passphrase"
+	lockInput.EchoMode = textinput.EchoPassword
+	lockInput.Width = 30
+
 	emailInput := textinput.New()
 	emailInput.Placeholder = "email"
 	emailInput.CharLimit = 100
@@ -179,12 +344,21 @@ func NewModel() (*Model, error) {
 	searchInput.Width = 40
 
 	exportInput := textinput.New()
-	exportInput.Placeholder = "~/Documents/justtype"
+	exportInput.Placeholder = defaultExportDir
 	exportInput.CharLimit = 200
 	exportInput.Width = 50
 
+	exportFileInput := textinput.New()
+	exportFileInput.CharLimit = 300
+	exportFileInput.Width = 50
+
+	profileInput := textinput.New()
+	profileInput.Placeholder = "work"
+	profileInput.CharLimit = 40
+	profileInput.Width = 30
+
 	s := spinner.New()
-	s.Spinner = spinner.Dot
+	s.Spinner = spinners.For(cfg.SpinnerStyle, cfg.SpinnerFrames)
 	s.Style = SpinnerStyle
 
 	// Determine initial view and mode
@@ -202,20 +376,43 @@ func NewModel() (*Model, error) {
 	}
 
 	m := &Model{
-		view:          initialView,
-		mode:          mode,
-		config:        cfg,
-		store:         st,
-		client:        client,
-		slates:        st.List(),
-		titleInput:    ti,
-		textarea:      ta,
-		usernameInput: userInput,
-		passwordInput: passInput,
-		emailInput:    emailInput,
-		searchInput:   searchInput,
-		exportInput:   exportInput,
-		spinner:       s,
+		view:            initialView,
+		mode:            mode,
+		config:          cfg,
+		store:           st,
+		client:          client,
+		slates:          st.Recent(cfg.RecentLimit),
+		titleInput:      ti,
+		textarea:        ta,
+		usernameInput:   userInput,
+		passwordInput:   passInput,
+		emailInput:      emailInput,
+		searchInput:     searchInput,
+		searchScope:     cfg.SearchScope,
+		exportInput:     exportInput,
+		exportFileInput: exportFileInput,
+		profileInput:    profileInput,
+		spinner:         s,
+		notifications:   notify.New(notify.DefaultSize),
+		slateErrors:     make(map[string]string),
+		idle:            idlelock.New(cfg.LockMinutes),
+		lockInput:       lockInput,
+	}
+	m.syncWorkers = cfg.SyncWorkers
+	if m.syncWorkers <= 0 {
+		m.syncWorkers = DefaultSyncWorkers
+	}
+	if themeErr != nil {
+		m.setError(themeErr.Error())
+	}
+	if notice := st.Recovered(); notice != "" {
+		// An error rather than a status, so it stays until dismissed
+		m.setError(notice)
+	}
+	if st.Locked() {
+		m.locked = true
+		m.storeLocked = true
+		m.lockInput.Focus()
 	}
 
 	return m, nil
@@ -227,7 +424,15 @@ func (m Model) Init() tea.Cmd {
 		textinput.Blink,
 		textarea.Blink,
 		m.spinner.Tick,
-		checkForUpdate(),
+		idleTick(),
+	}
+
+	if updater.ShouldCheck(m.config.UpdateMode) {
+		cmds = append(cmds, checkForUpdate())
+	}
+
+	if m.config.SweepMinutes > 0 {
+		cmds = append(cmds, m.scheduleSweep())
 	}
 
 	// If going straight to editor, create or load a slate
@@ -239,13 +444,16 @@ func (m Model) Init() tea.Cmd {
 	}
 
 	// If logged in, sync slates
-	if m.mode == ModeAccount {
-		cmds = append(cmds, m.pullCloudSlates())
+	// (once the store is unlocked, if it's encrypted)
+	if m.mode == ModeAccount && !m.storeLocked {
+		cmds = append(cmds, m.pullCloudSlates(context.Background(), 1))
 	}
 
 	return tea.Batch(cmds...)
 }
 
+// checkForUpdate checks at most once per updater.CheckInterval, answering
+// from the last check in between
 func checkForUpdate() tea.Cmd {
 	return func() tea.Msg {
 		info, err := updater.CheckForUpdate()
@@ -259,22 +467,48 @@ func checkForUpdate() tea.Cmd {
 	}
 }
 
+// checkForUpdateNow asks the server whenever the last check was
+func checkForUpdateNow() tea.Cmd {
+	return func() tea.Msg {
+		info, err := updater.CheckForUpdateNow()
+		if err != nil {
+			return updateCheckMsg{manual: true, err: err}
+		}
+		return updateCheckMsg{
+			available: info.Available,
+			version:   info.LatestVersion,
+			manual:    true,
+		}
+	}
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		// Update textarea size
-		m.textarea.SetWidth(min(m.width-4, 100))
-		m.textarea.SetHeight(m.height - 8)
+		m.resize(msg.Width, msg.Height)
 		return m, nil
 
 	case tea.KeyMsg:
-		// Global quit with ctrl+c
+		// Global quit with ctrl+c; a second one skips saving
 		if msg.String() == "ctrl+c" {
-			return m, tea.Quit
+			if m.view == ViewUnsaved {
+				return m.forceQuit()
+			}
+			return m.quit()
+		}
+
+		m.idle.Touch()
+		if m.locked {
+			return m.updateLock(msg)
+		}
+
+		// Notification log from anywhere
+		if msg.String() == "ctrl+l" && m.view != ViewLog {
+			m.logReturn = m.view
+			m.view = ViewLog
+			return m, nil
 		}
 
 		// Handle by view
@@ -297,6 +531,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateExport(msg)
 		case ViewConfirm:
 			return m.updateConfirm(msg)
+		case ViewUnsaved:
+			return m.updateUnsaved(msg)
+		case ViewLog:
+			return m.updateLog(msg)
+		case ViewTrash:
+			return m.updateTrash(msg)
+		case ViewStats:
+			return m.updateStats(msg)
+		case ViewConflict:
+			return m.updateConflict(msg)
+		case ViewExportOne:
+			return m.updateExportOne(msg)
+		case ViewImport:
+			return m.updateImport(msg)
+		case ViewProfiles:
+			return m.updateProfiles(msg)
 		}
 
 	case spinner.TickMsg:
@@ -310,58 +560,164 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case registerResultMsg:
 		return m.handleRegisterResult(msg)
 
+	case logoutMsg:
+		return m.handleLogout(msg)
+
 	case updateCheckMsg:
-		if msg.err == nil && msg.available {
+		if at, version := updater.LastUpdateCheck(); !at.Equal(m.config.LastCheck) {
+			m.config.SetLastCheck(at, version)
+		}
+		if msg.manual {
+			m.loading = false
+			switch {
+			case msg.err != nil:
+				m.setError("couldn't check for updates: " + msg.err.Error())
+			case msg.available:
+				m.updateAvailable = true
+				m.latestVersion = msg.version
+			default:
+				m.setStatus("up to date (v" + updater.GetVersion() + ")")
+			}
+			return m, nil
+		}
+		if msg.err == nil && msg.available && msg.version != m.config.SkippedVersion &&
+			updater.ShouldPrompt(m.config.UpdateMode, m.config.UpdateSnoozed, time.Now()) {
 			m.updateAvailable = true
 			m.latestVersion = msg.version
 		}
 		return m, nil
 
+	case updateDoneMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.setError("update failed: " + msg.err.Error())
+		} else {
+			m.updateAvailable = false
+			m.setStatus("updated, restart justtype")
+		}
+		return m, nil
+
 	case cloudSyncMsg:
 		m.loading = false
+		for _, warning := range msg.warnings {
+			m.notifications.Info(warning)
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			// Left the view that asked for it
+			return m, nil
+		}
+		var limited *api.ErrRateLimited
+		if errors.As(msg.err, &limited) && msg.page > 0 {
+			// Only reads are retried; a full sync also pushes
+			return m, m.retryPull(msg.page, limited)
+		}
 		if msg.err != nil {
-			m.errorMsg = "sync failed: " + msg.err.Error()
+			m.setError("sync failed: " + msg.err.Error())
 		} else {
+			if msg.page > 0 {
+				m.cloudPage, m.cloudMore = msg.page, msg.more
+			} else {
+				// A full sync lists everything
+				m.cloudMore = false
+			}
+			var conflicts []*store.Slate
 			for _, slate := range msg.slates {
-				m.store.ImportFromCloud(slate)
+				if m.store.ImportFromCloud(slate) == store.SyncConflict {
+					conflicts = append(conflicts, slate)
+				}
 			}
-			m.slates = m.store.List()
-			if len(msg.slates) > 0 {
-				m.statusMsg = fmt.Sprintf("synced %d slates", len(msg.slates))
-				m.statusTime = time.Now()
+			m.slates = m.listSlates()
+			if msg.failed > 0 {
+				m.setError(fmt.Sprintf("synced %d slates, %d couldn't be downloaded", len(msg.slates), msg.failed))
+			} else if len(msg.slates) > 0 {
+				m.setStatus(fmt.Sprintf("synced %d slates", len(msg.slates)))
 			}
+			m.showConflicts(conflicts)
 		}
 		return m, nil
 
+	case retryPullMsg:
+		if msg.ctx.Err() != nil {
+			// Given up with esc while waiting
+			return m, nil
+		}
+		return m, m.pullCloudSlates(msg.ctx, msg.page)
+
 	case cloudSaveMsg:
 		if msg.err != nil {
 			// Check if session expired
 			if strings.Contains(msg.err.Error(), "401") || strings.Contains(msg.err.Error(), "unauthorized") {
 				m.confirmMsg = "session expired. re-login to continue?"
-				m.confirmAction = func() {
+				m.confirmAction = func() tea.Cmd {
 					m.mode = ModeLocal
 					m.config.ClearCredentials()
 					m.client.SetToken("")
 					m.view = ViewLogin
 					m.usernameInput.Focus()
+					return nil
 				}
 				m.view = ViewConfirm
 			} else {
-				m.statusMsg = fmt.Sprintf("save error: %v", msg.err)
-				m.statusTime = time.Now()
+				m.setError(fmt.Sprintf("save error: %v", msg.err))
 			}
 		} else if msg.cloudID > 0 {
 			m.store.SetCloudID(msg.slateID, msg.cloudID)
 			if m.currentSlate != nil && m.currentSlate.ID == msg.slateID {
 				m.currentSlate = m.store.Get(msg.slateID)
 			}
-			m.statusMsg = "saved to cloud"
-			m.statusTime = time.Now()
+			m.setStatus("saved to cloud")
 		}
 		return m, nil
 
+	case slateFetchMsg:
+		m.loading = false
+		if msg.full.Unavailable {
+			m.slateErrors[msg.slate.ID] = "couldn't load from the cloud"
+			m.setError(fmt.Sprintf("couldn't load \"%s\" from the cloud, try again later", msg.slate.Title))
+			return m, nil
+		}
+		delete(m.slateErrors, msg.slate.ID)
+		if m.store.ImportFromCloud(msg.full) == store.SyncConflict {
+			m.showConflicts([]*store.Slate{msg.full})
+		}
+		m.slates = m.listSlates()
+		if slate := m.store.Get(msg.slate.ID); slate != nil {
+			return m.openSlate(slate)
+		}
+		return m, nil
+
+	case cloudDeleteMsg:
+		if errors.Is(msg.err, api.ErrOffline) {
+			// Trash it here and finish the cloud delete on the next sync
+			return m, m.trashSlate(msg.slate, true, msg.undo)
+		}
+		if msg.err != nil {
+			// Still on the server, so keep it here too or it'd come back on
+			// the next sync
+			m.slateErrors[msg.slate.ID] = "delete failed: " + msg.err.Error()
+			m.setError(fmt.Sprintf("couldn't delete \"%s\": %v", msg.slate.Title, msg.err))
+			return m, nil
+		}
+		return m, m.trashSlate(msg.slate, false, msg.undo)
+
+	case pinMsg:
+		m.handlePin(msg)
+		return m, nil
+
 	case autoSaveMsg:
 		return m.doAutoSave()
+
+	case undoExpiredMsg:
+		if m.undoSlate != nil && !time.Now().Before(m.undoUntil) {
+			m.undoSlate = nil
+		}
+		return m, nil
+
+	case idleTickMsg:
+		return m, m.checkIdle()
+
+	case sweepMsg:
+		return m, tea.Batch(m.removeBlankSlates(false), m.scheduleSweep())
 	}
 
 	return m, tea.Batch(cmds...)
@@ -371,6 +727,9 @@ func (m Model) View() string {
 	if m.width == 0 {
 		return ""
 	}
+	if m.locked {
+		return m.viewLock()
+	}
 
 	switch m.view {
 	case ViewWelcome:
@@ -391,6 +750,22 @@ func (m Model) View() string {
 		return m.viewExport()
 	case ViewConfirm:
 		return m.viewConfirm()
+	case ViewUnsaved:
+		return m.viewUnsaved()
+	case ViewLog:
+		return m.viewLog()
+	case ViewStats:
+		return m.viewStats()
+	case ViewTrash:
+		return m.viewTrash()
+	case ViewConflict:
+		return m.viewConflict()
+	case ViewExportOne:
+		return m.viewExportOne()
+	case ViewImport:
+		return m.viewImport()
+	case ViewProfiles:
+		return m.viewProfiles()
 	}
 
 	return ""
@@ -408,7 +783,7 @@ func (m Model) viewWelcome() string {
 
 	var b strings.Builder
 	b.WriteString(LogoStyle.Render(logo) + "\n")
-	b.WriteString(DimStyle.Render("        v" + updater.GetVersion()) + "\n\n")
+	b.WriteString(DimStyle.Render("        v"+updater.GetVersion()) + "\n\n")
 	b.WriteString(SubtitleStyle.Render("distraction-free writing for your terminal") + "\n\n")
 
 	options := []string{
@@ -471,7 +846,7 @@ func (m *Model) updateWelcome(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, textinput.Blink
 		}
 	case "q", "esc":
-		return m, tea.Quit
+		return m.quit()
 	}
 	return m, nil
 }
@@ -539,6 +914,7 @@ func (m *Model) updateLogin(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		return m.doLogin()
 	case "esc":
+		m.cancelPending()
 		m.view = ViewWelcome
 		m.usernameInput.SetValue("")
 		m.passwordInput.SetValue("")
@@ -572,41 +948,50 @@ func (m *Model) doLogin() (tea.Model, tea.Cmd) {
 	m.loading = true
 	m.loginError = ""
 
+	ctx := m.requestContext()
 	return m, func() tea.Msg {
-		resp, err := m.client.Login(user, pass)
+		resp, err := m.client.LoginCtx(ctx, user, pass)
 		if err != nil {
 			return loginResultMsg{err: err}
 		}
 		return loginResultMsg{
-			success:  true,
-			username: resp.User.Username,
-			token:    resp.Token,
+			success:      true,
+			username:     resp.User.Username,
+			token:        resp.Token,
+			refreshToken: resp.RefreshToken,
 		}
 	}
 }
 
 func (m *Model) handleLoginResult(msg loginResultMsg) (tea.Model, tea.Cmd) {
 	m.loading = false
+	m.cancelPending() // done with; releases the context
 
+	if errors.Is(msg.err, context.Canceled) {
+		// Given up on with esc
+		return m, nil
+	}
 	if msg.err != nil {
 		m.loginError = msg.err.Error()
+		m.notifications.Error("login failed: " + msg.err.Error())
 		return m, nil
 	}
 
+	m.config.RefreshToken = msg.refreshToken
 	m.config.SetCredentials(msg.token, msg.username)
 	m.config.CompleteFirstRun()
 	m.client.SetToken(msg.token)
+	m.client.SetRefreshToken(msg.refreshToken)
 	m.mode = ModeAccount
 	m.view = ViewEditor
 	m.currentSlate = nil
 	m.usernameInput.SetValue("")
 	m.passwordInput.SetValue("")
-	m.statusMsg = fmt.Sprintf("welcome, %s!", msg.username)
-	m.statusTime = time.Now()
+	m.setStatus(fmt.Sprintf("welcome, %s!", msg.username))
 	m.textarea.Focus()
 
 	// Pull cloud slates
-	return m, tea.Batch(textarea.Blink, m.pullCloudSlates())
+	return m, tea.Batch(textarea.Blink, m.pullCloudSlates(context.Background(), 1))
 }
 
 // ============================================================================
@@ -688,6 +1073,7 @@ func (m *Model) updateRegister(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		return m.doRegister()
 	case "esc":
+		m.cancelPending()
 		m.view = ViewWelcome
 		m.usernameInput.SetValue("")
 		m.emailInput.SetValue("")
@@ -734,38 +1120,46 @@ func (m *Model) doRegister() (tea.Model, tea.Cmd) {
 	m.loading = true
 	m.loginError = ""
 
+	ctx := m.requestContext()
 	return m, func() tea.Msg {
-		resp, err := m.client.Register(user, email, pass)
+		resp, err := m.client.RegisterCtx(ctx, user, email, pass)
 		if err != nil {
 			return registerResultMsg{err: err}
 		}
 		return registerResultMsg{
-			success:  true,
-			username: resp.User.Username,
-			token:    resp.Token,
+			success:      true,
+			username:     resp.User.Username,
+			token:        resp.Token,
+			refreshToken: resp.RefreshToken,
 		}
 	}
 }
 
 func (m *Model) handleRegisterResult(msg registerResultMsg) (tea.Model, tea.Cmd) {
 	m.loading = false
+	m.cancelPending() // done with; releases the context
 
+	if errors.Is(msg.err, context.Canceled) {
+		return m, nil
+	}
 	if msg.err != nil {
 		m.loginError = msg.err.Error()
+		m.notifications.Error("register failed: " + msg.err.Error())
 		return m, nil
 	}
 
+	m.config.RefreshToken = msg.refreshToken
 	m.config.SetCredentials(msg.token, msg.username)
 	m.config.CompleteFirstRun()
 	m.client.SetToken(msg.token)
+	m.client.SetRefreshToken(msg.refreshToken)
 	m.mode = ModeAccount
 	m.view = ViewEditor
 	m.currentSlate = nil
 	m.usernameInput.SetValue("")
 	m.emailInput.SetValue("")
 	m.passwordInput.SetValue("")
-	m.statusMsg = fmt.Sprintf("welcome, %s!", msg.username)
-	m.statusTime = time.Now()
+	m.setStatus(fmt.Sprintf("welcome, %s!", msg.username))
 	m.textarea.Focus()
 
 	return m, textarea.Blink
@@ -778,43 +1172,40 @@ func (m *Model) handleRegisterResult(msg registerResultMsg) (tea.Model, tea.Cmd)
 func (m Model) viewEditor() string {
 	// Word count
 	content := m.textarea.Value()
-	words := len(strings.Fields(content))
-
-	// Calculate centered textarea dimensions
-	maxTextWidth := 80
-	textWidth := min(m.width-8, maxTextWidth)
-	textHeight := m.height - 4 // leave room for footer
-
-	// Update textarea size
-	m.textarea.SetWidth(textWidth)
-	m.textarea.SetHeight(textHeight)
-
-	// Center the textarea horizontally
-	leftPadding := (m.width - textWidth) / 2
-	if leftPadding < 0 {
-		leftPadding = 0
-	}
+	words := textstats.Count(content, true).Words
 
-	// Build the centered textarea
+	// Build the centered textarea; it was sized on the last resize
 	textareaView := m.textarea.View()
 
 	// Pad each line to center it
+	padding := strings.Repeat(" ", m.layout.leftPadding)
 	lines := strings.Split(textareaView, "\n")
-	var centeredLines []string
-	for _, line := range lines {
-		centeredLines = append(centeredLines, strings.Repeat(" ", leftPadding)+line)
+	centeredLines := make([]string, len(lines))
+	for i, line := range lines {
+		centeredLines[i] = padding + line
 	}
 	centeredTextarea := strings.Join(centeredLines, "\n")
 
 	// Build footer
 	var footerParts []string
 
-	// Word count
-	wordStr := fmt.Sprintf("%d words", words)
-	footerParts = append(footerParts, DimStyle.Render(wordStr))
+	// Word count, with progress toward the goal if there is one
+	if goal := m.config.WordGoal; goal > 0 {
+		wordStr := fmt.Sprintf("%d / %d words", words, goal)
+		if words >= goal {
+			footerParts = append(footerParts, SuccessStyle.Render(wordStr))
+		} else {
+			footerParts = append(footerParts, DimStyle.Render(wordStr))
+		}
+	} else {
+		wordStr := fmt.Sprintf("%d words", words)
+		footerParts = append(footerParts, DimStyle.Render(wordStr))
+	}
 
 	// Status message
-	if m.statusMsg != "" && time.Since(m.statusTime) < 3*time.Second {
+	if m.loading {
+		footerParts = append(footerParts, m.loadingLine())
+	} else if m.statusShown() {
 		footerParts = append(footerParts, SuccessStyle.Render("✓ "+m.statusMsg))
 	} else if m.errorMsg != "" {
 		footerParts = append(footerParts, ErrorStyle.Render(m.errorMsg))
@@ -823,13 +1214,16 @@ func (m Model) viewEditor() string {
 
 	// Mode indicator
 	if m.mode == ModeAccount {
+		if m.currentSlate != nil && !m.currentSlate.Synced {
+			footerParts = append(footerParts, WarningStyle.Render("not synced"))
+		}
 		footerParts = append(footerParts, DimStyle.Render(m.config.Username))
 	} else {
 		footerParts = append(footerParts, DimStyle.Render("local"))
 	}
 
 	// Help
-	footerParts = append(footerParts, DimStyle.Render("esc menu"))
+	footerParts = append(footerParts, DimStyle.Render("esc menu · ctrl+l log"))
 
 	footer := strings.Join(footerParts, DimStyle.Render("  ·  "))
 
@@ -850,21 +1244,35 @@ func (m Model) viewEditor() string {
 	return centeredTextarea + strings.Repeat("\n", emptyLines) + "\n" + centeredFooter
 }
 
+// resumeEditor returns to the open slate without reloading the textarea, so
+// the caret and scroll position survive a trip through the menus
+func (m *Model) resumeEditor() (tea.Model, tea.Cmd) {
+	m.view = ViewEditor
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
 func (m *Model) updateEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Check for escape to open menu
 	if msg.String() == "esc" {
 		// Save current content first
 		m.saveCurrentSlate()
-		m.view = ViewMenu
-		m.selected = 0
-		return m, nil
+		toMenu := func(m *Model) (tea.Model, tea.Cmd) {
+			m.view = ViewMenu
+			m.selected = 0
+			return m, nil
+		}
+		if m.dirty {
+			// Held back as too short to keep yet
+			return m.confirmUnsaved(toMenu)
+		}
+		return toMenu(m)
 	}
 
 	// Handle ctrl+s for manual save
 	if msg.String() == "ctrl+s" {
 		m.saveCurrentSlate()
-		m.statusMsg = "saved"
-		m.statusTime = time.Now()
+		m.setStatus("saved")
 
 		// Sync to cloud if logged in
 		if m.mode == ModeAccount && m.currentSlate != nil {
@@ -875,10 +1283,22 @@ func (m *Model) updateEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// Update textarea
 	var cmd tea.Cmd
+	before := m.textarea.Value()
 	m.textarea, cmd = m.textarea.Update(msg)
+	if m.textarea.Value() != before {
+		m.dirty = true
+		if m.config.StatusSeconds < 0 {
+			m.statusMsg = ""
+		}
+	}
 
-	// Schedule auto-save after typing stops (debounced)
-	return m, tea.Batch(cmd, tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
+	// Schedule auto-save after typing stops (debounced), unless it's off.
+	// The setting is read here so changing it applies straight away.
+	seconds := m.config.AutosaveSeconds
+	if seconds == 0 {
+		return m, cmd
+	}
+	return m, tea.Batch(cmd, tea.Tick(time.Duration(seconds)*time.Second, func(t time.Time) tea.Msg {
 		return autoSaveMsg{}
 	}))
 }
@@ -899,13 +1319,14 @@ func (m *Model) doAutoSave() (tea.Model, tea.Cmd) {
 
 	// Don't save if nothing has changed
 	if m.currentSlate != nil && m.currentSlate.Content == content {
+		m.dirty = false
 		return m, nil
 	}
 
 	m.saveCurrentSlate()
 
-	// Sync to cloud if in account mode
-	if m.mode == ModeAccount && m.currentSlate != nil {
+	// Sync to cloud if in account mode, unless that waits for ctrl+s
+	if m.mode == ModeAccount && m.currentSlate != nil && m.config.CloudAutosave {
 		return m, m.syncSlateToCloud(m.currentSlate)
 	}
 
@@ -913,17 +1334,23 @@ func (m *Model) doAutoSave() (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) saveCurrentSlate() {
+	m.saveSlate(m.config.MinWords)
+}
+
+// saveSlate saves the editor's content, unless it's a new slate with fewer
+// than minWords words
+func (m *Model) saveSlate(minWords int) {
 	content := m.textarea.Value()
-	if content == "" {
+	if !storage.ShouldSave(content, m.currentSlate == nil, minWords) {
+		if content != "" {
+			m.setStatus(fmt.Sprintf("not saved until %d words", minWords))
+		}
 		return
 	}
 
-	// Extract title from first line
-	lines := strings.SplitN(content, "\n", 2)
-	title := strings.TrimSpace(lines[0])
-	if title == "" {
-		title = "untitled"
-	}
+	// Same title rules as the other storage: first line, capped at a word
+	// or sentence boundary
+	title := storage.ExtractTitle(content)
 
 	if m.currentSlate == nil {
 		// Create new slate
@@ -933,9 +1360,12 @@ func (m *Model) saveCurrentSlate() {
 		m.store.Update(m.currentSlate.ID, title, content)
 		m.currentSlate = m.store.Get(m.currentSlate.ID)
 	}
+	// Kept on save rather than on every keystroke
+	m.store.SetCursor(m.currentSlate.ID, cursorOffset(m.textarea))
 
-	m.slates = m.store.List()
+	m.slates = m.listSlates()
 	m.lastSave = time.Now()
+	m.dirty = false
 }
 
 // ============================================================================
@@ -947,19 +1377,41 @@ func (m Model) viewSlates() string {
 
 	// Header
 	header := TitleStyle.Render(" my slates ")
+	if m.showArchived {
+		header = TitleStyle.Render(" archived ")
+	}
 	newBtn := ButtonStyle.Render("+ new")
 	headerLine := header + "  " + newBtn
+	if m.config.ManualOrder {
+		headerLine += "  " + DimStyle.Render("manual order · alt+↑/↓ to move")
+	}
 	b.WriteString(headerLine + "\n\n")
 
 	if m.searching {
-		b.WriteString(FocusedInputStyle.Render(m.searchInput.View()) + "\n\n")
+		scope := "title + content"
+		if m.searchScope == store.ScopeTitle {
+			scope = "title only"
+		}
+		archived := "shift+tab to include archived"
+		if m.searchArchived {
+			archived = "including archived"
+		}
+		b.WriteString(DimStyle.Render("searching "+scope+" · tab to switch · "+archived+" · #tag for tags · /regex/") + "\n")
+		b.WriteString(FocusedInputStyle.Render(m.searchInput.View()) + "\n")
+		if m.searchErr != "" {
+			b.WriteString(ErrorStyle.Render(m.searchErr) + "\n")
+		}
+		b.WriteString("\n")
 	}
 
-	if len(m.slates) == 0 {
+	if len(m.slates) == 0 && m.showArchived {
+		b.WriteString(DimStyle.Render("nothing archived. press A on a slate to archive it.") + "\n")
+	} else if len(m.slates) == 0 {
 		b.WriteString(DimStyle.Render("no slates yet. press n to create one.") + "\n")
 	} else {
 		// List slates in web-style format
-		listWidth := min(m.width-8, 80)
+		listWidth := max(min(m.width-8, 80), 10)
+		const titleWidth = 40
 
 		for i, slate := range m.slates {
 			cursor := "  "
@@ -974,9 +1426,7 @@ func (m Model) viewSlates() string {
 			if title == "" {
 				title = "untitled"
 			}
-			if len(title) > 40 {
-				title = title[:37] + "..."
-			}
+			title = runewidth.Truncate(title, titleWidth, "...")
 
 			// Word count and time
 			wordStr := fmt.Sprintf("%d words", slate.WordCount)
@@ -984,28 +1434,65 @@ func (m Model) viewSlates() string {
 
 			// Status badges
 			var badges string
+			if slate.Archived && !m.showArchived {
+				// Only in search results that include the archive
+				badges += " " + BadgeStyle.Render("archived")
+			} else if slate.Pinned {
+				badges += " " + BadgeStyle.Render("pinned")
+			}
 			if slate.IsPublished {
 				badges += " " + PublishedBadgeStyle.Render("public")
 			}
-			if slate.Synced && m.mode == ModeAccount {
+			if slate.Unavailable && m.mode != ModeAccount {
+				// Never downloaded, and can't be until logging back in
+				badges += " " + DimStyle.Render("cloud only")
+			} else if slate.Synced && m.mode == ModeAccount {
 				badges += " " + SyncedBadgeStyle.Render("synced")
 			}
 
 			// Build line
 			meta := DimStyle.Render(fmt.Sprintf("%s  %s", wordStr, timeStr))
-			line := style.Render(fmt.Sprintf("%-40s", title)) + "  " + meta + badges
+			line := style.Render(runewidth.FillRight(title, titleWidth)) + "  " + meta + badges
 
-			// Ensure line fits
-			if len(line) > listWidth {
-				line = line[:listWidth-3] + "..."
-			}
+			// Ensure line fits; it's styled, so cut by display width
+			line = truncate.StringWithTail(line, uint(listWidth), "...")
 
 			b.WriteString(cursor + line + "\n")
+
+			if hit, ok := m.searchHits[slate.ID]; ok && m.searching && hit.Snippet != "" {
+				b.WriteString("    " + renderSnippet(hit, listWidth-4) + "\n")
+			}
+
+			if slate.ID == m.linkSlateID {
+				if slate.IsPublished && slate.ShareID != "" {
+					b.WriteString("    " + DimStyle.Render("link: ") + SuccessStyle.Render(api.ShareURL(m.config.APIURL, slate.ShareID)) + DimStyle.Render("  c copy") + "\n")
+				} else {
+					b.WriteString("    " + DimStyle.Render("not published") + "\n")
+				}
+			}
+
+			if errMsg, ok := m.slateErrors[slate.ID]; ok {
+				b.WriteString("    " + ErrorStyle.Render("● "+errMsg) + "\n")
+			}
 		}
 	}
 
+	if hidden := m.store.Len() - len(m.slates); hidden > 0 && !m.showAllSlates && m.searchInput.Value() == "" {
+		b.WriteString("\n" + DimStyle.Render(fmt.Sprintf("%d older slates · a to load all", hidden)) + "\n")
+	} else if m.cloudMore && m.mode == ModeAccount && m.searchInput.Value() == "" {
+		b.WriteString("\n" + DimStyle.Render("more slates in the cloud · m to load more") + "\n")
+	}
+
+	if m.loading {
+		b.WriteString("\n" + m.loadingLine() + "\n")
+	}
+
+	if m.undoActive() {
+		b.WriteString("\n" + SuccessStyle.Render(fmt.Sprintf("deleted \"%s\"", m.undoSlate.Title)) + "  " + CursorStyle.Render("u undo") + "\n")
+	}
+
 	b.WriteString("\n")
-	b.WriteString(HelpStyle.Render("↑/↓ select • enter open • n new • d delete • / search • esc back"))
+	b.WriteString(HelpStyle.Render("↑/↓ select • enter open • n new • f pin • A archive • C duplicate • e export • l link • d delete • o order • / search • esc back"))
 
 	return AppStyle.Render(b.String())
 }
@@ -1016,22 +1503,28 @@ func (m *Model) updateSlates(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "esc":
 			m.searching = false
 			m.searchInput.SetValue("")
-			m.slates = m.store.List()
+			m.slates = m.listSlates()
 			return m, nil
 		case "enter":
 			m.searching = false
 			return m, nil
+		case "tab":
+			if m.searchScope == store.ScopeTitle {
+				m.searchScope = store.ScopeAll
+			} else {
+				m.searchScope = store.ScopeTitle
+			}
+			m.filterSlates()
+			return m, nil
+		case "shift+tab":
+			m.searchArchived = !m.searchArchived
+			m.store.SetSearchArchived(m.searchArchived)
+			m.filterSlates()
+			return m, nil
 		default:
 			var cmd tea.Cmd
 			m.searchInput, cmd = m.searchInput.Update(msg)
-			// Filter slates
-			query := m.searchInput.Value()
-			if query != "" {
-				m.slates = m.store.Search(query)
-			} else {
-				m.slates = m.store.List()
-			}
-			m.selected = 0
+			m.filterSlates()
 			return m, cmd
 		}
 	}
@@ -1045,41 +1538,108 @@ func (m *Model) updateSlates(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.selected < len(m.slates)-1 {
 			m.selected++
 		}
+	case "a":
+		if !m.showAllSlates {
+			m.showAllSlates = true
+			m.slates = m.listSlates()
+		}
+	case "m":
+		if m.cloudMore && m.mode == ModeAccount && !m.loading {
+			m.loading = true
+			m.loadingMsg = "loading more slates..."
+			return m, m.pullCloudSlates(m.requestContext(), m.cloudPage+1)
+		}
+	case "alt+up", "alt+down":
+		m.moveSelected(msg.String() == "alt+down")
+	case "o":
+		m.config.SetManualOrder(!m.config.ManualOrder)
+		m.store.SetManualOrder(m.config.ManualOrder)
+		m.slates = m.listSlates()
+		if m.config.ManualOrder {
+			m.setStatus("manual order · alt+↑/↓ to move slates")
+		} else {
+			m.setStatus("sorted by last update")
+		}
 	case "enter":
 		if len(m.slates) > 0 && m.selected < len(m.slates) {
-			m.currentSlate = m.slates[m.selected]
-			m.textarea.SetValue(m.currentSlate.Content)
-			m.view = ViewEditor
-			m.textarea.Focus()
-			return m, textarea.Blink
+			if m.currentSlate != nil && m.currentSlate.ID == m.slates[m.selected].ID {
+				return m.resumeEditor()
+			}
+			slate := m.slates[m.selected]
+			return m.leaveSlate(func(m *Model) (tea.Model, tea.Cmd) {
+				return m.openSlate(slate)
+			})
 		}
 	case "n":
-		m.currentSlate = nil
-		m.textarea.SetValue("")
-		m.view = ViewEditor
-		m.textarea.Focus()
-		return m, textarea.Blink
+		return m.leaveSlate((*Model).newSlate)
 	case "d":
 		if len(m.slates) > 0 && m.selected < len(m.slates) {
 			slate := m.slates[m.selected]
+			if !m.config.ConfirmDelete {
+				return m, m.deleteSlate(slate, true)
+			}
 			m.confirmMsg = fmt.Sprintf("delete \"%s\"?", slate.Title)
-			m.confirmAction = func() {
-				m.store.Delete(slate.ID)
-				if m.mode == ModeAccount && slate.CloudID > 0 {
-					m.client.DeleteSlate(slate.CloudID)
-				}
-				m.slates = m.store.List()
-				if m.selected >= len(m.slates) && m.selected > 0 {
-					m.selected--
-				}
+			m.confirmAction = func() tea.Cmd {
+				return m.deleteSlate(slate, false)
 			}
 			m.view = ViewConfirm
 		}
+	case "l":
+		if len(m.slates) > 0 && m.selected < len(m.slates) {
+			slate := m.slates[m.selected]
+			if m.linkSlateID == slate.ID {
+				m.linkSlateID = ""
+			} else {
+				m.linkSlateID = slate.ID
+			}
+		}
+	case "c":
+		if len(m.slates) > 0 && m.selected < len(m.slates) {
+			slate := m.slates[m.selected]
+			if slate.ID == m.linkSlateID && slate.IsPublished && slate.ShareID != "" {
+				m.copyLink(api.ShareURL(m.config.APIURL, slate.ShareID))
+			}
+		}
+	case "f":
+		if len(m.slates) > 0 && m.selected < len(m.slates) {
+			return m, m.togglePin(m.slates[m.selected])
+		}
+	case "A":
+		if len(m.slates) > 0 && m.selected < len(m.slates) {
+			m.toggleArchived(m.slates[m.selected])
+		}
+	case "C":
+		if len(m.slates) > 0 && m.selected < len(m.slates) {
+			copied := m.store.Duplicate(m.slates[m.selected].ID)
+			if copied == nil {
+				m.setError("can't duplicate a slate that isn't downloaded yet")
+				break
+			}
+			m.slates = m.listSlates()
+			for i, slate := range m.slates {
+				if slate.ID == copied.ID {
+					m.selected = i
+				}
+			}
+			m.setStatus(fmt.Sprintf("duplicated as \"%s\"", copied.Title))
+		}
+	case "u":
+		if m.undoActive() {
+			return m, m.undoDelete()
+		}
+	case "e":
+		if len(m.slates) > 0 && m.selected < len(m.slates) {
+			return m, m.openExportOne(m.slates[m.selected])
+		}
 	case "/":
 		m.searching = true
 		m.searchInput.Focus()
 		return m, textinput.Blink
 	case "esc":
+		if m.cancelPending() {
+			m.loading = false
+		}
+		m.showArchived = false
 		m.view = ViewMenu
 		m.selected = 0
 		return m, nil
@@ -1087,6 +1647,276 @@ func (m *Model) updateSlates(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// copyLink puts a share link on the clipboard. Without a clipboard tool it
+// says so and leaves the link on screen to select.
+func (m *Model) copyLink(url string) {
+	if err := clipboard.Copy(url); err != nil {
+		m.setError("couldn't copy the link: " + err.Error())
+		return
+	}
+	m.setStatus("link copied to clipboard")
+}
+
+// moveSelected moves the selected slate one place in the manual order,
+// keeping it selected
+func (m *Model) moveSelected(down bool) {
+	if m.selected >= len(m.slates) {
+		return
+	}
+	if !m.config.ManualOrder {
+		m.setStatus("press o for manual order to move slates")
+		return
+	}
+
+	delta := -1
+	if down {
+		delta = 1
+	}
+	id := m.slates[m.selected].ID
+	if !m.store.Move(id, delta) {
+		return
+	}
+
+	m.searchInput.SetValue("")
+	m.slates = m.listSlates()
+	for i, slate := range m.slates {
+		if slate.ID == id {
+			m.selected = i
+		}
+	}
+}
+
+// regexQuery unwraps a /pattern/ search, or /pattern/c to match case
+func regexQuery(query string) (pattern string, caseSensitive, ok bool) {
+	if len(query) < 2 || query[0] != '/' {
+		return "", false, false
+	}
+	if strings.HasSuffix(query, "/c") && len(query) >= 3 {
+		return query[1 : len(query)-2], true, true
+	}
+	if strings.HasSuffix(query, "/") {
+		return query[1 : len(query)-1], false, true
+	}
+	return "", false, false
+}
+
+// renderSnippet shows where a search matched, with the match highlighted
+func renderSnippet(hit store.SearchResult, width int) string {
+	line := DimStyle.Render(hit.Snippet[:hit.MatchStart]) +
+		MatchStyle.Render(hit.Snippet[hit.MatchStart:hit.MatchEnd]) +
+		DimStyle.Render(hit.Snippet[hit.MatchEnd:])
+	return truncate.StringWithTail(line, uint(max(width, 10)), "…")
+}
+
+// listSlates is what the slates view shows: every slate, or only the most
+// recent startup_recent_limit until they're all asked for
+func (m *Model) listSlates() []*store.Slate {
+	if m.showArchived {
+		return m.store.ListArchived()
+	}
+	if m.showAllSlates {
+		return m.store.List()
+	}
+	return m.store.Recent(m.config.RecentLimit)
+}
+
+// filterSlates narrows the list to the search query. A query starting with
+// '#' lists the slates with that tag instead, and one wrapped in slashes is
+// a regular expression (see regexQuery).
+func (m *Model) filterSlates() {
+	query := m.searchInput.Value()
+	m.searchHits = nil
+	m.searchErr = ""
+	if pattern, caseSensitive, ok := regexQuery(query); ok && strings.TrimSpace(pattern) != "" {
+		slates, err := m.store.SearchRegex(pattern, caseSensitive)
+		if err != nil {
+			// Keep the last good results while the pattern is half typed
+			m.searchErr = "invalid pattern: " + err.Error()
+			return
+		}
+		m.slates = slates
+	} else if strings.HasPrefix(query, "#") && len(query) > 1 {
+		m.slates = m.store.ListByTag(query)
+	} else if !ok && strings.TrimSpace(query) != "" {
+		results := m.store.SearchRankedIn(query, m.searchScope)
+		m.slates = make([]*store.Slate, len(results))
+		m.searchHits = make(map[string]store.SearchResult, len(results))
+		for i, r := range results {
+			m.slates[i] = r.Slate
+			m.searchHits[r.Slate.ID] = r
+		}
+	} else {
+		m.slates = m.listSlates()
+	}
+	m.selected = 0
+}
+
+// openSlate opens a slate in the editor. Slates whose content never arrived
+// from the cloud are fetched first; opening them empty would let a save
+// wipe the real content.
+func (m *Model) openSlate(slate *store.Slate) (tea.Model, tea.Cmd) {
+	// Cloud slates are listed without their content and downloaded the
+	// first time they're opened; after that the store has it
+	if slate.Unavailable {
+		if m.mode != ModeAccount {
+			m.setError("this slate's content is only in the cloud, log in to open it")
+			return m, nil
+		}
+
+		m.loading = true
+		m.loadingMsg = "loading slate..."
+		cs := slate.Model().ToAPI()
+		return m, func() tea.Msg {
+			// The timestamps are the ones ToAPI wrote, so they read back
+			full, _, _ := m.fetchCloudSlate(context.Background(), cs)
+			return slateFetchMsg{slate: slate, full: full}
+		}
+	}
+
+	m.currentSlate = slate
+	setContent(&m.textarea, slate.Content, slate.CursorOffset)
+	m.dirty = false
+	m.view = ViewEditor
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
+// deleteSlate deletes a slate. In account mode the cloud copy goes first and
+// the local one is only removed once that worked. With undo set, u brings
+// it back for undoWindow, once it's made it to the trash.
+func (m *Model) deleteSlate(slate *store.Slate, undo bool) tea.Cmd {
+	if m.mode == ModeAccount && slate.CloudID > 0 {
+		return func() tea.Msg {
+			return cloudDeleteMsg{slate: slate, undo: undo, err: m.client.DeleteSlate(slate.CloudID)}
+		}
+	}
+	return m.trashSlate(slate, false, undo)
+}
+
+// trashSlate moves a slate to the local trash, offering to undo it with
+// undo set. cloudPending queues the cloud delete for the next sync. If the
+// trash can't be written the slate stays, marked with the error.
+func (m *Model) trashSlate(slate *store.Slate, cloudPending, undo bool) tea.Cmd {
+	if err := m.removeSlate(slate, cloudPending); err != nil {
+		m.slateErrors[slate.ID] = "delete failed: " + err.Error()
+		m.setError(fmt.Sprintf("couldn't delete \"%s\": %v", slate.Title, err))
+		return nil
+	}
+	if cloudPending {
+		m.setStatus("deleted here, will delete from cloud when back online")
+	}
+	if !undo {
+		return nil
+	}
+	m.undoSlate = slate
+	m.undoUntil = time.Now().Add(undoWindow)
+	return tea.Tick(undoWindow, func(time.Time) tea.Msg {
+		return undoExpiredMsg{}
+	})
+}
+
+// removeSlate moves a slate to the local trash. cloudPending queues the
+// cloud delete for the next sync.
+func (m *Model) removeSlate(slate *store.Slate, cloudPending bool) error {
+	var err error
+	if cloudPending {
+		err = m.store.QueueDelete(slate.ID)
+	} else {
+		err = m.store.Delete(slate.ID)
+	}
+	if err != nil {
+		return err
+	}
+	delete(m.slateErrors, slate.ID)
+	// The editor still holds the deleted slate; start fresh next time
+	if m.currentSlate != nil && m.currentSlate.ID == slate.ID {
+		m.currentSlate = nil
+		m.textarea.SetValue("")
+		m.dirty = false
+	}
+	m.slates = m.listSlates()
+	if m.selected >= len(m.slates) && m.selected > 0 {
+		m.selected--
+	}
+	return nil
+}
+
+// removeBlankSlates moves slates that were created empty and never written
+// in to the trash. The open slate is left alone unless closing is set, so a
+// sweep never pulls a fresh slate out from under the cursor.
+func (m *Model) removeBlankSlates(closing bool) tea.Cmd {
+	if m.store == nil {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for _, slate := range m.store.Blank() {
+		if m.currentSlate != nil && slate.ID == m.currentSlate.ID {
+			// Unsaved words in the editor mean it's not blank after all
+			if !closing || strings.TrimSpace(m.textarea.Value()) != "" {
+				continue
+			}
+		}
+		cmds = append(cmds, m.deleteSlate(slate, false))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m Model) scheduleSweep() tea.Cmd {
+	interval := time.Duration(m.config.SweepMinutes) * time.Minute
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
+		return sweepMsg{}
+	})
+}
+
+// quit cleans up blank slates before exiting, asking first about edits
+// that aren't saved
+func (m *Model) quit() (tea.Model, tea.Cmd) {
+	if m.dirty {
+		return m.confirmUnsaved((*Model).forceQuit)
+	}
+	return m.forceQuit()
+}
+
+// forceQuit exits without asking about unsaved edits
+func (m *Model) forceQuit() (tea.Model, tea.Cmd) {
+	// Let cloud deletes finish before exiting
+	return m, tea.Sequence(m.removeBlankSlates(true), tea.Quit)
+}
+
+func (m *Model) undoActive() bool {
+	return m.undoSlate != nil && time.Now().Before(m.undoUntil)
+}
+
+func (m *Model) undoDelete() tea.Cmd {
+	id := m.undoSlate.ID
+	m.undoSlate = nil
+	return m.restoreSlate(id)
+}
+
+// trashDays is how long the trash keeps slates, for display
+const trashDays = store.TrashRetention / (24 * time.Hour)
+
+// restoreSlate brings a slate back from the trash
+func (m *Model) restoreSlate(id string) tea.Cmd {
+	// A queued cloud delete never happened, so the cloud copy is still there
+	cloudKept := m.store.DeletePending(id)
+	slate := m.store.Restore(id)
+	if slate == nil {
+		return nil
+	}
+
+	m.slates = m.listSlates()
+	m.setStatus("restored")
+
+	// The cloud copy was deleted, so push the slate again as a new one
+	if m.mode == ModeAccount && slate.CloudID > 0 && !cloudKept {
+		m.store.Detach(slate.ID)
+		return m.syncSlateToCloud(m.store.Get(slate.ID))
+	}
+	return nil
+}
+
 // ============================================================================
 // MENU VIEW - Quick menu (esc from editor)
 // ============================================================================
@@ -1102,7 +1932,8 @@ func (m Model) viewMenu() string {
 	}{
 		{"go back", ""},
 		{"new slate", "create new note"},
-		{"my slates", fmt.Sprintf("%d notes", len(m.slates))},
+		{"my slates", fmt.Sprintf("%d notes", len(m.store.List()))},
+		{"archived", fmt.Sprintf("%d put away", len(m.store.ListArchived()))},
 	}
 
 	if m.mode == ModeAccount {
@@ -1116,16 +1947,20 @@ func (m Model) viewMenu() string {
 	}
 
 	items = append(items,
-		struct{ label, desc string }{"settings", "export, update"},
+		struct{ label, desc string }{"trash", fmt.Sprintf("%d deleted", len(m.store.ListTrash()))},
+		struct{ label, desc string }{"stats", "words, streak"},
+		struct{ label, desc string }{"settings", "export, import, update"},
 	)
 
 	if m.mode == ModeAccount {
 		items = append(items,
 			struct{ label, desc string }{"logout", m.config.Username},
+			struct{ label, desc string }{"sign out everywhere", "all devices"},
 		)
 	}
 
 	items = append(items,
+		struct{ label, desc string }{"switch account", config.Profile()},
 		struct{ label, desc string }{"quit", ""},
 	)
 
@@ -1145,8 +1980,12 @@ func (m Model) viewMenu() string {
 	}
 
 	// Status
-	if m.statusMsg != "" && time.Since(m.statusTime) < 3*time.Second {
-		b.WriteString("\n" + SuccessStyle.Render("✓ " + m.statusMsg))
+	if m.loading {
+		b.WriteString("\n" + m.loadingLine())
+	} else if m.statusShown() {
+		b.WriteString("\n" + SuccessStyle.Render("✓ "+m.statusMsg))
+	} else if m.errorMsg != "" {
+		b.WriteString("\n" + ErrorStyle.Render(m.errorMsg))
 	}
 
 	b.WriteString("\n\n" + HelpStyle.Render("↑/↓ select • enter choose • esc back to editor"))
@@ -1156,9 +1995,9 @@ func (m Model) viewMenu() string {
 }
 
 func (m *Model) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	menuLen := 6
+	menuLen := 10
 	if m.mode == ModeAccount {
-		menuLen = 7
+		menuLen = 12
 	}
 
 	switch msg.String() {
@@ -1173,11 +2012,9 @@ func (m *Model) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		return m.handleMenuSelect()
 	case "esc":
-		m.view = ViewEditor
-		m.textarea.Focus()
-		return m, textarea.Blink
+		return m.resumeEditor()
 	case "q":
-		return m, tea.Quit
+		return m.quit()
 	}
 	return m, nil
 }
@@ -1188,62 +2025,73 @@ func (m *Model) handleMenuSelect() (tea.Model, tea.Cmd) {
 	if m.mode == ModeAccount {
 		switch idx {
 		case 0: // Go back
+			m.showArchived = false
 			m.view = ViewSlates
 			m.selected = 0
-			m.slates = m.store.List()
+			m.slates = m.listSlates()
 		case 1: // New slate
-			m.currentSlate = nil
-			m.textarea.SetValue("")
-			m.view = ViewEditor
-			m.textarea.Focus()
-			return m, textarea.Blink
+			return m.leaveSlate((*Model).newSlate)
 		case 2: // My slates
+			m.flushEdits()
+			m.showArchived = false
 			m.view = ViewSlates
 			m.selected = 0
-			m.slates = m.store.List()
-		case 3: // Sync
+			m.slates = m.listSlates()
+		case 3: // Archived
+			m.openArchived()
+		case 4: // Sync
 			m.loading = true
 			m.loadingMsg = "syncing..."
 			return m, m.syncSlates()
-		case 4: // Settings
+		case 5: // Trash
+			m.openTrash()
+		case 6: // Stats
+			m.view = ViewStats
+		case 7: // Settings
 			m.view = ViewSettings
 			m.selected = 0
-		case 5: // Logout
-			m.config.ClearCredentials()
-			m.client.SetToken("")
-			m.mode = ModeLocal
-			m.statusMsg = "logged out"
-			m.statusTime = time.Now()
-			m.selected = 0
-		case 6: // Quit
-			return m, tea.Quit
+		case 8: // Logout
+			return m, m.logout(false)
+		case 9: // Sign out everywhere
+			m.confirmLogoutEverywhere()
+		case 10: // Switch account
+			m.openProfiles()
+		case 11: // Quit
+			return m.quit()
 		}
 	} else {
 		switch idx {
 		case 0: // Go back
+			m.showArchived = false
 			m.view = ViewSlates
 			m.selected = 0
-			m.slates = m.store.List()
+			m.slates = m.listSlates()
 		case 1: // New slate
-			m.currentSlate = nil
-			m.textarea.SetValue("")
-			m.view = ViewEditor
-			m.textarea.Focus()
-			return m, textarea.Blink
+			return m.leaveSlate((*Model).newSlate)
 		case 2: // My slates
+			m.flushEdits()
+			m.showArchived = false
 			m.view = ViewSlates
 			m.selected = 0
-			m.slates = m.store.List()
-		case 3: // Login
+			m.slates = m.listSlates()
+		case 3: // Archived
+			m.openArchived()
+		case 4: // Login
 			m.view = ViewLogin
 			m.selected = 0
 			m.usernameInput.Focus()
 			return m, textinput.Blink
-		case 4: // Settings
+		case 5: // Trash
+			m.openTrash()
+		case 6: // Stats
+			m.view = ViewStats
+		case 7: // Settings
 			m.view = ViewSettings
 			m.selected = 0
-		case 5: // Quit
-			return m, tea.Quit
+		case 8: // Switch account
+			m.openProfiles()
+		case 9: // Quit
+			return m.quit()
 		}
 	}
 	return m, nil
@@ -1258,17 +2106,37 @@ func (m Model) viewSettings() string {
 
 	b.WriteString(TitleStyle.Render(" settings ") + "\n\n")
 
+	confirmDelete := "off (undo instead)"
+	if m.config.ConfirmDelete {
+		confirmDelete = "on"
+	}
+
+	saveNew := "immediately"
+	if m.config.MinWords > 0 {
+		saveNew = fmt.Sprintf("at %d+ words", m.config.MinWords)
+	}
+
 	items := []struct {
 		label string
 		value string
 	}{
 		{"export all slates", ""},
+		{"import files", ".txt, .md"},
+		{"confirm deletes", confirmDelete},
+		{"save new slates", saveNew},
+		{"autosave", config.AutosaveLabel(m.config.AutosaveSeconds)},
+		{"updates", updater.NormalizeMode(m.config.UpdateMode)},
+		{"update channel", updater.NormalizeChannel(m.config.UpdateChannel)},
 	}
 
 	if m.updateAvailable {
 		items = append(items, struct{ label, value string }{"update", "v" + m.latestVersion + " available"})
 	} else {
-		items = append(items, struct{ label, value string }{"check for updates", "v" + updater.GetVersion()})
+		checked := "v" + updater.GetVersion()
+		if !m.config.LastCheck.IsZero() {
+			checked += ", checked " + formatTimeAgo(m.config.LastCheck)
+		}
+		items = append(items, struct{ label, value string }{"check for updates", checked})
 	}
 
 	items = append(items, struct{ label, value string }{"back", ""})
@@ -1288,7 +2156,15 @@ func (m Model) viewSettings() string {
 		b.WriteString(cursor + line + "\n")
 	}
 
-	b.WriteString("\n" + HelpStyle.Render("↑/↓ select • enter choose • esc back"))
+	if m.loading {
+		b.WriteString("\n" + m.loadingLine() + "\n")
+	}
+
+	help := "↑/↓ select • enter choose • ←/→ adjust • esc back"
+	if m.updateAvailable && m.selected == 7 {
+		help = "enter update • s skip this version • esc back"
+	}
+	b.WriteString("\n" + HelpStyle.Render(help))
 
 	box := DialogStyle.Width(45).Render(b.String())
 	return Centered(m.width, m.height, box)
@@ -1301,31 +2177,71 @@ func (m *Model) updateSettings(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.selected--
 		}
 	case "down", "j":
-		if m.selected < 2 {
+		if m.selected < 8 {
 			m.selected++
 		}
+	case "s":
+		if m.selected == 7 && m.updateAvailable {
+			m.config.SkipVersion(m.latestVersion)
+			m.updateAvailable = false
+			m.setStatus("won't offer v" + m.latestVersion + " again")
+		}
 	case "enter":
 		switch m.selected {
 		case 0: // Export
 			m.view = ViewExport
+			m.exportAll = 0
 			m.exportInput.Focus()
 			return m, textinput.Blink
-		case 1: // Update
+		case 1: // Import
+			return m, m.openImport()
+		case 2: // Confirm deletes
+			m.config.SetConfirmDelete(!m.config.ConfirmDelete)
+		case 3: // New slate threshold: immediately <-> 10+ words
+			if m.config.MinWords == 0 {
+				m.config.SetMinWords(storage.SuggestedMinWords)
+			} else {
+				m.config.SetMinWords(0)
+			}
+		case 4: // Autosave interval
+			m.config.SetAutosaveSeconds(config.NextAutosave(m.config.AutosaveSeconds))
+		case 5: // Update preference
+			m.config.SetUpdateMode(updater.NextMode(m.config.UpdateMode))
+		case 6: // Update channel
+			m.config.SetUpdateChannel(updater.NextChannel(m.config.UpdateChannel))
+			updater.SetChannel(m.config.UpdateChannel)
+			m.updateAvailable = false
+			if updater.ShouldCheck(m.config.UpdateMode) {
+				return m, checkForUpdate()
+			}
+		case 7: // Update, or check for one
 			if m.updateAvailable {
 				m.loading = true
 				m.loadingMsg = "updating..."
 				return m, func() tea.Msg {
-					err := updater.Update()
-					if err != nil {
-						return updateCheckMsg{err: err}
-					}
-					return updateCheckMsg{available: false}
+					return updateDoneMsg{err: updater.Update()}
 				}
 			}
-		case 2: // Back
+			if !m.loading {
+				m.loading = true
+				m.loadingMsg = "checking for updates..."
+				return m, checkForUpdateNow()
+			}
+		case 8: // Back
 			m.view = ViewMenu
 			m.selected = 0
 		}
+	case "left", "h", "right", "l":
+		step := 1
+		if msg.String() == "left" || msg.String() == "h" {
+			step = -1
+		}
+		switch m.selected {
+		case 3: // Custom threshold
+			m.config.SetMinWords(m.config.MinWords + step)
+		case 4: // Custom interval, stopping at off rather than wrapping
+			m.config.SetAutosaveSeconds(max(m.config.AutosaveSeconds+step, 0))
+		}
 	case "esc":
 		m.view = ViewMenu
 		m.selected = 0
@@ -1333,6 +2249,76 @@ func (m *Model) updateSettings(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// ============================================================================
+// NOTIFICATION LOG VIEW
+// ============================================================================
+
+// setStatus flashes an info message and keeps it in the notification log
+func (m *Model) setStatus(msg string) {
+	m.statusMsg = msg
+	m.statusTime = time.Now()
+	m.notifications.Info(msg)
+}
+
+// statusShown reports whether the last status message is still on screen
+func (m Model) statusShown() bool {
+	return m.statusMsg != "" && statusVisible(m.statusTime, time.Now(), m.config.StatusSeconds)
+}
+
+// statusVisible applies the status_seconds setting: 0 means the default
+// three seconds, and below 0 keeps the message until an edit clears it
+func statusVisible(shown, now time.Time, seconds int) bool {
+	switch {
+	case seconds < 0:
+		return true
+	case seconds == 0:
+		seconds = 3
+	}
+	return now.Sub(shown) < time.Duration(seconds)*time.Second
+}
+
+// setError shows an error in the footer and keeps it in the notification log
+func (m *Model) setError(msg string) {
+	m.errorMsg = msg
+	m.notifications.Error(msg)
+}
+
+func (m Model) viewLog() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(" notifications ") + "\n\n")
+
+	entries := m.notifications.Entries()
+	if len(entries) == 0 {
+		b.WriteString(DimStyle.Render("nothing yet") + "\n")
+	}
+
+	for _, e := range entries {
+		stamp := DimStyle.Render(e.Time.Format("15:04:05"))
+		if e.Level == notify.Error {
+			b.WriteString(stamp + "  " + ErrorStyle.Render(e.Message) + "\n")
+		} else {
+			b.WriteString(stamp + "  " + e.Message + "\n")
+		}
+	}
+
+	b.WriteString("\n" + HelpStyle.Render("esc back"))
+
+	box := DialogStyle.Width(min(m.width-4, 70)).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+l":
+		if m.logReturn == ViewEditor {
+			return m.resumeEditor()
+		}
+		m.view = m.logReturn
+	}
+	return m, nil
+}
+
 // ============================================================================
 // EXPORT VIEW
 // ============================================================================
@@ -1341,36 +2327,98 @@ func (m Model) viewExport() string {
 	var b strings.Builder
 
 	b.WriteString(TitleStyle.Render(" export slates ") + "\n\n")
+
+	if m.exportConflicts != nil {
+		// Waiting on what to do about files already there
+		b.WriteString(WarningStyle.Render(fmt.Sprintf("will overwrite %d files in %s", len(m.exportConflicts), m.exportPath)) + "\n\n")
+		for i, name := range m.exportConflicts {
+			if i == 5 {
+				b.WriteString(DimStyle.Render(fmt.Sprintf("  and %d more", len(m.exportConflicts)-i)) + "\n")
+				break
+			}
+			b.WriteString(DimStyle.Render("  "+name) + "\n")
+		}
+		b.WriteString("\n" + HelpStyle.Render("o overwrite • s skip existing • t new timestamped folder • esc back"))
+
+		box := DialogStyle.Width(55).Render(b.String())
+		return Centered(m.width, m.height, box)
+	}
+
 	b.WriteString(LabelStyle.Render("export directory:") + "\n")
 	b.WriteString(FocusedInputStyle.Render(m.exportInput.View()) + "\n\n")
-	b.WriteString(DimStyle.Render(fmt.Sprintf("will export %d slates as .txt files", len(m.slates))) + "\n\n")
-	b.WriteString(HelpStyle.Render("enter export • esc cancel"))
+
+	var formats []string
+	for i, f := range exportAllFormats {
+		if i == m.exportAll {
+			formats = append(formats, SelectedListStyle.Render(f.label))
+		} else {
+			formats = append(formats, DimStyle.Render(f.label))
+		}
+	}
+	b.WriteString(LabelStyle.Render("format: ") + strings.Join(formats, " ") + "\n\n")
+
+	if ext := exportAllFormats[m.exportAll].ext; ext != "" {
+		b.WriteString(DimStyle.Render(fmt.Sprintf("will export %d slates to %s", m.store.Len(), exportAllName(ext))) + "\n")
+	} else {
+		b.WriteString(DimStyle.Render(fmt.Sprintf("will export %d slates as .txt files", m.store.Len())) + "\n")
+	}
+	if m.config.ExportWrap > 0 {
+		b.WriteString(DimStyle.Render(fmt.Sprintf("wrapped at %d columns", m.config.ExportWrap)) + "\n")
+	}
+	b.WriteString(DimStyle.Render(m.exportMetadataLine()) + "\n")
+	b.WriteString("\n")
+	b.WriteString(HelpStyle.Render("tab format • ctrl+t metadata • enter export • esc cancel"))
 
 	box := DialogStyle.Width(55).Render(b.String())
 	return Centered(m.width, m.height, box)
 }
 
 func (m *Model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.exportConflicts != nil {
+		switch msg.String() {
+		case "o":
+			m.runExport(store.ExportOverwrite)
+		case "s":
+			m.runExport(store.ExportSkip)
+		case "t":
+			m.runExport(store.ExportSubdir)
+		case "esc":
+			m.exportConflicts = nil
+		}
+		return m, nil
+	}
+
 	switch msg.String() {
+	case "tab":
+		m.exportAll = (m.exportAll + 1) % len(exportAllFormats)
+	case "ctrl+t":
+		m.toggleExportMetadata()
 	case "enter":
 		path := m.exportInput.Value()
 		if path == "" {
-			path = "~/Documents/justtype"
+			path = defaultExportDir
 		}
-		// Expand ~
-		if strings.HasPrefix(path, "~/") {
-			home, _ := os.UserHomeDir()
-			path = home + path[1:]
-		}
-		err := m.store.ExportAll(path)
+		path, err := config.ExpandHome(path)
 		if err != nil {
-			m.errorMsg = "export failed: " + err.Error()
-		} else {
-			m.statusMsg = fmt.Sprintf("exported %d slates to %s", len(m.slates), path)
-			m.statusTime = time.Now()
+			m.setError("export failed: " + err.Error())
+			m.view = ViewSettings
+			m.selected = 0
+			return m, nil
 		}
-		m.view = ViewSettings
-		m.selected = 0
+
+		if ext := exportAllFormats[m.exportAll].ext; ext != "" {
+			// One new file, so nothing to overwrite
+			m.runExportFile(filepath.Join(path, exportAllName(ext)), ext)
+			return m, nil
+		}
+
+		m.exportPath = path
+		if conflicts := m.store.ExportConflicts(path); len(conflicts) > 0 {
+			// Ask before replacing earlier exports
+			m.exportConflicts = conflicts
+			return m, nil
+		}
+		m.runExport(store.ExportOverwrite)
 	case "esc":
 		m.view = ViewSettings
 		m.selected = 0
@@ -1382,6 +2430,70 @@ func (m *Model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// toggleExportMetadata turns the front matter at the top of exports on or
+// off, for both export dialogs
+func (m *Model) toggleExportMetadata() {
+	on := !m.config.ExportMetadata
+	if err := m.config.SetExportMetadata(on); err != nil {
+		m.setError("couldn't save settings: " + err.Error())
+	}
+	m.store.SetExportMetadata(on, m.config.APIURL)
+}
+
+func (m Model) exportMetadataLine() string {
+	if m.config.ExportMetadata {
+		return "metadata: dates, words, share link"
+	}
+	return "metadata: off (plain text)"
+}
+
+// exportAllFormats are the ways the export dialog writes every slate, in
+// the order tab cycles them. ext is the single file's extension, empty for a
+// .txt file per slate.
+var exportAllFormats = []struct{ label, ext string }{
+	{"txt files", ""},
+	{"markdown", "md"},
+	{"zip", "zip"},
+}
+
+// exportAllName is the file a single-file export writes, by date
+func exportAllName(ext string) string {
+	return "justtype-" + time.Now().Format("2006-01-02") + "." + ext
+}
+
+// runExportFile exports everything to the file at path, a .md or .zip, and
+// goes back to settings
+func (m *Model) runExportFile(path, ext string) {
+	export := m.store.ExportAllMarkdown
+	if ext == "zip" {
+		export = m.store.ExportAllZip
+	}
+	if result, err := export(path); err != nil {
+		m.setError("export failed: " + err.Error())
+	} else {
+		m.setStatus(fmt.Sprintf("exported %d slates to %s", result.Written, result.File))
+	}
+	m.view = ViewSettings
+	m.selected = 0
+}
+
+// runExport exports to exportPath and goes back to settings
+func (m *Model) runExport(onConflict string) {
+	result, err := m.store.ExportAll(m.exportPath, onConflict)
+	switch {
+	case err != nil:
+		m.setError("export failed: " + err.Error())
+	case result.Skipped > 0:
+		m.setStatus(fmt.Sprintf("exported %d slates to %s, skipped %d already there", result.Written, result.Dir, result.Skipped))
+	default:
+		m.setStatus(fmt.Sprintf("exported %d slates to %s", result.Written, result.Dir))
+	}
+
+	m.exportConflicts = nil
+	m.view = ViewSettings
+	m.selected = 0
+}
+
 // ============================================================================
 // CONFIRM VIEW
 // ============================================================================
@@ -1400,12 +2512,15 @@ func (m Model) viewConfirm() string {
 func (m *Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y", "enter":
-		if m.confirmAction != nil {
-			m.confirmAction()
-		}
+		var cmd tea.Cmd
+		action := m.confirmAction
 		m.view = ViewSlates
 		m.confirmMsg = ""
 		m.confirmAction = nil
+		if action != nil {
+			cmd = action()
+		}
+		return m, cmd
 	case "n", "esc":
 		m.view = ViewSlates
 		m.confirmMsg = ""
@@ -1418,49 +2533,135 @@ func (m *Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // CLOUD SYNC HELPERS
 // ============================================================================
 
-func (m *Model) pullCloudSlates() tea.Cmd {
+// requestContext is the context for a request the user waits on and can
+// leave with esc. Any earlier one is given up.
+func (m *Model) requestContext() context.Context {
+	m.cancelPending()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelRequest = cancel
+	return ctx
+}
+
+// cancelPending gives up the request from requestContext, reporting whether
+// there was one
+func (m *Model) cancelPending() bool {
+	if m.cancelRequest == nil {
+		return false
+	}
+	m.cancelRequest()
+	m.cancelRequest = nil
+	return true
+}
+
+// rateLimitWait is how long to wait before pulling again when the server
+// rate limits without saying for how long
+const rateLimitWait = 5 * time.Second
+
+// retryPull waits out a rate limit, with the spinner showing how long, and
+// pulls the page again. esc gives up on it.
+func (m *Model) retryPull(page int, limited *api.ErrRateLimited) tea.Cmd {
+	wait := limited.RetryAfter
+	if wait <= 0 {
+		wait = rateLimitWait
+	}
+	m.loading = true
+	m.loadingMsg = fmt.Sprintf("rate limited, retry in %ds", int(wait.Round(time.Second)/time.Second))
+	m.notifications.Info(m.loadingMsg)
+
+	ctx := m.requestContext()
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return retryPullMsg{ctx: ctx, page: page}
+	})
+}
+
+// pullCloudSlates lists a page of the account's slates, newest first. Only
+// slates changed since they were last here are downloaded; see cloudSlate.
+func (m *Model) pullCloudSlates(ctx context.Context, page int) tea.Cmd {
+	local := m.cloudCopies()
 	return func() tea.Msg {
-		cloudSlates, err := m.client.ListSlates()
+		cloudSlates, more, err := m.client.ListSlatesPageCtx(ctx, page, api.DefaultPageSize)
 		if err != nil {
-			return cloudSyncMsg{err: err}
+			// The page lets a rate limited pull be retried
+			return cloudSyncMsg{page: page, err: err}
 		}
 
-		var slates []*store.Slate
-		for _, cs := range cloudSlates {
-			full, err := m.client.GetSlate(cs.ID)
-			if err != nil {
-				continue
-			}
+		slates, warnings, failed := m.cloudSlates(ctx, cloudSlates, local)
+		return cloudSyncMsg{slates: slates, page: page, more: more, failed: failed, warnings: warnings}
+	}
+}
 
-			createdAt, _ := time.Parse(time.RFC3339, cs.CreatedAt)
-			updatedAt, _ := time.Parse(time.RFC3339, cs.UpdatedAt)
-
-			slates = append(slates, &store.Slate{
-				ID:          fmt.Sprintf("cloud-%d", cs.ID),
-				Title:       full.Title,
-				Content:     full.Content,
-				WordCount:   full.WordCount,
-				CreatedAt:   createdAt,
-				UpdatedAt:   updatedAt,
-				CloudID:     cs.ID,
-				IsPublished: cs.IsPublished == 1,
-				ShareID:     cs.ShareID,
-				Synced:      true,
-			})
+// cloudSlate turns a slate from the server's list into a store slate. The
+// content is only downloaded for slates already here (local, nil if not)
+// that changed on the server, to catch conflicts; the rest come without it,
+// marked unavailable, and are fetched when opened. The warning is only worth
+// logging; see listedSlate. err says the download failed, which leaves the
+// slate unavailable too.
+func (m *Model) cloudSlate(ctx context.Context, cs api.Slate, local *store.Slate) (slate *store.Slate, warning, err error) {
+	slate, warning = listedSlate(cs)
+	if local != nil && !local.Unavailable {
+		if store.RemoteChanged(local, slate.UpdatedAt) {
+			return m.fetchCloudSlate(ctx, cs)
 		}
+	}
+
+	slate.Unavailable = true
+	return slate, warning, nil
+}
+
+// listedSlate is a slate as the server lists it, without content. It's
+// always returned; the error says which timestamps the server sent that
+// couldn't be read and were filled in.
+func listedSlate(cs api.Slate) (*store.Slate, error) {
+	m, err := model.FromAPI(cs)
+	slate := store.FromModel(m)
+	slate.Synced = true
+	return slate, err
+}
+
+// fetchCloudSlate downloads a slate listed by the server. If the content
+// can't be fetched the slate is still returned, marked unavailable, so it
+// shows up in the list and is fetched again when opened; err says why.
+func (m *Model) fetchCloudSlate(ctx context.Context, cs api.Slate) (slate *store.Slate, warning, err error) {
+	slate, warning = listedSlate(cs)
+
+	full, err := m.client.GetSlateCtx(ctx, cs.ID)
+	if err != nil {
+		slate.Unavailable = true
+		return slate, warning, err
+	}
+
+	slate.Title = full.Title
+	slate.Content = full.Content
+	slate.WordCount = full.WordCount
+	slate.Tags = tags.Parse(full.Content)
+	return slate, warning, nil
+}
 
-		return cloudSyncMsg{slates: slates}
+// cloudIDFor returns the cloud slate to update, or 0 to create a new one.
+// Slates pulled from the cloud that lost their CloudID are matched back up
+// by ID; ok is false if that fails, since pushing them would duplicate them.
+func cloudIDFor(slate *store.Slate) (cloudID int, ok bool) {
+	if slate.CloudID > 0 || !store.FromCloud(slate) {
+		return slate.CloudID, true
 	}
+
+	fmt.Sscanf(slate.ID, "cloud-%d", &cloudID)
+	return cloudID, cloudID > 0
 }
 
 func (m *Model) syncSlateToCloud(slate *store.Slate) tea.Cmd {
 	return func() tea.Msg {
-		if slate.CloudID > 0 {
-			err := m.client.UpdateSlate(slate.CloudID, slate.Title, slate.Content)
+		cloudID, ok := cloudIDFor(slate)
+		if !ok {
+			return cloudSaveMsg{slateID: slate.ID, err: fmt.Errorf("lost track of this slate's cloud copy")}
+		}
+
+		if cloudID > 0 {
+			err := m.client.UpdateSlate(cloudID, slate.Title, slate.Content)
 			if err != nil {
 				return cloudSaveMsg{slateID: slate.ID, err: err}
 			}
-			return cloudSaveMsg{slateID: slate.ID, cloudID: slate.CloudID}
+			return cloudSaveMsg{slateID: slate.ID, cloudID: cloudID}
 		} else {
 			cloudSlate, err := m.client.CreateSlate(slate.Title, slate.Content)
 			if err != nil {
@@ -1472,51 +2673,63 @@ func (m *Model) syncSlateToCloud(slate *store.Slate) tea.Cmd {
 }
 
 func (m *Model) syncSlates() tea.Cmd {
+	local := m.cloudCopies()
 	return func() tea.Msg {
-		// Push local unsynced slates
-		for _, slate := range m.store.List() {
-			if !slate.Synced && slate.CloudID == 0 {
-				cloudSlate, err := m.client.CreateSlate(slate.Title, slate.Content)
-				if err == nil {
-					m.store.SetCloudID(slate.ID, cloudSlate.ID)
-				}
-			} else if !slate.Synced && slate.CloudID > 0 {
-				m.client.UpdateSlate(slate.CloudID, slate.Title, slate.Content)
-				m.store.SetCloudID(slate.ID, slate.CloudID)
+		// Finish deletes that couldn't reach the server
+		for _, t := range m.store.PendingDeletes() {
+			if err := m.client.DeleteSlate(t.CloudID); err == nil {
+				m.store.ClearPending(t.ID)
 			}
 		}
 
-		// Pull cloud slates
+		// Pull first, so a slate edited elsewhere since the last sync isn't
+		// pushed over
 		cloudSlates, err := m.client.ListSlates()
 		if err != nil {
 			return cloudSyncMsg{err: err}
 		}
 
-		var slates []*store.Slate
-		for _, cs := range cloudSlates {
-			full, err := m.client.GetSlate(cs.ID)
-			if err != nil {
+		slates, warnings, failed := m.cloudSlates(context.Background(), cloudSlates, local)
+		remote := make(map[int]*store.Slate, len(slates))
+		for _, slate := range slates {
+			remote[slate.CloudID] = slate
+		}
+
+		// Push local unsynced slates, archived ones too
+		for _, slate := range m.store.All() {
+			if slate.Synced {
 				continue
 			}
 
-			createdAt, _ := time.Parse(time.RFC3339, cs.CreatedAt)
-			updatedAt, _ := time.Parse(time.RFC3339, cs.UpdatedAt)
-
-			slates = append(slates, &store.Slate{
-				ID:          fmt.Sprintf("cloud-%d", cs.ID),
-				Title:       full.Title,
-				Content:     full.Content,
-				WordCount:   full.WordCount,
-				CreatedAt:   createdAt,
-				UpdatedAt:   updatedAt,
-				CloudID:     cs.ID,
-				IsPublished: cs.IsPublished == 1,
-				ShareID:     cs.ShareID,
-				Synced:      true,
-			})
+			cloudID, ok := cloudIDFor(slate)
+			if !ok {
+				continue
+			}
+
+			if cloudID == 0 {
+				cloudSlate, err := m.client.CreateSlate(slate.Title, slate.Content)
+				if err == nil {
+					m.store.SetCloudID(slate.ID, cloudSlate.ID)
+				}
+				continue
+			}
+
+			r := remote[cloudID]
+			if r != nil && !r.Unavailable && store.Compare(slate, r) == store.SyncConflict {
+				// Left for the import to report
+				continue
+			}
+			if m.client.UpdateSlate(cloudID, slate.Title, slate.Content) == nil {
+				m.store.SetCloudID(slate.ID, cloudID)
+				if r != nil {
+					// What the cloud holds now
+					r.Title = slate.Title
+					r.Content = slate.Content
+				}
+			}
 		}
 
-		return cloudSyncMsg{slates: slates}
+		return cloudSyncMsg{slates: slates, failed: failed, warnings: warnings}
 	}
 }
 
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/justtype/cli/internal/store"
+)
+
+// openArchived lists the archived slates in the slates view
+func (m *Model) openArchived() {
+	m.flushEdits()
+	m.showArchived = true
+	m.view = ViewSlates
+	m.selected = 0
+	m.slates = m.listSlates()
+}
+
+// toggleArchived archives a slate, taking it out of the list, or brings it
+// back from the archive
+func (m *Model) toggleArchived(slate *store.Slate) {
+	archived := !slate.Archived
+	m.store.SetArchived(slate.ID, archived)
+	m.slates = m.listSlates()
+	if m.selected >= len(m.slates) && m.selected > 0 {
+		m.selected = len(m.slates) - 1
+	}
+
+	if archived {
+		m.setStatus(fmt.Sprintf("archived \"%s\" · it's under archived in the menu", slate.Title))
+	} else {
+		m.setStatus(fmt.Sprintf("unarchived \"%s\"", slate.Title))
+	}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/store"
+)
+
+// showConflicts asks, one slate at a time, which side to keep for slates
+// edited both here and on another device since the last sync
+func (m *Model) showConflicts(conflicts []*store.Slate) {
+	if len(conflicts) == 0 {
+		return
+	}
+	if m.view != ViewConflict {
+		m.conflictReturn = m.view
+	}
+	m.conflicts = append(m.conflicts, conflicts...)
+	m.view = ViewConflict
+}
+
+func (m Model) viewConflict() string {
+	var b strings.Builder
+
+	remote := m.conflicts[0]
+	local := m.store.ByCloudID(remote.CloudID)
+
+	title := remote.Title
+	if title == "" {
+		title = "untitled"
+	}
+
+	b.WriteString(WarningStyle.Render("⚠ sync conflict") + "\n\n")
+	b.WriteString(fmt.Sprintf("\"%s\" was edited here and on another device.\n\n", title))
+	if local != nil {
+		b.WriteString(DimStyle.Render(fmt.Sprintf("here:  %d words, %s", local.WordCount, formatTimeAgo(local.UpdatedAt))) + "\n")
+	}
+	b.WriteString(DimStyle.Render(fmt.Sprintf("cloud: %d words, %s", remote.WordCount, formatTimeAgo(remote.UpdatedAt))) + "\n\n")
+
+	if n := len(m.conflicts); n > 1 {
+		b.WriteString(DimStyle.Render(fmt.Sprintf("%d more after this", n-1)) + "\n\n")
+	}
+
+	b.WriteString(HelpStyle.Render("l keep local • c keep cloud • b save both • esc decide later"))
+
+	box := DialogStyle.Width(min(m.width-4, 60)).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	remote := m.conflicts[0]
+
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "l":
+		if local := m.store.KeepLocal(remote); local != nil {
+			cmd = m.syncSlateToCloud(local)
+		}
+		m.setStatus("kept local copy")
+	case "c":
+		if local := m.store.KeepCloud(remote); local != nil && m.currentSlate != nil && m.currentSlate.ID == local.ID {
+			// Don't leave the old text in the editor to be saved back
+			m.textarea.SetValue(local.Content)
+			m.dirty = false
+		}
+		m.setStatus("kept cloud copy")
+	case "b":
+		if copied := m.store.KeepBoth(remote); copied != nil {
+			cmd = m.syncSlateToCloud(copied)
+			if m.currentSlate != nil && m.currentSlate.CloudID == remote.CloudID {
+				m.textarea.SetValue(m.currentSlate.Content)
+				m.dirty = false
+			}
+			m.setStatus(fmt.Sprintf("kept both; your edits are in \"%s\"", copied.Title))
+		}
+	case "esc":
+		// Left as it is; the next sync finds it again
+	default:
+		return m, nil
+	}
+
+	m.conflicts = m.conflicts[1:]
+	m.slates = m.listSlates()
+	if len(m.conflicts) == 0 {
+		m.view = m.conflictReturn
+	}
+	return m, cmd
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/store"
+)
+
+func TestSyncConflictChoices(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	cloudCopy := func(content string, at time.Time) *store.Slate {
+		return &store.Slate{Slate: model.Slate{
+			ID: "cloud-5", CloudID: 5, Title: "Plans", Content: content, WordCount: 3, UpdatedAt: at,
+		}}
+	}
+	// conflicted syncs a slate edited both here and elsewhere, and returns
+	// the model showing the conflict, the local slate and the server
+	conflicted := func(t *testing.T) (*Model, *store.Slate, *pushServer) {
+		t.Helper()
+		srv := &pushServer{}
+		m := localModel(t, t.TempDir())
+		m.resize(80, 24)
+		m.mode = ModeAccount
+		m.client = srv.client(t)
+		m.store.ImportFromCloud(cloudCopy("first draft", base))
+		local := m.store.ByCloudID(5)
+		m.store.Update(local.ID, "Plans", "edited here")
+		m.slates = m.listSlates()
+
+		// A clean pull alongside it goes through without asking
+		other := &store.Slate{Slate: model.Slate{ID: "cloud-6", CloudID: 6, Title: "Other", Content: "untouched", UpdatedAt: base}}
+		update(m, cloudSyncMsg{slates: []*store.Slate{cloudCopy("edited on the laptop", base.Add(time.Minute)), other}})
+		if m.view != ViewConflict || len(m.conflicts) != 1 {
+			t.Fatalf("view %d with %d conflicts, want the conflict screen for one", m.view, len(m.conflicts))
+		}
+		if view := m.viewConflict(); !strings.Contains(view, "sync conflict") || !strings.Contains(view, `"Plans"`) {
+			t.Fatal("conflict screen doesn't name the slate")
+		}
+		if m.store.ByCloudID(6) == nil {
+			t.Fatal("the clean slate wasn't pulled")
+		}
+		return m, local, srv
+	}
+	run := func(m *Model, cmd tea.Cmd) {
+		if cmd != nil {
+			update(m, cmd())
+		}
+	}
+
+	t.Run("keep local", func(t *testing.T) {
+		m, local, srv := conflicted(t)
+		run(m, update(m, key('l')))
+		if m.view != ViewSlates || local.Content != "edited here" {
+			t.Fatalf("view %d, content %q", m.view, local.Content)
+		}
+		if len(srv.updated) != 1 || srv.updated[0] != "/api/slates/5" {
+			t.Fatalf("updated %v, want the local copy pushed over the cloud's", srv.updated)
+		}
+	})
+
+	t.Run("keep cloud", func(t *testing.T) {
+		m, local, srv := conflicted(t)
+		m.currentSlate = local
+		m.textarea.SetValue(local.Content)
+		run(m, update(m, key('c')))
+		if local.Content != "edited on the laptop" || m.textarea.Value() != "edited on the laptop" {
+			t.Fatalf("slate %q, editor %q; want the cloud's text in both", local.Content, m.textarea.Value())
+		}
+		if len(srv.created)+len(srv.updated) != 0 {
+			t.Fatal("keeping the cloud copy pushed something")
+		}
+	})
+
+	t.Run("save both", func(t *testing.T) {
+		m, local, srv := conflicted(t)
+		cmd := update(m, key('b'))
+		if !strings.Contains(m.statusMsg, "Plans"+store.ConflictSuffix) {
+			t.Fatalf("status %q doesn't name the copy", m.statusMsg)
+		}
+		run(m, cmd)
+		if local.Content != "edited on the laptop" {
+			t.Fatalf("original holds %q, want the cloud's", local.Content)
+		}
+		if len(srv.created) != 1 || srv.created[0] != "edited here" {
+			t.Fatalf("created %q, want the local edits uploaded as a new slate", srv.created)
+		}
+	})
+
+	t.Run("decide later", func(t *testing.T) {
+		m, local, _ := conflicted(t)
+		update(m, tea.KeyMsg{Type: tea.KeyEsc})
+		if m.view != ViewSlates || local.Content != "edited here" {
+			t.Fatalf("view %d, content %q", m.view, local.Content)
+		}
+		// The next sync asks again
+		update(m, cloudSyncMsg{slates: []*store.Slate{cloudCopy("edited on the laptop", base.Add(time.Minute))}})
+		if m.view != ViewConflict {
+			t.Fatal("the conflict wasn't raised again")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textarea"
+)
+
+// cursorOffset returns the cursor's byte offset into the textarea's value
+func cursorOffset(ta textarea.Model) int {
+	lines := strings.Split(ta.Value(), "\n")
+	row := min(ta.Line(), len(lines)-1)
+
+	offset := 0
+	for _, line := range lines[:row] {
+		offset += len(line) + 1
+	}
+	info := ta.LineInfo()
+	col := []rune(lines[row])
+	return offset + len(string(col[:min(info.StartColumn+info.ColumnOffset, len(col))]))
+}
+
+// setContent loads content into the textarea with the cursor at offset,
+// clamped to the content in case it shrank. Without an offset the cursor
+// goes to the end, as SetValue leaves it.
+func setContent(ta *textarea.Model, content string, offset int) {
+	ta.SetValue(content)
+	if offset <= 0 {
+		return
+	}
+	offset = min(offset, len(content))
+	for offset < len(content) && !utf8.RuneStart(content[offset]) {
+		offset--
+	}
+
+	before := content[:offset]
+	row := strings.Count(before, "\n")
+	col := len([]rune(before[strings.LastIndex(before, "\n")+1:]))
+
+	// SetValue leaves the cursor on the last line
+	for ta.Line() > row {
+		ta.CursorUp()
+	}
+	ta.SetCursor(col)
+}
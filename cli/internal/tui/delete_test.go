@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/idlelock"
+	"github.com/justtype/cli/internal/notify"
+	"github.com/justtype/cli/internal/store"
+)
+
+// localModel is a local-mode Model on a store in dir, listing its slates
+func localModel(t *testing.T, dir string) *Model {
+	t.Helper()
+	st, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Model{
+		store:         st,
+		config:        &config.Config{},
+		slateErrors:   map[string]string{},
+		notifications: notify.New(notify.DefaultSize),
+		textarea:      textarea.New(),
+		idle:          idlelock.New(0),
+		view:          ViewSlates,
+	}
+}
+
+// update feeds msg to m's Update, keeping the result in m. Update hands
+// back a Model, or the *Model a handler it delegated to returned.
+func update(m *Model, msg tea.Msg) tea.Cmd {
+	updated, cmd := m.Update(msg)
+	switch u := updated.(type) {
+	case Model:
+		*m = u
+	case *Model:
+		*m = *u
+	}
+	return cmd
+}
+
+func key(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestUndoWindow(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.store.Create("", "first\n\nkeep it")
+	m.store.Create("", "second\n\nalso keep it")
+	m.slates = m.listSlates()
+
+	// Without confirmation d deletes straight away and u brings it back
+	m.selected = 0
+	target := m.slates[0]
+	if _, cmd := m.updateSlates(key('d')); cmd == nil {
+		t.Fatal("no undo timer started")
+	}
+	if m.store.Get(target.ID) != nil || m.view != ViewSlates {
+		t.Fatal("d didn't delete without asking")
+	}
+	if !m.undoActive() || !strings.Contains(m.viewSlates(), "u undo") {
+		t.Fatal("no undo offered after the delete")
+	}
+	m.updateSlates(key('u'))
+	if m.store.Get(target.ID) == nil || len(m.store.ListTrash()) != 0 {
+		t.Fatal("u didn't restore the slate")
+	}
+	if m.undoActive() {
+		t.Fatal("undo still offered once used")
+	}
+
+	// An expiry for an earlier delete leaves a later one's window alone
+	m.updateSlates(key('d'))
+	expire := func() { update(m, undoExpiredMsg{}) }
+	expire()
+	if !m.undoActive() {
+		t.Fatal("undo withdrawn before its window ran out")
+	}
+
+	// Once the window is over, u does nothing
+	m.undoUntil = time.Now().Add(-time.Millisecond)
+	expire()
+	if m.undoSlate != nil {
+		t.Fatal("undo still offered after its window")
+	}
+	m.updateSlates(key('u'))
+	if len(m.store.ListTrash()) != 1 || len(m.store.List()) != 1 {
+		t.Fatal("u restored a slate after the window")
+	}
+}
+
+func TestFailedDeleteOffersNoUndo(t *testing.T) {
+	dir := t.TempDir()
+	m := localModel(t, dir)
+	slate := m.store.Create("", "draft\n\nstill needed")
+
+	trash := filepath.Join(dir, "trash.json")
+	if err := os.Mkdir(trash, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := m.deleteSlate(slate, true); cmd != nil {
+		t.Fatal("a failed delete started the undo timer")
+	}
+	if m.undoSlate != nil {
+		t.Fatal("undo offered for a slate that never reached the trash")
+	}
+	if m.slateErrors[slate.ID] == "" {
+		t.Fatal("failed delete not marked on the slate")
+	}
+
+	os.Remove(trash)
+	reopened, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Get(slate.ID) == nil {
+		t.Fatal("slate lost after a failed delete")
+	}
+	if cmd := m.deleteSlate(slate, true); cmd == nil || m.undoSlate != slate {
+		t.Fatal("no undo offered once the slate is in the trash")
+	}
+	if _, ok := m.slateErrors[slate.ID]; ok {
+		t.Fatal("old delete error kept after it worked")
+	}
+}
+
+// deleteServer answers every DELETE with status and counts them
+func deleteServer(t *testing.T, status int, deletes *int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "DELETE":
+			*deletes++
+			w.WriteHeader(status)
+		case "GET":
+			w.Write([]byte("[]"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// accountModel is localModel signed in to the server at url, with one
+// synced slate
+func accountModel(t *testing.T, url string) (*Model, *store.Slate) {
+	t.Helper()
+	m := localModel(t, t.TempDir())
+	m.mode = ModeAccount
+	m.client = api.New(url, "token")
+	m.client.MaxRetries = 0
+	m.syncWorkers = DefaultSyncWorkers
+	slate := m.store.Create("", "shared\n\non the server too")
+	m.store.SetCloudID(slate.ID, 12)
+	m.slates = m.listSlates()
+	return m, slate
+}
+
+// runDelete deletes slate the way d does and feeds back the result
+func runDelete(t *testing.T, m *Model, slate *store.Slate) {
+	t.Helper()
+	cmd := m.deleteSlate(slate, false)
+	if cmd == nil {
+		t.Fatal("no cloud delete started")
+	}
+	update(m, cmd())
+}
+
+func TestCloudDeleteFailureKeepsSlate(t *testing.T) {
+	var deletes int
+	srv := deleteServer(t, http.StatusInternalServerError, &deletes)
+	m, slate := accountModel(t, srv.URL)
+
+	runDelete(t, m, slate)
+	if deletes != 1 {
+		t.Fatalf("server saw %d deletes, want 1", deletes)
+	}
+	if m.store.Get(slate.ID) == nil || len(m.store.ListTrash()) != 0 {
+		t.Fatal("slate deleted here while the server still has it")
+	}
+	if m.slateErrors[slate.ID] == "" {
+		t.Fatal("failed cloud delete not marked on the slate")
+	}
+}
+
+func TestOfflineDeleteIsQueued(t *testing.T) {
+	var deletes int
+	srv := deleteServer(t, http.StatusNoContent, &deletes)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	m, slate := accountModel(t, down.URL)
+
+	runDelete(t, m, slate)
+	if m.store.Get(slate.ID) != nil {
+		t.Fatal("offline delete kept the slate in the list")
+	}
+	if !m.store.DeletePending(slate.ID) {
+		t.Fatal("cloud delete not queued for the next sync")
+	}
+
+	// Back online, the next sync finishes it
+	m.client = api.New(srv.URL, "token")
+	if msg := m.syncSlates()().(cloudSyncMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if deletes != 1 {
+		t.Fatalf("server saw %d deletes, want the queued one", deletes)
+	}
+	if m.store.DeletePending(slate.ID) || m.store.Get(slate.ID) != nil {
+		t.Fatal("queued delete not finished by the sync")
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/storage"
+)
+
+func TestCaretSurvivesMenuRoundTrip(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	var lines []string
+	for i := range 200 {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	content := strings.Join(lines, "\n")
+	m.currentSlate = m.store.Create("", content)
+	m.textarea = textarea.New()
+	m.textarea.CharLimit = 0
+	m.textarea.MaxHeight = 0
+	m.textarea.SetHeight(20)
+	setContent(&m.textarea, content, 1000)
+	m.textarea.Focus()
+	m.view = ViewEditor
+	want := cursorOffset(m.textarea)
+
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	m.updateEditor(esc)
+	if m.view != ViewMenu {
+		t.Fatalf("esc from the editor went to view %d, not the menu", m.view)
+	}
+	// Through settings and back
+	m.selected = 7
+	m.updateMenu(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.view != ViewSettings {
+		t.Fatalf("menu item 7 went to view %d, not settings", m.view)
+	}
+	m.updateSettings(esc)
+	m.updateMenu(esc)
+
+	if m.view != ViewEditor {
+		t.Fatalf("esc from the menu went to view %d, not the editor", m.view)
+	}
+	if got := cursorOffset(m.textarea); got != want || m.textarea.Value() != content {
+		t.Fatalf("caret at %d after the menus, want %d", got, want)
+	}
+	if !m.textarea.Focused() {
+		t.Fatal("editor not focused after the menus")
+	}
+}
+
+func TestLongSingleLineInEditor(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	m, err := NewModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A pasted blob: 10,000 characters on one line, some of them wide
+	blob := strings.Repeat("aé漢🙂", 2500)
+	m.currentSlate = m.store.Create("", blob)
+	setContent(&m.textarea, blob, 0)
+	m.view = ViewEditor
+	update(m, tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	if m.textarea.Value() != blob {
+		t.Fatalf("editor holds %d characters of the %d pasted", utf8.RuneCountInString(m.textarea.Value()), utf8.RuneCountInString(blob))
+	}
+	lines := strings.Split(m.textarea.View(), "\n")
+	if len(lines) != m.layout.textHeight {
+		t.Fatalf("textarea is %d lines, want %d", len(lines), m.layout.textHeight)
+	}
+	for _, line := range lines {
+		if w := lipgloss.Width(line); w > m.layout.textWidth {
+			t.Fatalf("textarea line is %d wide, want the blob wrapped at %d", w, m.layout.textWidth)
+		}
+	}
+	if m.textarea.LineInfo().Height < 2 {
+		t.Fatal("the long line wasn't soft-wrapped")
+	}
+	for _, line := range strings.Split(m.viewEditor(), "\n") {
+		if w := lipgloss.Width(line); w > 100 {
+			t.Fatalf("editor view line is %d wide on a 100 column terminal", w)
+		}
+	}
+}
+
+func TestTitleLengthSetting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	if err := os.WriteFile(filepath.Join(home, "config.json"), []byte(`{"title_length": 30}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.SetTitleLength(0) })
+	m, err := NewModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := strings.Repeat("word ", 20) + "\n\nand a body"
+	m.textarea.SetValue(content)
+	m.saveSlate(0)
+	if m.currentSlate == nil {
+		t.Fatal("not saved")
+	}
+	if m.currentSlate.Title != "word word word word word word…" {
+		t.Fatalf("title %q, want it cut to the configured 30 characters", m.currentSlate.Title)
+	}
+	if m.currentSlate.Content != content {
+		t.Fatalf("saving changed the content to %q", m.currentSlate.Content)
+	}
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestExportAsksBeforeOverwriting(t *testing.T) {
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	// start opens the export view aimed at a folder holding an earlier export
+	start := func(t *testing.T) (*Model, string) {
+		t.Helper()
+		m := localModel(t, t.TempDir())
+		m.resize(80, 24)
+		m.store.Create("Alpha", "new text")
+		m.store.Create("Beta", "other text")
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "Alpha.txt"), []byte("old export"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		m.exportInput = textinput.New()
+		m.exportInput.SetValue(dir)
+		m.view = ViewExport
+
+		update(m, enter)
+		if m.view != ViewExport || len(m.exportConflicts) != 1 {
+			t.Fatalf("enter went to view %d with conflicts %v, want the question", m.view, m.exportConflicts)
+		}
+		if view := m.viewExport(); !strings.Contains(view, "will overwrite 1 files") || !strings.Contains(view, "Alpha.txt") {
+			t.Fatal("the preview doesn't name the file it would overwrite")
+		}
+		return m, dir
+	}
+	alpha := func(dir string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, "Alpha.txt"))
+		return string(data)
+	}
+
+	t.Run("overwrite", func(t *testing.T) {
+		m, dir := start(t)
+		update(m, key('o'))
+		if !strings.Contains(alpha(dir), "new text") || m.view != ViewSettings {
+			t.Fatalf("o: Alpha.txt holds %q, view %d", alpha(dir), m.view)
+		}
+		if !strings.Contains(m.statusMsg, "exported 2 slates") {
+			t.Fatalf("status %q", m.statusMsg)
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		m, dir := start(t)
+		update(m, key('s'))
+		if alpha(dir) != "old export" {
+			t.Fatal("s overwrote Alpha.txt")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Beta.txt")); err != nil {
+			t.Fatal("s didn't export Beta")
+		}
+		if !strings.Contains(m.statusMsg, "skipped 1") {
+			t.Fatalf("status %q", m.statusMsg)
+		}
+	})
+
+	t.Run("subdir", func(t *testing.T) {
+		m, dir := start(t)
+		update(m, key('t'))
+		if alpha(dir) != "old export" {
+			t.Fatal("t overwrote the earlier export")
+		}
+		folders, _ := filepath.Glob(filepath.Join(dir, "justtype-*", "Alpha.txt"))
+		if len(folders) != 1 {
+			t.Fatalf("found %v, want Alpha.txt in one new folder", folders)
+		}
+	})
+
+	t.Run("back", func(t *testing.T) {
+		m, dir := start(t)
+		update(m, tea.KeyMsg{Type: tea.KeyEsc})
+		if m.view != ViewExport || m.exportConflicts != nil {
+			t.Fatal("esc didn't go back to the directory prompt")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Beta.txt")); err == nil {
+			t.Fatal("esc still exported")
+		}
+	})
+}
+
+func TestExportWithoutCollisionsDoesNotAsk(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.store.Create("Alpha", "text")
+	dir := filepath.Join(t.TempDir(), "new folder")
+	m.exportInput = textinput.New()
+	m.exportInput.SetValue(dir)
+	m.view = ViewExport
+
+	update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.exportConflicts != nil || m.view != ViewSettings {
+		t.Fatal("asked about overwriting in an empty folder")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Alpha.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/store"
+)
+
+// defaultExportDir is where exports go unless another path is typed
+const defaultExportDir = "~/Documents/justtype"
+
+// openExportOne shows the dialog for exporting one slate, with a file name
+// made from its title
+func (m *Model) openExportOne(slate *store.Slate) tea.Cmd {
+	m.exportSlate = slate
+	m.exportFormat = 0
+	name := store.SanitizeFilename(slate.Title) + "." + store.ExportFormats[0]
+	m.exportFileInput.SetValue(filepath.Join(defaultExportDir, name))
+	m.exportFileInput.CursorEnd()
+	m.view = ViewExportOne
+	m.exportFileInput.Focus()
+	return textinput.Blink
+}
+
+func (m Model) viewExportOne() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(" export slate ") + "\n\n")
+	b.WriteString(LabelStyle.Render("save as:") + "\n")
+	b.WriteString(FocusedInputStyle.Render(m.exportFileInput.View()) + "\n\n")
+
+	var formats []string
+	for i, f := range store.ExportFormats {
+		if i == m.exportFormat {
+			formats = append(formats, SelectedListStyle.Render(f))
+		} else {
+			formats = append(formats, DimStyle.Render(f))
+		}
+	}
+	b.WriteString(LabelStyle.Render("format: ") + strings.Join(formats, " ") + "\n")
+	if store.ExportFormats[m.exportFormat] != store.FormatHTML {
+		b.WriteString(DimStyle.Render(m.exportMetadataLine()) + "\n")
+	}
+	b.WriteString("\n" + HelpStyle.Render("tab format • ctrl+t metadata • enter export • esc cancel"))
+
+	box := DialogStyle.Width(60).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateExportOne(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab":
+		// Swap the extension along with the format
+		old := "." + store.ExportFormats[m.exportFormat]
+		m.exportFormat = (m.exportFormat + 1) % len(store.ExportFormats)
+		if path := m.exportFileInput.Value(); strings.HasSuffix(path, old) {
+			m.exportFileInput.SetValue(strings.TrimSuffix(path, old) + "." + store.ExportFormats[m.exportFormat])
+			m.exportFileInput.CursorEnd()
+		}
+	case "ctrl+t":
+		m.toggleExportMetadata()
+	case "enter":
+		path, err := config.ExpandHome(strings.TrimSpace(m.exportFileInput.Value()))
+		if err == nil && path == "" {
+			path, err = config.ExpandHome(filepath.Join(defaultExportDir, store.SanitizeFilename(m.exportSlate.Title)+"."+store.ExportFormats[m.exportFormat]))
+		}
+		if err == nil {
+			path, err = m.store.ExportOne(m.exportSlate.ID, path, store.ExportFormats[m.exportFormat])
+		}
+		if err != nil {
+			m.setError("export failed: " + err.Error())
+		} else {
+			m.setStatus("exported to " + path)
+		}
+		m.exportSlate = nil
+		m.view = ViewSlates
+	case "esc":
+		m.exportSlate = nil
+		m.view = ViewSlates
+	default:
+		var cmd tea.Cmd
+		m.exportFileInput, cmd = m.exportFileInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"context"
+	"sync"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/store"
+)
+
+// DefaultSyncWorkers is how many slates a sync downloads at once when the
+// sync_workers setting is 0
+const DefaultSyncWorkers = 5
+
+// cloudSlates runs cloudSlate over slates listed by the server, with up to
+// m.syncWorkers downloads at a time, and returns them in the list's order.
+// A slate that couldn't be downloaded doesn't stop the rest: it's returned
+// unavailable, like the ones not downloaded at all, and counted in failed.
+// local is from cloudCopies; the workers only read it and m.client.
+func (m *Model) cloudSlates(ctx context.Context, listed []api.Slate, local map[int]*store.Slate) (slates []*store.Slate, warnings []string, failed int) {
+	slates = make([]*store.Slate, len(listed))
+	warned := make([]error, len(listed))
+	errs := make([]error, len(listed))
+	forEachLimit(len(listed), m.syncWorkers, func(i int) {
+		slates[i], warned[i], errs[i] = m.cloudSlate(ctx, listed[i], local[listed[i].ID])
+	})
+
+	for i := range listed {
+		if warned[i] != nil {
+			warnings = append(warnings, warned[i].Error())
+		}
+		if errs[i] != nil {
+			failed++
+		}
+	}
+	return slates, warnings, failed
+}
+
+// cloudCopies copies the slates here that came from the cloud, by cloud ID,
+// for cloudSlates to compare the server's list against. It's called before
+// the command is returned, on the UI goroutine, so the fetch workers never
+// read the store while an update is changing it.
+func (m *Model) cloudCopies() map[int]*store.Slate {
+	copies := make(map[int]*store.Slate)
+	for _, slate := range m.store.All() {
+		if slate.CloudID == 0 || slate.Unavailable {
+			continue
+		}
+		if _, ok := copies[slate.CloudID]; !ok {
+			c := *slate
+			copies[slate.CloudID] = &c
+		}
+	}
+	return copies
+}
+
+// forEachLimit calls fn with every index below n, running up to limit calls
+// at once, and returns when they've all returned
+func forEachLimit(n, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := range n {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
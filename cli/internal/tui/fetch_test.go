@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/store"
+)
+
+// slowServer serves every slate after delay, fails the one with id broken,
+// and records the most downloads it saw at once
+func slowServer(t *testing.T, delay time.Duration, broken int, peak *int32) *api.Client {
+	t.Helper()
+	var inFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(peak)
+			if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/slates/"))
+		if id == broken {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(api.Slate{ID: id, Title: "slate", Content: "content of " + strconv.Itoa(id)})
+	}))
+	t.Cleanup(srv.Close)
+	return api.New(srv.URL, "token")
+}
+
+func TestCloudSlatesFetchesInParallel(t *testing.T) {
+	const n, workers, broken = 10, 5, 7
+	const delay = 50 * time.Millisecond
+
+	var peak int32
+	m := &Model{client: slowServer(t, delay, broken, &peak), syncWorkers: workers}
+
+	// Every slate is here and changed on the server since it was synced
+	synced := time.Now().Add(-time.Hour)
+	listed := make([]api.Slate, n)
+	local := make(map[int]*store.Slate, n)
+	for i := range listed {
+		id := i + 1
+		listed[i] = api.Slate{ID: id, Title: "slate", UpdatedAt: time.Now().Format(model.TimeLayout)}
+		local[id] = &store.Slate{Slate: model.Slate{CloudID: id}, LastSyncedAt: synced, Synced: true}
+	}
+
+	start := time.Now()
+	slates, _, failed := m.cloudSlates(context.Background(), listed, local)
+	elapsed := time.Since(start)
+
+	if serial := n * delay; elapsed >= serial/2 {
+		t.Errorf("took %v, serial would be %v", elapsed, serial)
+	}
+	if peak > workers || peak < 2 {
+		t.Errorf("%d downloads at once, want 2 to %d", peak, workers)
+	}
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	for i, slate := range slates {
+		id := i + 1
+		if slate.CloudID != id {
+			t.Fatalf("slate %d is cloud slate %d, want the list's order", i, slate.CloudID)
+		}
+		if id == broken {
+			if !slate.Unavailable {
+				t.Errorf("failed slate %d not marked unavailable", id)
+			}
+			continue
+		}
+		if slate.Unavailable || slate.Content != "content of "+strconv.Itoa(id) {
+			t.Errorf("slate %d: unavailable %v, content %q", id, slate.Unavailable, slate.Content)
+		}
+	}
+}
+
+func TestCloudSlatesSkipsUnchanged(t *testing.T) {
+	var peak int32
+	m := &Model{client: slowServer(t, 0, 0, &peak), syncWorkers: DefaultSyncWorkers}
+
+	updated := time.Now().Add(-time.Hour)
+	listed := []api.Slate{
+		{ID: 1, UpdatedAt: updated.Format(model.TimeLayout)}, // unchanged here
+		{ID: 2, UpdatedAt: updated.Format(model.TimeLayout)}, // not here at all
+	}
+	local := map[int]*store.Slate{1: {Slate: model.Slate{CloudID: 1}, LastSyncedAt: time.Now()}}
+
+	slates, _, failed := m.cloudSlates(context.Background(), listed, local)
+	if peak != 0 || failed != 0 {
+		t.Fatalf("downloaded %d at once, %d failed; want nothing fetched", peak, failed)
+	}
+	for _, slate := range slates {
+		if !slate.Unavailable {
+			t.Errorf("slate %d fetched without changing", slate.CloudID)
+		}
+	}
+}
+
+// listedOnly adds a cloud slate whose content never arrived, as a sync that
+// couldn't download it leaves it
+func listedOnly(t *testing.T, st *store.Store) *store.Slate {
+	t.Helper()
+	st.ImportFromCloud(&store.Slate{
+		Slate: model.Slate{
+			ID:        "cloud-12",
+			CloudID:   12,
+			Title:     "Listed",
+			UpdatedAt: time.Now().Add(-time.Hour).Truncate(time.Second),
+		},
+		Unavailable: true,
+	})
+	slate := st.Get("cloud-12")
+	if slate == nil || !slate.Unavailable {
+		t.Fatalf("listed slate = %+v", slate)
+	}
+	return slate
+}
+
+func TestUnavailableSlateNeedsAccount(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	slate := listedOnly(t, m.store)
+
+	if _, cmd := m.openSlate(slate); cmd != nil {
+		t.Fatal("tried to fetch without an account")
+	}
+	if m.view != ViewSlates || m.currentSlate != nil {
+		t.Fatal("opened an empty editor for a slate with no content")
+	}
+}
+
+func TestUnavailableSlateIsFetchedOnOpen(t *testing.T) {
+	var up atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(api.Slate{
+			ID:        12,
+			Title:     "Listed",
+			Content:   "Listed\n\nthe real content",
+			UpdatedAt: time.Now().Add(-time.Hour).Format(model.TimeLayout),
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	m := localModel(t, t.TempDir())
+	m.mode = ModeAccount
+	m.client = api.New(srv.URL, "token")
+	m.client.MaxRetries = 0
+	slate := listedOnly(t, m.store)
+	open := func() {
+		t.Helper()
+		_, cmd := m.openSlate(slate)
+		if cmd == nil || !m.loading {
+			t.Fatal("no fetch started for an unavailable slate")
+		}
+		update(m, cmd())
+	}
+
+	// Still failing: stay on the list with the error, not in an empty editor
+	open()
+	if m.view != ViewSlates || m.currentSlate != nil {
+		t.Fatal("opened an empty editor after the fetch failed")
+	}
+	if m.slateErrors[slate.ID] == "" || m.loading {
+		t.Fatalf("failed fetch not reported: errors %v, loading %v", m.slateErrors, m.loading)
+	}
+	if got := m.store.Get(slate.ID); got.Content != "" || !got.Unavailable {
+		t.Fatal("failed fetch changed the stored slate")
+	}
+
+	up.Store(true)
+	open()
+	if m.view != ViewEditor || m.textarea.Value() != "Listed\n\nthe real content" {
+		t.Fatalf("view %v, editor holds %q; want the fetched content open", m.view, m.textarea.Value())
+	}
+	if got := m.store.Get(slate.ID); got.Unavailable {
+		t.Fatal("fetched slate still marked unavailable")
+	}
+	if _, ok := m.slateErrors[slate.ID]; ok {
+		t.Fatal("old fetch error kept after it worked")
+	}
+}
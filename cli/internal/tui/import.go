@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/store"
+)
+
+// openImport asks for a file or folder to import, in the export dialog's
+// input
+func (m *Model) openImport() tea.Cmd {
+	m.exportInput.SetValue("")
+	m.view = ViewImport
+	m.exportInput.Focus()
+	return textinput.Blink
+}
+
+func (m Model) viewImport() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(" import ") + "\n\n")
+	b.WriteString(LabelStyle.Render("file or folder:") + "\n")
+	b.WriteString(FocusedInputStyle.Render(m.exportInput.View()) + "\n\n")
+	b.WriteString(DimStyle.Render("imports .txt and .md files; ones already imported are skipped") + "\n\n")
+	b.WriteString(HelpStyle.Render("enter import • esc cancel"))
+
+	box := DialogStyle.Width(55).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateImport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		path := strings.TrimSpace(m.exportInput.Value())
+		if path == "" {
+			path = defaultExportDir
+		}
+		if path, err := config.ExpandHome(path); err != nil {
+			m.setError("import failed: " + err.Error())
+		} else {
+			m.runImport(path)
+		}
+		m.closeImport()
+	case "esc":
+		m.closeImport()
+	default:
+		var cmd tea.Cmd
+		m.exportInput, cmd = m.exportInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// closeImport goes back to settings, leaving the input empty for the next
+// export
+func (m *Model) closeImport() {
+	m.exportInput.SetValue("")
+	m.view = ViewSettings
+	m.selected = 1
+}
+
+// runImport imports path, a file or a folder of them, and says how it went
+func (m *Model) runImport(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		m.setError("import failed: " + err.Error())
+		return
+	}
+
+	if !info.IsDir() {
+		slate, err := m.store.ImportFile(path)
+		switch {
+		case errors.Is(err, store.ErrAlreadyImported), errors.Is(err, store.ErrEmptyFile):
+			m.setStatus("skipped " + err.Error())
+		case err != nil:
+			m.setError("import failed: " + err.Error())
+		default:
+			m.slates = m.listSlates()
+			m.setStatus(fmt.Sprintf("imported \"%s\"", slate.Title))
+		}
+		return
+	}
+
+	imported, skipped, err := m.store.ImportDir(path)
+	if len(imported) > 0 {
+		m.slates = m.listSlates()
+	}
+	summary := fmt.Sprintf("imported %d slates, skipped %d", len(imported), skipped)
+	if err != nil {
+		m.setError(summary + "; " + err.Error())
+		return
+	}
+	m.setStatus(summary)
+}
@@ -0,0 +1,34 @@
+package tui
+
+// maxTextWidth keeps lines readable on wide terminals
+const maxTextWidth = 80
+
+// editorLayout is where the editor sits in the window
+type editorLayout struct {
+	textWidth   int
+	textHeight  int
+	leftPadding int // spaces before each line to center the text
+}
+
+// layoutEditor works out the editor size for a window. It's only computed
+// on resize, so View just reads it and every frame between resizes lines up.
+func layoutEditor(width, height int) editorLayout {
+	textWidth := max(min(width-8, maxTextWidth), 1)
+	textHeight := max(height-4, 1) // leave room for footer
+
+	return editorLayout{
+		textWidth:   textWidth,
+		textHeight:  textHeight,
+		leftPadding: max((width-textWidth)/2, 0),
+	}
+}
+
+// resize records the window size and fits the editor to it
+func (m *Model) resize(width, height int) {
+	m.width = width
+	m.height = height
+
+	m.layout = layoutEditor(width, height)
+	m.textarea.SetWidth(m.layout.textWidth)
+	m.textarea.SetHeight(m.layout.textHeight)
+}
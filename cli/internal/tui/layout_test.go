@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestLayoutEditor(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          editorLayout
+	}{
+		{120, 40, editorLayout{textWidth: 80, textHeight: 36, leftPadding: 20}},
+		{88, 30, editorLayout{textWidth: 80, textHeight: 26, leftPadding: 4}},
+		{60, 20, editorLayout{textWidth: 52, textHeight: 16, leftPadding: 4}},
+		{61, 20, editorLayout{textWidth: 53, textHeight: 16, leftPadding: 4}},
+		{9, 5, editorLayout{textWidth: 1, textHeight: 1, leftPadding: 4}},
+		{0, 0, editorLayout{textWidth: 1, textHeight: 1, leftPadding: 0}},
+	}
+	for _, tt := range tests {
+		if got := layoutEditor(tt.width, tt.height); got != tt.want {
+			t.Errorf("layoutEditor(%d, %d) = %+v, want %+v", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestResizeKeepsEditorInStep(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.textarea.CharLimit = 0
+	content := "A heading\n\n" + strings.Repeat("some words that wrap on a narrow window ", 10)
+	m.currentSlate = m.store.Create("", content)
+	setContent(&m.textarea, content, 20)
+	m.textarea.Focus()
+	m.view = ViewEditor
+
+	views := map[[2]int]string{}
+	for _, size := range [][2]int{{120, 40}, {50, 15}, {200, 60}, {30, 10}, {120, 40}, {50, 15}} {
+		update(m, tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+
+		want := layoutEditor(size[0], size[1])
+		if m.layout != want {
+			t.Fatalf("%v: layout %+v, want %+v", size, m.layout, want)
+		}
+		lines := strings.Split(m.textarea.View(), "\n")
+		if len(lines) != want.textHeight {
+			t.Fatalf("%v: textarea is %d lines, want %d", size, len(lines), want.textHeight)
+		}
+		for _, line := range lines {
+			if w := lipgloss.Width(line); w > want.textWidth {
+				t.Fatalf("%v: textarea line is %d wide, want at most %d", size, w, want.textWidth)
+			}
+		}
+		if m.textarea.Value() != content || cursorOffset(m.textarea) != 20 {
+			t.Fatalf("%v: resize changed the text or moved the caret to %d", size, cursorOffset(m.textarea))
+		}
+
+		view := m.viewEditor()
+		padding := strings.Repeat(" ", want.leftPadding)
+		for _, line := range lines {
+			if !strings.Contains(view, padding+line) {
+				t.Fatalf("%v: editor line %q not padded by %d", size, line, want.leftPadding)
+			}
+		}
+
+		// Coming back to a size draws exactly what it drew before
+		if before, ok := views[size]; ok && before != view {
+			t.Fatalf("%v: view differs after resizing away and back", size)
+		}
+		views[size] = view
+	}
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/idlelock"
+)
+
+// idleTickMsg checks whether the idle lock should kick in
+type idleTickMsg struct{}
+
+func idleTick() tea.Cmd {
+	return tea.Tick(15*time.Second, func(time.Time) tea.Msg {
+		return idleTickMsg{}
+	})
+}
+
+// checkIdle locks the screen once the configured idle time has passed.
+// Whatever view was open stays as it was underneath.
+func (m *Model) checkIdle() tea.Cmd {
+	m.idle.SetMinutes(m.config.LockMinutes)
+	if !m.locked && m.idle.Expired(time.Now()) {
+		m.locked = true
+		m.lockError = ""
+		m.lockInput.SetValue("")
+		m.lockInput.Focus()
+	}
+	return idleTick()
+}
+
+func (m *Model) updateLock(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.lockInput, cmd = m.lockInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.storeLocked {
+		return m.unlockStore()
+	}
+
+	if !idlelock.Check(m.config.LockHash, m.lockInput.Value()) {
+		m.lockInput.SetValue("")
+		m.lockError = "wrong passphrase"
+		return m, nil
+	}
+
+	m.locked = false
+	m.lockInput.SetValue("")
+	m.lockInput.Blur()
+	return m, nil
+}
+
+// unlockStore decrypts the slates with the passphrase typed on the lock
+// screen
+func (m *Model) unlockStore() (tea.Model, tea.Cmd) {
+	err := m.store.Unlock(m.lockInput.Value())
+	m.lockInput.SetValue("")
+	if errors.Is(err, atrest.ErrWrongPassphrase) {
+		m.lockError = "wrong passphrase"
+		return m, nil
+	}
+	if err != nil {
+		m.lockError = err.Error()
+		return m, nil
+	}
+
+	m.locked = false
+	m.storeLocked = false
+	m.lockError = ""
+	if notice := m.store.Recovered(); notice != "" {
+		m.setError(notice)
+	}
+	m.lockInput.Blur()
+	m.slates = m.listSlates()
+	if m.view == ViewEditor && m.currentSlate == nil && len(m.slates) > 0 {
+		m.currentSlate = m.slates[0]
+	}
+	if m.mode == ModeAccount {
+		return m, m.pullCloudSlates(context.Background(), 1)
+	}
+	return m, nil
+}
+
+func (m Model) viewLock() string {
+	var b strings.Builder
+
+	if m.storeLocked {
+		b.WriteString(TitleStyle.Render(" slates are encrypted ") + "\n\n")
+		b.WriteString(LabelStyle.Render("passphrase") + "\n")
+		b.WriteString(FocusedInputStyle.Render(m.lockInput.View()))
+		if m.lockError != "" {
+			b.WriteString("\n\n" + ErrorStyle.Render(m.lockError))
+		}
+		return Centered(m.width, m.height, DialogStyle.Width(40).Render(b.String()))
+	}
+
+	b.WriteString(TitleStyle.Render(" locked ") + "\n\n")
+	if m.config.LockHash == "" {
+		b.WriteString(HelpStyle.Render("press enter to resume"))
+	} else {
+		b.WriteString(LabelStyle.Render("lock passphrase") + "\n")
+		b.WriteString(FocusedInputStyle.Render(m.lockInput.View()))
+		if m.lockError != "" {
+			b.WriteString("\n\n" + ErrorStyle.Render(m.lockError))
+		}
+	}
+
+	box := DialogStyle.Width(40).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
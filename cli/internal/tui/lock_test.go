@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/justtype/cli/internal/idlelock"
+)
+
+func TestIdleLockAndResume(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.lockInput = textinput.New()
+	m.resize(80, 24)
+	m.config.LockMinutes = 5
+	hash, err := idlelock.Hash("open sesame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.config.LockHash = hash
+
+	const text = "private thoughts, not saved yet"
+	m.currentSlate = m.store.Create("", "private thoughts")
+	m.textarea.SetValue(text)
+	m.textarea.Focus()
+	m.view = ViewEditor
+
+	// Input a moment ago: stays open
+	update(m, idleTickMsg{})
+	if m.locked {
+		t.Fatal("locked before the idle time was up")
+	}
+
+	// No input since long ago
+	m.idle = new(idlelock.Idle)
+	if cmd := update(m, idleTickMsg{}); cmd == nil {
+		t.Fatal("idle checks stopped")
+	}
+	if !m.locked {
+		t.Fatal("not locked after the idle time")
+	}
+	if view := m.View(); strings.Contains(view, "private thoughts") || !strings.Contains(view, "locked") {
+		t.Fatalf("lock screen shows the slate:\n%s", view)
+	}
+
+	// Keys go to the passphrase, not the editor
+	typed := func(s string) {
+		for _, r := range s {
+			update(m, key(r))
+		}
+		update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	typed("wrong")
+	if !m.locked || m.lockError == "" {
+		t.Fatal("wrong passphrase unlocked")
+	}
+	typed("open sesame")
+	if m.locked {
+		t.Fatal("right passphrase didn't unlock")
+	}
+	if m.view != ViewEditor || m.textarea.Value() != text {
+		t.Fatalf("after resuming: view %v, editor holds %q", m.view, m.textarea.Value())
+	}
+	if m.idle.Expired(time.Now()) {
+		t.Fatal("typing the passphrase didn't count as input")
+	}
+}
+
+func TestIdleLockWithoutPassphrase(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.lockInput = textinput.New()
+	m.resize(80, 24)
+	m.config.LockMinutes = 1
+	m.idle = new(idlelock.Idle)
+	m.view = ViewSlates
+
+	update(m, idleTickMsg{})
+	if !m.locked || !strings.Contains(m.View(), "press enter") {
+		t.Fatal("not locked, or no hint to press enter")
+	}
+	update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.locked || m.view != ViewSlates {
+		t.Fatalf("enter didn't resume: locked %v, view %v", m.locked, m.view)
+	}
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/justtype/cli/internal/api"
+)
+
+func TestEscCancelsLogin(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // a server that's hung
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	m := localModel(t, t.TempDir())
+	m.client = api.New(srv.URL, "")
+	m.usernameInput = textinput.New()
+	m.passwordInput = textinput.New()
+	m.usernameInput.SetValue("ada")
+	m.passwordInput.SetValue("hunter2")
+	m.view = ViewLogin
+
+	cmd := update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil || !m.loading {
+		t.Fatal("enter didn't start logging in")
+	}
+	result := make(chan tea.Msg, 1)
+	start := time.Now()
+	go func() { result <- cmd() }()
+
+	update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.view != ViewWelcome {
+		t.Fatalf("esc went to view %d, not back to the welcome screen", m.view)
+	}
+
+	var msg tea.Msg
+	select {
+	case msg = <-result:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the login request kept going after esc")
+	}
+	if err := msg.(loginResultMsg).err; !errors.Is(err, context.Canceled) {
+		t.Fatalf("login ended with %v, want context.Canceled", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("took %v to give up", took)
+	}
+
+	// Arriving late, the canceled result is dropped quietly
+	update(m, msg)
+	if m.view != ViewWelcome || m.loginError != "" || m.loading {
+		t.Fatalf("view %d, error %q, loading %v after the canceled result", m.view, m.loginError, m.loading)
+	}
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logout ends the session on the server (every session, with everywhere
+// set) and then, in handleLogout, forgets the credentials here
+func (m *Model) logout(everywhere bool) tea.Cmd {
+	m.loading = true
+	m.loadingMsg = "logging out..."
+	client := m.client
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		var err error
+		if everywhere {
+			err = client.RevokeAllSessions()
+		} else {
+			err = client.Logout()
+		}
+		return logoutMsg{everywhere: everywhere, err: err}
+	})
+}
+
+// handleLogout forgets the credentials whether or not the server heard
+// about it, and warns if it didn't, since the token still works there
+func (m *Model) handleLogout(msg logoutMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.config.ClearCredentials()
+	m.client.SetToken("")
+	m.mode = ModeLocal
+	m.selected = 0
+
+	switch {
+	case msg.err != nil:
+		m.setError("logged out here, but not on the server (" + msg.err.Error() + "); log in again and sign out of all devices to end the session")
+	case msg.everywhere:
+		m.setStatus("signed out of all devices")
+	default:
+		m.setStatus("logged out")
+	}
+	return m, nil
+}
+
+// confirmLogoutEverywhere asks before ending every session on the account
+func (m *Model) confirmLogoutEverywhere() {
+	m.confirmMsg = "sign out of all devices? each will have to log in again"
+	m.confirmAction = func() tea.Cmd {
+		m.view = ViewMenu
+		return m.logout(true)
+	}
+	m.view = ViewConfirm
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/store"
+)
+
+// pinMsg is the server's answer to pinning or unpinning a slate
+type pinMsg struct {
+	slate  *store.Slate
+	pinned bool
+	err    error
+}
+
+// togglePin pins a slate above the rest of the list, or unpins it. In
+// account mode the server keeps the pin too, so it's undone here if the
+// server can't take it; the next sync would undo it anyway.
+func (m *Model) togglePin(slate *store.Slate) tea.Cmd {
+	pinned := !slate.Pinned
+	m.setPinned(slate, pinned)
+	if pinned {
+		m.setStatus(fmt.Sprintf("pinned \"%s\"", slate.Title))
+	} else {
+		m.setStatus(fmt.Sprintf("unpinned \"%s\"", slate.Title))
+	}
+
+	if m.mode != ModeAccount || slate.CloudID == 0 {
+		return nil
+	}
+	cloudID := slate.CloudID
+	return func() tea.Msg {
+		return pinMsg{slate: slate, pinned: pinned, err: m.client.SetPinned(cloudID, pinned)}
+	}
+}
+
+func (m *Model) handlePin(msg pinMsg) {
+	if msg.err == nil {
+		return
+	}
+	m.setPinned(msg.slate, !msg.pinned)
+	action := "pin"
+	if !msg.pinned {
+		action = "unpin"
+	}
+	m.setError(fmt.Sprintf("couldn't %s \"%s\": %v", action, msg.slate.Title, msg.err))
+}
+
+// setPinned pins or unpins slate in the store and lists it again, keeping
+// it selected where it moved to
+func (m *Model) setPinned(slate *store.Slate, pinned bool) {
+	m.store.SetPinned(slate.ID, pinned)
+	m.slates = m.listSlates()
+	for i, s := range m.slates {
+		if s.ID == slate.ID {
+			m.selected = i
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/config"
+)
+
+// openProfiles lists the profiles to switch to, each with its own account,
+// settings and slates
+func (m *Model) openProfiles() {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		m.setError("couldn't list profiles: " + err.Error())
+		return
+	}
+	m.profiles = profiles
+	m.naming = false
+	m.view = ViewProfiles
+	m.selected = 0
+}
+
+func (m Model) viewProfiles() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(" switch account ") + "\n\n")
+
+	current := config.Profile()
+	for i, name := range append(m.profiles, "new profile") {
+		cursor := "  "
+		style := MenuItemStyle
+		if i == m.selected {
+			cursor = CursorStyle.Render("▸ ")
+			style = SelectedStyle
+		}
+
+		line := style.Render(name)
+		if name == current {
+			line += "  " + DimStyle.Render("in use")
+		}
+		b.WriteString(cursor + line + "\n")
+	}
+
+	if m.naming {
+		b.WriteString("\n" + LabelStyle.Render("name:") + "\n")
+		b.WriteString(FocusedInputStyle.Render(m.profileInput.View()) + "\n")
+	}
+	if m.errorMsg != "" {
+		b.WriteString("\n" + ErrorStyle.Render(m.errorMsg) + "\n")
+	}
+
+	help := "↑/↓ select • enter switch • esc back"
+	if m.naming {
+		help = "enter create and switch • esc cancel"
+	}
+	b.WriteString("\n" + HelpStyle.Render(help))
+
+	box := DialogStyle.Width(45).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateProfiles(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.naming {
+		switch msg.String() {
+		case "enter":
+			name := strings.TrimSpace(m.profileInput.Value())
+			if err := config.CheckProfileName(name); err != nil || name == "" {
+				m.setError(config.ErrProfileName.Error())
+				return m, nil
+			}
+			m.naming = false
+			m.profileInput.Blur()
+			return m.leaveSlate(func(m *Model) (tea.Model, tea.Cmd) {
+				return m.switchProfile(name)
+			})
+		case "esc":
+			m.naming = false
+			m.profileInput.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.profileInput, cmd = m.profileInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j":
+		if m.selected < len(m.profiles) {
+			m.selected++
+		}
+	case "enter":
+		if m.selected == len(m.profiles) {
+			m.naming = true
+			m.profileInput.SetValue("")
+			m.profileInput.Focus()
+			return m, textinput.Blink
+		}
+		name := m.profiles[m.selected]
+		if name == config.Profile() {
+			return m.resumeEditor()
+		}
+		return m.leaveSlate(func(m *Model) (tea.Model, tea.Cmd) {
+			return m.switchProfile(name)
+		})
+	case "esc", "q":
+		m.view = ViewMenu
+		m.selected = 0
+	}
+	return m, nil
+}
+
+// switchProfile starts over on the named profile's config, token and
+// slates, as if justtype had been run with --profile. The session here isn't
+// logged out, so switching back needs no login.
+func (m *Model) switchProfile(name string) (tea.Model, tea.Cmd) {
+	previous := config.Profile()
+	if err := config.UseProfile(name); err != nil {
+		m.setError(err.Error())
+		return m, nil
+	}
+	next, err := NewModel()
+	if err != nil {
+		config.UseProfile(previous)
+		m.setError("couldn't switch to " + name + ": " + err.Error())
+		return m, nil
+	}
+	// Saved now so a new profile is listed even if it's never set up
+	if err := next.config.Save(); err != nil {
+		next.setError("couldn't save the profile: " + err.Error())
+	}
+
+	next.resize(m.width, m.height)
+	next.setStatus("switched to " + name)
+
+	// The old store's blank slates go as they would on quit
+	cmds := []tea.Cmd{m.removeBlankSlates(true), next.spinner.Tick}
+	if next.mode == ModeAccount && !next.storeLocked {
+		cmds = append(cmds, next.pullCloudSlates(context.Background(), 1))
+	}
+	return *next, tea.Batch(cmds...)
+}
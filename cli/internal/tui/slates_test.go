@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestShowLinkOfPublishedSlate(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.config.APIURL = "https://notes.example.com/"
+	slate := m.store.Create("", "Essay\n\nout in the world")
+	slate.IsPublished = true
+	slate.ShareID = "k3y"
+	m.store.Create("", "Draft\n\nnot yet")
+	m.slates = m.listSlates()
+
+	for i, s := range m.slates {
+		m.selected = i
+		update(m, key('l'))
+		view := m.viewSlates()
+		if s.ID == slate.ID {
+			if !strings.Contains(view, "https://notes.example.com/s/k3y") {
+				t.Fatal("published slate's link not shown")
+			}
+		} else if !strings.Contains(view, "not published") {
+			t.Fatal("unpublished slate not marked as such")
+		}
+		update(m, key('l'))
+		if strings.Contains(m.viewSlates(), "/s/k3y") {
+			t.Fatal("l again didn't hide the link")
+		}
+	}
+}
+
+func TestAltArrowsMoveSelectedSlate(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	for _, content := range []string{"third", "second", "first"} {
+		m.store.Create("", content)
+		time.Sleep(time.Millisecond)
+	}
+	m.slates = m.listSlates()
+	altDown := tea.KeyMsg{Type: tea.KeyDown, Alt: true}
+	order := func() string {
+		var names []string
+		for _, slate := range m.slates {
+			names = append(names, slate.Content)
+		}
+		return strings.Join(names, " ")
+	}
+
+	// Only in manual order
+	update(m, altDown)
+	if got := order(); got != "first second third" || !strings.Contains(m.statusMsg, "manual order") {
+		t.Fatalf("moved to %q without manual order, status %q", got, m.statusMsg)
+	}
+
+	update(m, key('o'))
+	m.selected = 0
+	update(m, altDown)
+	update(m, altDown)
+	if got := order(); got != "second third first" || m.selected != 2 {
+		t.Fatalf("after two alt+down: %q, selected %d; want first at the bottom and still selected", got, m.selected)
+	}
+	update(m, tea.KeyMsg{Type: tea.KeyUp, Alt: true})
+	if got := order(); got != "second first third" || m.selected != 1 {
+		t.Fatalf("after alt+up: %q, selected %d", got, m.selected)
+	}
+}
+
+func TestLongSingleLineInSlateList(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	// A 10,000 character line, with accents, wide CJK and emoji in it
+	blob := strings.Repeat("aé漢🙂", 2500)
+	slate := m.store.Create("", blob)
+	slate.Title = blob
+	m.store.Create("", "short one")
+	m.slates = m.listSlates()
+
+	for _, width := range []int{120, 60, 20, 0} {
+		m.width, m.height = width, 40
+		view := m.viewSlates()
+		if !strings.Contains(view, "...") {
+			t.Fatalf("width %d: long title not cut with an ellipsis", width)
+		}
+		for _, line := range strings.Split(view, "\n") {
+			if !strings.Contains(line, "aé") {
+				continue
+			}
+			// The view pads lines out to center them; the entry itself has to fit
+			if w := lipgloss.Width(strings.TrimSpace(line)); w > max(min(width-8, 80), 10) {
+				t.Fatalf("width %d: slate line is %d columns wide", width, w)
+			}
+			if !utf8.ValidString(line) {
+				t.Fatalf("width %d: line cut through a character: %q", width, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package tui
+
+// loadingLine renders the spinner and what it's waiting on
+func (m Model) loadingLine() string {
+	return m.spinner.View() + " " + DimStyle.Render(m.loadingMsg)
+}
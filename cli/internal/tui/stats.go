@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/store"
+)
+
+// statsBarWidth is the longest bar in the stats view, for the best day
+const statsBarWidth = 24
+
+func (m Model) viewStats() string {
+	var b strings.Builder
+
+	st := m.store.Stats(time.Now())
+
+	b.WriteString(TitleStyle.Render(" stats ") + "\n\n")
+
+	rows := []struct{ label, value string }{
+		{"today", fmt.Sprintf("%d words", st.WordsToday)},
+		{"streak", plural(st.Streak, "day")},
+		{"total", fmt.Sprintf("%d words in %s", st.TotalWords, plural(st.Slates, "slate"))},
+	}
+	for _, row := range rows {
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("%-8s", row.label)) + row.value + "\n")
+	}
+
+	best := 0
+	for _, d := range st.Days {
+		best = max(best, d.Words)
+	}
+
+	b.WriteString("\n")
+	for _, d := range st.Days {
+		bar := ""
+		if d.Words > 0 {
+			bar = strings.Repeat("█", max(d.Words*statsBarWidth/best, 1))
+		}
+		b.WriteString(DimStyle.Render(d.Day.Format("Mon")+"  ") + CursorStyle.Render(bar) + " " + DimStyle.Render(fmt.Sprint(d.Words)) + "\n")
+	}
+
+	b.WriteString("\n" + DimStyle.Render(fmt.Sprintf("streaks allow %s off in a row", plural(store.StreakGrace, "day"))) + "\n")
+	b.WriteString(HelpStyle.Render("esc back"))
+
+	box := DialogStyle.Width(min(m.width-4, 60)).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "enter":
+		m.view = ViewMenu
+		m.selected = 0
+	}
+	return m, nil
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
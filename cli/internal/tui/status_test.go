@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusVisible(t *testing.T) {
+	shown := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		seconds int
+		after   time.Duration
+		want    bool
+	}{
+		{"default, just shown", 0, 0, true},
+		{"default, before 3s", 0, 2999 * time.Millisecond, true},
+		{"default, at 3s", 0, 3 * time.Second, false},
+		{"10s, at 9s", 10, 9 * time.Second, true},
+		{"10s, at 10s", 10, 10 * time.Second, false},
+		{"1s, at 2s", 1, 2 * time.Second, false},
+		{"until next edit, an hour on", -1, time.Hour, true},
+	}
+	for _, tt := range tests {
+		if got := statusVisible(shown, shown.Add(tt.after), tt.seconds); got != tt.want {
+			t.Errorf("%s: statusVisible = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatusUntilNextEdit(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.resize(80, 24)
+	m.config.StatusSeconds = -1
+	m.view = ViewEditor
+	m.textarea.Focus()
+
+	m.setStatus("saved")
+	m.statusTime = time.Now().Add(-time.Hour)
+	if !strings.Contains(m.viewEditor(), "✓ saved") {
+		t.Fatal("status gone before the next edit")
+	}
+	update(m, key('x'))
+	if strings.Contains(m.viewEditor(), "✓ saved") {
+		t.Fatal("status still shown after an edit")
+	}
+
+	// With a duration, an edit leaves it to run out
+	m.config.StatusSeconds = 30
+	m.setStatus("saved")
+	update(m, key('y'))
+	if !strings.Contains(m.viewEditor(), "✓ saved") {
+		t.Fatal("status cleared by an edit before its 30s were up")
+	}
+}
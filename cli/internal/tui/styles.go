@@ -1,187 +1,271 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justtype/cli/internal/config"
+)
 
 var (
 	// Brand colors
-	purple     = lipgloss.Color("#8B5CF6")
-	purpleDim  = lipgloss.Color("#6D28D9")
-	green      = lipgloss.Color("#10B981")
-	red        = lipgloss.Color("#EF4444")
-	yellow     = lipgloss.Color("#F59E0B")
-	white      = lipgloss.Color("#FFFFFF")
-	gray       = lipgloss.Color("#9CA3AF")
-	darkGray   = lipgloss.Color("#4B5563")
-	darkerGray = lipgloss.Color("#374151")
-	darkest    = lipgloss.Color("#1F2937")
-	black      = lipgloss.Color("#111827")
+	purple      = lipgloss.Color("#8B5CF6")
+	purpleDim   = lipgloss.Color("#6D28D9")
+	green       = lipgloss.Color("#10B981")
+	red         = lipgloss.Color("#EF4444")
+	yellow      = lipgloss.Color("#F59E0B")
+	white       = lipgloss.Color("#FFFFFF")
+	gray        = lipgloss.Color("#9CA3AF")
+	darkGray    = lipgloss.Color("#4B5563")
+	darkerGray  = lipgloss.Color("#374151")
+	darkest     = lipgloss.Color("#1F2937")
+	black       = lipgloss.Color("#111827")
+	lightGray   = lipgloss.Color("#E5E7EB")
+	lighterGray = lipgloss.Color("#D1D5DB")
+)
+
+var (
+	LogoStyle           lipgloss.Style
+	AppStyle            lipgloss.Style
+	TitleStyle          lipgloss.Style
+	SubtitleStyle       lipgloss.Style
+	MenuItemStyle       lipgloss.Style
+	SelectedStyle       lipgloss.Style
+	ListItemStyle       lipgloss.Style
+	SelectedListStyle   lipgloss.Style
+	InputStyle          lipgloss.Style
+	FocusedInputStyle   lipgloss.Style
+	LabelStyle          lipgloss.Style
+	HelpStyle           lipgloss.Style
+	SuccessStyle        lipgloss.Style
+	ErrorStyle          lipgloss.Style
+	WarningStyle        lipgloss.Style
+	DimStyle            lipgloss.Style
+	MatchStyle          lipgloss.Style
+	BadgeStyle          lipgloss.Style
+	PublishedBadgeStyle lipgloss.Style
+	SyncedBadgeStyle    lipgloss.Style
+	PreviewStyle        lipgloss.Style
+	DialogStyle         lipgloss.Style
+	StatusBarStyle      lipgloss.Style
+	BoxStyle            lipgloss.Style
+	WelcomeBoxStyle     lipgloss.Style
+	ButtonStyle         lipgloss.Style
+	ButtonDimStyle      lipgloss.Style
+	CursorStyle         lipgloss.Style
+	WordCountStyle      lipgloss.Style
+	SpinnerStyle        lipgloss.Style
+)
+
+func init() {
+	buildStyles()
+}
 
+// buildStyles makes the styles from the colors above; ApplyTheme changes
+// those and builds them again
+func buildStyles() {
 	// Logo style
 	LogoStyle = lipgloss.NewStyle().
-			Foreground(purple).
-			Bold(true)
+		Foreground(purple).
+		Bold(true)
 
 	// App container
 	AppStyle = lipgloss.NewStyle().
-			Padding(1, 2)
+		Padding(1, 2)
 
 	// Title bar
 	TitleStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Background(purple).
-			Bold(true).
-			Padding(0, 2).
-			MarginBottom(1)
+		Foreground(white).
+		Background(purple).
+		Bold(true).
+		Padding(0, 2).
+		MarginBottom(1)
 
 	// Subtitle / description
 	SubtitleStyle = lipgloss.NewStyle().
-			Foreground(gray).
-			MarginBottom(1)
+		Foreground(gray).
+		MarginBottom(1)
 
 	// Menu item (not selected)
 	MenuItemStyle = lipgloss.NewStyle().
-			Foreground(gray).
-			PaddingLeft(2)
+		Foreground(gray).
+		PaddingLeft(2)
 
 	// Menu item (selected)
 	SelectedStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Background(purpleDim).
-			Bold(true).
-			PaddingLeft(1).
-			PaddingRight(1)
+		Foreground(white).
+		Background(purpleDim).
+		Bold(true).
+		PaddingLeft(1).
+		PaddingRight(1)
 
 	// List item style
 	ListItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#E5E7EB")).
-			PaddingLeft(2)
+		Foreground(lightGray).
+		PaddingLeft(2)
 
 	// Selected list item
 	SelectedListStyle = lipgloss.NewStyle().
-				Foreground(white).
-				Background(darkerGray).
-				PaddingLeft(1).
-				PaddingRight(1)
+		Foreground(white).
+		Background(darkerGray).
+		PaddingLeft(1).
+		PaddingRight(1)
 
 	// Input field
 	InputStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Background(darkest).
-			Padding(0, 1).
-			MarginTop(0).
-			MarginBottom(1)
+		Foreground(white).
+		Background(darkest).
+		Padding(0, 1).
+		MarginTop(0).
+		MarginBottom(1)
 
 	// Focused input
 	FocusedInputStyle = lipgloss.NewStyle().
-				Foreground(white).
-				Background(darkest).
-				BorderStyle(lipgloss.RoundedBorder()).
-				BorderForeground(purple).
-				Padding(0, 1)
+		Foreground(white).
+		Background(darkest).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(purple).
+		Padding(0, 1)
 
 	// Label for inputs
 	LabelStyle = lipgloss.NewStyle().
-			Foreground(gray).
-			MarginBottom(0)
+		Foreground(gray).
+		MarginBottom(0)
 
 	// Help text at bottom
 	HelpStyle = lipgloss.NewStyle().
-			Foreground(darkGray).
-			MarginTop(1)
+		Foreground(darkGray).
+		MarginTop(1)
 
 	// Success message
 	SuccessStyle = lipgloss.NewStyle().
-			Foreground(green)
+		Foreground(green)
 
 	// Error message
 	ErrorStyle = lipgloss.NewStyle().
-			Foreground(red)
+		Foreground(red)
 
 	// Warning
 	WarningStyle = lipgloss.NewStyle().
-			Foreground(yellow)
+		Foreground(yellow)
 
 	// Dim text
 	DimStyle = lipgloss.NewStyle().
-			Foreground(darkGray)
+		Foreground(darkGray)
+
+	// Search match within a snippet
+	MatchStyle = lipgloss.NewStyle().
+		Foreground(yellow).
+		Bold(true)
 
 	// Badge styles
 	BadgeStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Background(darkGray).
-			Padding(0, 1)
+		Foreground(white).
+		Background(darkGray).
+		Padding(0, 1)
 
 	PublishedBadgeStyle = lipgloss.NewStyle().
-				Foreground(white).
-				Background(green).
-				Padding(0, 1)
+		Foreground(white).
+		Background(green).
+		Padding(0, 1)
 
 	SyncedBadgeStyle = lipgloss.NewStyle().
-				Foreground(white).
-				Background(purple).
-				Padding(0, 1)
+		Foreground(white).
+		Background(purple).
+		Padding(0, 1)
 
 	// Preview box for content
 	PreviewStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#D1D5DB")).
-			Background(darkest).
-			Padding(1, 2).
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(darkerGray)
+		Foreground(lighterGray).
+		Background(darkest).
+		Padding(1, 2).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(darkerGray)
 
 	// Dialog box
 	DialogStyle = lipgloss.NewStyle().
-			Background(darkest).
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(purple).
-			Padding(1, 2).
-			Width(50)
+		Background(darkest).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(purple).
+		Padding(1, 2).
+		Width(50)
 
 	// Status bar
 	StatusBarStyle = lipgloss.NewStyle().
-			Foreground(gray).
-			Background(black).
-			Padding(0, 1)
+		Foreground(gray).
+		Background(black).
+		Padding(0, 1)
 
 	// Box for sections
 	BoxStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(darkerGray).
-			Padding(1, 2)
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(darkerGray).
+		Padding(1, 2)
 
 	// Welcome screen specific
 	WelcomeBoxStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(purple).
-			Padding(2, 4).
-			Width(60)
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(purple).
+		Padding(2, 4).
+		Width(60)
 
 	// Button style
 	ButtonStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Background(purple).
-			Padding(0, 2).
-			MarginRight(1)
+		Foreground(white).
+		Background(purple).
+		Padding(0, 2).
+		MarginRight(1)
 
 	ButtonDimStyle = lipgloss.NewStyle().
-			Foreground(gray).
-			Background(darkerGray).
-			Padding(0, 2).
-			MarginRight(1)
+		Foreground(gray).
+		Background(darkerGray).
+		Padding(0, 2).
+		MarginRight(1)
 
 	// Cursor
 	CursorStyle = lipgloss.NewStyle().
-			Foreground(purple).
-			Bold(true)
+		Foreground(purple).
+		Bold(true)
 
 	// Word count
 	WordCountStyle = lipgloss.NewStyle().
-			Foreground(darkGray)
+		Foreground(darkGray)
 
 	// Spinner
 	SpinnerStyle = lipgloss.NewStyle().
-			Foreground(purple)
-)
+		Foreground(purple)
+}
+
+// ApplyTheme recolors every style with theme. Its few colors stand in for
+// the default palette's many shades, which are blended from them.
+func ApplyTheme(theme config.Theme) {
+	purple = lipgloss.Color(theme.Accent)
+	purpleDim = mix(theme.Accent, theme.Background, 0.35)
+	green = lipgloss.Color(theme.Success)
+	red = lipgloss.Color(theme.Error)
+	yellow = lipgloss.Color(theme.Warning)
+	white = lipgloss.Color(theme.Foreground)
+	gray = mix(theme.Foreground, theme.Dim, 0.5)
+	darkGray = lipgloss.Color(theme.Dim)
+	darkerGray = mix(theme.Background, theme.Dim, 0.5)
+	darkest = mix(theme.Background, theme.Foreground, 0.08)
+	black = lipgloss.Color(theme.Background)
+	lightGray = lipgloss.Color(theme.Foreground)
+	lighterGray = lipgloss.Color(theme.Foreground)
+	buildStyles()
+}
+
+// mix blends the #rrggbb colors a and b, t of the way from a to b
+func mix(a, b string, t float64) lipgloss.Color {
+	var out [3]int64
+	for i := range out {
+		x, _ := strconv.ParseInt(a[1+2*i:3+2*i], 16, 0)
+		y, _ := strconv.ParseInt(b[1+2*i:3+2*i], 16, 0)
+		out[i] = x + int64(float64(y-x)*t)
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", out[0], out[1], out[2]))
+}
 
 // Centered places content in the center of the screen
 func Centered(width, height int, content string) string {
@@ -0,0 +1,276 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/justtype/cli/internal/api"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/store"
+)
+
+// pushServer lists no slates and records what's created and updated
+type pushServer struct {
+	mu      sync.Mutex
+	created []string // contents
+	updated []string // paths
+}
+
+func (p *pushServer) client(t *testing.T) *api.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		switch r.Method {
+		case "GET":
+			w.Write([]byte("[]"))
+		case "POST":
+			var body struct{ Content string }
+			json.NewDecoder(r.Body).Decode(&body)
+			p.created = append(p.created, body.Content)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(api.Slate{ID: 100 + len(p.created)})
+		case "PUT":
+			p.updated = append(p.updated, r.URL.Path)
+			w.Write([]byte("{}"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return api.New(srv.URL, "token")
+}
+
+// pulled adds a slate as a sync would, then loses its CloudID the way an
+// older version or a hand edit could
+func pulled(t *testing.T, st *store.Store, id string, cloudID int) {
+	t.Helper()
+	st.ImportFromCloud(&store.Slate{Slate: model.Slate{
+		ID:        id,
+		CloudID:   cloudID,
+		Content:   "pulled " + id,
+		UpdatedAt: time.Now(),
+	}})
+	slate := st.Get(id)
+	if slate == nil || slate.Origin != store.OriginCloud {
+		t.Fatalf("pulled slate %s: %+v", id, slate)
+	}
+	slate.CloudID = 0
+	slate.Synced = false
+}
+
+func TestSyncDoesNotRecreatePulledSlates(t *testing.T) {
+	var srv pushServer
+	m := localModel(t, t.TempDir())
+	m.client = srv.client(t)
+	m.syncWorkers = DefaultSyncWorkers
+
+	m.store.Create("", "written here")
+	m.store.Import("notes.txt", "from a file")
+	pulled(t, m.store, "cloud-7", 7)
+	pulled(t, m.store, "renamed", 8)
+
+	if msg := m.syncSlates()().(cloudSyncMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+
+	created := map[string]bool{}
+	for _, content := range srv.created {
+		created[content] = true
+	}
+	if len(srv.created) != 2 || !created["written here"] || !created["from a file"] {
+		t.Fatalf("created %q on the server, want only the two slates made on this device", srv.created)
+	}
+	if len(srv.updated) != 1 || srv.updated[0] != "/api/slates/7" {
+		t.Fatalf("updated %v, want the pulled slate matched back to cloud slate 7", srv.updated)
+	}
+	if got := m.store.Get("cloud-7").CloudID; got != 7 {
+		t.Fatalf("cloud-7 has CloudID %d after syncing, want 7", got)
+	}
+	if m.store.Get("renamed").CloudID != 0 {
+		t.Fatal("a pulled slate that can't be matched up was given a cloud copy")
+	}
+}
+
+func TestCloudIDFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		slate  model.Slate
+		origin string
+		want   int
+		ok     bool
+	}{
+		{"new here", model.Slate{ID: "abc"}, store.OriginLocal, 0, true},
+		{"imported file", model.Slate{ID: "abc"}, store.OriginImported, 0, true},
+		{"synced", model.Slate{ID: "abc", CloudID: 4}, store.OriginLocal, 4, true},
+		{"pulled, lost its id", model.Slate{ID: "cloud-9"}, store.OriginCloud, 9, true},
+		{"pulled, can't tell", model.Slate{ID: "abc"}, store.OriginCloud, 0, false},
+		{"saved before origins", model.Slate{ID: "cloud-3"}, "", 3, true},
+	}
+	for _, tt := range tests {
+		got, ok := cloudIDFor(&store.Slate{Slate: tt.slate, Origin: tt.origin})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: cloudIDFor = %d, %v; want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCloudAutosave(t *testing.T) {
+	for _, cloud := range []bool{true, false} {
+		t.Run(fmt.Sprintf("cloud_autosave %v", cloud), func(t *testing.T) {
+			var srv pushServer
+			m := localModel(t, t.TempDir())
+			m.mode = ModeAccount
+			m.client = srv.client(t)
+			m.config.CloudAutosave = cloud
+			m.view = ViewEditor
+			m.textarea.SetValue("Draft\n\nwritten on a train")
+
+			if _, cmd := m.doAutoSave(); cmd != nil {
+				update(m, cmd())
+			}
+			if m.currentSlate == nil || m.store.Get(m.currentSlate.ID) == nil {
+				t.Fatal("autosave didn't save locally")
+			}
+			if cloud {
+				if len(srv.created) != 1 || !m.currentSlate.Synced {
+					t.Fatalf("created %d on the server, synced %v; want the autosave pushed", len(srv.created), m.currentSlate.Synced)
+				}
+				return
+			}
+
+			if len(srv.created)+len(srv.updated) != 0 {
+				t.Fatalf("autosave reached the server: created %v, updated %v", srv.created, srv.updated)
+			}
+			if !strings.Contains(m.viewEditor(), "not synced") {
+				t.Fatal("footer doesn't show the slate is only saved here")
+			}
+
+			// ctrl+s still syncs
+			if cmd := update(m, tea.KeyMsg{Type: tea.KeyCtrlS}); cmd != nil {
+				update(m, cmd())
+			}
+			if len(srv.created) != 1 || !m.currentSlate.Synced {
+				t.Fatalf("created %d on ctrl+s, synced %v; want it pushed", len(srv.created), m.currentSlate.Synced)
+			}
+			if strings.Contains(m.viewEditor(), "not synced") {
+				t.Fatal("footer still says not synced after ctrl+s")
+			}
+		})
+	}
+}
+
+// pagedServer lists total slates a page at a time and counts the slates
+// downloaded one by one
+func pagedServer(t *testing.T, total int) (*api.Client, *atomic.Int32) {
+	t.Helper()
+	var downloads atomic.Int32
+	updated := time.Now().Add(-time.Hour).Format(model.TimeLayout)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/slates" {
+			downloads.Add(1)
+			id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/slates/"))
+			json.NewEncoder(w).Encode(api.Slate{ID: id, Title: "slate", Content: "content", UpdatedAt: updated})
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		slates := []api.Slate{}
+		for id := (page-1)*limit + 1; id <= min(page*limit, total); id++ {
+			slates = append(slates, api.Slate{ID: id, Title: fmt.Sprintf("slate %d", id), WordCount: 3, UpdatedAt: updated})
+		}
+		json.NewEncoder(w).Encode(slates)
+	}))
+	t.Cleanup(srv.Close)
+	return api.New(srv.URL, "token"), &downloads
+}
+
+func TestLoadMoreCloudSlates(t *testing.T) {
+	const total = 120
+	client, downloads := pagedServer(t, total)
+	m := localModel(t, t.TempDir())
+	m.resize(80, 24)
+	m.mode = ModeAccount
+	m.client = client
+	m.syncWorkers = DefaultSyncWorkers
+
+	update(m, m.pullCloudSlates(context.Background(), 1)())
+	for _, want := range []int{api.DefaultPageSize, 2 * api.DefaultPageSize, total} {
+		if n := m.store.Len(); n != want {
+			t.Fatalf("%d slates after page %d, want %d", n, m.cloudPage, want)
+		}
+		more := want < total
+		if m.cloudMore != more || strings.Contains(m.viewSlates(), "m to load more") != more {
+			t.Fatalf("after %d slates: cloudMore %v, want %v and the hint to match", want, m.cloudMore, more)
+		}
+		if cmd := update(m, key('m')); cmd != nil {
+			if !m.loading {
+				t.Fatal("no loading state while the next page comes")
+			}
+			update(m, cmd())
+		} else if more {
+			t.Fatal("m didn't load the next page")
+		}
+	}
+
+	// Listed, not downloaded: content comes when a slate is opened
+	if n := downloads.Load(); n != 0 {
+		t.Fatalf("downloaded %d slates to list them", n)
+	}
+	for _, slate := range m.store.All() {
+		if !slate.Unavailable || slate.Content != "" {
+			t.Fatalf("slate %s came with its content", slate.ID)
+		}
+	}
+}
+
+func TestRateLimitedPullRetries(t *testing.T) {
+	var lists atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lists.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode([]api.Slate{{ID: 3, Title: "Plans", UpdatedAt: time.Now().Format(model.TimeLayout)}})
+	}))
+	t.Cleanup(srv.Close)
+	client := api.New(srv.URL, "token")
+	client.MaxRetries = 0
+
+	m := localModel(t, t.TempDir())
+	m.mode = ModeAccount
+	m.client = client
+	m.syncWorkers = DefaultSyncWorkers
+
+	tick := update(m, m.pullCloudSlates(context.Background(), 1)())
+	if tick == nil || !m.loading || m.loadingMsg != "rate limited, retry in 1s" {
+		t.Fatalf("loading %v with %q, want the spinner saying when it retries", m.loading, m.loadingMsg)
+	}
+	if m.errorMsg != "" {
+		t.Fatalf("rate limiting shown as an error: %q", m.errorMsg)
+	}
+
+	start := time.Now()
+	pull := update(m, tick())
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Fatalf("retried after %v, want the second the server asked for", waited)
+	}
+	update(m, pull())
+	if m.loading || m.store.ByCloudID(3) == nil {
+		t.Fatalf("loading %v, pulled %v after the retry", m.loading, m.store.ByCloudID(3) != nil)
+	}
+	if lists.Load() != 2 {
+		t.Fatalf("listed %d times, want 2", lists.Load())
+	}
+}
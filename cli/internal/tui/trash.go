@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// openTrash shows the deleted slates, newest first
+func (m *Model) openTrash() {
+	m.trashed = m.store.ListTrash()
+	m.view = ViewTrash
+	m.selected = 0
+}
+
+func (m Model) viewTrash() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(" trash ") + "\n\n")
+
+	if len(m.trashed) == 0 {
+		b.WriteString(DimStyle.Render("nothing here") + "\n")
+	}
+
+	for i, t := range m.trashed {
+		cursor := "  "
+		style := ListItemStyle
+		if i == m.selected {
+			cursor = CursorStyle.Render("▸ ")
+			style = SelectedListStyle
+		}
+
+		title := t.Title
+		if title == "" {
+			title = "untitled"
+		}
+		title = runewidth.FillRight(runewidth.Truncate(title, 36, "..."), 36)
+
+		meta := DimStyle.Render("deleted " + formatTimeAgo(t.DeletedAt))
+		b.WriteString(cursor + style.Render(title) + "  " + meta + "\n")
+	}
+
+	if m.statusShown() {
+		b.WriteString("\n" + SuccessStyle.Render("✓ "+m.statusMsg) + "\n")
+	}
+
+	b.WriteString("\n" + HelpStyle.Render(fmt.Sprintf("enter restore • esc back • kept for %d days", int(trashDays))))
+
+	box := DialogStyle.Width(min(m.width-4, 70)).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateTrash(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j":
+		if m.selected < len(m.trashed)-1 {
+			m.selected++
+		}
+	case "enter", "r":
+		if m.selected < len(m.trashed) {
+			cmd := m.restoreSlate(m.trashed[m.selected].ID)
+			m.trashed = m.store.ListTrash()
+			if m.selected >= len(m.trashed) && m.selected > 0 {
+				m.selected--
+			}
+			return m, cmd
+		}
+	case "esc", "q":
+		m.view = ViewMenu
+		m.selected = 0
+	}
+	return m, nil
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/storage"
+)
+
+// confirmUnsaved asks what to do with edits that aren't saved before next
+// leaves the editor: save them first, leave them, or go back to writing.
+// next is given the model as it is then, since Update works on a copy.
+func (m *Model) confirmUnsaved(next func(*Model) (tea.Model, tea.Cmd)) (tea.Model, tea.Cmd) {
+	m.unsavedNext = next
+	m.view = ViewUnsaved
+	m.textarea.Blur()
+	return m, nil
+}
+
+func (m Model) viewUnsaved() string {
+	var b strings.Builder
+
+	b.WriteString(WarningStyle.Render("⚠ unsaved changes") + "\n\n")
+	b.WriteString("save before leaving?\n")
+	if !storage.ShouldSave(m.textarea.Value(), m.currentSlate == nil, m.config.MinWords) {
+		b.WriteString(DimStyle.Render("it's under the words new slates need; y saves it anyway") + "\n")
+	}
+	b.WriteString("\n" + HelpStyle.Render("y save • n don't save • esc keep writing"))
+
+	box := DialogStyle.Width(45).Render(b.String())
+	return Centered(m.width, m.height, box)
+}
+
+func (m *Model) updateUnsaved(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	next := m.unsavedNext
+	switch msg.String() {
+	case "y", "enter":
+		m.unsavedNext = nil
+		// Asked for, so not held back until it's long enough
+		m.saveSlate(0)
+		return next(m)
+	case "n":
+		m.unsavedNext = nil
+		return next(m)
+	case "esc":
+		m.unsavedNext = nil
+		return m.resumeEditor()
+	}
+	return m, nil
+}
+
+// flushEdits saves the editor's edits, if there are any, so work in
+// progress isn't left waiting on the autosave
+func (m *Model) flushEdits() {
+	if m.dirty {
+		m.saveCurrentSlate()
+	}
+}
+
+// leaveSlate saves the editor's edits before next replaces them with
+// another slate. Edits held back as too short to save yet are asked about
+// instead of dropped.
+func (m *Model) leaveSlate(next func(*Model) (tea.Model, tea.Cmd)) (tea.Model, tea.Cmd) {
+	m.flushEdits()
+	if m.dirty {
+		return m.confirmUnsaved(next)
+	}
+	return next(m)
+}
+
+// newSlate empties the editor for a new slate
+func (m *Model) newSlate() (tea.Model, tea.Cmd) {
+	m.currentSlate = nil
+	m.textarea.SetValue("")
+	m.dirty = false
+	m.view = ViewEditor
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/justtype/cli/internal/store"
+)
+
+// typeInto opens slate in the editor and types text at its start, leaving
+// the edit to the autosave
+func typeInto(t *testing.T, m *Model, slate *store.Slate, text string) {
+	t.Helper()
+	m.openSlate(slate)
+	m.textarea.CursorStart()
+	for _, r := range text {
+		update(m, key(r))
+	}
+	if !m.dirty {
+		t.Fatal("typing didn't leave the editor dirty")
+	}
+}
+
+// selectSlate lists the slates and selects the one with id
+func selectSlate(t *testing.T, m *Model, id string) {
+	t.Helper()
+	m.view = ViewSlates
+	m.slates = m.listSlates()
+	for i, s := range m.slates {
+		if s.ID == id {
+			m.selected = i
+			return
+		}
+	}
+	t.Fatalf("slate %s not listed", id)
+}
+
+func TestLeavingSlateSavesEdits(t *testing.T) {
+	tests := []struct {
+		name  string
+		leave func(t *testing.T, m *Model, other *store.Slate)
+		// opened is the slate the editor holds afterwards, "" for a new one
+		opened func(other *store.Slate) string
+		view   View
+	}{
+		{
+			name: "enter opens another slate",
+			leave: func(t *testing.T, m *Model, other *store.Slate) {
+				selectSlate(t, m, other.ID)
+				update(m, tea.KeyMsg{Type: tea.KeyEnter})
+			},
+			opened: func(other *store.Slate) string { return other.Content },
+			view:   ViewEditor,
+		},
+		{
+			name: "n starts a new slate",
+			leave: func(t *testing.T, m *Model, other *store.Slate) {
+				selectSlate(t, m, other.ID)
+				update(m, key('n'))
+			},
+			opened: func(*store.Slate) string { return "" },
+			view:   ViewEditor,
+		},
+		{
+			name: "menu new slate",
+			leave: func(t *testing.T, m *Model, other *store.Slate) {
+				m.view = ViewMenu
+				m.selected = 1
+				update(m, tea.KeyMsg{Type: tea.KeyEnter})
+			},
+			opened: func(*store.Slate) string { return "" },
+			view:   ViewEditor,
+		},
+		{
+			name: "menu my slates",
+			leave: func(t *testing.T, m *Model, other *store.Slate) {
+				m.view = ViewMenu
+				m.selected = 2
+				update(m, tea.KeyMsg{Type: tea.KeyEnter})
+			},
+			view: ViewSlates,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := localModel(t, t.TempDir())
+			first := m.store.Create("first", "first slate")
+			other := m.store.Create("other", "the other slate")
+
+			typeInto(t, m, first, "edited ")
+			tt.leave(t, m, other)
+
+			if got := m.store.Get(first.ID).Content; got != "edited first slate" {
+				t.Errorf("first slate holds %q after leaving it, want the edit saved", got)
+			}
+			if m.dirty {
+				t.Error("editor still dirty after saving")
+			}
+			if m.view != tt.view {
+				t.Errorf("view = %d, want %d", m.view, tt.view)
+			}
+			if tt.opened != nil {
+				if got, want := m.textarea.Value(), tt.opened(other); got != want {
+					t.Errorf("editor holds %q, want %q", got, want)
+				}
+			}
+			if m.store.Len() != 2 {
+				t.Errorf("%d slates stored, want the 2 there were", m.store.Len())
+			}
+		})
+	}
+}
+
+func TestLeavingShortNewSlateAsks(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.config.MinWords = 5
+	other := m.store.Create("other", "the other slate")
+
+	m.newSlate()
+	for _, r := range "two words" {
+		update(m, key(r))
+	}
+	selectSlate(t, m, other.ID)
+	update(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.view != ViewUnsaved {
+		t.Fatalf("view = %d, want the unsaved changes dialog for a slate under the minimum", m.view)
+	}
+	if m.store.Len() != 1 {
+		t.Fatal("short slate saved without asking")
+	}
+
+	// y saves it anyway, then opens the slate that was picked
+	update(m, key('y'))
+	if m.store.Len() != 2 {
+		t.Fatalf("%d slates stored after y, want the short one saved too", m.store.Len())
+	}
+	if m.view != ViewEditor || m.textarea.Value() != other.Content {
+		t.Fatalf("after y the editor holds %q in view %d, want the other slate", m.textarea.Value(), m.view)
+	}
+}
+
+func TestLeavingShortNewSlateEscKeepsWriting(t *testing.T) {
+	m := localModel(t, t.TempDir())
+	m.config.MinWords = 5
+	m.store.Create("other", "the other slate")
+
+	m.newSlate()
+	for _, r := range "draft" {
+		update(m, key(r))
+	}
+	m.view = ViewSlates
+	m.slates = m.listSlates()
+	update(m, key('n'))
+	update(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	if m.view != ViewEditor || m.textarea.Value() != "draft" {
+		t.Fatalf("esc left the editor holding %q in view %d, want the draft kept", m.textarea.Value(), m.view)
+	}
+}
@@ -0,0 +1,126 @@
+package updater
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// versionServer serves latest as version.txt and counts the requests
+func versionServer(t *testing.T, latest string) *atomic.Int32 {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(latest + "\n"))
+	}))
+	oldURL, oldChannel := BaseURL, channel
+	SetAPIURL(srv.URL)
+	SetLastCheck(time.Time{}, "")
+	t.Cleanup(func() {
+		srv.Close()
+		BaseURL, channel = oldURL, oldChannel
+		SetLastCheck(time.Time{}, "")
+	})
+	return &hits
+}
+
+func TestCheckForUpdateThrottles(t *testing.T) {
+	hits := versionServer(t, "99.0.0")
+
+	first, err := CheckForUpdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 || !first.Available || first.LatestVersion != "99.0.0" {
+		t.Fatalf("first check: %d requests, %+v", hits.Load(), first)
+	}
+	at, version := LastUpdateCheck()
+	if time.Since(at) > time.Minute || version != "99.0.0" {
+		t.Fatalf("LastUpdateCheck = %v, %q after checking", at, version)
+	}
+
+	// Within the interval the answer comes from the last check
+	again, err := CheckForUpdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("second check went to the server: %d requests", hits.Load())
+	}
+	if *again != *first {
+		t.Fatalf("cached check = %+v, want %+v", again, first)
+	}
+
+	// Checking by hand always asks
+	if _, err := CheckForUpdateNow(); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("CheckForUpdateNow made %d requests in all, want 2", hits.Load())
+	}
+}
+
+func TestCheckForUpdateSavedCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		at      time.Duration // before now
+		version string
+		network bool
+	}{
+		{"an hour ago", time.Hour, "99.0.0", false},
+		{"just inside the interval", CheckInterval - time.Minute, "99.0.0", false},
+		{"a day ago", CheckInterval + time.Minute, "99.0.0", true},
+		{"in the future", -time.Hour, "99.0.0", true},
+		{"never", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := versionServer(t, "1.0.0")
+			var at time.Time
+			if tt.version != "" {
+				at = time.Now().Add(-tt.at)
+			}
+			SetLastCheck(at, tt.version)
+
+			info, err := CheckForUpdate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hits.Load() > 0; got != tt.network {
+				t.Fatalf("went to the server: %v, want %v", got, tt.network)
+			}
+			want := tt.version
+			if tt.network {
+				want = "1.0.0"
+			}
+			if info.LatestVersion != want || info.Available != Newer(want, CurrentVersion) {
+				t.Fatalf("check = %+v, want latest %s", info, want)
+			}
+		})
+	}
+}
+
+func TestChangingChannelForgetsCheck(t *testing.T) {
+	hits := versionServer(t, "99.0.0")
+	SetChannel(ChannelStable)
+	SetLastCheck(time.Now(), "1.0.0")
+
+	SetChannel(ChannelStable)
+	if _, version := LastUpdateCheck(); version != "1.0.0" {
+		t.Fatal("setting the same channel forgot the last check")
+	}
+
+	SetChannel(ChannelBeta)
+	if at, version := LastUpdateCheck(); !at.IsZero() || version != "" {
+		t.Fatalf("last check still %v, %q on the beta channel", at, version)
+	}
+	if _, err := CheckForUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("beta channel's first check made %d requests, want 1", hits.Load())
+	}
+}
@@ -0,0 +1,142 @@
+package updater
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/justtype/cli/internal/config"
+)
+
+// UserAgent identifies the CLI to the server
+func UserAgent() string {
+	return "justtype-cli/" + GetVersion()
+}
+
+// SetHeaders stamps req with the CLI's User-Agent and X-CLI-Version
+func SetHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", UserAgent())
+	req.Header.Set("X-CLI-Version", GetVersion())
+}
+
+// NewClient returns an HTTP client that sends the version headers on every
+// request, so the server can tell which CLI is calling and signal updates.
+// It goes through the proxy in $HTTPS_PROXY or $HTTP_PROXY, if set, and
+// trusts what ConfigureTransport adds.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &versionTransport{},
+	}
+}
+
+// versionTransport sends through the transport ConfigureTransport last set,
+// so clients made before it (like httpClient) pick it up too
+type versionTransport struct{}
+
+func (t *versionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	SetHeaders(req)
+	return currentTransport().RoundTrip(req)
+}
+
+// transport is set by ConfigureTransport; nil means http.DefaultTransport
+var transport atomic.Pointer[http.Transport]
+
+func currentTransport() http.RoundTripper {
+	if t := transport.Load(); t != nil {
+		return t
+	}
+	return http.DefaultTransport
+}
+
+// ConfigureTransport sets up every client from NewClient for the network
+// it's on. caFile is a PEM file of root certificates trusted on top of the
+// system's, for proxies that present their own; noProxy lists hosts reached
+// directly, in $NO_PROXY's format, on top of $NO_PROXY. Empty values leave
+// the defaults. Call it at startup, after loading the config.
+func ConfigureTransport(caFile, noProxy string) error {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxyFunc(noProxy)
+
+	if caFile != "" {
+		roots, err := loadRoots(caFile)
+		if err != nil {
+			return err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	if old := transport.Swap(t); old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// loadRoots returns the system's root certificates with the ones in the PEM
+// file at path added
+func loadRoots(path string) (*x509.CertPool, error) {
+	path, err := config.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ca_cert_file: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("ca_cert_file: no PEM certificates in %s", path)
+	}
+	return roots, nil
+}
+
+// proxyFunc is http.ProxyFromEnvironment, except that hosts matching
+// noProxy are reached directly
+func proxyFunc(noProxy string) func(*http.Request) (*url.URL, error) {
+	var patterns []string
+	for _, p := range strings.Split(noProxy, ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), patterns) {
+			return nil, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+}
+
+// bypassProxy reports whether host matches one of the $NO_PROXY style
+// patterns: "*" for every host, an exact host or IP, or a domain, with or
+// without a leading dot, which covers its subdomains too. A port on a
+// pattern is ignored.
+func bypassProxy(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	for _, p := range patterns {
+		if p == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(p); err == nil {
+			p = h
+		}
+		domain := strings.TrimPrefix(p, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
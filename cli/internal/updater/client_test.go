@@ -0,0 +1,190 @@
+package updater
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClientSendsVersion(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("User-Agent", "stale/2.0")
+	resp, err := NewClient(time.Second).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if ua := got.Get("User-Agent"); ua != "justtype-cli/"+GetVersion() {
+		t.Errorf("User-Agent = %q, want justtype-cli/%s", ua, GetVersion())
+	}
+	if v := got.Get("X-CLI-Version"); v != GetVersion() {
+		t.Errorf("X-CLI-Version = %q, want %s", v, GetVersion())
+	}
+	if req.Header.Get("User-Agent") != "stale/2.0" || req.Header.Get("X-CLI-Version") != "" {
+		t.Error("the caller's request was changed")
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	patterns := []string{"corp.internal", ".example.org", "10.0.0.5", "build.local:8080"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"corp.internal", true},
+		{"git.corp.internal", true},
+		{"CORP.INTERNAL", true},
+		{"notcorp.internal", false},
+		{"example.org", true},
+		{"www.example.org", true},
+		{"10.0.0.5", true},
+		{"10.0.0.50", false},
+		{"build.local", true},
+		{"api.justtype.io", false},
+	}
+	for _, tt := range tests {
+		if got := bypassProxy(tt.host, patterns); got != tt.want {
+			t.Errorf("bypassProxy(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+	if !bypassProxy("anything.at.all", []string{"*"}) {
+		t.Error("* didn't cover every host")
+	}
+	if bypassProxy("api.justtype.io", nil) {
+		t.Error("no patterns bypassed the proxy")
+	}
+}
+
+// useTransport restores the default transport once the test is done
+func useTransport(t *testing.T) {
+	t.Cleanup(func() {
+		if old := transport.Swap(nil); old != nil {
+			old.CloseIdleConnections()
+		}
+	})
+}
+
+// TestTransportUsesHTTPSProxy runs in a child process with $HTTPS_PROXY
+// pointing at a fake proxy, since net/http reads the proxy variables once
+// per process
+func TestTransportUsesHTTPSProxy(t *testing.T) {
+	if os.Getenv("JUSTTYPE_TEST_PROXY") == "" {
+		connects := make(chan string, 10)
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connects <- r.Method + " " + r.Host
+			http.Error(w, "not today", http.StatusForbidden)
+		}))
+		defer proxy.Close()
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestTransportUsesHTTPSProxy$", "-test.v")
+		cmd.Env = append(os.Environ(), "JUSTTYPE_TEST_PROXY=1", "HTTPS_PROXY="+proxy.URL, "https_proxy=", "NO_PROXY=", "no_proxy=")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("child: %v\n%s", err, out)
+		}
+		close(connects)
+		var seen []string
+		for c := range connects {
+			seen = append(seen, c)
+		}
+		if len(seen) != 1 || seen[0] != "CONNECT api.justtype.example:443" {
+			t.Fatalf("proxy saw %q, want one CONNECT to api.justtype.example:443", seen)
+		}
+		return
+	}
+
+	useTransport(t)
+	if err := ConfigureTransport("", "skip.example, .inside.example"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Through the proxy, which turns the tunnel down
+	if _, err := NewClient(5 * time.Second).Get("https://api.justtype.example/cli/version.txt"); err == nil {
+		t.Fatal("request succeeded though the proxy refused it")
+	}
+
+	// no_proxy hosts go direct
+	proxyFor := transport.Load().Proxy
+	for host, direct := range map[string]bool{
+		"api.justtype.example": false,
+		"skip.example":         true,
+		"git.inside.example":   true,
+	} {
+		req, _ := http.NewRequest("GET", "https://"+host+"/", nil)
+		u, err := proxyFor(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (u == nil) != direct {
+			t.Errorf("%s: proxy %v, want direct %v", host, u, direct)
+		}
+	}
+}
+
+func TestConfigureTransportTrustsCA(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("User-Agent")))
+	}))
+	// The untrusted attempt fails its handshake on purpose
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	useTransport(t)
+
+	// Not trusted before it's configured
+	if err := ConfigureTransport("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(5 * time.Second).Get(srv.URL); err == nil {
+		t.Fatal("a self-signed server was trusted without its CA")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "corp-ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureTransport(caFile, ""); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewClient(5 * time.Second).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("with the CA configured: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != UserAgent() {
+		t.Errorf("server saw User-Agent %q, want %q", body, UserAgent())
+	}
+}
+
+func TestConfigureTransportBadCA(t *testing.T) {
+	useTransport(t)
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.txt")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0600)
+
+	for _, path := range []string{notPEM, filepath.Join(dir, "missing.pem")} {
+		if err := ConfigureTransport(path, ""); err == nil || !strings.HasPrefix(err.Error(), "ca_cert_file: ") {
+			t.Errorf("ConfigureTransport(%s) = %v, want a ca_cert_file error", filepath.Base(path), err)
+		}
+	}
+	if transport.Load() != nil {
+		t.Error("a bad CA file replaced the transport")
+	}
+}
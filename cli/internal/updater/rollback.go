@@ -0,0 +1,87 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/justtype/cli/internal/config"
+)
+
+// ErrNoBackup means there's no earlier version to roll back to. Rollback
+// wraps it with how to reinstall from BaseURL.
+var ErrNoBackup = errors.New("no backup of the previous version to roll back to")
+
+// backupPath is where Update keeps the binary it replaced at target:
+// .justtype.bak next to the executable, or justtype.bak in ~/.local/bin
+// when the update went there
+func backupPath(target string) string {
+	dir := filepath.Dir(target)
+	if home, err := config.HomeDir(); err == nil && dir == filepath.Join(home, ".local", "bin") {
+		return filepath.Join(dir, "justtype.bak")
+	}
+	return filepath.Join(dir, ".justtype.bak")
+}
+
+// backup copies the binary about to be replaced to its backupPath,
+// overwriting the last backup. A fresh install has nothing to back up.
+func backup(target string) error {
+	if _, err := os.Stat(target); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	// Copy to a temp name first, so a failed copy doesn't cost the
+	// previous backup
+	path := backupPath(target)
+	tmp := path + ".tmp"
+	if err := copyFile(target, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("couldn't back up the current version: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("couldn't back up the current version: %w", err)
+	}
+	return nil
+}
+
+// Rollback puts back the binary the last update replaced. It looks next to
+// the running executable first, then in ~/.local/bin, and returns the path
+// it restored.
+func Rollback() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("couldn't find executable: %w", err)
+	}
+	execPath, err = filepath.EvalSymlinks(execPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't resolve executable path: %w", err)
+	}
+
+	targets := []string{execPath}
+	if home, err := config.HomeDir(); err == nil {
+		targets = append(targets, filepath.Join(home, ".local", "bin", "justtype"))
+	}
+
+	for _, target := range targets {
+		path := backupPath(target)
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("couldn't read backup %s: %w", path, err)
+		}
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 || info.Size() == 0 {
+			return "", fmt.Errorf("backup %s isn't an executable, not restoring it", path)
+		}
+
+		if err := os.Rename(path, target); err != nil {
+			return "", fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		return target, nil
+	}
+	return "", fmt.Errorf("%w; reinstall with %s", ErrNoBackup, InstallCommand())
+}
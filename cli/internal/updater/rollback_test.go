@@ -0,0 +1,24 @@
+package updater
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/justtype/cli/internal/config"
+)
+
+func TestRollbackWithoutBackupNamesInstaller(t *testing.T) {
+	t.Setenv(config.HomeEnv, t.TempDir())
+	defer SetAPIURL(config.DefaultAPIURL)
+	SetAPIURL("https://slates.example.org/")
+
+	_, err := Rollback()
+	if !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("got %v, want ErrNoBackup", err)
+	}
+	want := "curl -fsSL https://slates.example.org/cli/install.sh | bash"
+	if !strings.HasSuffix(err.Error(), want) || strings.Contains(err.Error(), "justtype.io") {
+		t.Fatalf("message %q, want it to end with %q", err, want)
+	}
+}
@@ -3,21 +3,58 @@ package updater
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/justtype/cli/internal/config"
 )
 
+const CurrentVersion = "2.3.4"
+
+// BaseURL is where releases are downloaded from. It follows the API server
+// (see SetAPIURL) so a self-hosted instance can serve its own binaries.
+var BaseURL = config.DefaultAPIURL + "/cli"
+
+// SetAPIURL points the updater at the releases served by apiURL. Call it at
+// startup, before checking for updates.
+func SetAPIURL(apiURL string) {
+	BaseURL = strings.TrimRight(apiURL, "/") + "/cli"
+}
+
+// InstallCommand is the shell command that installs the latest release
+// from BaseURL
+func InstallCommand() string {
+	return "curl -fsSL " + BaseURL + "/install.sh | bash"
+}
+
+// Set at build time with -ldflags "-X ...updater.Commit=... -X ...updater.BuildDate=..."
+var (
+	Commit    string
+	BuildDate string
+)
+
+// httpClient has no timeout: downloads can be slow on bad connections
+var httpClient = NewClient(0)
+
+// Update preferences
 const (
-	BaseURL        = "https://justtype.io/cli"
-	CurrentVersion = "2.3.4"
+	ModeAuto   = "auto"   // install updates as soon as they're found
+	ModeNotify = "notify" // ask before installing
+	ModeNever  = "never"  // don't check at all
 )
 
+// SnoozeDuration is how long "later" hides the update prompt
+const SnoozeDuration = 7 * 24 * time.Hour
+
 type UpdateInfo struct {
 	Available      bool
 	CurrentVersion string
@@ -25,14 +62,49 @@ type UpdateInfo struct {
 	DownloadURL    string
 }
 
-// CheckForUpdate checks if a newer version is available
+// CheckInterval is how long the result of a check is trusted before
+// CheckForUpdate asks the server again
+const CheckInterval = 24 * time.Hour
+
+// The last check's time and the version it found, shared by every caller so
+// a restart or a second check doesn't go back to the server. See SetLastCheck.
+var (
+	checkMu     sync.Mutex
+	lastCheck   time.Time
+	lastVersion string
+)
+
+// CheckForUpdate checks if a newer version is available. Within
+// CheckInterval of the last check it answers from that check's result
+// instead of the network; CheckForUpdateNow always asks.
 func CheckForUpdate() (*UpdateInfo, error) {
+	checkMu.Lock()
+	at, latest := lastCheck, lastVersion
+	checkMu.Unlock()
+
+	if latest != "" && time.Since(at) >= 0 && time.Since(at) < CheckInterval {
+		_, archiveURL := releaseURLs()
+		return &UpdateInfo{
+			Available:      Newer(latest, CurrentVersion),
+			CurrentVersion: CurrentVersion,
+			LatestVersion:  latest,
+			DownloadURL:    archiveURL,
+		}, nil
+	}
+	return CheckForUpdateNow()
+}
+
+// CheckForUpdateNow asks the server for the latest version, whenever the
+// last check was, and remembers the answer
+func CheckForUpdateNow() (*UpdateInfo, error) {
 	info := &UpdateInfo{
 		CurrentVersion: CurrentVersion,
 	}
 
+	versionURL, archiveURL := releaseURLs()
+
 	// Fetch latest version
-	resp, err := http.Get(BaseURL + "/version.txt")
+	resp, err := httpClient.Get(versionURL)
 	if err != nil {
 		return nil, err
 	}
@@ -48,15 +120,21 @@ func CheckForUpdate() (*UpdateInfo, error) {
 	}
 
 	info.LatestVersion = strings.TrimSpace(string(body))
-	info.Available = info.LatestVersion != CurrentVersion
-	info.DownloadURL = fmt.Sprintf("%s/justtype_%s_%s.tar.gz", BaseURL, runtime.GOOS, runtime.GOARCH)
+	info.Available = Newer(info.LatestVersion, CurrentVersion)
+	info.DownloadURL = archiveURL
+	SetLastCheck(time.Now(), info.LatestVersion)
 
 	return info, nil
 }
 
+// archiveName is the release archive for this platform
+func archiveName() string {
+	return fmt.Sprintf("justtype_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+}
+
 // Update downloads and installs the latest version
 func Update() error {
-	info, err := CheckForUpdate()
+	info, err := CheckForUpdateNow()
 	if err != nil {
 		return err
 	}
@@ -86,71 +164,71 @@ func Update() error {
 	// If we can't write to install dir, use ~/.local/bin instead
 	targetPath := execPath
 	if !canWriteToInstallDir {
-		homeDir, err := os.UserHomeDir()
+		homeDir, err := config.HomeDir()
 		if err != nil {
-			return fmt.Errorf("couldn't find home directory: %w", err)
+			return err
 		}
 		localBin := filepath.Join(homeDir, ".local", "bin")
 		os.MkdirAll(localBin, 0755)
 		targetPath = filepath.Join(localBin, "justtype")
 	}
 
-	// Download new version
-	resp, err := http.Get(info.DownloadURL)
-	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	if err := install(info.DownloadURL, targetPath); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed: %s", resp.Status)
+	// If we installed to a different location, return a message
+	if targetPath != execPath {
+		return fmt.Errorf("installed to %s (add to PATH if needed)", targetPath)
 	}
 
-	// Extract from tar.gz
-	gzr, err := gzip.NewReader(resp.Body)
+	return nil
+}
+
+// install downloads the archive at downloadURL and puts the binary in it at
+// targetPath, backing up what was there. A download that doesn't match its
+// checksum, or signature, leaves targetPath untouched.
+func install(downloadURL, targetPath string) error {
+	// Fetch the checksum first, so a missing one stops the update before
+	// the download
+	wantSum, err := fetchChecksum(downloadURL)
 	if err != nil {
-		return fmt.Errorf("failed to decompress: %w", err)
+		return err
 	}
-	defer gzr.Close()
-
-	tr := tar.NewReader(gzr)
 
-	var newBinary []byte
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to read archive: %w", err)
-		}
-
-		if header.Name == "justtype" {
-			newBinary, err = io.ReadAll(tr)
-			if err != nil {
-				return fmt.Errorf("failed to read binary: %w", err)
-			}
-			break
-		}
+	// Download new version
+	resp, err := httpClient.Get(downloadURL)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	if newBinary == nil {
-		return fmt.Errorf("binary not found in archive")
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("download failed: %s", resp.Status)
 	}
 
-	// Write to temp file
+	// Stream the binary out of the archive into a temp file
 	tmpFile, err := os.CreateTemp("", "justtype-update-*")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 	tmpPath := tmpFile.Name()
 
-	if _, err := tmpFile.Write(newBinary); err != nil {
-		tmpFile.Close()
+	archiveHash := sha256.New()
+	binarySum, err := extractBinary(io.TeeReader(resp.Body, archiveHash), tmpFile)
+	if closeErr := tmpFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write binary: %w", closeErr)
+	}
+	if err == nil {
+		// Drain any padding gzip left unread so the archive sum is whole
+		io.Copy(archiveHash, resp.Body)
+		err = verifyChecksum(wantSum, hex.EncodeToString(archiveHash.Sum(nil)), binarySum)
+	}
+	if err != nil {
+		// The installed binary hasn't been touched
 		os.Remove(tmpPath)
-		return fmt.Errorf("failed to write binary: %w", err)
+		return err
 	}
-	tmpFile.Close()
 
 	// Make executable
 	if err := os.Chmod(tmpPath, 0755); err != nil {
@@ -158,6 +236,12 @@ func Update() error {
 		return fmt.Errorf("failed to set permissions: %w", err)
 	}
 
+	// Keep the current version for Rollback
+	if err := backup(targetPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
 	// Try to replace the binary
 	err = os.Rename(tmpPath, targetPath)
 	if err != nil {
@@ -168,13 +252,38 @@ func Update() error {
 			return fmt.Errorf("failed to install update: %w", err)
 		}
 	}
+	return nil
+}
 
-	// If we installed to a different location, return a message
-	if targetPath != execPath {
-		return fmt.Errorf("installed to %s (add to PATH if needed)", targetPath)
+// extractBinary copies the justtype binary out of a .tar.gz stream into dst
+// and returns its SHA-256 as hex. Nothing is held in memory beyond io.Copy's
+// buffer, however large the binary is.
+func extractBinary(archive io.Reader, dst io.Writer) (string, error) {
+	gzr, err := gzip.NewReader(archive)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %w", err)
 	}
+	defer gzr.Close()
 
-	return nil
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("binary not found in archive")
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Name != "justtype" {
+			continue
+		}
+
+		hash := sha256.New()
+		if _, err := io.Copy(dst, io.TeeReader(tr, hash)); err != nil {
+			return "", fmt.Errorf("failed to write binary: %w", err)
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
 }
 
 // copyFile copies src to dst, overwriting dst if it exists
@@ -195,13 +304,85 @@ func copyFile(src, dst string) error {
 	return err
 }
 
+// NormalizeMode returns a known update mode, defaulting to auto
+func NormalizeMode(mode string) string {
+	switch mode {
+	case ModeNotify, ModeNever:
+		return mode
+	}
+	return ModeAuto
+}
+
+// NextMode cycles through the update modes, for settings toggles
+func NextMode(mode string) string {
+	switch NormalizeMode(mode) {
+	case ModeAuto:
+		return ModeNotify
+	case ModeNotify:
+		return ModeNever
+	}
+	return ModeAuto
+}
+
+// ShouldCheck reports whether the update check should run at all
+func ShouldCheck(mode string) bool {
+	return NormalizeMode(mode) != ModeNever
+}
+
+// ShouldPrompt reports whether an available update should be shown now
+func ShouldPrompt(mode string, snoozedUntil, now time.Time) bool {
+	if !ShouldCheck(mode) {
+		return false
+	}
+	return !now.Before(snoozedUntil)
+}
+
 // GetVersion returns the current version
 func GetVersion() string {
 	return CurrentVersion
 }
 
-// LastUpdateCheck returns when we last checked for updates
-func LastUpdateCheck() time.Time {
-	// Could store this in config, for now just return zero
-	return time.Time{}
+// BuildInfo describes the commit and date the binary was built from. It
+// falls back to what the Go toolchain records, for builds made without
+// build.sh.
+func BuildInfo() string {
+	commit, date := Commit, BuildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && commit == "":
+				commit = s.Value
+			case s.Key == "vcs.time" && date == "":
+				date = s.Value
+			}
+		}
+	}
+
+	if commit == "" {
+		return "unknown"
+	}
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if date != "" {
+		return commit + " (" + date + ")"
+	}
+	return commit
+}
+
+// LastUpdateCheck returns when updates were last checked for and the
+// version found then, for the caller to save in its config. The time is zero
+// if there hasn't been a check.
+func LastUpdateCheck() (time.Time, string) {
+	checkMu.Lock()
+	defer checkMu.Unlock()
+	return lastCheck, lastVersion
+}
+
+// SetLastCheck restores a check saved by an earlier run. Call it at startup,
+// after SetChannel.
+func SetLastCheck(at time.Time, version string) {
+	checkMu.Lock()
+	defer checkMu.Unlock()
+	lastCheck, lastVersion = at, strings.TrimSpace(version)
 }
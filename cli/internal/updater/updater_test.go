@@ -0,0 +1,155 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModes(t *testing.T) {
+	for _, tt := range []struct{ mode, normal, next string }{
+		{"", ModeAuto, ModeNotify},
+		{"bogus", ModeAuto, ModeNotify},
+		{ModeAuto, ModeAuto, ModeNotify},
+		{ModeNotify, ModeNotify, ModeNever},
+		{ModeNever, ModeNever, ModeAuto},
+	} {
+		if got := NormalizeMode(tt.mode); got != tt.normal {
+			t.Errorf("NormalizeMode(%q) = %q, want %q", tt.mode, got, tt.normal)
+		}
+		if got := NextMode(tt.mode); got != tt.next {
+			t.Errorf("NextMode(%q) = %q, want %q", tt.mode, got, tt.next)
+		}
+	}
+}
+
+func TestShouldPrompt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snoozed := now.Add(SnoozeDuration)
+	tests := []struct {
+		name   string
+		mode   string
+		until  time.Time
+		now    time.Time
+		check  bool
+		prompt bool
+	}{
+		{"auto, never snoozed", ModeAuto, time.Time{}, now, true, true},
+		{"notify, never snoozed", ModeNotify, time.Time{}, now, true, true},
+		{"just snoozed", ModeNotify, snoozed, now, true, false},
+		{"a day before the snooze ends", ModeAuto, snoozed, snoozed.Add(-24 * time.Hour), true, false},
+		{"snooze over", ModeNotify, snoozed, snoozed, true, true},
+		{"never", ModeNever, time.Time{}, now, false, false},
+		{"never, snooze over", ModeNever, snoozed, snoozed.Add(time.Hour), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldCheck(tt.mode); got != tt.check {
+				t.Errorf("ShouldCheck = %v, want %v", got, tt.check)
+			}
+			if got := ShouldPrompt(tt.mode, tt.until, tt.now); got != tt.prompt {
+				t.Errorf("ShouldPrompt = %v, want %v", got, tt.prompt)
+			}
+		})
+	}
+}
+
+func TestBuildInfoFromLdflags(t *testing.T) {
+	oldCommit, oldDate := Commit, BuildDate
+	t.Cleanup(func() { Commit, BuildDate = oldCommit, oldDate })
+
+	Commit, BuildDate = "0123456789abcdef0123", "2026-10-01T12:00:00Z"
+	if got := BuildInfo(); got != "0123456789ab (2026-10-01T12:00:00Z)" {
+		t.Errorf("BuildInfo = %q", got)
+	}
+	Commit, BuildDate = "abc1234", ""
+	if got := BuildInfo(); got != "abc1234" {
+		t.Errorf("without a date: BuildInfo = %q", got)
+	}
+}
+
+// chunkWriter writes to a file and remembers the largest single write
+type chunkWriter struct {
+	f       *os.File
+	largest int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.largest = max(w.largest, len(p))
+	return w.f.Write(p)
+}
+
+func TestExtractBinaryStreams(t *testing.T) {
+	binary := make([]byte, 8<<20)
+	rand.Read(binary)
+
+	// Packed on the fly, after another file, so the archive is never whole
+	// in memory either
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		tw := tar.NewWriter(gz)
+		tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 5})
+		tw.Write([]byte("hello"))
+		tw.WriteHeader(&tar.Header{Name: "justtype", Mode: 0755, Size: int64(len(binary))})
+		tw.Write(binary)
+		tw.Close()
+		gz.Close()
+		pw.Close()
+	}()
+
+	tmp, err := os.CreateTemp(t.TempDir(), "justtype-update-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := &chunkWriter{f: tmp}
+	sum, err := extractBinary(pr, dst)
+	tmp.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := sha256.Sum256(binary)
+	if sum != hex.EncodeToString(want[:]) {
+		t.Fatalf("checksum %s, want %x", sum, want)
+	}
+	written, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, binary) {
+		t.Fatalf("temp file is %d bytes and differs from the %d byte binary", len(written), len(binary))
+	}
+	if dst.largest >= len(binary)/8 {
+		t.Fatalf("wrote %d bytes at once; the binary wasn't streamed", dst.largest)
+	}
+}
+
+func TestExtractBinaryMissing(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 5})
+	tw.Write([]byte("hello"))
+	tw.Close()
+	gz.Close()
+
+	var out bytes.Buffer
+	if _, err := extractBinary(&buf, &out); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("got %v, want binary not found", err)
+	}
+	if _, err := extractBinary(strings.NewReader("not gzip"), &out); err == nil {
+		t.Fatal("want an error for an archive that isn't gzip")
+	}
+	if out.Len() != 0 {
+		t.Fatalf("wrote %d bytes without finding the binary", out.Len())
+	}
+}
@@ -0,0 +1,228 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// release is what a test server publishes: the archive, its .sha256 and
+// its .sig, each of which a test may tamper with
+type release struct {
+	archive  []byte
+	checksum []byte
+	sig      []byte
+}
+
+func serveRelease(t *testing.T, r *release) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/justtype.tar.gz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(r.archive)
+	})
+	mux.HandleFunc("/justtype.tar.gz.sha256", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(r.checksum)
+	})
+	mux.HandleFunc("/justtype.tar.gz.sig", func(w http.ResponseWriter, _ *http.Request) {
+		if r.sig == nil {
+			http.NotFound(w, nil)
+			return
+		}
+		w.Write(r.sig)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL + "/justtype.tar.gz"
+}
+
+// tarball packs binary into a release archive the way the build does
+func tarball(t *testing.T, binary string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "justtype", Mode: 0755, Size: int64(len(binary))}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write([]byte(binary))
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func sha256Line(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return []byte(hex.EncodeToString(sum[:]) + "  justtype.tar.gz\n")
+}
+
+// installedBinary is a stand-in for the running executable
+func installedBinary(t *testing.T) string {
+	t.Helper()
+	target := filepath.Join(t.TempDir(), "justtype")
+	if err := os.WriteFile(target, []byte("old version"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func assertUntouched(t *testing.T, target string) {
+	t.Helper()
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old version" {
+		t.Fatalf("installed binary was replaced with %q", data)
+	}
+	if _, err := os.Stat(backupPath(target)); err == nil {
+		t.Fatal("a refused update still made a backup")
+	}
+}
+
+func TestInstallVerified(t *testing.T) {
+	archive := tarball(t, "new version")
+	url := serveRelease(t, &release{archive: archive, checksum: sha256Line(archive)})
+	target := installedBinary(t)
+
+	if err := install(url, target); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new version" {
+		t.Fatalf("installed %q, want the new version", data)
+	}
+	if data, _ := os.ReadFile(backupPath(target)); string(data) != "old version" {
+		t.Fatalf("backup holds %q, want the old version", data)
+	}
+}
+
+func TestInstallRefusesTamperedArchive(t *testing.T) {
+	genuine := tarball(t, "new version")
+	tampered := tarball(t, "new version, with a backdoor")
+	url := serveRelease(t, &release{archive: tampered, checksum: sha256Line(genuine)})
+	target := installedBinary(t)
+
+	err := install(url, target)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want ErrChecksumMismatch", err)
+	}
+	assertUntouched(t, target)
+}
+
+func TestInstallRefusesBadSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPrivate, _ := ed25519.GenerateKey(nil)
+
+	old := SigningKey
+	SigningKey = base64.StdEncoding.EncodeToString(public)
+	t.Cleanup(func() { SigningKey = old })
+
+	// A tampered archive with a checksum to match, signed by someone else
+	archive := tarball(t, "new version, with a backdoor")
+	checksum := sha256Line(archive)
+	forged := base64.StdEncoding.EncodeToString(ed25519.Sign(otherPrivate, checksum))
+	url := serveRelease(t, &release{archive: archive, checksum: checksum, sig: []byte(forged)})
+	target := installedBinary(t)
+
+	if err := install(url, target); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("got %v, want ErrBadSignature", err)
+	}
+	assertUntouched(t, target)
+
+	// Properly signed, the same release goes in
+	signed := base64.StdEncoding.EncodeToString(ed25519.Sign(private, checksum))
+	url = serveRelease(t, &release{archive: archive, checksum: checksum, sig: []byte(signed)})
+	if err := install(url, target); err != nil {
+		t.Fatalf("signed release refused: %v", err)
+	}
+}
+
+func TestInstallNeedsSignatureWhenKeySet(t *testing.T) {
+	public, _, _ := ed25519.GenerateKey(nil)
+	old := SigningKey
+	SigningKey = base64.StdEncoding.EncodeToString(public)
+	t.Cleanup(func() { SigningKey = old })
+
+	archive := tarball(t, "new version")
+	url := serveRelease(t, &release{archive: archive, checksum: sha256Line(archive)})
+	target := installedBinary(t)
+
+	if err := install(url, target); err == nil {
+		t.Fatal("installed without a signature")
+	}
+	assertUntouched(t, target)
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"bare digest", sum, sum, false},
+		{"sha256sum output", sum + "  justtype_linux_amd64.tar.gz\n", sum, false},
+		{"upper case", strings.ToUpper(sum) + "\n", sum, false},
+		{"empty", "  \n", "", true},
+		{"not hex", strings.Repeat("zz", 32), "", true},
+		{"too short", "abcd", "", true},
+		{"sha1 length", strings.Repeat("ab", 20), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	if err := verifyChecksum("aa", "aa", "bb"); err != nil {
+		t.Fatalf("archive sum rejected: %v", err)
+	}
+	if err := verifyChecksum("bb", "aa", "bb"); err != nil {
+		t.Fatalf("binary sum rejected: %v", err)
+	}
+	if err := verifyChecksum("cc", "aa", "bb"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	key := base64.StdEncoding.EncodeToString(public)
+	checksum := []byte(strings.Repeat("ab", 32) + "\n")
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, checksum)) + "\n")
+
+	if err := verifySignature(key, checksum, sig); err != nil {
+		t.Fatalf("good signature rejected: %v", err)
+	}
+	if err := verifySignature(key, []byte(strings.Repeat("cd", 32)+"\n"), sig); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("signature of other content: got %v, want ErrBadSignature", err)
+	}
+	if err := verifySignature(key, checksum, []byte("not base64!")); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("garbage signature: got %v, want ErrBadSignature", err)
+	}
+	if err := verifySignature("short", checksum, sig); err == nil || errors.Is(err, ErrBadSignature) {
+		t.Fatalf("invalid key: got %v, want a key error", err)
+	}
+}
@@ -0,0 +1,151 @@
+package updater
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Update channels
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta" // pre-releases, published as version-beta.txt and under beta/
+)
+
+// channel is the one CheckForUpdate follows; see SetChannel
+var channel = ChannelStable
+
+// NormalizeChannel returns a known update channel, defaulting to stable
+func NormalizeChannel(ch string) string {
+	if ch == ChannelBeta {
+		return ch
+	}
+	return ChannelStable
+}
+
+// NextChannel switches between the channels, for settings toggles
+func NextChannel(ch string) string {
+	if NormalizeChannel(ch) == ChannelStable {
+		return ChannelBeta
+	}
+	return ChannelStable
+}
+
+// SetChannel picks the channel updates come from. Call it at startup and
+// whenever the setting changes. Changing it forgets the last check, which
+// was of the other channel.
+func SetChannel(ch string) {
+	ch = NormalizeChannel(ch)
+	if ch != channel {
+		SetLastCheck(time.Time{}, "")
+	}
+	channel = ch
+}
+
+// releaseURLs returns where the channel's latest version number and this
+// platform's archive are published
+func releaseURLs() (versionURL, archiveURL string) {
+	if channel == ChannelBeta {
+		return BaseURL + "/version-beta.txt", BaseURL + "/beta/" + archiveName()
+	}
+	return BaseURL + "/version.txt", BaseURL + "/" + archiveName()
+}
+
+// Newer reports whether version latest comes after current, by semantic
+// versioning rules: a leading "v" is ignored, major.minor.patch compare as
+// numbers, and a pre-release (2.4.0-beta.1) comes before its release. Build
+// metadata after "+" doesn't count. Anything that doesn't parse is never
+// newer, so a bad version.txt can't offer a downgrade.
+func Newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	return compareVersions(l, c) > 0
+}
+
+type version struct {
+	core [3]int
+	pre  []string // dot-separated pre-release identifiers, nil for a release
+}
+
+func parseVersion(s string) (version, bool) {
+	var v version
+
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if pre == "" {
+			return v, false
+		}
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if id == "" {
+				return v, false
+			}
+		}
+	}
+
+	fields := strings.Split(s, ".")
+	if len(fields) != 3 {
+		return v, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || strings.HasPrefix(f, "+") {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, the same as or
+// newer than b
+func compareVersions(a, b version) int {
+	for i := range a.core {
+		if a.core[i] != b.core[i] {
+			return cmp.Compare(a.core[i], b.core[i])
+		}
+	}
+
+	// A release outranks any of its pre-releases
+	switch {
+	case a.pre == nil && b.pre == nil:
+		return 0
+	case a.pre == nil:
+		return 1
+	case b.pre == nil:
+		return -1
+	}
+
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		if c := comparePre(a.pre[i], b.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a.pre), len(b.pre))
+}
+
+// comparePre orders pre-release identifiers: numbers numerically and below
+// words, words alphabetically
+func comparePre(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
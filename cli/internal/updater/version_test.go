@@ -0,0 +1,108 @@
+package updater
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"2.3.10", "2.3.3", true},  // numbers, not strings
+		{"2.3.3", "2.3.10", false}, // so never a downgrade
+		{"2.4.0", "2.3.9", true},
+		{"3.0.0", "2.99.99", true},
+		{"2.3.4", "2.3.4", false},
+		{"v2.3.4", "2.3.4", false}, // the same version, written differently
+		{"V2.3.5", "v2.3.4", true},
+		{" 2.3.5\n", "2.3.4", true},
+		{"2.3.4+build.7", "2.3.4", false}, // build metadata doesn't count
+		{"2.4.0-beta.1", "2.3.9", true},
+		{"2.4.0-beta.1", "2.4.0", false}, // a pre-release is before its release
+		{"2.4.0", "2.4.0-beta.2", true},
+		{"2.4.0-beta.10", "2.4.0-beta.9", true},
+		{"2.4.0-rc.1", "2.4.0-beta.9", true},
+		{"2.4.0-beta", "2.4.0-beta.1", false},
+		{"", "2.3.4", false},
+		{"2.4", "2.3.4", false},
+		{"2.4.0.1", "2.3.4", false},
+		{"latest", "2.3.4", false},
+		{"2.x.0", "2.3.4", false},
+		{"-1.0.0", "2.3.4", false},
+		{"2.4.0-", "2.3.4", false},
+		{"2.4.0-beta..1", "2.3.4", false},
+		{"<html>404</html>", "2.3.4", false},
+		{"2.4.0", "not a version", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsOrder(t *testing.T) {
+	// The precedence example from the semver spec, oldest first
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := parseVersion(ordered[i])
+			b, _ := parseVersion(ordered[j])
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := compareVersions(a, b); got != want {
+				t.Errorf("compareVersions(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCheckOffersOnlyNewer(t *testing.T) {
+	var latest string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(latest + "\n"))
+	}))
+	defer srv.Close()
+	oldURL := BaseURL
+	SetAPIURL(srv.URL)
+	t.Cleanup(func() {
+		BaseURL = oldURL
+		SetLastCheck(time.Time{}, "")
+	})
+
+	for _, tt := range []struct {
+		latest    string
+		available bool
+	}{
+		{"1.0.0", false},
+		{"v" + CurrentVersion, false},
+		{CurrentVersion + "-beta.1", false},
+		{"garbage", false},
+		{"99.0.0", true},
+		{"v99.1.0", true},
+	} {
+		latest = tt.latest
+		info, err := CheckForUpdateNow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Available != tt.available {
+			t.Errorf("server says %q: Available = %v, want %v", tt.latest, info.Available, tt.available)
+		}
+		// The cached answer agrees
+		if cached, _ := CheckForUpdate(); cached.Available != tt.available {
+			t.Errorf("server said %q: cached Available = %v", tt.latest, cached.Available)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package versions
+
+import "strings"
+
+// Line is one line of a diff
+type Line struct {
+	Op   byte // ' ' unchanged, '-' only in the old text, '+' only in the new
+	Text string
+}
+
+// maxDiffCells bounds the diff table; past it, the texts are shown as one
+// removed block and one added block instead
+const maxDiffCells = 4_000_000
+
+// Diff compares old and new line by line
+func Diff(old, new string) []Line {
+	a := strings.Split(old, "\n")
+	b := strings.Split(new, "\n")
+
+	// Common lines at either end don't need the table
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	end := 0
+	for end < len(a)-start && end < len(b)-start && a[len(a)-1-end] == b[len(b)-1-end] {
+		end++
+	}
+
+	var lines []Line
+	for _, l := range a[:start] {
+		lines = append(lines, Line{' ', l})
+	}
+	lines = append(lines, diffMiddle(a[start:len(a)-end], b[start:len(b)-end])...)
+	for _, l := range a[len(a)-end:] {
+		lines = append(lines, Line{' ', l})
+	}
+	return lines
+}
+
+// diffMiddle is a longest-common-subsequence diff
+func diffMiddle(a, b []string) []Line {
+	var lines []Line
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			lines = append(lines, Line{'-', l})
+		}
+		for _, l := range b {
+			lines = append(lines, Line{'+', l})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, Line{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{'+', b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,263 @@
+// Package versions keeps a short history of each slate's content, so an
+// earlier draft can be recovered after autosave has overwritten it
+package versions
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/justtype/cli/internal/e2e"
+)
+
+const (
+	// MinChange is how many characters must differ from the last snapshot
+	// before another is taken, so autosaves of a few keystrokes don't each
+	// become a version
+	MinChange = 20
+
+	// Keep is how many versions are kept per slate; older ones are dropped
+	Keep = 50
+)
+
+// ErrNotFound is returned for a version that isn't in the log
+var ErrNotFound = errors.New("version not found")
+
+// Version is one saved snapshot of a slate
+type Version struct {
+	Time    time.Time
+	Content string
+}
+
+// entry is a version as stored: one JSON line with gzipped content
+type entry struct {
+	Time time.Time `json:"time"`
+	Gz   []byte    `json:"gz"`
+}
+
+// Log stores versions as <dir>/<slate id>.jsonl
+type Log struct {
+	dir string
+	key *e2e.Key // encrypts snapshots when local slates are encrypted
+}
+
+// New returns a log kept in dir, which is created on the first write
+func New(dir string) *Log {
+	return &Log{dir: dir}
+}
+
+// SetKey sets the key snapshots are read and written with, nil for none
+func (l *Log) SetKey(key *e2e.Key) {
+	l.key = key
+}
+
+// Rekey encodes every slate's history with key and hands each file to
+// stage, to be moved into place with the rest of a change of key; see
+// atrest.Rekey. The log keeps its key until SetKey.
+func (l *Log) Rekey(key *e2e.Key, stage func(path string, data []byte) error) error {
+	files, err := filepath.Glob(filepath.Join(l.dir, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		existing, err := l.read(id)
+		if err != nil {
+			return err
+		}
+		data, err := encode(existing, key)
+		if err != nil {
+			return err
+		}
+		if err := stage(l.path(id), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Log) path(id string) string {
+	return filepath.Join(l.dir, filepath.Base(id)+".jsonl")
+}
+
+// Record snapshots content if it differs enough from the latest version
+func (l *Log) Record(id, content string, at time.Time) error {
+	existing, err := l.read(id)
+	if err != nil {
+		return err
+	}
+	if n := len(existing); n > 0 && Changed(existing[n-1].Content, content) <= MinChange {
+		return nil
+	}
+
+	existing = append(existing, Version{Time: at, Content: content})
+	if len(existing) > Keep {
+		// Rewrite without the oldest
+		return l.write(id, existing[len(existing)-Keep:], os.O_TRUNC)
+	}
+	return l.write(id, existing[len(existing)-1:], os.O_APPEND)
+}
+
+// List returns a slate's versions, newest first
+func (l *Log) List(id string) ([]Version, error) {
+	versions, err := l.read(id)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
+
+// Get returns the version saved at t
+func (l *Log) Get(id string, t time.Time) (*Version, error) {
+	versions, err := l.read(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Time.Equal(t) {
+			return &v, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Remove drops a slate's history
+func (l *Log) Remove(id string) error {
+	err := os.Remove(l.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *Log) read(id string) ([]Version, error) {
+	f, err := os.Open(l.path(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var versions []Version
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A line cut short by a crash; the rest are still good
+			continue
+		}
+		gz := e.Gz
+		if e2e.IsEncrypted(string(gz)) {
+			// Written while encrypted; unreadable without the key
+			plain, err := e2e.Open(l.key, string(gz))
+			if err != nil {
+				continue
+			}
+			gz = []byte(plain)
+		}
+		content, err := gunzip(gz)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{Time: e.Time, Content: content})
+	}
+	return versions, scanner.Err()
+}
+
+func (l *Log) write(id string, versions []Version, mode int) error {
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := encode(versions, l.key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path(id), os.O_WRONLY|os.O_CREATE|mode, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encode writes versions as lines of the log, encrypted with key if it's set
+func encode(versions []Version, key *e2e.Key) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, v := range versions {
+		gz, err := gzipString(v.Content)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			sealed, err := key.Encrypt(string(gz))
+			if err != nil {
+				return nil, err
+			}
+			gz = []byte(sealed)
+		}
+		line, err := json.Marshal(entry{Time: v.Time, Gz: gz})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, s); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzip(data []byte) (string, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	return string(out), err
+}
+
+// Changed is roughly how many characters differ between a and b: the
+// length of the stretch between their common start and common end
+func Changed(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ra)-prefix && suffix < len(rb)-prefix &&
+		ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+	return max(len(ra), len(rb)) - prefix - suffix
+}
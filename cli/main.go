@@ -3,12 +3,56 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/justtype/cli/internal/app"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/updater"
 )
 
 func main() {
-	app := app.New()
+	// --profile picks the account before anything reads the config
+	args, err := profileFlag(os.Args[1:])
+	if err != nil {
+		fail("profile", err)
+	}
+
+	// Undoes the last update; not listed in the help
+	if len(args) > 0 && args[0] == "--rollback" {
+		// So the reinstall hint points at the configured server
+		if cfg, err := config.Load(); err == nil {
+			updater.SetAPIURL(cfg.APIURL)
+		}
+		path, err := updater.Rollback()
+		if err != nil {
+			fail("rollback", err)
+		}
+		fmt.Printf("restored the previous version to %s\n", path)
+		return
+	}
+
+	// Headless subcommands run without the TUI
+	if len(args) > 0 {
+		if run, ok := commands[args[0]]; ok {
+			if err := run(args[1:]); err != nil {
+				fail(args[0], err)
+			}
+			return
+		}
+	}
+
+	app, err := app.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Anything else is a file to edit in place
+	if len(args) > 0 {
+		if err := app.OpenFile(args[0]); err != nil {
+			fail("edit", err)
+		}
+	}
 	defer app.Close()
 
 	if err := app.Run(); err != nil {
@@ -16,3 +60,42 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// commands maps subcommand names to their handlers
+var commands = map[string]func(args []string) error{
+	"serve":  runServe,
+	"import": runImport,
+	"dedupe": runDedupe,
+	"status": runStatus,
+	"new":    runNew,
+	"list":   runList,
+	"get":    runGet,
+	"export": runExport,
+}
+
+// profileFlag takes --profile <name> (or --profile=<name>) off the front of
+// args and switches to that profile, returning the rest. Without it the
+// default profile, config.json, is used.
+func profileFlag(args []string) ([]string, error) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "--profile") {
+		return args, nil
+	}
+
+	name, rest := "", args[1:]
+	switch {
+	case args[0] == "--profile":
+		if len(rest) == 0 {
+			return nil, usageErrorf("--profile needs a name")
+		}
+		name, rest = rest[0], rest[1:]
+	case strings.HasPrefix(args[0], "--profile="):
+		name = strings.TrimPrefix(args[0], "--profile=")
+	default:
+		return args, nil
+	}
+
+	if err := config.UseProfile(name); err != nil {
+		return nil, usageError{fmt.Errorf("%q: %w", name, err)}
+	}
+	return rest, nil
+}
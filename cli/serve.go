@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/justtype/cli/internal/atrest"
+	"github.com/justtype/cli/internal/server"
+)
+
+// runServe exposes the local store over a read-only localhost HTTP API
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", server.DefaultAddr, "address to listen on (loopback only)")
+	token := fs.String("token", os.Getenv("JUSTTYPE_SERVE_TOKEN"), "require this bearer token on every request")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// Read once for the life of the server. Slates saved after it starts
+	// show up once it's restarted.
+	s, err := openReader()
+	if errors.Is(err, atrest.ErrLocked) {
+		// There's no one to ask for the passphrase
+		return fmt.Errorf("slates are encrypted at rest: %w", atrest.ErrLocked)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "serving slates on http://%s (read-only)\n", *addr)
+	return server.ListenAndServe(*addr, server.NewHandler(s, *token))
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/justtype/cli/internal/app"
+	"github.com/justtype/cli/internal/model"
+	"github.com/justtype/cli/internal/storage"
+	"github.com/justtype/cli/internal/store"
+)
+
+// openStorage opens the configured slates for a subcommand. What the UI
+// would have warned about, such as a damaged slates.json moved aside, goes
+// to stderr: there's no UI to show it in.
+func openStorage() (storage.Storage, error) {
+	s, warnings, err := app.OpenStorage()
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "warning: "+warning)
+	}
+	return s, nil
+}
+
+// openReader opens the configured slates for a subcommand that only reads
+// them, leaving them on disk as they are
+func openReader() (storage.Reader, error) {
+	return app.OpenReader()
+}
+
+// runNew creates a slate from stdin and prints its ID, e.g.
+// echo "notes" | justtype new --title "Standup"
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	title := fs.String("title", "", "first line of the slate, above what's read from stdin")
+	publish := fs.Bool("publish", false, "publish the slate and print its share URL (needs an account)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("new reads the slate from stdin and takes no arguments")
+	}
+
+	body, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	content := strings.TrimRight(string(body), "\n")
+	if *title != "" {
+		// Titles come from the first line
+		content = strings.TrimRight(*title+"\n\n"+content, "\n")
+	}
+	if strings.TrimSpace(content) == "" {
+		return usageErrorf("nothing to save: stdin was empty and there's no --title")
+	}
+
+	s, err := openStorage()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	cloud, isCloud := s.(*storage.CloudStorage)
+	if *publish && !isCloud {
+		return usageErrorf("--publish needs an account; run justtype and log in first")
+	}
+
+	slate := &storage.Slate{Slate: model.Slate{Content: content}}
+	if err := s.Save(slate); err != nil {
+		return err
+	}
+	fmt.Println(slate.ID)
+	if !*publish {
+		return nil
+	}
+
+	if cloud.Offline() {
+		// Queued; it has no cloud ID to publish yet
+		return fmt.Errorf("saved, but couldn't publish: %w", cloud.OfflineError())
+	}
+	url, err := cloud.Publish(slate)
+	if err != nil {
+		return fmt.Errorf("saved, but couldn't publish: %w", err)
+	}
+	fmt.Println(url)
+	return nil
+}
+
+// runList prints every slate's ID, title, word count and last update
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := openReader()
+	if err != nil {
+		return err
+	}
+
+	slates, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, slate := range slates {
+		fmt.Fprintf(w, "%s\t%s\t%d words\t%s\n", slate.ID, slate.Title, slate.WordCount,
+			slate.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// runGet prints one slate's content
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("usage: justtype get <id>")
+	}
+
+	s, err := openReader()
+	if err != nil {
+		return err
+	}
+
+	slate, err := s.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Print(slate.Content)
+	if !strings.HasSuffix(slate.Content, "\n") {
+		fmt.Println()
+	}
+	return nil
+}
+
+// runExport writes every slate to a directory as a .txt file. Files that
+// are already there are kept; the new one gets a numbered name.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "directory to write the files to")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("usage: justtype export [--dir <dir>]")
+	}
+
+	s, err := openReader()
+	if err != nil {
+		return err
+	}
+
+	slates, err := s.List()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, listed := range slates {
+		// Cloud listings leave the content out
+		slate, err := s.Load(listed.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed %s: %v\n", listed.ID, err)
+			failed++
+			continue
+		}
+
+		path, err := store.WriteNew(filepath.Join(*dir, store.SanitizeFilename(slate.Title)+".txt"), slate.Content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed %s: %v\n", listed.ID, err)
+			failed++
+			continue
+		}
+		fmt.Println(path)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d failed to export", failed)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureStdout runs fn with os.Stdout going to a pipe, and returns what it
+// printed
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	err = fn()
+	w.Close()
+	return <-out, err
+}
+
+// unchanged fails t if the files under home aren't exactly files
+func unchanged(t *testing.T, home string, files map[string]string) {
+	t.Helper()
+	if after := listing(t, home); len(after) != len(files) {
+		t.Fatalf("files afterwards: %v, want only the fixture's", after)
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(home, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s changed or moved: %v", name, err)
+		}
+	}
+}
+
+func TestReadCommandsLeaveFilesAlone(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		files  map[string]string
+		want   string // in list's output
+	}{
+		{
+			"damaged slates.json with a staged copy",
+			`{"storage_path": "$HOME/notes"}`,
+			map[string]string{
+				"notes/slates.json":     `[{"id": "a", "con`,
+				"notes/slates.json.tmp": `[{"id": "a", "title": "Whole", "content": "whole"}]`,
+			},
+			"a  Whole",
+		},
+		{
+			"sqlite backend not migrated yet",
+			`{"storage_path": "$HOME/notes", "storage_backend": "sqlite"}`,
+			map[string]string{"notes/slates.json": `[{"id": "a", "title": "Still JSON", "content": "x"}]`},
+			"a  Still JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := writeHome(t, tt.config, tt.files)
+
+			out, err := captureStdout(t, func() error { return runList(nil) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("list printed %q, want %q", out, tt.want)
+			}
+			if _, err := captureStdout(t, func() error { return runGet([]string{"a"}) }); err != nil {
+				t.Errorf("get: %v", err)
+			}
+			unchanged(t, home, tt.files)
+		})
+	}
+}
+
+func TestReadCommandsLeaveDamagedSlatesToEditor(t *testing.T) {
+	files := map[string]string{"notes/slates.json": `[{"id": "a", "con`}
+	home := writeHome(t, `{"storage_path": "$HOME/notes"}`, files)
+
+	for name, run := range map[string]func() error{
+		"list": func() error { return runList(nil) },
+		"get":  func() error { return runGet([]string{"a"}) },
+		"export": func() error {
+			return runExport([]string{"--dir", filepath.Join(home, "notes")})
+		},
+	} {
+		_, err := captureStdout(t, run)
+		if err == nil || !strings.Contains(err.Error(), "run justtype to recover it") {
+			t.Errorf("%s: %v, want it to leave recovery to the editor", name, err)
+		}
+	}
+	unchanged(t, home, files)
+}
+
+func TestReadCommandsInCloudModeDontWrite(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodGet && r.URL.Path == "/api/slates" {
+			w.Write([]byte(`[{"id": 7, "title": "Remote", "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-02T00:00:00Z"}]`))
+			return
+		}
+		http.Error(w, "not in this test", http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	files := map[string]string{
+		// An offline edit waiting to be pushed, and one queued
+		"cache/writer/slates.json": `[{"id": "cloud-7", "cloud_id": 7, "title": "Remote", "content": "edited offline", "synced": false}]`,
+		"temp/queue.jsonl":         `{"op": "create", "id": "local-1", "content": "queued", "queued_at": "2026-01-03T00:00:00Z"}` + "\n",
+	}
+	home := writeHome(t, `{"token": "tok", "username": "writer", "api_url": "`+srv.URL+`"}`, files)
+
+	out, err := captureStdout(t, func() error { return runList(nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "cloud-7  Remote") {
+		t.Errorf("list printed %q", out)
+	}
+
+	// The offline edit is newer than the server's copy
+	out, err = captureStdout(t, func() error { return runGet([]string{"cloud-7"}) })
+	if err != nil || out != "edited offline\n" {
+		t.Errorf("get printed %q, %v; want the offline edit", out, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, req := range requests {
+		if !strings.HasPrefix(req, "GET ") {
+			t.Errorf("read command sent %s", req)
+		}
+	}
+	unchanged(t, home, files)
+}
+
+func TestReadCommandsOfflineUseCache(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	files := map[string]string{
+		"cache/writer/slates.json": `[{"id": "cloud-7", "cloud_id": 7, "title": "Cached", "content": "cached copy", "synced": true},` +
+			`{"id": "cloud-8", "cloud_id": 8, "title": "Listed", "synced": true, "content_unavailable": true}]`,
+	}
+	home := writeHome(t, `{"token": "tok", "username": "writer", "api_url": "`+down.URL+`"}`, files)
+
+	out, err := captureStdout(t, func() error { return runList(nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "cloud-7  Cached") || !strings.Contains(out, "cloud-8  Listed") {
+		t.Errorf("offline list printed %q, want the cached slates", out)
+	}
+	out, err = captureStdout(t, func() error { return runGet([]string{"cloud-7"}) })
+	if err != nil || out != "cached copy\n" {
+		t.Errorf("offline get printed %q, %v", out, err)
+	}
+	if _, err := captureStdout(t, func() error { return runGet([]string{"cloud-8"}) }); err == nil {
+		t.Error("offline get of a slate only listed succeeded")
+	}
+	unchanged(t, home, files)
+}
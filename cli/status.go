@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/justtype/cli/internal/app"
+)
+
+// runStatus prints the storage mode, where slates live and sync state
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	st, err := app.ReadStatus()
+	if err != nil {
+		return err
+	}
+	return printStatus(os.Stdout, st)
+}
+
+// printStatus writes st as aligned "field: value" lines
+func printStatus(out io.Writer, st *app.Status) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "mode:\t%s\n", st.Mode)
+	if st.Mode == "cloud" {
+		fmt.Fprintf(w, "account:\t%s\n", st.Username)
+	} else if st.Path != "" {
+		fmt.Fprintf(w, "storage:\t%s\n", st.Path)
+	} else {
+		fmt.Fprintf(w, "storage:\tnot set up, run justtype to choose\n")
+	}
+	fmt.Fprintf(w, "api:\t%s\n", st.APIURL)
+	fmt.Fprintf(w, "version:\t%s\n", st.Version)
+	if st.Locked {
+		fmt.Fprintf(w, "slates:\tlocked (encrypted at rest)\n")
+	} else {
+		fmt.Fprintf(w, "slates:\t%d\n", st.Slates)
+	}
+	if st.Mode == "cloud" {
+		fmt.Fprintf(w, "unsynced:\t%d\n", st.Unsynced)
+		lastSync := "never"
+		if !st.LastSync.IsZero() {
+			lastSync = st.LastSync.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "last sync:\t%s\n", lastSync)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justtype/cli/internal/app"
+	"github.com/justtype/cli/internal/config"
+	"github.com/justtype/cli/internal/e2e"
+)
+
+// statusFixture sets up a justtype home with configJSON and files, paths
+// relative to the home, and returns the home and the status output
+func statusFixture(t *testing.T, configJSON string, files map[string]string) (string, string) {
+	t.Helper()
+	home := writeHome(t, configJSON, files)
+
+	st, err := app.ReadStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := printStatus(&out, st); err != nil {
+		t.Fatal(err)
+	}
+	return home, out.String()
+}
+
+// writeHome sets up a justtype home with configJSON, where $HOME stands for
+// the home, and files, paths relative to it, and returns the home. files
+// gets config.json added.
+func writeHome(t *testing.T, configJSON string, files map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv(config.HomeEnv, home)
+	t.Setenv(config.APIURLEnv, "")
+	configJSON = strings.ReplaceAll(configJSON, "$HOME", filepath.ToSlash(home))
+	files["config.json"] = configJSON
+	for name, content := range files {
+		path := filepath.Join(home, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+// fields parses status output into field -> value
+func fields(out string) map[string]string {
+	got := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		name, value, _ := strings.Cut(line, ":")
+		got[name] = strings.TrimSpace(value)
+	}
+	return got
+}
+
+// listing is every file under dir, relative to it
+func listing(t *testing.T, dir string) []string {
+	t.Helper()
+	var names []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return names
+}
+
+func TestStatusLocal(t *testing.T) {
+	home, out := statusFixture(t, `{"storage_path": "$HOME/notes"}`, map[string]string{
+		"notes/slates.json": `[{"id": "a", "content": "one"}, {"id": "b", "content": "two"}]`,
+	})
+	got := fields(out)
+	want := map[string]string{
+		"mode":    "local",
+		"storage": filepath.Join(home, "notes"),
+		"api":     config.DefaultAPIURL,
+		"slates":  "2",
+	}
+	for field, value := range want {
+		if got[field] != value {
+			t.Errorf("%s: %q, want %q", field, got[field], value)
+		}
+	}
+	if got["version"] == "" {
+		t.Error("no version")
+	}
+	if _, ok := got["unsynced"]; ok {
+		t.Error("unsynced shown in local mode")
+	}
+}
+
+func TestStatusCloud(t *testing.T) {
+	_, out := statusFixture(t, `{"token": "tok", "username": "writer", "api_url": "https://notes.example.com"}`, map[string]string{
+		"cache/writer/slates.json": `[{"id": "cloud-1", "synced": true}, {"id": "cloud-2", "synced": false}]`,
+		"cache/writer/last_sync":   "2026-01-02T03:04:05Z",
+		"temp/queue.jsonl":         `{"op": "delete", "id": "cloud-3", "cloud_id": 3}` + "\n",
+	})
+	got := fields(out)
+	want := map[string]string{
+		"mode":     "cloud",
+		"account":  "writer",
+		"api":      "https://notes.example.com",
+		"slates":   "2",
+		"unsynced": "2",
+	}
+	for field, value := range want {
+		if got[field] != value {
+			t.Errorf("%s: %q, want %q", field, got[field], value)
+		}
+	}
+	if got["last sync"] == "never" {
+		t.Error("last sync not read from the cache")
+	}
+}
+
+func TestStatusIsReadOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		files  map[string]string
+		slates string
+	}{
+		{
+			"damaged slates.json with a staged copy",
+			`{"storage_path": "$HOME/notes"}`,
+			map[string]string{
+				"notes/slates.json":     `[{"id": "a", "con`,
+				"notes/slates.json.tmp": `[{"id": "a", "content": "whole"}]`,
+			},
+			"1",
+		},
+		{
+			"sqlite backend not migrated yet",
+			`{"storage_path": "$HOME/notes", "storage_backend": "sqlite"}`,
+			map[string]string{"notes/slates.json": `[{"id": "a"}, {"id": "b"}, {"id": "c"}]`},
+			"3",
+		},
+		{
+			"encrypted at rest",
+			`{"storage_path": "$HOME/notes"}`,
+			map[string]string{"notes/slates.json": e2e.Prefix + "not really ciphertext"},
+			"locked (encrypted at rest)",
+		},
+		{
+			"cloud with a draft left behind",
+			`{"token": "tok", "username": "writer"}`,
+			map[string]string{"temp/current.json": `{"id": "cloud-1", "content": "draft"}`},
+			"0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, out := statusFixture(t, tt.config, tt.files)
+			before := len(tt.files) // config.json included
+			if got := fields(out)["slates"]; got != tt.slates {
+				t.Errorf("slates: %q, want %q", got, tt.slates)
+			}
+			after := listing(t, dir)
+			if len(after) != before {
+				t.Fatalf("files after status: %v, want only the fixture's", after)
+			}
+			for name, content := range tt.files {
+				if name == "config.json" {
+					continue
+				}
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil || string(data) != content {
+					t.Errorf("%s changed or moved: %v", name, err)
+				}
+			}
+		})
+	}
+}
@@ -60,11 +60,16 @@ GOOS=darwin GOARCH=arm64 go build -o dist/justtype .
 tar -czf dist/justtype_darwin_arm64.tar.gz -C dist justtype
 rm dist/justtype
 
+# Checksums; the updater refuses archives without a matching one
+for archive in dist/*.tar.gz; do
+  (cd dist && shasum -a 256 "$(basename "$archive")" > "$(basename "$archive").sha256")
+done
+
 cd ..
 
 # Copy to public/cli
 echo -e "${YELLOW}Copying binaries to public/cli...${NC}"
-cp cli/dist/*.tar.gz public/cli/
+cp cli/dist/*.tar.gz cli/dist/*.tar.gz.sha256 public/cli/
 
 # Update version.txt
 echo "${NEW_VERSION}" > public/cli/version.txt
@@ -513,6 +513,16 @@ try {
     console.log('✓ Database migrated: Added export_cooldown_until column');
   }
 
+  // Add refresh token columns to sessions (CLI sessions renew without the password)
+  const sessionColumns = db.pragma('table_info(sessions)');
+  const hasRefreshTokenHash = sessionColumns.some(col => col.name === 'refresh_token_hash');
+  if (!hasRefreshTokenHash) {
+    db.exec(`ALTER TABLE sessions ADD COLUMN refresh_token_hash TEXT;`);
+    db.exec(`ALTER TABLE sessions ADD COLUMN refresh_wrapped_key TEXT;`);
+    db.exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON sessions(refresh_token_hash);`);
+    console.log('✓ Database migrated: Added refresh_token_hash/refresh_wrapped_key columns to sessions');
+  }
+
   // Create incidents tables for status page
   db.exec(`
     CREATE TABLE IF NOT EXISTS incidents (
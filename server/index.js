@@ -894,6 +894,25 @@ const createSession = (userId, token, req) => {
   }
 };
 
+// Refresh tokens let the CLI renew an expired session without asking for the
+// password again. Only a hash is stored. For accounts whose encryption key
+// lives on the server, the key is also kept wrapped with the refresh token,
+// so a refresh can put it back in the cache after a restart; the stored
+// hash alone can't unwrap it.
+const hashToken = (token) => crypto.createHash('sha256').update(token).digest('hex');
+
+const refreshWrappingKey = (refreshToken) =>
+  crypto.createHash('sha256').update(`justtype-refresh:${refreshToken}`).digest();
+
+// Attach a new refresh token to the session for token, returning it
+const issueRefreshToken = (token, encryptionKey) => {
+  const refreshToken = crypto.randomBytes(32).toString('base64url');
+  const wrappedKey = encryptionKey ? wrapKey(encryptionKey, refreshWrappingKey(refreshToken)) : null;
+  db.prepare('UPDATE sessions SET refresh_token_hash = ?, refresh_wrapped_key = ? WHERE token_hash = ?')
+    .run(hashToken(refreshToken), wrappedKey, hashToken(token));
+  return refreshToken;
+};
+
 // Helper function to update user's storage usage
 const updateUserStorage = (userId) => {
   try {
@@ -953,7 +972,8 @@ const authenticateToken = (req, res, next) => {
     if (err) {
       // Clear invalid cookie if present
       res.clearCookie('justtype_token', { path: '/' });
-      return res.status(403).json({ error: 'Invalid or expired token' });
+      // 401 so the CLI knows to try its refresh token
+      return res.status(401).json({ error: 'Invalid or expired token' });
     }
 
     // Check if session exists in database and update last activity
@@ -1224,6 +1244,7 @@ app.post('/api/auth/login', verifyTurnstileToken, createRateLimitMiddleware('log
 
       return res.json({
         token: isCLI ? token : undefined,
+        refresh_token: isCLI ? issueRefreshToken(token, encryptionKey) : undefined,
         user: {
           id: user.id,
           username: user.username,
@@ -1252,6 +1273,7 @@ app.post('/api/auth/login', verifyTurnstileToken, createRateLimitMiddleware('log
 
     res.json({
       token: isCLI ? token : undefined,
+      refresh_token: isCLI ? issueRefreshToken(token, encryptionKey) : undefined,
       user: {
         id: user.id,
         username: user.username,
@@ -1269,6 +1291,55 @@ app.post('/api/auth/login', verifyTurnstileToken, createRateLimitMiddleware('log
   }
 });
 
+// Refresh a CLI session: trade the refresh token from login for a new
+// session token and refresh token. The old refresh token stops working.
+app.post('/api/auth/refresh', createRateLimitMiddleware('refreshToken'), (req, res) => {
+  const refreshToken = req.body?.refresh_token;
+
+  if (typeof refreshToken !== 'string' || !refreshToken) {
+    return res.status(400).json({ error: 'Refresh token required' });
+  }
+
+  try {
+    const session = db.prepare(`
+      SELECT sessions.id, sessions.user_id, sessions.refresh_wrapped_key, users.username
+      FROM sessions JOIN users ON users.id = sessions.user_id
+      WHERE sessions.refresh_token_hash = ?
+    `).get(hashToken(refreshToken));
+
+    if (!session) {
+      return res.status(401).json({ error: 'Session expired or logged out' });
+    }
+
+    // Put the encryption key back if a restart dropped it
+    let encryptionKey = null;
+    if (session.refresh_wrapped_key) {
+      try {
+        encryptionKey = unwrapKey(session.refresh_wrapped_key, refreshWrappingKey(refreshToken));
+        if (!getCachedEncryptionKey(session.user_id)) {
+          cacheEncryptionKey(session.user_id, encryptionKey);
+        }
+      } catch (err) {
+        console.error(`Refresh key unwrap failed for user #${session.user_id}:`, err.message);
+      }
+    }
+
+    const token = jwt.sign({ id: session.user_id, username: session.username }, JWT_SECRET, { expiresIn: '30d' });
+    const newRefreshToken = crypto.randomBytes(32).toString('base64url');
+    const wrappedKey = encryptionKey ? wrapKey(encryptionKey, refreshWrappingKey(newRefreshToken)) : null;
+
+    db.prepare(`
+      UPDATE sessions SET token_hash = ?, refresh_token_hash = ?, refresh_wrapped_key = ?, last_activity = CURRENT_TIMESTAMP
+      WHERE id = ?
+    `).run(hashToken(token), hashToken(newRefreshToken), wrappedKey, session.id);
+
+    res.json({ token, refresh_token: newRefreshToken });
+  } catch (error) {
+    console.error('Token refresh error:', error);
+    res.status(500).json({ error: 'Token refresh failed' });
+  }
+});
+
 // Logout (delete current session)
 app.post('/api/auth/logout', authenticateToken, async (req, res) => {
   try {
@@ -1603,6 +1674,7 @@ app.post('/api/cli/token', createRateLimitMiddleware('pollToken'), (req, res) =>
 
     res.json({
       token: token,
+      refresh_token: issueRefreshToken(token, getCachedEncryptionKey(user.id)),
       username: user.username
     });
   } catch (error) {
@@ -2198,14 +2270,34 @@ app.get('/auth/google/link/callback',
 // ============ SLATE ROUTES ============
 
 // Get all slates for authenticated user
-// Client handles search/sort - server just returns all slates
+// Client handles search/sort - server just returns all slates, unless the
+// client asks for a page (?page=&limit=, newest first). Paged responses
+// carry the total in X-Total-Count so the body stays a plain array.
 app.get('/api/slates', authenticateToken, (req, res) => {
   try {
-    const slates = db.prepare(`
-      SELECT id, title, encrypted_title, encrypted_tags, pinned_at, is_published, share_id, word_count, char_count, created_at, updated_at, published_at
-      FROM slates
-      WHERE user_id = ?
-    `).all(req.user.id);
+    let slates;
+    if (req.query.limit !== undefined) {
+      const page = Math.max(parseInt(req.query.page) || 1, 1);
+      const limit = Math.min(Math.max(parseInt(req.query.limit) || 50, 1), 200);
+      const offset = (page - 1) * limit;
+
+      const totalResult = db.prepare('SELECT COUNT(*) as count FROM slates WHERE user_id = ?').get(req.user.id);
+      res.set('X-Total-Count', String(totalResult.count));
+
+      slates = db.prepare(`
+        SELECT id, title, encrypted_title, encrypted_tags, pinned_at, is_published, share_id, word_count, char_count, created_at, updated_at, published_at
+        FROM slates
+        WHERE user_id = ?
+        ORDER BY updated_at DESC, id DESC
+        LIMIT ? OFFSET ?
+      `).all(req.user.id, limit, offset);
+    } else {
+      slates = db.prepare(`
+        SELECT id, title, encrypted_title, encrypted_tags, pinned_at, is_published, share_id, word_count, char_count, created_at, updated_at, published_at
+        FROM slates
+        WHERE user_id = ?
+      `).all(req.user.id);
+    }
 
     // For unpublished slates with encrypted_title, hide plaintext (client decrypts)
     const result = slates.map(slate => {
@@ -31,6 +31,7 @@ class RateLimiter {
     approveDevice: { max: 10, windowMs: 15 * 60 * 1000 }, // 10 approvals per 15 minutes
     requestDeviceCode: { max: 10, windowMs: 15 * 60 * 1000 }, // 10 device code requests per 15 minutes (IP-based)
     pollToken: { max: 120, windowMs: 15 * 60 * 1000 }, // 120 polls per 15 minutes (CLI polls every 5s for max 10 min)
+    refreshToken: { max: 30, windowMs: 15 * 60 * 1000 }, // 30 refreshes per 15 minutes per IP
   };
 
   check(userId, operation) {
@@ -157,6 +158,7 @@ function createRateLimitMiddleware(operation) {
     'adminAuth',
     'viewPublicSlate',
     'requestDeviceCode',
+    'refreshToken',
   ]);
 
   return (req, res, next) => {